@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+	"golang.org/x/term"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// addTaskTypes are the task types `vstask add` offers, the default first.
+var addTaskTypes = []string{"shell", "process", "npm"}
+
+// runAddCommand adds a task to the workspace's tasks.json (see tasks.AddTask), asking for
+// what the flags don't give: its label, type, command, group and dependencies. Without a
+// terminal (or with --no-input), the label and command flags are required.
+func runAddCommand(args []string, flags globalFlags) error {
+	fs := flag.NewFlagSet("add", flag.ContinueOnError)
+	label := fs.String("label", "", "the task's label")
+	typ := fs.String("type", "", "shell, process or npm")
+	command := fs.String("command", "", "the command to run (the script, for npm)")
+	group := fs.String("group", "", "build or test")
+	isDefault := fs.Bool("default", false, "make it the group's default task")
+	dependsOn := fs.String("depends-on", "", "labels of tasks to run first, comma-separated")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	root, err := tasks.ProjectRoot()
+	if err != nil {
+		return err
+	}
+	var labels []string
+	if taskList, err := tasks.GetTasks(); err == nil {
+		for _, t := range taskList {
+			labels = append(labels, t.Label)
+		}
+	}
+	checkLabel := func(s string) error {
+		if strings.TrimSpace(s) == "" {
+			return errors.New("a task needs a label")
+		}
+		if slices.Contains(labels, s) {
+			return fmt.Errorf("there's already a task labeled %q", s)
+		}
+		return nil
+	}
+	checkDeps := func(s string) error {
+		for _, l := range splitLabels(s) {
+			if !slices.Contains(labels, l) {
+				return fmt.Errorf("no task labeled %q", l)
+			}
+		}
+		return nil
+	}
+	checkCommand := func(s string) error {
+		if strings.TrimSpace(s) == "" {
+			return errors.New("a task needs a command")
+		}
+		return nil
+	}
+
+	if flags.noInput || os.Getenv("VSTASK_NO_INPUT") == "1" || !term.IsTerminal(int(os.Stdin.Fd())) {
+		if !set["label"] || !set["command"] {
+			return errors.New("usage: vstask add --label <label> --command <command> [--type shell|process|npm] [--group build|test [--default]] [--depends-on <labels>]")
+		}
+	} else {
+		fmt.Println("New task (CTRL-C to cancel)")
+		if !set["label"] {
+			if *label, err = promptLine("Label", checkLabel); err != nil {
+				return err
+			}
+		}
+		if !set["type"] {
+			if *typ, err = promptChoice("Type", addTaskTypes); err != nil {
+				return err
+			}
+		}
+		if !set["command"] {
+			name := "Command"
+			if *typ == "npm" {
+				name = "npm script"
+			}
+			if *command, err = promptLine(name, checkCommand); err != nil {
+				return err
+			}
+		}
+		if !set["group"] {
+			if *group, err = promptChoice("Group", []string{"none", "build", "test"}); err != nil {
+				return err
+			}
+			if *group == "none" {
+				*group = ""
+			}
+		}
+		if *group != "" && !set["default"] {
+			if *isDefault, err = promptConfirm(fmt.Sprintf("Make it the default %s task", *group)); err != nil {
+				return err
+			}
+		}
+		if !set["depends-on"] && len(labels) > 0 {
+			if *dependsOn, err = promptLine("Depends on (labels, comma-separated; optional)", checkDeps); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := checkLabel(*label); err != nil {
+		return err
+	}
+	if err := checkCommand(*command); err != nil {
+		return err
+	}
+	if err := checkDeps(*dependsOn); err != nil {
+		return fmt.Errorf("--depends-on: %w", err)
+	}
+	t := tasks.NewTask{Label: *label, Type: *typ, DependsOn: splitLabels(*dependsOn)}
+	switch t.Type {
+	case "":
+		t.Type = "shell"
+	case "shell", "process", "npm":
+	default:
+		return fmt.Errorf("--type: want shell, process or npm, got %q", t.Type)
+	}
+	if t.Type == "npm" {
+		t.Script = *command
+	} else {
+		t.Command = *command
+	}
+	switch *group {
+	case "":
+		if *isDefault {
+			return errors.New("--default needs a --group")
+		}
+	case "build", "test":
+		t.Group = &tasks.Group{Kind: *group, IsDefault: *isDefault}
+	default:
+		return fmt.Errorf("--group: want build or test, got %q", *group)
+	}
+
+	p, err := tasks.AddTask(root, t)
+	if err != nil {
+		return err
+	}
+	name := p
+	if rel, err := filepath.Rel(root, p); err == nil {
+		name = rel
+	}
+	fmt.Printf("Added task %q to %s.\n", t.Label, name)
+	return nil
+}
+
+// splitLabels splits a comma-separated list of labels, leaving out blank ones.
+func splitLabels(s string) []string {
+	var out []string
+	for l := range strings.SplitSeq(s, ",") {
+		if l = strings.TrimSpace(l); l != "" {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+func promptLine(label string, validate func(string) error) (string, error) {
+	p := promptui.Prompt{Label: label, Validate: validate}
+	s, err := p.Run()
+	return strings.TrimSpace(s), err
+}
+
+func promptChoice(label string, items []string) (string, error) {
+	s := promptui.Select{Label: label, Items: items}
+	_, v, err := s.Run()
+	return v, err
+}
+
+// promptConfirm asks a yes/no question; no is the default.
+func promptConfirm(label string) (bool, error) {
+	p := promptui.Prompt{Label: label, IsConfirm: true}
+	if _, err := p.Run(); err != nil {
+		if errors.Is(err, promptui.ErrAbort) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// runAliasesCommand prints shell function definitions for every task, e.g.
+// `eval "$(vstask aliases)"`, named with tasks.DefaultAliasPrefix unless --prefix says otherwise.
+func runAliasesCommand(args []string) error {
+	fs := flag.NewFlagSet("aliases", flag.ContinueOnError)
+	prefix := fs.String("prefix", tasks.DefaultAliasPrefix, `prefix for generated function names ("" for none)`)
+	shell := fs.String("shell", "sh", "target shell: sh, bash, zsh or fish")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	taskList, err := tasks.GetTasks()
+	if err != nil {
+		return err
+	}
+	out, err := tasks.GenerateAliases(taskList, *prefix, *shell)
+	if err != nil {
+		return err
+	}
+	fmt.Print(out)
+	return nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+	"time"
+
+	"github.com/chenasraf/vstask/runner"
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// runStatusCommand lists the background tasks vstask runs in this workspace: their process,
+// how long they've been up and where their output is logged.
+func runStatusCommand(args []string) error {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	root, err := tasks.ProjectRoot()
+	if err != nil {
+		return err
+	}
+	running, err := runner.BackgroundTasks(root)
+	if err != nil {
+		return err
+	}
+	if len(running) == 0 {
+		fmt.Println("No background tasks running.")
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TASK\tPID\tUP\tLOG")
+	for _, t := range running {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", t.Name, t.PID, time.Since(t.Started).Round(time.Second), t.Log)
+	}
+	return tw.Flush()
+}
+
+// runStopCommand stops a background task (its whole process tree) by name or label.
+func runStopCommand(args []string) error {
+	fs := flag.NewFlagSet("stop", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: vstask stop <label>")
+	}
+	root, err := tasks.ProjectRoot()
+	if err != nil {
+		return err
+	}
+	stopped, err := runner.StopBackgroundTask(root, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	for _, t := range stopped {
+		fmt.Printf("Stopped background task: %s (pid %d)\n", t.Name, t.PID)
+	}
+	return nil
+}
+
+// runLogsCommand prints the end of a background task's log (-n lines) and, with -f, what
+// it writes next, until it stops or CTRL-C. The log of a task that stopped is still there.
+func runLogsCommand(args []string) error {
+	fs := flag.NewFlagSet("logs", flag.ContinueOnError)
+	n := fs.Int("n", 50, "how many of the last lines to print (0 for all)")
+	follow := fs.Bool("f", false, "keep printing the output as it comes")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: vstask logs [-n lines] [-f] <label>")
+	}
+	root, err := tasks.ProjectRoot()
+	if err != nil {
+		return err
+	}
+	name := fs.Arg(0)
+	var task *runner.BackgroundTask
+	if running, err := runner.BackgroundTasks(root); err == nil {
+		for _, t := range running {
+			if t.Name == name || t.Label == name {
+				task = &t
+				break
+			}
+		}
+	}
+	p := ""
+	if task != nil && task.Log != "" {
+		p = task.Log
+	} else if p, err = runner.BackgroundLogPath(root, name); err != nil {
+		return err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no log for %q: it hasn't run in the background in this workspace", name)
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	_, _ = os.Stdout.Write(lastLines(data, *n))
+	if !*follow || task == nil {
+		return nil
+	}
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	tick := time.NewTicker(250 * time.Millisecond)
+	defer tick.Stop()
+	for {
+		if _, err := io.Copy(os.Stdout, f); err != nil {
+			return err
+		}
+		select {
+		case <-interrupt:
+			return nil
+		case <-tick.C:
+		}
+		if running, err := runner.BackgroundTasks(root); err == nil && !hasBackgroundTask(running, task.Name, task.PID) {
+			_, err := io.Copy(os.Stdout, f) // what it wrote last
+			return err
+		}
+	}
+}
+
+// lastLines returns the last n lines of data (all of it when n <= 0).
+func lastLines(data []byte, n int) []byte {
+	if n <= 0 {
+		return data
+	}
+	end := len(bytes.TrimSuffix(data, []byte("\n")))
+	for i := end - 1; i >= 0; i-- {
+		if data[i] == '\n' {
+			if n--; n == 0 {
+				return data[i+1:]
+			}
+		}
+	}
+	return data
+}
+
+func hasBackgroundTask(ts []runner.BackgroundTask, name string, pid int) bool {
+	for _, t := range ts {
+		if t.Name == name && t.PID == pid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/chenasraf/vstask/runner"
+	"github.com/chenasraf/vstask/utils"
+)
+
+// runBugReportCommand writes a sanitized diagnostic bundle (see runner.BugReport) to a zip file
+// to attach to a GitHub issue.
+func runBugReportCommand(args []string) error {
+	fs := flag.NewFlagSet("bug-report", flag.ContinueOnError)
+	out := fs.String("o", "", "where to write the zip (default vstask-bug-report-<time>.zip)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *out == "" {
+		*out = fmt.Sprintf("vstask-bug-report-%s.zip", time.Now().Format("20060102-150405"))
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	names, err := runner.BugReport(f, utils.AppVersion)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(*out)
+		return err
+	}
+	fmt.Printf("Wrote %s:\n", *out)
+	for _, name := range names {
+		fmt.Printf("  %s\n", name)
+	}
+	fmt.Println("Secrets are redacted, but please look it over before attaching it to an issue:")
+	fmt.Println("https://github.com/chenasraf/vstask/issues/new")
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/chenasraf/vstask/runner"
+	"github.com/chenasraf/vstask/tasks"
+	"github.com/chenasraf/vstask/utils"
+)
+
+// runCleanCommand removes vstask's caches and leftovers for the current workspace
+// (or every workspace with --all). With --dry-run it only lists them.
+func runCleanCommand(args []string) error {
+	fs := flag.NewFlagSet("clean", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "list what would be removed without removing it")
+	all := fs.Bool("all", false, "clean every workspace, not just the current one")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var dirs []string
+	if *all {
+		ds, err := utils.AllWorkspaceStateDirs()
+		if err != nil {
+			return err
+		}
+		dirs = ds
+	} else {
+		root, err := tasks.ProjectRoot()
+		if err != nil {
+			return err
+		}
+		dir, err := utils.WorkspaceStateDir(root)
+		if err != nil {
+			return err
+		}
+		dirs = []string{dir}
+	}
+
+	n := 0
+	for _, dir := range dirs {
+		plan, err := runner.CleanPlan(context.Background(), dir)
+		if err != nil {
+			return err
+		}
+		for _, a := range plan {
+			n++
+			if *dryRun {
+				fmt.Printf("Would remove %s (%s)\n", a.Path, a.What)
+				continue
+			}
+			if err := a.Apply(); err != nil {
+				return fmt.Errorf("clean %s: %w", a.Path, err)
+			}
+			fmt.Printf("Removed %s (%s)\n", a.Path, a.What)
+		}
+	}
+	if n == 0 {
+		fmt.Println("Nothing to clean.")
+	}
+	return nil
+}
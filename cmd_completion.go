@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/chenasraf/vstask/tasks"
+	"github.com/chenasraf/vstask/utils"
+)
+
+// runCompletionCommand prints the completion script for a shell, e.g.
+// `source <(vstask completion bash)`.
+func runCompletionCommand(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: vstask completion bash|zsh|fish|pwsh")
+	}
+	out, err := utils.Completion(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Print(out)
+	return nil
+}
+
+// runCompleteCommand is what the completion scripts call: "vstask __complete labels" prints the
+// workspace's task labels and "vstask __complete inputs" its input ids followed by "=" (for
+// --input), one per line. Outside a workspace, or with a broken tasks.json, it prints nothing.
+// It only reads: the tasks file generator doesn't run, and included URLs come from the cache
+// (see tasks.Offline).
+func runCompleteCommand(args []string) {
+	if len(args) != 1 {
+		return
+	}
+	tasks.Offline()
+	switch args[0] {
+	case "labels":
+		taskList, err := tasks.GetTasks()
+		if err != nil {
+			return
+		}
+		seen := map[string]bool{}
+		for _, t := range taskList {
+			if t.Label != "" && !seen[t.Label] {
+				seen[t.Label] = true
+				fmt.Println(t.Label)
+			}
+		}
+	case "inputs":
+		inputs, err := tasks.GetInputs()
+		if err != nil {
+			return
+		}
+		for _, in := range inputs {
+			if in.ID != "" {
+				fmt.Println(in.ID + "=")
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/chenasraf/vstask/daemon"
+)
+
+// runDaemonCommand implements `vstask daemon [start|stop|status]`.
+// start (the default) serves in the foreground until stopped or interrupted.
+func runDaemonCommand(args []string) error {
+	fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	sub := "start"
+	if fs.NArg() > 0 {
+		sub = fs.Arg(0)
+	}
+
+	path, err := daemon.SocketPath()
+	if err != nil {
+		return err
+	}
+	switch sub {
+	case "start":
+		l, err := daemon.Listen(path)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(path)
+		srv := daemon.NewServer()
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt)
+		go func() {
+			<-sig
+			srv.Stop()
+		}()
+		fmt.Printf("vstask daemon listening on %s\n", path)
+		return srv.Serve(l)
+	case "stop":
+		if _, err := daemon.CallAt(path, daemon.Request{Op: daemon.OpStop}); err != nil {
+			return fmt.Errorf("no daemon running (%w)", err)
+		}
+		fmt.Println("vstask daemon stopped")
+		return nil
+	case "status":
+		resp, err := daemon.CallAt(path, daemon.Request{Op: daemon.OpPing})
+		if err != nil {
+			fmt.Println("vstask daemon is not running")
+			return nil
+		}
+		fmt.Printf("vstask daemon is running (pid %d, %s)\n", resp.Pid, path)
+		return nil
+	default:
+		return fmt.Errorf("unknown daemon command %q (want start, stop or status)", sub)
+	}
+}
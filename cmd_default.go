@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/chenasraf/vstask/runner"
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// defaultTaskLabel returns the task a bare `vstask` should run according to the .vstaskrc
+// defaults and the configured default action, or "" to open the picker.
+func defaultTaskLabel(rc tasks.RC) string {
+	if rc.DefaultTask != "" {
+		return rc.DefaultTask
+	}
+	root, _ := tasks.ProjectRoot()
+	switch tasks.DefaultAction(root, rc) {
+	case tasks.ActionBuild:
+		taskList, err := tasks.GetTasks()
+		if err != nil {
+			return ""
+		}
+		if t, ok := tasks.DefaultTaskForGroup(taskList, "build"); ok {
+			return t.Label
+		}
+		fmt.Println("No default build task; pick one.")
+	case tasks.ActionLast:
+		if label, ok := lastRunLabel(); ok {
+			return label
+		}
+		fmt.Println("No previous run in this workspace; pick a task.")
+	}
+	return ""
+}
+
+// lastRunLabel returns the last task run in the current workspace, if any.
+func lastRunLabel() (string, bool) {
+	root, err := tasks.ProjectRoot()
+	if err != nil || root == "" {
+		return "", false
+	}
+	return runner.LastRunLabel(root)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/manifoldco/promptui"
+	"golang.org/x/term"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// forwardDeprecated prints the notice of a deprecated task and, if it names a replacement
+// ("replacedBy") and there's a terminal to ask on, offers to run that instead. It returns the
+// task to run.
+func forwardDeprecated(t tasks.Task) tasks.Task {
+	if t.Deprecated == "" {
+		return t
+	}
+	fmt.Fprintf(os.Stderr, "Warning: task %q is deprecated: %s\n", t.Label, t.Deprecated)
+	if t.ReplacedBy == "" || !term.IsTerminal(int(os.Stdin.Fd())) {
+		return t
+	}
+	taskList, err := tasks.GetTasks()
+	if err != nil {
+		return t
+	}
+	for _, r := range taskList {
+		if r.Label != t.ReplacedBy {
+			continue
+		}
+		p := promptui.Prompt{Label: fmt.Sprintf("Run %q instead", r.Label), IsConfirm: true}
+		if _, err := p.Run(); err == nil {
+			return r
+		}
+		return t
+	}
+	fmt.Fprintf(os.Stderr, "Warning: replacement task %q not found\n", t.ReplacedBy)
+	return t
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	json "github.com/neilotoole/jsoncolor"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// runDiffCommand shows the tasks added, removed or modified in tasks.json since a task last
+// ran in this workspace, e.g. to notice a teammate's changes after a pull.
+func runDiffCommand(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	root, err := tasks.ProjectRoot()
+	if err != nil {
+		return err
+	}
+	changes, since, err := tasks.TaskChangesSinceLastRun(root)
+	if err != nil {
+		return err
+	}
+	if since.IsZero() {
+		fmt.Println("No tasks have run in this workspace yet; nothing to compare against.")
+		return nil
+	}
+	if len(changes) == 0 {
+		fmt.Printf("No task changes since the last run (%s).\n", since.Format("2006-01-02 15:04"))
+		return nil
+	}
+	fmt.Printf("Task changes since the last run (%s):\n\n", since.Format("2006-01-02 15:04"))
+	tasks.WriteTaskDiff(os.Stdout, changes, colorOutput())
+	return nil
+}
+
+// colorOutput reports whether stdout is a color terminal (and $NO_COLOR isn't set).
+func colorOutput() bool {
+	return os.Getenv("NO_COLOR") == "" && json.IsColorTerminal(os.Stdout)
+}
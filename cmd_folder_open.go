@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chenasraf/vstask/runner"
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// runFolderOpenCommand runs every task marked "runOptions": {"runOn": "folderOpen"}, as VS
+// Code does when the folder opens, for `vstask folder-open`. They run in one run, like `vstask
+// run`'s tasks: together, so a background task (a watcher) doesn't hold up the others, and
+// dependencies they share only once. It returns once all have exited.
+func runFolderOpenCommand(flags globalFlags) error {
+	taskList, err := tasks.GetTasks()
+	if err != nil {
+		return err
+	}
+	var open []tasks.Task
+	for _, t := range taskList {
+		if t.RunsOnFolderOpen() {
+			open = append(open, t)
+		}
+	}
+	if len(open) == 0 {
+		fmt.Println("No folderOpen tasks in this workspace.")
+		return nil
+	}
+	labels := make([]string, len(open))
+	for i, t := range open {
+		labels[i] = t.Label
+	}
+	fmt.Printf("Running folderOpen tasks: %s\n", strings.Join(labels, ", "))
+
+	return runner.RunTasks(labels, "parallel", flags.runOptions())
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/chenasraf/vstask/tasks"
+	"golang.org/x/term"
+)
+
+// groupTask returns the task to run for group, like VS Code's "Run Build Task" and "Run Test
+// Task": the group's default task (see tasks.DefaultTaskForGroup), or else one picked among its
+// tasks.
+func groupTask(group string, flags globalFlags) (tasks.Task, error) {
+	taskList, err := tasks.GetTasks()
+	if err != nil {
+		return tasks.Task{}, err
+	}
+	if t, ok := tasks.DefaultTaskForGroup(taskList, group); ok {
+		return t, nil
+	}
+	members := tasks.GroupTasks(taskList, group)
+	if len(members) == 0 {
+		return tasks.Task{}, fmt.Errorf(`no %s task: set "group": %q on one`, group, group)
+	}
+	if flags.noInput || os.Getenv("VSTASK_NO_INPUT") == "1" || !term.IsTerminal(int(os.Stdin.Fd())) {
+		labels := make([]string, len(members))
+		for i, t := range members {
+			labels[i] = t.Label
+		}
+		return tasks.Task{}, fmt.Errorf(`no default %s task to pick among %s: set "isDefault": true on one`, group, strings.Join(labels, ", "))
+	}
+	fmt.Printf("No default %s task; pick one.\n", group)
+	t, err := tasks.PromptForGroupTask(group)
+	if err != nil {
+		return tasks.Task{}, err
+	}
+	if t.IsEmpty() {
+		return tasks.Task{}, errors.New("no task selected")
+	}
+	return t, nil
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// runHelpCommand prints per-task documentation for `vstask help <label>`.
+func runHelpCommand(query string) error {
+	taskList, err := tasks.GetTasks()
+	if err != nil {
+		return err
+	}
+	task, err := tasks.FindTask(taskList, query)
+	if err != nil {
+		return err
+	}
+	inputs, err := tasks.GetInputs()
+	if err != nil {
+		inputs = nil
+	}
+	fmt.Print(tasks.DescribeTask(task, inputs))
+	return nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// runListCommand prints every task (label, type, group, background, detail), as a table
+// or, with --json, as a JSON array for scripts. --verbose adds run counts and last run times.
+func runListCommand(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print a JSON array instead of a table")
+	verbose := fs.Bool("verbose", false, "include how often and when last each task ran")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	taskList, err := tasks.GetTasks()
+	if err != nil {
+		return err
+	}
+	var usage map[string]tasks.Usage
+	if *verbose {
+		root, _ := tasks.ProjectRoot()
+		usage = tasks.TaskUsage(root)
+	}
+	return tasks.WriteTaskList(os.Stdout, taskList, *asJSON, usage)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/manifoldco/promptui"
+	"golang.org/x/term"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// ensureApprovedTasks guards against unreviewed changes to the workspace's tasks (those of
+// tasks.json, the files it includes and the .code-workspace file, their inputs, and the
+// settings and .vstaskrc env changing how they run) when pinning is on (see
+// tasks.PinningEnabled): it shows what changed since the approved version and asks before
+// running (see approveChanges).
+func ensureApprovedTasks(accept bool) error {
+	root, err := tasks.ProjectRoot()
+	if err != nil || !tasks.PinningEnabled(root) {
+		return nil
+	}
+	changes, ok, err := tasks.CheckPinnedTasks(root)
+	if err != nil || ok {
+		return err
+	}
+	fmt.Println("The workspace's tasks changed since they were approved:")
+	fmt.Println()
+	tasks.WriteTaskDiff(os.Stdout, changes.Tasks, colorOutput())
+	tasks.WriteInputDiff(os.Stdout, changes.Inputs, colorOutput())
+	tasks.WriteSettingDiff(os.Stdout, changes.Settings, colorOutput())
+	fmt.Println()
+	if err := approveChanges(accept); err != nil {
+		return err
+	}
+	return tasks.PinTasks(root)
+}
+
+// ensureApprovedGenerator is ensureApprovedTasks for the command generating tasks.json (see
+// tasks.TasksFileGenerator), checked before it runs; what it generates is checked after.
+func ensureApprovedGenerator(accept bool) error {
+	root, err := tasks.ProjectRoot()
+	if err != nil || !tasks.PinningEnabled(root) {
+		return nil
+	}
+	old, cur, ok, err := tasks.CheckPinnedGenerator(root)
+	if err != nil || ok {
+		return err
+	}
+	fmt.Println(`The command generating tasks.json ("vstask.tasksFile.generate") changed since it was approved:`)
+	fmt.Println()
+	if old != "" {
+		fmt.Println("  -", old)
+	}
+	if cur != "" {
+		fmt.Println("  +", cur)
+	}
+	fmt.Println()
+	if err := approveChanges(accept); err != nil {
+		return err
+	}
+	return tasks.PinGenerator(root)
+}
+
+// approveChanges asks whether to approve the changes just shown, unless accept
+// (--accept-changes) already does; without a terminal to ask on, it's required.
+func approveChanges(accept bool) error {
+	if accept {
+		return nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return errors.New("the workspace's tasks changed since they were approved; review the changes and run with --accept-changes")
+	}
+	p := promptui.Prompt{Label: "Approve these changes", IsConfirm: true}
+	if _, err := p.Run(); err != nil {
+		if errors.Is(err, promptui.ErrAbort) {
+			return errors.New("changes not approved")
+		}
+		return err
+	}
+	return nil
+}
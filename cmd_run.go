@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/chenasraf/vstask/runner"
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// runRunCommand runs several tasks in one run: "vstask run a b c [--order sequence|parallel]".
+// Each label is matched like a task name given alone (see tasks.FindTask); the flag may come
+// anywhere. A single task runs as `vstask <label>` runs it (as shell aliases do, see
+// tasks.GenerateAliases).
+func runRunCommand(args []string, flags globalFlags) error {
+	order := "parallel"
+	var queries []string
+	for i := 0; i < len(args); i++ {
+		name, val, hasVal := strings.Cut(args[i], "=")
+		if name != "--order" {
+			queries = append(queries, args[i])
+			continue
+		}
+		if !hasVal {
+			if i+1 == len(args) {
+				return errors.New("--order needs a value")
+			}
+			i++
+			val = args[i]
+		}
+		if val != "sequence" && val != "parallel" {
+			return fmt.Errorf("--order: want sequence or parallel, got %q", val)
+		}
+		order = val
+	}
+	if len(queries) == 0 {
+		return errors.New("usage: vstask run <label>... [--order sequence|parallel]")
+	}
+
+	allowRun(flags)
+	taskList, err := tasks.GetTasks()
+	if err != nil {
+		return err
+	}
+	labels := make([]string, len(queries))
+	for i, q := range queries {
+		t, err := tasks.FindTask(taskList, q)
+		if err != nil {
+			return err
+		}
+		if len(queries) == 1 {
+			return runner.Run(forwardDeprecated(t), flags.runOptions())
+		}
+		labels[i] = t.Label
+	}
+	return runner.RunTasks(labels, order, flags.runOptions())
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/chenasraf/vstask/runner"
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// runSecretCommand manages the values of secret inputs kept in the OS keychain:
+// "vstask secret forget <input-id>" removes one, so the next run asks for it again.
+func runSecretCommand(args []string) error {
+	if len(args) != 2 || args[0] != "forget" {
+		return errors.New("usage: vstask secret forget <input-id>")
+	}
+	root, err := tasks.ProjectRoot()
+	if err != nil {
+		return err
+	}
+	id := args[1]
+	found, err := runner.ForgetSecret(root, id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		fmt.Printf("No stored value for %q in %s.\n", id, root)
+		return nil
+	}
+	fmt.Printf("Forgot the stored value of %q; the next run asks for it.\n", id)
+	return nil
+}
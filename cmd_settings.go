@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// runSettingsCommand shows the value vstask uses for a VS Code setting and where it comes
+// from (`vstask settings <key>`), listing every source it checked in precedence order.
+func runSettingsCommand(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: vstask settings <key>\nvstask reads: %s", strings.Join(tasks.KnownSettings(), ", "))
+	}
+	key := args[0]
+	root, _ := tasks.ProjectRoot()
+	chain := tasks.SettingChain(root, key)
+	merged := tasks.MergedSetting(key)
+
+	winner := -1
+	for i, s := range chain {
+		if s.Value != nil {
+			winner = i
+			break
+		}
+	}
+	switch {
+	case winner < 0:
+		fmt.Printf("%s is not set\n", key)
+	case merged:
+		fmt.Printf("%s is merged from every source (earlier ones override keys of later ones)\n", key)
+	default:
+		fmt.Printf("%s = %s\n  from %s\n", key, chain[winner].Value, chain[winner].Source)
+	}
+
+	fmt.Println("Sources (highest precedence first):")
+	for i, s := range chain {
+		var state string
+		switch {
+		case !s.Exists && strings.HasPrefix(s.Source, "$"):
+			state = "not set"
+		case !s.Exists:
+			state = "no such file"
+		case s.Value == nil:
+			state = "not set"
+		case i == winner || merged:
+			state = string(s.Value)
+		default:
+			state = string(s.Value) + " (overridden)"
+		}
+		mark := " "
+		if i == winner && !merged {
+			mark = "*"
+		}
+		fmt.Printf("  %s %s: %s\n", mark, s.Source, state)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// taskCommands are the subcommands a task can shadow. vstask ran any task by its label before
+// they were added, so a task labelled like one of them still runs (see shadowingTask). `vstask
+// run <label>` always runs the task; build and test have their own rule (see
+// runNamedOrGroupTask).
+var taskCommands = map[string]bool{
+	"add":    true,
+	"clean":  true,
+	"diff":   true,
+	"list":   true,
+	"logs":   true,
+	"status": true,
+	"stop":   true,
+	"watch":  true,
+}
+
+// shadowingTask reports whether `vstask <args>` runs a task of taskList rather than the
+// subcommand args[0]: a task is labelled like it (case-insensitively) and nothing follows.
+// With arguments following, which one is meant can't be told, and it's an error.
+func shadowingTask(taskList []tasks.Task, args []string) (bool, error) {
+	name := args[0]
+	if !taskCommands[name] || !slices.ContainsFunc(taskList, func(t tasks.Task) bool { return strings.EqualFold(t.Label, name) }) {
+		return false, nil
+	}
+	if len(args) > 1 {
+		return false, fmt.Errorf("ambiguous: %q is both a vstask command and a task; use `vstask run %s` to run the task", name, name)
+	}
+	return true, nil
+}
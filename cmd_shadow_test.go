@@ -0,0 +1,34 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func TestShadowingTask(t *testing.T) {
+	for _, cmd := range slices.Sorted(maps.Keys(taskCommands)) {
+		t.Run(cmd, func(t *testing.T) {
+			taskList := []tasks.Task{{Label: "build"}, {Label: cmd}}
+			if ok, err := shadowingTask(taskList, []string{cmd}); !ok || err != nil {
+				t.Fatalf("vstask %s with a %q task: %v, %v; want the task", cmd, cmd, ok, err)
+			}
+			if _, err := shadowingTask(taskList, []string{cmd, "build"}); err == nil || !strings.Contains(err.Error(), "vstask run "+cmd) {
+				t.Fatalf("vstask %s build with a %q task: err = %v", cmd, cmd, err)
+			}
+			if ok, err := shadowingTask(taskList[:1], []string{cmd, "build"}); ok || err != nil {
+				t.Fatalf("vstask %s build without a %q task: %v, %v; want the command", cmd, cmd, ok, err)
+			}
+		})
+	}
+
+	if ok, _ := shadowingTask([]tasks.Task{{Label: "Clean"}}, []string{"clean"}); !ok {
+		t.Error("labels match case-insensitively")
+	}
+	if ok, err := shadowingTask([]tasks.Task{{Label: "run"}}, []string{"run", "run"}); ok || err != nil {
+		t.Errorf("vstask run is never shadowed: %v, %v", ok, err)
+	}
+}
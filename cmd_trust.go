@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/manifoldco/promptui"
+	"golang.org/x/term"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// ensureTrusted asks before running tasks from a workspace that hasn't been trusted yet
+// (see tasks.IsTrusted), or from a .code-workspace file outside it that hasn't (see
+// tasks.UntrustedWorkspaceFile), and remembers the answer. With trust (--trust) it trusts the workspace
+// without asking; without a terminal to ask on, --trust is required.
+func ensureTrusted(trust bool) error {
+	root, err := tasks.ProjectRoot()
+	if err != nil {
+		return nil // no workspace: loading its tasks reports the error
+	}
+	if !tasks.IsTrusted(root) {
+		if err := askTrust(root, "workspace", trust); err != nil {
+			return err
+		}
+	}
+	// The .code-workspace file's tasks, when it's outside the workspace folder.
+	if p := tasks.UntrustedWorkspaceFile(root); p != "" {
+		return askTrust(p, "workspace file", trust)
+	}
+	return nil
+}
+
+// askTrust asks whether to trust the tasks in p (a workspace folder or file; what says which)
+// and records it, unless trust (--trust) trusts it without asking.
+func askTrust(p, what string, trust bool) error {
+	if !trust {
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			return fmt.Errorf("%s %s is not trusted; its tasks can run any command. Run with --trust to trust it", what, p)
+		}
+		prompt := promptui.Prompt{
+			Label:     fmt.Sprintf("Do you trust the tasks in %s? They can run any command", p),
+			IsConfirm: true,
+		}
+		if _, err := prompt.Run(); err != nil {
+			if errors.Is(err, promptui.ErrAbort) {
+				return fmt.Errorf("%s not trusted", what)
+			}
+			return err
+		}
+	}
+	if err := tasks.TrustWorkspace(p); err != nil {
+		return err
+	}
+	fmt.Printf("Trusted %s.\n", p)
+	return nil
+}
+
+// ensureTrustedIncludes asks before including tasks from URLs the workspace's tasks file
+// includes for the first time, or whose content changed since (see tasks.TrustInclude), like
+// ensureTrusted does for the workspace; declining stops the run. Each URL is asked about on its
+// own, those a trusted one includes in turn too. With trust (--trust) they're trusted without
+// asking.
+func ensureTrustedIncludes(trust bool) error {
+	root, err := tasks.ProjectRoot()
+	if err != nil {
+		return nil
+	}
+	asked := map[string]bool{}
+	for {
+		pending, err := tasks.UntrustedIncludes(root)
+		if err != nil {
+			return err
+		}
+		pending = slices.DeleteFunc(pending, func(u tasks.UntrustedInclude) bool { return asked[u.URL] })
+		if len(pending) == 0 {
+			return nil
+		}
+		for _, u := range pending {
+			asked[u.URL] = true
+			if !trust {
+				if !term.IsTerminal(int(os.Stdin.Fd())) {
+					return fmt.Errorf("included tasks from %s are not trusted; they can run any command. Run with --trust to trust them", u.URL)
+				}
+				label := fmt.Sprintf("Include the tasks from %s? They can run any command", u.URL)
+				if u.Changed {
+					label = fmt.Sprintf("The tasks at %s changed since you trusted them. Include them? They can run any command", u.URL)
+				}
+				p := promptui.Prompt{Label: label, IsConfirm: true}
+				if _, err := p.Run(); err != nil {
+					if errors.Is(err, promptui.ErrAbort) {
+						return fmt.Errorf("included tasks from %s not trusted", u.URL)
+					}
+					return err
+				}
+			}
+			if err := tasks.TrustInclude(root, u.URL, u.Hash); err != nil {
+				return err
+			}
+			fmt.Printf("Trusted %s.\n", u.URL)
+		}
+	}
+}
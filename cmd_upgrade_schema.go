@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// runUpgradeSchemaCommand rewrites the workspace's tasks.json as a 2.0.0 file (see
+// tasks.UpgradeTasksFile) and prints what changed. With --dry-run it only prints it.
+func runUpgradeSchemaCommand(args []string) error {
+	fs := flag.NewFlagSet("upgrade-schema", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "print the changes without writing them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	root, err := tasks.ProjectRoot()
+	if err != nil {
+		return err
+	}
+	p, notes, err := tasks.UpgradeSchema(root, *dryRun)
+	if err != nil {
+		return err
+	}
+	name := p
+	if rel, err := filepath.Rel(root, p); err == nil {
+		name = rel
+	}
+	if len(notes) == 0 {
+		fmt.Printf("%s is up to date.\n", name)
+		return nil
+	}
+	if *dryRun {
+		fmt.Printf("Would upgrade %s to the 2.0.0 schema:\n", name)
+	} else {
+		fmt.Printf("Upgraded %s to the 2.0.0 schema:\n", name)
+	}
+	var manual []tasks.UpgradeNote
+	for _, n := range notes {
+		if n.Manual {
+			manual = append(manual, n)
+			continue
+		}
+		printUpgradeNote(n)
+	}
+	if len(manual) > 0 {
+		fmt.Println("\nLeft to do by hand:")
+		for _, n := range manual {
+			printUpgradeNote(n)
+		}
+	}
+	return nil
+}
+
+func printUpgradeNote(n tasks.UpgradeNote) {
+	if n.Task == "" {
+		fmt.Printf("  %s\n", n.Text)
+		return
+	}
+	fmt.Printf("  %s: %s\n", n.Task, n.Text)
+}
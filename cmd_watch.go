@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/chenasraf/vstask/runner"
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// runWatchCommand runs a task, then reruns it whenever files matching --glob (or the task's
+// "x-vstask": { "watch" }) change, stopping a run still going first. Each run is `vstask run`
+// with the task's label, so no label is taken for a subcommand, and the same global flags
+// (globalArgs); a stopped run stops its tasks like CTRL-C would.
+func runWatchCommand(args, globalArgs []string, flags globalFlags) error {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	var globs stringsFlag
+	fs.Var(&globs, "glob", "files to watch, relative to the workspace folder (repeatable; ** matches any directories)")
+	debounce := fs.Duration("debounce", runner.DefaultWatchDebounce, "how long changes must settle before rerunning")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	var label string
+	if fs.NArg() > 0 {
+		label = fs.Arg(0)
+		if err := fs.Parse(fs.Args()[1:]); err != nil { // flags may follow the label too
+			return err
+		}
+	}
+	if label == "" || fs.NArg() > 0 {
+		return errors.New("usage: vstask watch <label> [--glob <pattern>]... [--debounce <duration>]")
+	}
+
+	taskList, err := tasks.GetTasks()
+	if err != nil {
+		return err
+	}
+	task, err := tasks.FindTask(taskList, label)
+	if err != nil {
+		return err
+	}
+	if len(globs) == 0 && task.Extensions != nil {
+		globs = task.Extensions.Watch
+	}
+	if len(globs) == 0 {
+		return fmt.Errorf(`nothing to watch for %q: pass --glob, or set "x-vstask": { "watch": [...] } on the task`, label)
+	}
+	root, err := tasks.ProjectRoot()
+	if err != nil {
+		return err
+	}
+	allowRun(flags)
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	argv := append(append([]string{exe}, globalArgs...), "run", task.Label)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	fmt.Fprintf(os.Stderr, "Watch: running %s on changes to %s (CTRL-C to stop)\n", label, strings.Join(globs, ", "))
+	return runner.WatchTask(ctx, label, root, globs, *debounce, argv)
+}
+
+// stringsFlag is a repeatable string flag.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
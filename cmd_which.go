@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// runWhichCommand prints where the task matching query is defined for `vstask which <label>`:
+// the definition vstask runs, then the ones it shadows, like `which -a`.
+func runWhichCommand(query string) error {
+	taskList, err := tasks.GetTasks()
+	if err != nil {
+		return err
+	}
+	task, err := tasks.FindTask(taskList, query)
+	if err != nil {
+		return err
+	}
+	root, err := tasks.ProjectRoot()
+	if err != nil {
+		return err
+	}
+	defs, err := tasks.WhichTask(root, task.Label)
+	if err != nil {
+		return err
+	}
+	if len(defs) == 0 {
+		return fmt.Errorf("no definition of %q found", task.Label)
+	}
+	fmt.Println(task.Label)
+	for i, d := range defs {
+		if i == 0 {
+			fmt.Printf("  %s\n", d)
+		} else {
+			fmt.Printf("  %s (shadowed)\n", d)
+		}
+	}
+	return nil
+}
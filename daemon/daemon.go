@@ -0,0 +1,248 @@
+// Package daemon implements `vstask daemon`: a long-running process that keeps parsed
+// workspaces in memory and serves them to the CLI over a local (unix) socket, so repeated
+// invocations skip root discovery, JSONC parsing and settings reads.
+//
+// The protocol is one JSON request and one JSON response per connection.
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/chenasraf/vstask/tasks"
+	"github.com/chenasraf/vstask/utils"
+)
+
+const socketName = "daemon.sock"
+
+// dialTimeout bounds how long the CLI waits for the daemon before reading the disk itself.
+const dialTimeout = 200 * time.Millisecond
+
+// Request ops.
+const (
+	OpPing     = "ping"
+	OpSnapshot = "snapshot" // parsed workspace for Cwd (used by run and list)
+	OpStop     = "stop"
+)
+
+type Request struct {
+	Op  string `json:"op"`
+	Cwd string `json:"cwd,omitempty"`
+}
+
+type Response struct {
+	Snapshot *tasks.Snapshot `json:"snapshot,omitempty"`
+	Pid      int             `json:"pid,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// SocketPath returns the daemon socket path ($VSTASK_DAEMON_SOCKET, or daemon.sock in the
+// user state directory).
+func SocketPath() (string, error) {
+	if p := os.Getenv("VSTASK_DAEMON_SOCKET"); p != "" {
+		return p, nil
+	}
+	dir, err := utils.UserStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, socketName), nil
+}
+
+// ---- server ----
+
+// Server answers requests from an in-memory cache of workspaces.
+type Server struct {
+	mu    sync.Mutex
+	roots map[string]string          // cwd -> workspace root
+	snaps map[string]*tasks.Snapshot // root -> parsed workspace
+	dirs  map[string]string          // watched directory -> root it belongs to
+	files map[string]bool            // watched files outside watchedNames (symlinked tasks.json targets)
+	w     *fsnotify.Watcher          // nil if notifications are unavailable
+	stop  chan struct{}
+	once  sync.Once
+}
+
+func NewServer() *Server {
+	s := &Server{
+		roots: map[string]string{},
+		snaps: map[string]*tasks.Snapshot{},
+		dirs:  map[string]string{},
+		files: map[string]bool{},
+		stop:  make(chan struct{}),
+	}
+	s.startWatcher()
+	return s
+}
+
+// Listen binds the socket at path. A leftover socket from a daemon that's no longer running
+// is removed; a live one is an error.
+func Listen(path string) (net.Listener, error) {
+	if _, err := os.Stat(path); err == nil {
+		if c, err := net.DialTimeout("unix", path, dialTimeout); err == nil {
+			c.Close()
+			return nil, fmt.Errorf("a daemon is already listening on %s", path)
+		}
+		_ = os.Remove(path)
+	}
+	return net.Listen("unix", path)
+}
+
+// Serve handles connections until the listener fails or a stop request arrives.
+func (s *Server) Serve(l net.Listener) error {
+	go func() {
+		<-s.stop
+		l.Close()
+	}()
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			select {
+			case <-s.stop:
+				return nil
+			default:
+				return err
+			}
+		}
+		go s.handle(c)
+	}
+}
+
+// Stop makes Serve return.
+func (s *Server) Stop() {
+	s.once.Do(func() {
+		close(s.stop)
+		if s.w != nil {
+			s.w.Close()
+		}
+	})
+}
+
+func (s *Server) handle(c net.Conn) {
+	defer c.Close()
+	_ = c.SetDeadline(time.Now().Add(10 * time.Second))
+
+	var req Request
+	if err := json.NewDecoder(c).Decode(&req); err != nil {
+		_ = json.NewEncoder(c).Encode(Response{Error: "bad request: " + err.Error()})
+		return
+	}
+	resp := s.Handle(req)
+	_ = json.NewEncoder(c).Encode(resp)
+	if req.Op == OpStop {
+		s.Stop()
+	}
+}
+
+// Handle answers one request.
+func (s *Server) Handle(req Request) Response {
+	switch req.Op {
+	case OpPing, OpStop:
+		return Response{Pid: os.Getpid()}
+	case OpSnapshot:
+		snap, err := s.snapshot(req.Cwd)
+		if err != nil {
+			return Response{Error: err.Error()}
+		}
+		return Response{Snapshot: snap}
+	default:
+		return Response{Error: fmt.Sprintf("unknown op %q", req.Op)}
+	}
+}
+
+func (s *Server) snapshot(cwd string) (*tasks.Snapshot, error) {
+	if cwd == "" {
+		return nil, errors.New("missing cwd")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	root, ok := s.roots[cwd]
+	if !ok {
+		r, err := utils.FindProjectRootFrom(cwd)
+		if err != nil {
+			return nil, err
+		}
+		root = r
+		s.roots[cwd] = root
+	}
+	if snap, ok := s.snaps[root]; ok {
+		return snap, nil
+	}
+	// Included files (URLs, too) aren't watched: load them on every request instead of
+	// caching. A generated tasks.json is only generated by runs (see tasks.GenerateTasksFile),
+	// and watched like any other. Providers run again too: the files they read may have changed.
+	tasks.ForgetProvidedTasks()
+	snap, err := tasks.LoadSnapshot(root)
+	if err != nil {
+		return nil, err
+	}
+	if !tasks.HasIncludes(root) && s.watch(root) {
+		s.snaps[root] = snap
+	}
+	return snap, nil
+}
+
+// ---- client ----
+
+// Call sends one request to the daemon at the default socket path.
+func Call(req Request) (Response, error) {
+	path, err := SocketPath()
+	if err != nil {
+		return Response{}, err
+	}
+	return CallAt(path, req)
+}
+
+// CallAt sends one request to the daemon listening on path.
+func CallAt(path string, req Request) (Response, error) {
+	c, err := net.DialTimeout("unix", path, dialTimeout)
+	if err != nil {
+		return Response{}, err
+	}
+	defer c.Close()
+	_ = c.SetDeadline(time.Now().Add(10 * time.Second))
+	if err := json.NewEncoder(c).Encode(req); err != nil {
+		return Response{}, err
+	}
+	var resp Response
+	if err := json.NewDecoder(c).Decode(&resp); err != nil {
+		return Response{}, err
+	}
+	if resp.Error != "" {
+		return resp, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+// UseIfRunning loads the current workspace from a running daemon into the tasks package
+// (see tasks.UseSnapshot). It's a no-op when no daemon is running or $VSTASK_NO_DAEMON=1.
+func UseIfRunning() {
+	if os.Getenv("VSTASK_NO_DAEMON") == "1" {
+		return
+	}
+	path, err := SocketPath()
+	if err != nil {
+		return
+	}
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	resp, err := CallAt(path, Request{Op: OpSnapshot, Cwd: cwd})
+	if err != nil || resp.Snapshot == nil {
+		return
+	}
+	tasks.UseSnapshot(resp.Snapshot)
+}
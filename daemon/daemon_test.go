@@ -0,0 +1,218 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func startServer(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets")
+	}
+	path := filepath.Join(t.TempDir(), "d.sock")
+	l, err := Listen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer()
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(l) }()
+	t.Cleanup(func() {
+		srv.Stop()
+		<-done
+	})
+	return path
+}
+
+func writeWorkspace(t *testing.T, tasksJSON string) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".vscode"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".vscode", "tasks.json"), []byte(tasksJSON), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	real, _ := filepath.EvalSymlinks(root)
+	return real
+}
+
+func TestDaemon_Snapshot(t *testing.T) {
+	path := startServer(t)
+	root := writeWorkspace(t, `{
+		// jsonc
+		"tasks": [{"label": "build", "dependsOn": ["lint"]}, {"label": "lint"}],
+		"inputs": [{"id": "env", "type": "promptString"}]
+	}`)
+	sub := filepath.Join(root, "src")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := CallAt(path, Request{Op: OpSnapshot, Cwd: sub})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := resp.Snapshot
+	if s == nil || s.Root != root {
+		t.Fatalf("unexpected snapshot: %+v", s)
+	}
+	if len(s.Tasks) != 2 || s.Tasks[0].DependsOn.Labels()[0] != "lint" || len(s.Inputs) != 1 {
+		t.Fatalf("unexpected contents: %+v", s)
+	}
+}
+
+func TestDaemon_Errors(t *testing.T) {
+	path := startServer(t)
+	if _, err := CallAt(path, Request{Op: "bogus"}); err == nil {
+		t.Fatal("expected error for unknown op")
+	}
+	if _, err := CallAt(path, Request{Op: OpSnapshot}); err == nil {
+		t.Fatal("expected error without cwd")
+	}
+}
+
+func TestListen_RefusesLiveDaemonAndReplacesStale(t *testing.T) {
+	path := startServer(t)
+	if _, err := Listen(path); err == nil {
+		t.Fatal("expected error while a daemon is listening")
+	}
+
+	stale := filepath.Join(t.TempDir(), "stale.sock")
+	if err := os.WriteFile(stale, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	l, err := Listen(stale)
+	if err != nil {
+		t.Fatalf("stale socket should be replaced: %v", err)
+	}
+	l.Close()
+}
+
+func TestUseIfRunning(t *testing.T) {
+	path := startServer(t)
+	root := writeWorkspace(t, `{"tasks": [{"label": "from-daemon"}]}`)
+	t.Setenv("VSTASK_DAEMON_SOCKET", path)
+	t.Setenv("VSTASK_NO_DAEMON", "")
+	t.Chdir(root)
+	t.Cleanup(func() { tasks.UseSnapshot(nil) })
+
+	UseIfRunning()
+	got, err := tasks.GetTasks()
+	if err != nil || len(got) != 1 || got[0].Label != "from-daemon" {
+		t.Fatalf("got %+v, %v", got, err)
+	}
+	if r, _ := tasks.ProjectRoot(); r != root {
+		t.Fatalf("root: got %q, want %q", r, root)
+	}
+}
+
+// ---- invalidation ----
+
+func waitForLabel(t *testing.T, path, cwd, want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := CallAt(path, Request{Op: OpSnapshot, Cwd: cwd})
+		if err == nil && len(resp.Snapshot.Tasks) > 0 && resp.Snapshot.Tasks[0].Label == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("snapshot never showed %q (last: %+v, %v)", want, resp.Snapshot, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestDaemon_InvalidatesOnChange(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	path := startServer(t)
+	root := writeWorkspace(t, `{"tasks": [{"label": "before"}]}`)
+	waitForLabel(t, path, root, "before")
+
+	tasksPath := filepath.Join(root, ".vscode", "tasks.json")
+	if err := os.WriteFile(tasksPath, []byte(`{"tasks": [{"label": "after"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitForLabel(t, path, root, "after")
+}
+
+func TestDaemon_InvalidatesOnPackageJSON(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	path := startServer(t)
+	root := writeWorkspace(t, `{"tasks": [{"label": "a"}]}`)
+
+	resp, err := CallAt(path, Request{Op: OpSnapshot, Cwd: root})
+	if err != nil || resp.Snapshot.PackageManager != "" {
+		t.Fatalf("got %+v, %v", resp.Snapshot, err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "package.json"), []byte(`{"packageManager": "yarn@4"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err = CallAt(path, Request{Op: OpSnapshot, Cwd: root})
+		if err == nil && resp.Snapshot.PackageManager == "yarn" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("package manager change not picked up: %+v, %v", resp.Snapshot, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestDaemon_InvalidatesOnSymlinkedTasksJSON(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	path := startServer(t)
+	root := writeWorkspace(t, `{}`)
+	shared, _ := filepath.EvalSymlinks(t.TempDir())
+	target := filepath.Join(shared, "shared-tasks.json")
+	if err := os.WriteFile(target, []byte(`{"tasks": [{"label": "before"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(root, ".vscode", "tasks.json")
+	if err := os.Remove(link); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+	waitForLabel(t, path, root, "before")
+
+	if err := os.WriteFile(target, []byte(`{"tasks": [{"label": "after"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitForLabel(t, path, root, "after")
+}
+
+func TestDaemon_InvalidatesOnWorkspaceFile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("VSTASK_WORKSPACE", "")
+	path := startServer(t)
+	root := writeWorkspace(t, `{"tasks": [{"label": "build"}]}`)
+	waitForLabel(t, path, root, "build")
+
+	ws := `{"folders": [{"path": "."}], "tasks": {"tasks": [{"label": "up"}]}}`
+	if err := os.WriteFile(filepath.Join(root, "app.code-workspace"), []byte(ws), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := CallAt(path, Request{Op: OpSnapshot, Cwd: root})
+		if err == nil && len(resp.Snapshot.Tasks) == 2 && resp.Snapshot.Tasks[1].Label == "up" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("workspace file change not picked up: %+v, %v", resp.Snapshot, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,144 @@
+package daemon
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/chenasraf/vstask/tasks"
+	"github.com/chenasraf/vstask/utils"
+)
+
+// watchedNames are the files whose changes invalidate a cached workspace (including the files
+// task providers read). The .vscode directory itself is included so creating or removing it
+// is noticed too.
+var watchedNames = map[string]bool{
+	utils.TASKS_JSON: true,
+	utils.TASKS_YAML: true,
+	utils.TASKS_YML:  true,
+	"settings.json":  true,
+	"package.json":   true,
+	"Makefile":       true,
+	"makefile":       true,
+	"GNUmakefile":    true,
+	"Cargo.toml":     true,
+	"go.mod":         true,
+	"composer.json":  true,
+	"Rakefile":       true,
+	"rakefile":       true,
+	"Rakefile.rb":    true,
+	"rakefile.rb":    true,
+	utils.VSCODE_DIR: true,
+}
+
+// workspaceFileExt is the extension of multi-root workspace files, whose tasks the folders
+// they list get too (see tasks.FindWorkspace): their changes invalidate a cached workspace
+// like those of watchedNames.
+const workspaceFileExt = ".code-workspace"
+
+// allRoots marks a watched directory whose changes affect every workspace (user settings).
+const allRoots = ""
+
+// startWatcher sets up filesystem notifications. Without a watcher the server doesn't cache
+// snapshots at all, so it can never serve a stale task list.
+func (s *Server) startWatcher() {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return
+	}
+	s.w = w
+	for _, p := range tasks.UserSettingsPaths() {
+		if utils.DirExists(filepath.Dir(p)) {
+			if err := w.Add(filepath.Dir(p)); err == nil {
+				s.dirs[filepath.Dir(p)] = allRoots
+			}
+		}
+	}
+	go s.watchLoop(w)
+}
+
+// watch starts watching a workspace root and its .vscode directory, plus the directory of what
+// tasks.json links to if it's a symlink and that of the .code-workspace file it belongs to
+// (callers hold s.mu). It reports whether the root is fully watched, i.e. whether its
+// snapshot may be cached.
+func (s *Server) watch(root string) bool {
+	if s.w == nil {
+		return false
+	}
+	dirs := []string{root, filepath.Join(root, utils.VSCODE_DIR)}
+	if ws, err := tasks.FindWorkspace(root); err == nil && ws != nil {
+		dirs = append(dirs, filepath.Dir(ws.File))
+	}
+	for _, dir := range dirs {
+		if r, ok := s.dirs[dir]; ok {
+			if r != root {
+				s.dirs[dir] = allRoots // shared by several workspaces
+			}
+			continue
+		}
+		if err := s.w.Add(dir); err != nil {
+			return false
+		}
+		s.dirs[dir] = root
+	}
+	if target, ok := tasks.TasksFileTarget(root); ok {
+		dir := filepath.Dir(target)
+		if r, ok := s.dirs[dir]; !ok {
+			if err := s.w.Add(dir); err != nil {
+				return false
+			}
+			s.dirs[dir] = root
+		} else if r != root {
+			s.dirs[dir] = allRoots // shared by several workspaces
+		}
+		s.files[target] = true
+	}
+	return true
+}
+
+// watched reports whether a change to the file at p may invalidate a cached workspace.
+func (s *Server) watched(p string) bool {
+	if watchedNames[filepath.Base(p)] || strings.HasSuffix(p, workspaceFileExt) {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.files[p]
+}
+
+func (s *Server) watchLoop(w *fsnotify.Watcher) {
+	for {
+		select {
+		case ev, ok := <-w.Events:
+			if !ok {
+				return
+			}
+			if s.watched(ev.Name) {
+				s.invalidate(filepath.Dir(ev.Name))
+			}
+		case _, ok := <-w.Errors:
+			if !ok {
+				return
+			}
+			// Events may have been dropped; start over rather than risk stale data.
+			s.invalidate(allRoots)
+		}
+	}
+}
+
+// invalidate drops cached state affected by a change in dir.
+func (s *Server) invalidate(dir string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	root, ok := s.dirs[dir]
+	if !ok || root == allRoots {
+		clear(s.snaps)
+		clear(s.roots)
+		return
+	}
+	delete(s.snaps, root)
+	// A .vscode directory appearing or disappearing can change which root a cwd maps to.
+	clear(s.roots)
+}
@@ -0,0 +1,4 @@
+// Package integration holds vstask's end-to-end tests: each case in testdata is a workspace
+// the vstask binary runs in, with the output, exit code and files it should produce (see
+// integration_test.go for the layout). Run with -update to rewrite the expected output.
+package integration
@@ -0,0 +1,177 @@
+package integration
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+var update = flag.Bool("update", false, "rewrite the expected output and exit codes")
+
+// vstaskBin is the binary under test, built once by TestMain.
+var vstaskBin string
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	dir, err := os.MkdirTemp("", "vstask-integration-")
+	if err != nil {
+		panic(err)
+	}
+	vstaskBin = filepath.Join(dir, "vstask")
+	if runtime.GOOS == "windows" {
+		vstaskBin += ".exe"
+	}
+	build := exec.Command("go", "build", "-o", vstaskBin, "..")
+	build.Stderr = os.Stderr
+	if err := build.Run(); err != nil {
+		panic("build vstask: " + err.Error())
+	}
+	code := m.Run()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// TestCLI runs every case in testdata. A case is a directory with:
+//
+//	ws/      the workspace, copied to a temporary directory the command runs in ($WORK)
+//	args     the command-line arguments, one per line
+//	env      optional KEY=VALUE lines added to the environment ($WORK and $PATH are expanded)
+//	output   the expected stdout and stderr, with the workspace path written as $WORK
+//	exit     optional expected exit code (default 0)
+//	want/    optional files the run must leave in the workspace, with their expected content
+//
+// Each case runs with its own state directory and home, and its workspace already trusted.
+func TestCLI(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	cases, err := os.ReadDir("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range cases {
+		if c.IsDir() {
+			t.Run(c.Name(), func(t *testing.T) { runCase(t, filepath.Join("testdata", c.Name())) })
+		}
+	}
+}
+
+func runCase(t *testing.T, dir string) {
+	work := filepath.Join(t.TempDir(), "ws")
+	if err := os.CopyFS(work, os.DirFS(filepath.Join(dir, "ws"))); err != nil {
+		t.Fatal(err)
+	}
+	state := t.TempDir()
+	t.Setenv("VSTASK_STATE_DIR", state)
+	if err := tasks.TrustWorkspace(work); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(vstaskBin, lines(t, filepath.Join(dir, "args"))...)
+	cmd.Dir = work
+	cmd.Env = append(os.Environ(), "VSTASK_STATE_DIR="+state, "HOME="+t.TempDir(), "XDG_CONFIG_HOME=", "NO_COLOR=1")
+	for _, kv := range lines(t, filepath.Join(dir, "env")) {
+		cmd.Env = append(cmd.Env, os.Expand(kv, func(name string) string {
+			switch name {
+			case "WORK":
+				return work
+			default:
+				return os.Getenv(name)
+			}
+		}))
+	}
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	code := 0
+	if err := cmd.Run(); err != nil {
+		var ee *exec.ExitError
+		if !errors.As(err, &ee) {
+			t.Fatal(err)
+		}
+		code = ee.ExitCode()
+	}
+	got := strings.ReplaceAll(out.String(), work, "$WORK")
+
+	if *update {
+		write(t, filepath.Join(dir, "output"), got)
+		if code != 0 {
+			write(t, filepath.Join(dir, "exit"), strconv.Itoa(code)+"\n")
+		} else {
+			_ = os.Remove(filepath.Join(dir, "exit"))
+		}
+		return
+	}
+	if want := read(t, filepath.Join(dir, "output")); got != want {
+		t.Errorf("output:\n%s\nwant:\n%s", got, want)
+	}
+	want := 0
+	if s := read(t, filepath.Join(dir, "exit")); s != "" {
+		want, _ = strconv.Atoi(strings.TrimSpace(s))
+	}
+	if code != want {
+		t.Errorf("exit code %d, want %d", code, want)
+	}
+	checkFiles(t, filepath.Join(dir, "want"), work)
+}
+
+// checkFiles compares every file under wantDir with the one at the same path in work.
+func checkFiles(t *testing.T, wantDir, work string) {
+	t.Helper()
+	err := filepath.WalkDir(wantDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(wantDir, p)
+		got, err := os.ReadFile(filepath.Join(work, rel))
+		if err != nil {
+			t.Errorf("%s: %v", rel, err)
+			return nil
+		}
+		if want := read(t, p); string(got) != want {
+			t.Errorf("%s:\n%s\nwant:\n%s", rel, got, want)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		t.Fatal(err)
+	}
+}
+
+// read returns the file's content, or "" if it doesn't exist.
+func read(t *testing.T, p string) string {
+	t.Helper()
+	b, err := os.ReadFile(p)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func write(t *testing.T, p, content string) {
+	t.Helper()
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// lines returns the file's non-empty lines.
+func lines(t *testing.T, p string) []string {
+	t.Helper()
+	var out []string
+	for _, l := range strings.Split(read(t, p), "\n") {
+		if l != "" {
+			out = append(out, l)
+		}
+	}
+	return out
+}
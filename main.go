@@ -4,8 +4,10 @@ import (
 	_ "embed"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
+	"github.com/chenasraf/vstask/daemon"
 	"github.com/chenasraf/vstask/runner"
 	"github.com/chenasraf/vstask/tasks"
 	"github.com/chenasraf/vstask/utils"
@@ -17,6 +19,47 @@ var appVersion []byte // appVersion is embedded from version.txt and contains th
 func main() {
 	utils.SetVersion(strings.TrimSpace(string(appVersion)))
 	args := os.Args[1:]
+
+	flags, args, err := parseGlobalFlags(args)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	if flags.force {
+		tasks.ForceVersion()
+	}
+	if flags.verbose {
+		_ = os.Setenv("VSTASK_TRACE", "1") // also for the vstask processes it starts (see watch)
+	}
+	if flags.shellFallback != "" {
+		_ = os.Setenv("VSTASK_SHELL_FALLBACK", flags.shellFallback)
+	}
+	if flags.group != "" {
+		if len(args) > 0 {
+			fmt.Printf("Error: --group runs the group's task; got %q too\n", args[0])
+			os.Exit(1)
+		}
+		daemon.UseIfRunning()
+		allowRun(flags)
+		runGroupTask(flags.group, flags)
+		os.Exit(0)
+	}
+	if len(args) > 0 && taskCommands[args[0]] {
+		// A task labelled like the subcommand runs instead, as it did before the subcommand.
+		if taskList, err := tasks.GetTasks(); err == nil {
+			shadowed, err := shadowingTask(taskList, args)
+			if err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			if shadowed {
+				daemon.UseIfRunning()
+				allowRun(flags)
+				runNamedTask(args[0], flags)
+				os.Exit(0)
+			}
+		}
+	}
 	if len(args) > 0 {
 		switch args[0] {
 		case "--help", "-h":
@@ -25,22 +68,178 @@ func main() {
 		case "-v", "--version":
 			utils.PrintVersion()
 			os.Exit(0)
+		case "help":
+			if len(args) < 2 {
+				utils.PrintHelp()
+				os.Exit(0)
+			}
+			if err := runHelpCommand(args[1]); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		case "which":
+			if len(args) < 2 {
+				fmt.Println("Usage: vstask which <task-name>")
+				os.Exit(1)
+			}
+			if err := runWhichCommand(args[1]); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		case "settings":
+			if err := runSettingsCommand(args[1:]); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		case "daemon":
+			if err := runDaemonCommand(args[1:]); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		case "--retry-failed":
+			daemon.UseIfRunning()
+			allowRun(flags)
+			ran, err := runner.RetryFailed(flags.runOptions())
+			if err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(runner.ExitCode(err))
+			}
+			if !ran {
+				fmt.Println("Nothing to retry: the last run succeeded.")
+			}
+			os.Exit(0)
+		case "--last":
+			daemon.UseIfRunning()
+			allowRun(flags)
+			label, ok := lastRunLabel()
+			if !ok {
+				fmt.Println("Error: no previous run in this workspace")
+				os.Exit(1)
+			}
+			runNamedTask(label, flags)
+			os.Exit(0)
+		case "folder-open":
+			daemon.UseIfRunning()
+			allowRun(flags)
+			if err := runFolderOpenCommand(flags); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(runner.ExitCode(err))
+			}
+			os.Exit(0)
+		case "bug-report":
+			if err := runBugReportCommand(args[1:]); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		case "secret":
+			if err := runSecretCommand(args[1:]); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		case "clean":
+			if err := runCleanCommand(args[1:]); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		case "list":
+			daemon.UseIfRunning()
+			if err := runListCommand(args[1:]); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		case "diff":
+			if err := runDiffCommand(args[1:]); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		case "aliases":
+			if err := runAliasesCommand(args[1:]); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		case "status":
+			if err := runStatusCommand(args[1:]); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		case "stop":
+			if err := runStopCommand(args[1:]); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		case "logs":
+			if err := runLogsCommand(args[1:]); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		case "run":
+			daemon.UseIfRunning()
+			if err := runRunCommand(args[1:], flags); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(runner.ExitCode(err))
+			}
+			os.Exit(0)
+		case "build", "test":
+			// Shortcuts for --group, unless the name finds a task (it runs as before).
+			if len(args) == 1 {
+				daemon.UseIfRunning()
+				allowRun(flags)
+				runNamedOrGroupTask(args[0], flags)
+				os.Exit(0)
+			}
+		case "watch":
+			daemon.UseIfRunning()
+			globalArgs := os.Args[1 : len(os.Args)-len(args)]
+			if err := runWatchCommand(args[1:], globalArgs, flags); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		case "upgrade-schema":
+			if err := runUpgradeSchemaCommand(args[1:]); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		case "add":
+			if err := runAddCommand(args[1:], flags); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		case "completion":
+			if err := runCompletionCommand(args[1:]); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		case "__complete":
+			daemon.UseIfRunning()
+			runCompleteCommand(args[1:])
+			os.Exit(0)
 		}
-		taskList, err := tasks.GetTasks()
-		if err != nil {
-			fmt.Println("Error:", err)
-			os.Exit(1)
-		}
-		task, err := tasks.FindTask(taskList, args[0])
-		if err != nil {
-			fmt.Println("Error:", err)
-			os.Exit(1)
-		}
-		err = runner.RunTask(task)
-		if err != nil {
-			fmt.Println("Error:", err)
-			os.Exit(1)
-		}
+		daemon.UseIfRunning()
+		allowRun(flags)
+		runNamedTask(args[0], flags)
+		os.Exit(0)
+	}
+	daemon.UseIfRunning()
+	rc := allowRun(flags)
+	if label := defaultTaskLabel(rc); label != "" {
+		runNamedTask(label, flags)
 		os.Exit(0)
 	}
 	selected, err := tasks.PromptForTask()
@@ -52,9 +251,240 @@ func main() {
 		fmt.Println("No task selected.")
 		os.Exit(1)
 	}
-	err = runner.RunTask(selected)
+	runTask(selected, flags)
+}
+
+// globalFlags are the options accepted before the task name or command.
+type globalFlags struct {
+	trust         bool // --trust: trust the workspace (see ensureTrusted)
+	acceptChanges bool // --accept-changes: approve tasks.json changes (see ensureApprovedTasks)
+	force         bool // --force: read tasks files of unsupported schema versions (see tasks.ForceVersion)
+
+	// --file, --line, --selected-text: stand-ins for the editor's active file
+	file runner.FileContext
+
+	maxParallel  int      // --max-parallel: how many dependencies run at once (see runner.Options)
+	dependsOrder string   // --depends-order: every task's dependsOrder for this run (see runner.Options)
+	startAt      string   // --start-at: resume sequences at this step (see runner.Options)
+	skip         []string // --skip, repeatable: dependencies not to run (see runner.Options)
+	fail         []string // --fail, repeatable: tasks reported as failed without running (see runner.Options)
+	group        string   // --group: run this group's default task (see groupTask)
+	hermetic     bool     // --hermetic: resolve process commands up front (see runner.Options)
+	noInput      bool     // --no-input: never prompt for ${input:*} (see runner.Options)
+	quiet        bool     // --quiet: leave out progress lines (see runner.Options)
+	prefixOutput bool     // --prefix-output: start output lines with the task's name (see runner.Options)
+	timestamps   bool     // --timestamps: start output lines with the time (see runner.Options)
+
+	failOnProblems bool   // --fail-on-problems: fail when problem matchers find errors (see runner.Options)
+	sarif          string // --sarif <file>: write the problems found as SARIF (see runner.Options)
+
+	verbose       bool   // --verbose: trace fallbacks and other decisions (see utils.Trace)
+	shellFallback string // --shell-fallback: the shell to retry a bash that can't start with, or "off" (see tasks.ShellFallback)
+
+	inputs map[string]string // --input id=value, repeatable
+}
+
+// parseGlobalFlags strips the leading global flags from args. Flags with a value take it as
+// the next argument or after "=" (--file=main.go); switches only after "=" (--trust=false).
+func parseGlobalFlags(args []string) (globalFlags, []string, error) {
+	var f globalFlags
+	for len(args) > 0 {
+		name, val, hasVal := strings.Cut(args[0], "=")
+		value := func() (string, error) {
+			if hasVal {
+				return val, nil
+			}
+			if len(args) < 2 {
+				return "", fmt.Errorf("%s needs a value", name)
+			}
+			args = args[1:]
+			return args[0], nil
+		}
+		// A switch is true unless given "=false" (or another strconv.ParseBool false).
+		boolValue := func() (bool, error) {
+			if !hasVal {
+				return true, nil
+			}
+			b, err := strconv.ParseBool(val)
+			if err != nil {
+				return false, fmt.Errorf("%s: want true or false, got %q", name, val)
+			}
+			return b, nil
+		}
+		var err error
+		switch name {
+		case "--trust":
+			f.trust, err = boolValue()
+		case "--accept-changes":
+			f.acceptChanges, err = boolValue()
+		case "--force":
+			f.force, err = boolValue()
+		case "--file":
+			f.file.Path, err = value()
+		case "--line":
+			var v string
+			if v, err = value(); err == nil {
+				if f.file.Line, err = strconv.Atoi(v); err != nil || f.file.Line < 1 {
+					err = fmt.Errorf("--line: not a line number: %q", v)
+				}
+			}
+		case "--selected-text":
+			f.file.SelectedText, err = value()
+		case "--group":
+			f.group, err = value()
+		case "--hermetic":
+			f.hermetic, err = boolValue()
+		case "--no-input":
+			f.noInput, err = boolValue()
+		case "--quiet", "-q":
+			f.quiet, err = boolValue()
+		case "--prefix-output":
+			f.prefixOutput, err = boolValue()
+		case "--timestamps":
+			f.timestamps, err = boolValue()
+		case "--verbose":
+			f.verbose, err = boolValue()
+		case "--shell-fallback":
+			f.shellFallback, err = value()
+		case "--fail-on-problems":
+			f.failOnProblems, err = boolValue()
+		case "--sarif":
+			f.sarif, err = value()
+		case "--input":
+			var v string
+			if v, err = value(); err == nil {
+				id, val, ok := strings.Cut(v, "=")
+				if id = strings.TrimSpace(id); !ok || id == "" {
+					err = fmt.Errorf("--input: want id=value, got %q", v)
+				} else {
+					if f.inputs == nil {
+						f.inputs = map[string]string{}
+					}
+					f.inputs[id] = val
+				}
+			}
+		case "--depends-order":
+			if f.dependsOrder, err = value(); err == nil && f.dependsOrder != "sequence" && f.dependsOrder != "parallel" {
+				err = fmt.Errorf("--depends-order: want sequence or parallel, got %q", f.dependsOrder)
+			}
+		case "--start-at":
+			f.startAt, err = value()
+		case "--skip":
+			var v string
+			if v, err = value(); err == nil {
+				f.skip = append(f.skip, v)
+			}
+		case "--fail":
+			var v string
+			if v, err = value(); err == nil {
+				f.fail = append(f.fail, v)
+			}
+		case "--max-parallel":
+			var v string
+			if v, err = value(); err == nil {
+				if f.maxParallel, err = strconv.Atoi(v); err != nil || f.maxParallel < 1 {
+					err = fmt.Errorf("--max-parallel: not a positive number: %q", v)
+				}
+			}
+		default:
+			return f, args, nil
+		}
+		if err != nil {
+			return f, nil, err
+		}
+		args = args[1:]
+	}
+	return f, args, nil
+}
+
+// runOptions are the runner options the global flags set.
+func (f globalFlags) runOptions() runner.Options {
+	opts := runner.Options{MaxParallel: f.maxParallel, DependsOrder: f.dependsOrder, StartAt: f.startAt, Skip: f.skip, Fail: f.fail, Hermetic: f.hermetic, NoInput: f.noInput, Quiet: f.quiet, PrefixOutput: f.prefixOutput, Timestamps: f.timestamps, Inputs: f.inputs, FailOnProblems: f.failOnProblems, SARIF: f.sarif}
+	if f.file != (runner.FileContext{}) {
+		opts.File = &f.file
+	}
+	return opts
+}
+
+// allowRun exits unless the workspace is trusted (see ensureTrusted), as are the URLs its
+// tasks.json includes (see ensureTrustedIncludes), and its tasks.json changes are approved (see
+// ensureApprovedTasks). A generated tasks.json is generated in between (see
+// tasks.GenerateTasksFile), once its generator is approved. Then it applies the per-directory
+// defaults of the .vstaskrc files (see tasks.LoadRC), their env included, and returns them:
+// only once the workspace is trusted, as variables like BASH_ENV can run code.
+func allowRun(f globalFlags) tasks.RC {
+	err := ensureTrusted(f.trust)
+	if err == nil {
+		err = ensureApprovedGenerator(f.acceptChanges)
+	}
+	if err == nil {
+		if root, rootErr := tasks.ProjectRoot(); rootErr == nil {
+			tasks.GenerateTasksFile(root)
+		}
+		err = ensureTrustedIncludes(f.trust)
+	}
+	if err == nil {
+		err = ensureApprovedTasks(f.acceptChanges)
+	}
+	var rc tasks.RC
+	if err == nil {
+		root, _ := tasks.ProjectRoot()
+		if rc, err = tasks.LoadRC(".", root); err == nil {
+			err = rc.ApplyEnv()
+		}
+	}
 	if err != nil {
 		fmt.Println("Error:", err)
 		os.Exit(1)
 	}
+	return rc
+}
+
+// runNamedTask finds a task by label (see tasks.FindTask) and runs it, exiting on error.
+func runNamedTask(name string, flags globalFlags) {
+	taskList, err := tasks.GetTasks()
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(runner.ExitCode(err))
+	}
+	task, err := tasks.FindTask(taskList, name)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(runner.ExitCode(err))
+	}
+	runTask(task, flags)
+}
+
+// runNamedOrGroupTask runs the task name finds (see tasks.FindTask), as `vstask <name>` would,
+// or else group name's task (see runGroupTask), for `vstask build` and `vstask test`.
+func runNamedOrGroupTask(name string, flags globalFlags) {
+	taskList, err := tasks.GetTasks()
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(runner.ExitCode(err))
+	}
+	if task, err := tasks.FindTask(taskList, name); err == nil {
+		runTask(task, flags)
+		return
+	}
+	runGroupTask(name, flags)
+}
+
+// runGroupTask runs group's task (see groupTask), exiting on failure like runTask.
+func runGroupTask(group string, flags globalFlags) {
+	task, err := groupTask(group, flags)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(runner.ExitCode(err))
+	}
+	runTask(task, flags)
+}
+
+// runTask runs task (or its replacement, see forwardDeprecated). If it fails, vstask exits
+// with the failing task's exit code (see runner.ExitCode).
+func runTask(task tasks.Task, flags globalFlags) {
+	if err := runner.Run(forwardDeprecated(task), flags.runOptions()); err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(runner.ExitCode(err))
+	}
 }
@@ -0,0 +1,198 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"time"
+)
+
+// backgroundTasks tracks a run's readiness-gated dependencies (background tasks such as
+// watchers), which keep running once ready. They're stopped, process tree and all, when the
+// run ends, however it ends.
+type backgroundTasks struct {
+	mu    sync.Mutex
+	procs []*backgroundProc
+
+	// events receives what health checks notice and do (see healthMonitor); nil prints them.
+	events func(healthEvent)
+	// cycles receives the tasks' compile cycles (see cycleTracker); nil prints them.
+	cycles func(CycleEvent)
+	// workspace is where the tasks are registered and their output logged (see
+	// BackgroundTasks); "" for neither.
+	workspace string
+}
+
+// backgroundProc is a tracked task; its process changes when its health check restarts it.
+type backgroundProc struct {
+	name   string
+	health *healthMonitor // nil without a healthCheck
+	log    *backgroundLog // nil when not logged
+
+	mu  sync.Mutex
+	cmd *execCmdShim
+}
+
+func (p *backgroundProc) current() *execCmdShim {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.cmd
+}
+
+func (p *backgroundProc) replace(cmd *execCmdShim) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cmd = cmd
+}
+
+// add tracks cmd, which startAndWaitReady left running, registers it as the task name
+// (labeled label), and starts its health check, if any.
+func (b *backgroundTasks) add(name, label string, cmd *execCmdShim, health *healthMonitor, log *backgroundLog) {
+	if b == nil || cmd.exited == nil {
+		return
+	}
+	p := &backgroundProc{name: name, cmd: cmd, health: health, log: log}
+	b.register(name, label, cmd, log)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.procs = append(b.procs, p)
+	if health != nil {
+		emit := b.events
+		if emit == nil {
+			emit = printHealthEvent
+		}
+		go health.watch(p, emit)
+	}
+}
+
+// cycleSink returns where the compile cycles of b's tasks go.
+func (b *backgroundTasks) cycleSink() func(CycleEvent) {
+	if b == nil || b.cycles == nil {
+		return printCycleEvent
+	}
+	return b.cycles
+}
+
+// stopAll stops the tracked tasks that are still running and waits for them to exit.
+func (b *backgroundTasks) stopAll() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var wg sync.WaitGroup
+	for _, p := range b.procs {
+		if p.health != nil {
+			p.health.halt() // so it doesn't restart what's being stopped
+		}
+		cmd := p.current()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case <-cmd.exited: // already done by itself
+			default:
+				fmt.Printf("Stopping background task: %s\n", p.name)
+				_ = terminateProcessTree(cmd.Cmd)
+				<-cmd.exited
+			}
+			if b.workspace != "" && cmd.Cmd != nil && cmd.Cmd.Process != nil {
+				_, _ = unregisterBackground(b.workspace, p.name, cmd.Cmd.Process.Pid)
+			}
+			if p.log != nil {
+				_ = p.log.Close()
+			}
+		}()
+	}
+	wg.Wait()
+	b.procs = nil
+}
+
+// tracks reports whether every task of names is tracked, i.e. was left running.
+func (b *backgroundTasks) tracks(names []string) bool {
+	if b == nil || len(names) == 0 {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, name := range names {
+		if !slices.ContainsFunc(b.procs, func(p *backgroundProc) bool { return p.name == name }) {
+			return false
+		}
+	}
+	return true
+}
+
+// wait waits for the tracked tasks to exit for good: one a health check may restart is waited
+// for until the check gives up (or is halted, see stopAll).
+func (b *backgroundTasks) wait() {
+	b.mu.Lock()
+	procs := slices.Clone(b.procs)
+	b.mu.Unlock()
+	for _, p := range procs {
+		if p.health != nil {
+			<-p.health.done
+		}
+		<-p.current().exited
+	}
+}
+
+// openLog starts the log of the task name's output, or returns nil without a workspace or if
+// it can't (a task isn't held up for its log).
+func (b *backgroundTasks) openLog(name string) *backgroundLog {
+	if b == nil || b.workspace == "" {
+		return nil
+	}
+	log, err := openBackgroundLog(b.workspace, name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: logging %s: %v\n", name, err)
+		return nil
+	}
+	return log
+}
+
+// register records cmd as the running process of the task name, for `vstask status`, `stop`
+// and `logs` (best effort).
+func (b *backgroundTasks) register(name, label string, cmd *execCmdShim, log *backgroundLog) {
+	if b == nil || b.workspace == "" || cmd.Cmd == nil || cmd.Cmd.Process == nil {
+		return
+	}
+	pid, owner := cmd.Cmd.Process.Pid, os.Getpid()
+	t := BackgroundTask{Name: name, Label: label, PID: pid, Started: time.Now(), Owner: owner, Start: processStart(pid), OwnerStart: processStart(owner)}
+	if log != nil {
+		t.Log = log.path
+	}
+	_ = registerBackground(b.workspace, t)
+}
+
+// stoppedElsewhere reports whether cmd, the process of the task name, was stopped with
+// `vstask stop`, which unregisters it: it's not to be restarted.
+func (b *backgroundTasks) stoppedElsewhere(name string, cmd *execCmdShim) bool {
+	if b == nil || b.workspace == "" || cmd.Cmd == nil || cmd.Cmd.Process == nil {
+		return false
+	}
+	return !isRegistered(b.workspace, name, cmd.Cmd.Process.Pid)
+}
+
+// handleSignals makes an interrupt end the run: background tasks are stopped right away and no
+// more tasks start (the running ones handle the signal themselves). Call the returned func once
+// the run is over.
+func (r *graphRun) handleSignals() (stop func()) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, trapSignals()...)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-sigs:
+			r.interrupted.Store(true)
+			r.background.stopAll()
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(sigs)
+		close(done)
+	}
+}
@@ -0,0 +1,239 @@
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/chenasraf/vstask/utils"
+)
+
+// Background dependencies left running by a run are registered in the workspace's state
+// directory, so `vstask status`, `vstask stop` and `vstask logs` can find them from another
+// terminal. Their output is also written to a log file there, kept after they exit.
+const (
+	backgroundRegistryFile = "background.json"
+	backgroundLogsDirName  = "logs"
+)
+
+// ErrBackgroundTaskNotRunning is returned by StopBackgroundTask for a task that isn't running.
+var ErrBackgroundTaskNotRunning = errors.New("background task not running")
+
+// errStoppedElsewhere keeps a health check from restarting a task stopped with `vstask stop`.
+var errStoppedElsewhere = errors.New("stopped with vstask stop")
+
+// BackgroundTask is a background task a run left running, as registered.
+type BackgroundTask struct {
+	Name    string    `json:"name"`  // as in "Running task: ..." (see instanceName)
+	Label   string    `json:"label"` // the task's label
+	PID     int       `json:"pid"`   // its process, the leader of its process group (Unix)
+	Log     string    `json:"log"`   // the file its output goes to
+	Started time.Time `json:"started"`
+	Owner   int       `json:"owner"` // the vstask process running it
+
+	// When the process and its owner started, as processStart tells, to know them from later
+	// processes given the same PIDs; 0 if that can't be told.
+	Start      uint64 `json:"start,omitempty"`
+	OwnerStart uint64 `json:"ownerStart,omitempty"`
+}
+
+// running reports whether t's process is still there, and the run that started it too: once the
+// run is gone, its tasks were stopped with it (or orphaned, past vstask's reach).
+func (t BackgroundTask) running() bool {
+	return sameProcess(t.PID, t.Start) && sameProcess(t.Owner, t.OwnerStart)
+}
+
+// sameProcess reports whether the process pid exists and is the one that started at start (see
+// processStart), if that's known: a PID is reused once its process is gone.
+func sameProcess(pid int, start uint64) bool {
+	if !processAlive(pid) {
+		return false
+	}
+	if start == 0 {
+		return true
+	}
+	cur := processStart(pid)
+	return cur == 0 || cur == start
+}
+
+// matches reports whether name designates t (its name or its label).
+func (t BackgroundTask) matches(name string) bool {
+	return t.Name == name || t.Label == name
+}
+
+func backgroundRegistryPath(workspace string) (string, error) {
+	return statePath(workspace, backgroundRegistryFile)
+}
+
+// reLogNameUnsafe matches what a task name can't keep in its log file's name.
+var reLogNameUnsafe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// BackgroundLogPath returns the log file of the background task name in workspace (which exists
+// once the task ran in the background).
+func BackgroundLogPath(workspace, name string) (string, error) {
+	dir, err := utils.WorkspaceStateDir(workspace)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(name))
+	file := reLogNameUnsafe.ReplaceAllString(name, "_") + "-" + hex.EncodeToString(sum[:])[:8] + ".log"
+	return filepath.Join(dir, backgroundLogsDirName, file), nil
+}
+
+// registerBackground records t, replacing an earlier entry of the same name.
+func registerBackground(workspace string, t BackgroundTask) error {
+	p, err := backgroundRegistryPath(workspace)
+	if err != nil {
+		return err
+	}
+	return utils.UpdateJSONFile(p, func(ts *[]BackgroundTask) error {
+		*ts = slices.DeleteFunc(*ts, func(o BackgroundTask) bool { return o.Name == t.Name })
+		*ts = append(*ts, t)
+		return nil
+	})
+}
+
+// unregisterBackground drops the entry of name if it's still the process pid, and reports
+// whether there was one: a task stopped with `vstask stop` is gone already.
+func unregisterBackground(workspace, name string, pid int) (bool, error) {
+	p, err := backgroundRegistryPath(workspace)
+	if err != nil {
+		return false, err
+	}
+	found := false
+	err = utils.UpdateJSONFile(p, func(ts *[]BackgroundTask) error {
+		*ts = slices.DeleteFunc(*ts, func(o BackgroundTask) bool {
+			match := o.Name == name && o.PID == pid
+			found = found || match
+			return match
+		})
+		return nil
+	})
+	return found, err
+}
+
+// isRegistered reports whether name is registered as the process pid.
+func isRegistered(workspace, name string, pid int) bool {
+	p, err := backgroundRegistryPath(workspace)
+	if err != nil {
+		return false
+	}
+	var ts []BackgroundTask
+	if utils.ReadJSONFile(p, &ts) != nil {
+		return false
+	}
+	return slices.ContainsFunc(ts, func(o BackgroundTask) bool { return o.Name == name && o.PID == pid })
+}
+
+// BackgroundTasks returns the background tasks running in workspace, oldest first. Entries of
+// processes that are gone, or whose run is (it was killed, say), are dropped.
+func BackgroundTasks(workspace string) ([]BackgroundTask, error) {
+	p, err := backgroundRegistryPath(workspace)
+	if err != nil {
+		return nil, err
+	}
+	var running []BackgroundTask
+	err = utils.UpdateJSONFile(p, func(ts *[]BackgroundTask) error {
+		*ts = slices.DeleteFunc(*ts, func(t BackgroundTask) bool { return !t.running() })
+		running = slices.Clone(*ts)
+		return nil
+	})
+	return running, err
+}
+
+// StopBackgroundTask stops the running background tasks named (or labeled) name in workspace,
+// process trees and all, and returns them. The runs that started them carry on without them:
+// their health checks don't restart them.
+func StopBackgroundTask(workspace, name string) ([]BackgroundTask, error) {
+	running, err := BackgroundTasks(workspace)
+	if err != nil {
+		return nil, err
+	}
+	var stopped []BackgroundTask
+	for _, t := range running {
+		if !t.matches(name) {
+			continue
+		}
+		// Unregistered first, so its health check sees it was stopped on purpose.
+		if _, err := unregisterBackground(workspace, t.Name, t.PID); err != nil {
+			return stopped, err
+		}
+		if !sameProcess(t.PID, t.Start) {
+			continue // exited meanwhile
+		}
+		stopProcessTree(t.PID, t.Start)
+		stopped = append(stopped, t)
+	}
+	if len(stopped) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrBackgroundTaskNotRunning, name)
+	}
+	return stopped, nil
+}
+
+// stopProcessTree stops the process group (Unix) or tree (Windows) of pid, another process's
+// child that started at start (see sameProcess): asked to terminate first, then killed if it's
+// still there after a grace period.
+func stopProcessTree(pid int, start uint64) {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return
+	}
+	killTree(p)
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		if !sameProcess(pid, start) {
+			return
+		}
+	}
+	forceKillTree(pid)
+}
+
+// backgroundLog is a background task's log file, written to along with the terminal. Writes
+// never fail, so the output keeps reaching the terminal whatever happens to the file.
+type backgroundLog struct {
+	path string
+	mu   sync.Mutex
+	f    *os.File
+}
+
+// openBackgroundLog starts the log of the background task name in workspace afresh.
+func openBackgroundLog(workspace, name string) (*backgroundLog, error) {
+	p, err := BackgroundLogPath(workspace, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(p)
+	if err != nil {
+		return nil, err
+	}
+	return &backgroundLog{path: p, f: f}, nil
+}
+
+func (l *backgroundLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f != nil {
+		_, _ = l.f.Write(p)
+	}
+	return len(p), nil
+}
+
+func (l *backgroundLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
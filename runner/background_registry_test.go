@@ -0,0 +1,133 @@
+package runner
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func TestRun_RegistersBackgroundDependencies(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".vscode", "tasks.json"), `{
+  "version": "2.0.0",
+  "tasks": [
+    { "label": "serve", "command": "sleep 3", "dependsOn": ["watch"] },
+    {
+      "label": "watch",
+      "isBackground": true,
+      "problemMatcher": "$tsc-watch",
+      "command": "printf 'Starting compilation in watch mode...\\nwatching\\n'; sleep 30"
+    }
+  ]
+}`)
+	t.Chdir(dir)
+	root, err := tasks.ProjectRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, err := tasks.GetTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	serve, err := tasks.FindTask(all, "serve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- RunTask(serve) }()
+
+	var running []BackgroundTask
+	for deadline := time.Now().Add(5 * time.Second); len(running) == 0; time.Sleep(50 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("the background dependency wasn't registered")
+		}
+		if running, err = BackgroundTasks(root); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w := running[0]
+	if len(running) != 1 || w.Name != "watch" || w.Owner != os.Getpid() || !processAlive(w.PID) {
+		t.Fatalf("registered: %+v", running)
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(50 * time.Millisecond) {
+		if b, _ := os.ReadFile(w.Log); strings.Contains(string(b), "watching") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("log %s lacks the task's output", w.Log)
+		}
+	}
+
+	stopped, err := StopBackgroundTask(root, "watch")
+	if err != nil || len(stopped) != 1 {
+		t.Fatalf("stop: %+v, %v", stopped, err)
+	}
+	if processAlive(w.PID) {
+		t.Fatalf("pid %d still running after stop", w.PID)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("the run failed once its background dependency was stopped: %v", err)
+	}
+	if running, _ := BackgroundTasks(root); len(running) != 0 {
+		t.Fatalf("still registered after the run: %+v", running)
+	}
+	if _, err := os.Stat(w.Log); err != nil {
+		t.Fatalf("the log wasn't kept: %v", err)
+	}
+}
+
+func TestBackgroundTasks_DropsExited(t *testing.T) {
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	root := t.TempDir()
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if err := registerBackground(root, BackgroundTask{Name: "gone", Label: "gone", PID: cmd.Process.Pid}); err != nil {
+		t.Fatal(err)
+	}
+	me := os.Getpid()
+	if err := registerBackground(root, BackgroundTask{Name: "me", Label: "me", PID: me, Start: processStart(me), Owner: me}); err != nil {
+		t.Fatal(err)
+	}
+	// Its run was killed.
+	if err := registerBackground(root, BackgroundTask{Name: "orphan", Label: "orphan", PID: me, Owner: cmd.Process.Pid}); err != nil {
+		t.Fatal(err)
+	}
+	if start := processStart(me); start != 0 {
+		// Another process got its PID.
+		if err := registerBackground(root, BackgroundTask{Name: "reused", Label: "reused", PID: me, Start: start + 1, Owner: me}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	running, err := BackgroundTasks(root)
+	if err != nil || len(running) != 1 || running[0].Name != "me" {
+		t.Fatalf("running: %+v, %v", running, err)
+	}
+	for _, name := range []string{"gone", "orphan", "reused"} {
+		if _, err := StopBackgroundTask(root, name); !errors.Is(err, ErrBackgroundTaskNotRunning) {
+			t.Fatalf("stopping %s: %v", name, err)
+		}
+	}
+}
+
+func TestBackgroundLogPath(t *testing.T) {
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	root := t.TempDir()
+	a, _ := BackgroundLogPath(root, "api: dev server")
+	b, _ := BackgroundLogPath(root, "api/dev server")
+	if a == b || strings.ContainsAny(filepath.Base(a), " :/") || !strings.HasSuffix(a, ".log") {
+		t.Fatalf("log paths %q and %q", a, b)
+	}
+}
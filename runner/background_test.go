@@ -0,0 +1,115 @@
+package runner
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRun_StopsBackgroundDependencies(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".vscode", "tasks.json"), `{
+  "version": "2.0.0",
+  "tasks": [
+    { "label": "serve", "command": "true", "dependsOn": ["watch"] },
+    {
+      "label": "watch",
+      "isBackground": true,
+      "problemMatcher": "$tsc-watch",
+      "command": "echo $$ > watch.pid; printf 'Starting compilation in watch mode...\\n'; sleep 30"
+    }
+  ]
+}`)
+	t.Chdir(dir)
+	start := time.Now()
+	if err := runLabel(t, "serve"); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Fatal("the run waited for the background dependency to exit by itself")
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "watch.pid"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, err := os.FindProcess(pid); err == nil && p.Signal(syscall.Signal(0)) == nil {
+		_ = p.Kill()
+		t.Fatalf("background dependency (pid %d) still running after the run", pid)
+	}
+}
+
+func TestBackgroundTasks_IgnoresExited(t *testing.T) {
+	exited := make(chan struct{})
+	close(exited)
+	var b backgroundTasks
+	b.add("done", "done", &execCmdShim{exited: exited}, nil, nil)
+	b.add("not started", "not started", &execCmdShim{}, nil, nil)
+	b.stopAll() // must not block or try to kill anything
+	if len(b.procs) != 0 {
+		t.Fatalf("procs left: %d", len(b.procs))
+	}
+	var nilTasks *backgroundTasks
+	nilTasks.stopAll()
+}
+
+func TestRun_ReadyExportsToDependents(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".vscode", "tasks.json"), `{
+  "version": "2.0.0",
+  "tasks": [
+    { "label": "e2e", "command": "echo $VSTASK_READY_DEV_SERVER_HOST $VSTASK_READY_DEV_SERVER_2 > ready.txt", "dependsOn": ["dev-server"] },
+    {
+      "label": "dev-server",
+      "isBackground": true,
+      "exports": { "ready": true },
+      "problemMatcher": {
+        "pattern": { "regexp": "^error: (.*)$" },
+        "background": { "beginsPattern": "listening on (?P<host>[a-z]+):(\\d+)", "endsPattern": "^never$" }
+      },
+      "command": "echo starting; echo 'listening on localhost:5173'; sleep 30"
+    }
+  ]
+}`)
+	t.Chdir(dir)
+	if err := runLabel(t, "e2e"); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "ready.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(b)); got != "localhost 5173" {
+		t.Fatalf("dependent saw %q, want the readiness line's groups", got)
+	}
+}
+
+func TestReadyExports(t *testing.T) {
+	rx := regexp.MustCompile(`on (?P<host>\S+):(\d+)`)
+	got := readyExports("dev-server", rx, rx.FindStringSubmatch("on localhost:80"))
+	want := map[string]string{"VSTASK_READY_DEV_SERVER_HOST": "localhost", "VSTASK_READY_DEV_SERVER_2": "80"}
+	if !maps.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if readyExports("x", rx, nil) != nil {
+		t.Fatal("no match should export nothing")
+	}
+}
@@ -0,0 +1,208 @@
+package runner
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/chenasraf/vstask/tasks"
+	"github.com/chenasraf/vstask/utils"
+)
+
+const redacted = "<redacted>"
+
+// bugReportStateFiles are the workspace state files a bug report includes. The input cache
+// isn't one of them: it holds input values.
+var bugReportStateFiles = []string{runResultsFile, recentRunsFile, tasks.HistoryFile}
+
+// BugReport writes a diagnostic bundle to w as a zip archive, and returns the names of the
+// files in it: the vstask version and platform, the parsed tasks and inputs, the settings
+// vstask reads, and the workspace's last run and run history (durations and usage counts).
+// Nothing is sent anywhere.
+//
+// The bundle is sanitized: env values, password input defaults and exported values are
+// redacted, the values of secret-looking environment variables (see secretName) are removed
+// wherever they appear, and the home directory is shown as "~". Problems collecting a part
+// go to errors.txt, so a broken tasks.json still makes a useful report.
+func BugReport(w io.Writer, version string) ([]string, error) {
+	files := map[string][]byte{}
+	var problems []string
+	fail := func(what string, err error) {
+		problems = append(problems, fmt.Sprintf("%s: %v", what, err))
+	}
+
+	root, err := tasks.ProjectRoot()
+	if err != nil {
+		fail("project root", err)
+	}
+	files["system.txt"] = []byte(systemReport(version, root))
+
+	if all, err := tasks.GetTasks(); err != nil {
+		fail("tasks", err)
+	} else {
+		inputs, err := tasks.GetInputs()
+		if err != nil {
+			fail("inputs", err)
+		}
+		files["tasks.json"] = redactedJSON(map[string]any{"tasks": all, "inputs": inputs})
+	}
+
+	settings := map[string]any{}
+	for _, key := range tasks.KnownSettings() {
+		for _, src := range tasks.SettingChain(root, key) {
+			if src.Value != nil {
+				settings[key] = map[string]any{"value": src.Value, "source": src.Source}
+				break
+			}
+		}
+	}
+	files["settings.json"] = redactedJSON(settings)
+
+	if root != "" {
+		for _, name := range bugReportStateFiles {
+			p, err := statePath(root, name)
+			if err != nil {
+				fail(name, err)
+				break
+			}
+			var v any
+			if err := utils.ReadJSONFile(p, &v); err == nil && v != nil {
+				files["state/"+name] = redactedJSON(v)
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		files["errors.txt"] = []byte(strings.Join(problems, "\n") + "\n")
+	}
+
+	names := slices.Sorted(maps.Keys(files))
+	scrub := newScrubber()
+	zw := zip.NewWriter(w)
+	for _, name := range names {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write([]byte(scrub.Replace(string(files[name])))); err != nil {
+			return nil, err
+		}
+	}
+	return names, zw.Close()
+}
+
+// systemReport describes vstask's version, the platform and the vstask environment variables.
+func systemReport(version, root string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "vstask: %s\n", version)
+	fmt.Fprintf(&b, "go: %s\n", runtime.Version())
+	fmt.Fprintf(&b, "os: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	exe, args := defaultShell()
+	fmt.Fprintf(&b, "shell: %s %s\n", exe, strings.Join(args, " "))
+	fmt.Fprintf(&b, "project root: %s\n", root)
+	if ws, err := tasks.FindWorkspace(root); err == nil && ws != nil {
+		fmt.Fprintf(&b, "workspace file: %s (%d folders)\n", ws.File, len(ws.Folders))
+	}
+	var vars []string
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(strings.ToUpper(k), "VSTASK_") {
+			continue
+		}
+		if strings.HasPrefix(strings.ToUpper(k), "VSTASK_INPUT") || secretName(k) {
+			v = redacted
+		}
+		vars = append(vars, k+"="+v)
+	}
+	slices.Sort(vars)
+	b.WriteString("environment:\n")
+	for _, kv := range vars {
+		fmt.Fprintf(&b, "  %s\n", kv)
+	}
+	return b.String()
+}
+
+// redactedJSON marshals v (indented), with the values that may hold secrets redacted (see redact).
+func redactedJSON(v any) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return []byte(err.Error())
+	}
+	var tree any
+	if err := json.Unmarshal(b, &tree); err != nil {
+		return b
+	}
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(redact(tree)); err != nil {
+		return b
+	}
+	return out.Bytes()
+}
+
+// redact replaces the values of "env" and "exports" objects (keeping their keys) and the
+// defaults of password and secret inputs.
+func redact(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			switch obj, isObj := child.(map[string]any); {
+			case isObj && (k == "env" || k == "exports"):
+				for name := range obj {
+					obj[name] = redacted
+				}
+			default:
+				v[k] = redact(child)
+			}
+		}
+		if pw, _ := v["password"].(bool); (pw || v["type"] == "secret") && v["default"] != nil {
+			v["default"] = redacted
+		}
+	case []any:
+		for i := range v {
+			v[i] = redact(v[i])
+		}
+	}
+	return v
+}
+
+// newScrubber returns a replacer that removes the values of secret-looking environment
+// variables and shortens the home directory to "~".
+func newScrubber() *strings.Replacer {
+	var pairs []string
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		if secretName(k) && len(v) >= 4 {
+			pairs = append(pairs, v, redacted)
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil && len(home) > 1 {
+		pairs = append(pairs, home, "~")
+		if posix := filepath.ToSlash(home); posix != home {
+			pairs = append(pairs, posix, "~")
+		}
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// secretName reports whether an environment variable's name suggests it holds a secret.
+func secretName(name string) bool {
+	name = strings.ToUpper(name)
+	for _, s := range []string{"TOKEN", "SECRET", "PASSWORD", "PASSWD", "API_KEY", "APIKEY", "PRIVATE", "CREDENTIAL", "AUTH"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBugReportRedactsSecrets(t *testing.T) {
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	t.Setenv("VSTASK_INPUT_ENV", "prod")
+	t.Setenv("DEPLOY_TOKEN", "tok-12345")
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".vscode", "tasks.json"), `{
+  "version": "2.0.0",
+  "tasks": [
+    { "label": "deploy", "command": "deploy --token tok-12345", "options": { "env": { "DB_URL": "postgres://u:p@db" } } }
+  ],
+  "inputs": [
+    { "id": "pw", "type": "promptString", "password": true, "default": "hunter2" },
+    { "id": "env", "type": "pickString", "options": ["dev", "prod"], "default": "dev" }
+  ]
+}`)
+	t.Chdir(dir)
+	if err := saveRunResults(dir, &runResults{Label: "deploy", Tasks: map[string]taskResult{
+		"deploy": {OK: true, Exports: map[string]string{"SESSION": "s3cr3t"}},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	names, err := BugReport(&buf, "1.2.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(names, " ") != "settings.json state/last-results.json system.txt tasks.json" {
+		t.Fatalf("files %v", names)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var all strings.Builder
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(rc)
+		_ = rc.Close()
+		all.Write(b)
+	}
+	out := all.String()
+	for _, secret := range []string{"tok-12345", "postgres://", "hunter2", "s3cr3t", "VSTASK_INPUT_ENV=prod"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("bundle contains %q", secret)
+		}
+	}
+	for _, want := range []string{"vstask: 1.2.3", `"DB_URL": "<redacted>"`, `"default": "dev"`, `"label": "deploy"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("bundle lacks %q", want)
+		}
+	}
+}
@@ -21,26 +21,20 @@ func buildCmd(t tasks.Task, cwd string, env []string) (*exec.Cmd, func(), error)
 		if t.Command == "" {
 			return nil, cleanup, errors.New("process task has empty command")
 		}
-		cmd := exec.Command(t.Command, t.Args...)
+		cmd := exec.Command(lookPathIn(t.Command, t), t.Args...)
 		cmd.Dir = cwd
 		cmd.Env = env
 		return cmd, cleanup, nil
 
 	case "shell":
-		shExe, shArgs := defaultShell()
-		if t.Options != nil && t.Options.Shell != nil && t.Options.Shell.Executable != "" {
-			shExe = t.Options.Shell.Executable
-			if len(t.Options.Shell.Args) > 0 {
-				shArgs = append([]string(nil), t.Options.Shell.Args...)
-			}
-		}
+		shExe, shArgs := taskShell(t)
 
-		// Build a single command line for the shell.
-		line := buildCommandLine(t.Command, t.Args)
-		args := append([]string{}, shArgs...)
-		args = append(args, line)
+		// Build a single command line for the shell (quoted for bash, even on Windows).
+		line := commandLine(t.Command, t.Args, kindOfShell(shExe))
+		args := shellArgsWithCommand(shArgs, line)
 
 		cmd := exec.Command(shExe, args...)
+		cmdExeQuoting(cmd)
 		cmd.Dir = cwd
 		cmd.Env = env
 		return cmd, cleanup, nil
@@ -115,3 +109,24 @@ func buildCmd(t tasks.Task, cwd string, env []string) (*exec.Cmd, func(), error)
 		return nil, cleanup, fmt.Errorf("unsupported task type: %q", t.Type)
 	}
 }
+
+// commandPlaceholder in options.shell.args marks where the command line goes, for shells that
+// need it before other flags (e.g. ["-c", "${command}", "--"]). Without one, the command line
+// is appended after the shell args.
+const commandPlaceholder = "${command}"
+
+func shellArgsWithCommand(shArgs []string, line string) []string {
+	args := make([]string, 0, len(shArgs)+1)
+	placed := false
+	for _, a := range shArgs {
+		if strings.Contains(a, commandPlaceholder) {
+			a = strings.ReplaceAll(a, commandPlaceholder, line)
+			placed = true
+		}
+		args = append(args, a)
+	}
+	if !placed {
+		args = append(args, line)
+	}
+	return args
+}
@@ -0,0 +1,113 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/chenasraf/vstask/utils"
+)
+
+// CleanAction is one piece of workspace state `vstask clean` removes (or prunes).
+type CleanAction struct {
+	Path  string // file or directory
+	What  string // what it is, e.g. "cached task results"
+	apply func() error
+}
+
+// Apply performs the action.
+func (a CleanAction) Apply() error {
+	return a.apply()
+}
+
+// CleanPlan lists what `vstask clean` removes from a workspace state directory (see
+// utils.WorkspaceStateDir): cached task results and input values, leftover run scratch
+// directories, stale tmux pane registry entries, the logs of background tasks that aren't
+// running and interrupted writes. The run history (durations and usage counts, see
+// tasks.TaskHistory) and recent runs are kept; they aren't caches. So are lock files: one removed while another process opens it would
+// let two processes lock different files of the same name at once.
+func CleanPlan(ctx context.Context, stateDir string) ([]CleanAction, error) {
+	var plan []CleanAction
+	remove := func(name, what string) {
+		p := filepath.Join(stateDir, name)
+		if _, err := os.Lstat(p); err == nil {
+			plan = append(plan, CleanAction{Path: p, What: what, apply: func() error { return os.RemoveAll(p) }})
+		}
+	}
+	remove(runResultsFile, "cached task results")
+	remove(inputCacheFile, "cached input values")
+
+	runs, err := os.ReadDir(filepath.Join(stateDir, runTempDirName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, e := range runs {
+		remove(filepath.Join(runTempDirName, e.Name()), "run scratch directory")
+	}
+
+	if a, ok := prunePanes(ctx, filepath.Join(stateDir, tmuxPanesFile)); ok {
+		plan = append(plan, a)
+	}
+
+	var running []BackgroundTask
+	_ = utils.ReadJSONFile(filepath.Join(stateDir, backgroundRegistryFile), &running)
+	logs, err := os.ReadDir(filepath.Join(stateDir, backgroundLogsDirName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, e := range logs {
+		name := filepath.Join(backgroundLogsDirName, e.Name())
+		if !slices.ContainsFunc(running, func(t BackgroundTask) bool {
+			return t.Log == filepath.Join(stateDir, name) && t.running()
+		}) {
+			remove(name, "background task log")
+		}
+	}
+
+	// Temporary files of writes interrupted before their rename (see utils.WriteFileAtomic).
+	partial, err := filepath.Glob(filepath.Join(stateDir, ".*.tmp-*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range partial {
+		remove(filepath.Base(f), "interrupted write")
+	}
+	return plan, nil
+}
+
+// prunePanes plans dropping the tmux pane registry entries whose pane is gone.
+func prunePanes(ctx context.Context, p string) (CleanAction, bool) {
+	panes := map[string]string{}
+	if err := utils.ReadJSONFile(p, &panes); err != nil || len(panes) == 0 {
+		return CleanAction{}, false
+	}
+	var stale []string
+	for label, pane := range panes {
+		out, err := tmuxCommand(ctx, "display-message", "-p", "-t", pane, "#{pane_id}")
+		if err != nil || strings.TrimSpace(string(out)) != pane {
+			stale = append(stale, label)
+		}
+	}
+	if len(stale) == 0 {
+		return CleanAction{}, false
+	}
+	slices.Sort(stale)
+	if len(stale) == len(panes) {
+		return CleanAction{Path: p, What: "tmux pane registry (no live panes)", apply: func() error { return os.Remove(p) }}, true
+	}
+	return CleanAction{
+		Path: p,
+		What: fmt.Sprintf("stale tmux panes of %s", strings.Join(stale, ", ")),
+		apply: func() error {
+			return utils.UpdateJSONFile(p, func(panes *map[string]string) error {
+				for _, label := range stale {
+					delete(*panes, label)
+				}
+				return nil
+			})
+		},
+	}, true
+}
@@ -0,0 +1,84 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/chenasraf/vstask/tasks"
+	"github.com/chenasraf/vstask/utils"
+)
+
+func TestCleanPlan(t *testing.T) {
+	orig := tmuxCommand
+	t.Cleanup(func() { tmuxCommand = orig })
+	tmuxCommand = func(_ context.Context, args ...string) ([]byte, error) {
+		if args[len(args)-2] == "%1" { // -t <pane>
+			return []byte("%1\n"), nil
+		}
+		return nil, errors.New("can't find pane")
+	}
+
+	dir := t.TempDir()
+	keep := []string{tasks.HistoryFile, recentRunsFile, "history.json.lock"}
+	for _, name := range append(keep, runResultsFile, inputCacheFile, ".history.json.tmp-42") {
+		writeFile(t, filepath.Join(dir, name), "{}")
+	}
+	writeFile(t, filepath.Join(dir, runTempDirName, "run-123", "build", "out.txt"), "x")
+	panes := filepath.Join(dir, tmuxPanesFile)
+	if err := utils.WriteJSONFile(panes, map[string]string{"serve": "%1", "watch": "%2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	live := filepath.Join(dir, backgroundLogsDirName, "serve.log")
+	writeFile(t, live, "listening\n")
+	writeFile(t, filepath.Join(dir, backgroundLogsDirName, "watch.log"), "done\n")
+	if err := utils.WriteJSONFile(filepath.Join(dir, backgroundRegistryFile), []BackgroundTask{{Name: "serve", PID: os.Getpid(), Owner: os.Getpid(), Log: live}}); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := CleanPlan(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, a := range plan {
+		paths = append(paths, filepath.Base(a.Path))
+	}
+	slices.Sort(paths)
+	want := []string{".history.json.tmp-42", inputCacheFile, runResultsFile, "run-123", tmuxPanesFile, "watch.log"}
+	slices.Sort(want)
+	if !slices.Equal(paths, want) {
+		t.Fatalf("plan = %v, want %v", paths, want)
+	}
+
+	for _, a := range plan {
+		if err := a.Apply(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range keep {
+		if !utils.FileExists(filepath.Join(dir, name)) {
+			t.Errorf("%s should be kept", name)
+		}
+	}
+	if !utils.FileExists(live) {
+		t.Error("the log of a running background task should be kept")
+	}
+	if _, err := os.Stat(filepath.Join(dir, runTempDirName, "run-123")); !os.IsNotExist(err) {
+		t.Errorf("run scratch dir not removed: %v", err)
+	}
+	left := map[string]string{}
+	if err := utils.ReadJSONFile(panes, &left); err != nil || len(left) != 1 || left["serve"] != "%1" {
+		t.Errorf("pane registry = %v, %v; want only the live pane", left, err)
+	}
+
+	// Pruning the registry took its lock, which is left alone too.
+	plan, err = CleanPlan(context.Background(), dir)
+	if err != nil || len(plan) != 0 {
+		t.Fatalf("second plan = %v, %v; want nothing", plan, err)
+	}
+}
@@ -0,0 +1,52 @@
+package runner
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// cmdExeQuoting makes a cmd.exe command (cmd.exe [flags] /C <line>) hand line to cmd.exe as
+// written. Go quotes arguments for programs that parse their command line like C programs do,
+// escaping inner quotes with backslashes, which cmd.exe doesn't understand: a command with
+// quoted arguments would reach it mangled. It's a no-op except for cmd.exe on Windows.
+func cmdExeQuoting(cmd *exec.Cmd) {
+	if runtime.GOOS != "windows" {
+		return
+	}
+	if line, ok := cmdExeLine(cmd.Args); ok {
+		setCmdLine(cmd, line)
+	}
+}
+
+// cmdExeLine returns the command line for args (cmd.exe, its flags ending in /C or /K, then
+// the command) as cmd.exe [flags] /S /C "<command>": with /S, cmd.exe strips the outer quotes
+// and runs what's between them unchanged. ok is false if args aren't of that form.
+func cmdExeLine(args []string) (line string, ok bool) {
+	if len(args) < 3 || shellName(args[0]) != "cmd" {
+		return "", false
+	}
+	flags, command := args[1:len(args)-1], args[len(args)-1]
+	last := flags[len(flags)-1]
+	if !strings.EqualFold(last, "/c") && !strings.EqualFold(last, "/k") {
+		return "", false
+	}
+	exe := args[0]
+	if strings.ContainsAny(exe, " \t") {
+		exe = `"` + exe + `"`
+	}
+	parts := []string{exe}
+	hasS := false
+	for _, f := range flags[:len(flags)-1] {
+		if !strings.HasPrefix(f, "/") || strings.ContainsAny(f, " \t\"") {
+			return "", false
+		}
+		hasS = hasS || strings.EqualFold(f, "/s")
+		parts = append(parts, f)
+	}
+	if !hasS {
+		parts = append(parts, "/S")
+	}
+	parts = append(parts, last, `"`+command+`"`)
+	return strings.Join(parts, " "), true
+}
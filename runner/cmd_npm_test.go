@@ -290,7 +290,7 @@ func TestBuildCmd_Npm_PreservesEnvAndCwd(t *testing.T) {
 // Guard: ensure rebuildWithSh only touches argv[0]
 func TestRebuildWithSh_SwapsOnlyExe(t *testing.T) {
 	c := exec.Command("/bin/bash", "-lc", "echo ok")
-	c2 := rebuildWithSh(c)
+	c2 := rebuildWithSh(c, "/bin/sh")
 	if c2 == nil {
 		t.Fatal("rebuildWithSh returned nil")
 	}
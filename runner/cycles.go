@@ -0,0 +1,87 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// CycleEvent is a compile cycle of a background task (a watcher) starting or ending, as its
+// problem matcher's beginsPattern and endsPattern tell.
+type CycleEvent struct {
+	Task     string
+	Kind     string        // "compiling" (the first cycle) | "recompiling" | "ready"
+	Duration time.Duration // "ready" only: how long the cycle took
+	Time     time.Time
+}
+
+func (e CycleEvent) String() string {
+	if e.Kind == "ready" {
+		return fmt.Sprintf("Watch: %s ready in %s", e.Task, roundDuration(e.Duration))
+	}
+	return fmt.Sprintf("Watch: %s %s…", e.Task, e.Kind)
+}
+
+func printCycleEvent(e CycleEvent) {
+	fmt.Fprintln(os.Stderr, e)
+}
+
+// roundDuration rounds d for people to read: 340ms, 1.2s, 2m3.4s.
+func roundDuration(d time.Duration) time.Duration {
+	if d < time.Second {
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(100 * time.Millisecond)
+}
+
+// cycleTracker follows a background task's output (both streams) for its compile cycles: a
+// line matching beginsPattern starts one, and one matching endsPattern ends it. Without an
+// endsPattern, cycles never end, so none are reported.
+type cycleTracker struct {
+	task     string
+	beginsRx *regexp.Regexp
+	endsRx   *regexp.Regexp
+	emit     func(CycleEvent)
+	now      func() time.Time
+	mu       sync.Mutex
+	active   bool
+	cycles   int
+	start    time.Time
+}
+
+func newCycleTracker(task string, beginsRx, endsRx *regexp.Regexp, activeOnStart bool, emit func(CycleEvent)) *cycleTracker {
+	if endsRx == nil || emit == nil {
+		return nil
+	}
+	c := &cycleTracker{task: task, beginsRx: beginsRx, endsRx: endsRx, emit: emit, now: time.Now}
+	if activeOnStart {
+		c.active, c.cycles, c.start = true, 1, c.now()
+	}
+	return c
+}
+
+// chunk tracks a chunk of output (see scanLines).
+func (c *cycleTracker) chunk(ch lineChunk) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if !c.active && c.beginsRx != nil && ch.match(c.beginsRx) != nil {
+		c.active, c.start = true, now
+		c.cycles++
+		kind := "recompiling"
+		if c.cycles == 1 {
+			kind = "compiling"
+		}
+		c.emit(CycleEvent{Task: c.task, Kind: kind, Time: now})
+	}
+	// A line can both begin and end a cycle ("Compiled in 120ms" after a silent start).
+	if c.active && ch.match(c.endsRx) != nil {
+		c.active = false
+		c.emit(CycleEvent{Task: c.task, Kind: "ready", Duration: now.Sub(c.start), Time: now})
+	}
+}
@@ -0,0 +1,97 @@
+package runner
+
+import (
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func TestCycleTracker(t *testing.T) {
+	var events []CycleEvent
+	c := newCycleTracker("watch", regexp.MustCompile(`^File change detected`), regexp.MustCompile(`Watching for file changes`), true, func(e CycleEvent) {
+		events = append(events, e)
+	})
+	clock := time.Unix(0, 0)
+	c.now = func() time.Time { return clock }
+	c.start = clock
+	line := func(s string, after time.Duration) {
+		clock = clock.Add(after)
+		c.chunk(lineChunk{text: s, start: true, end: true})
+	}
+	line("Found 0 errors. Watching for file changes.", 2300*time.Millisecond) // the initial compile, active on start
+	line("src/a.ts(1,1): error TS1005", time.Second)
+	line("File change detected. Starting incremental compilation...", time.Second)
+	line("File change detected. Starting incremental compilation...", time.Second) // still compiling
+	line("Found 1 error. Watching for file changes.", 450*time.Millisecond)
+
+	var got []string
+	for _, e := range events {
+		got = append(got, e.String())
+	}
+	want := []string{"Watch: watch ready in 2.3s", "Watch: watch recompiling…", "Watch: watch ready in 1.5s"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("events %q, want %q", got, want)
+	}
+
+	if newCycleTracker("watch", regexp.MustCompile(`x`), nil, false, printCycleEvent) != nil {
+		t.Fatal("cycles can't end without an endsPattern")
+	}
+	var none *cycleTracker
+	none.chunk(lineChunk{text: "x", start: true, end: true})
+}
+
+func TestRun_ReportsBackgroundCycles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".vscode", "tasks.json"), `{
+  "version": "2.0.0",
+  "tasks": [
+    { "label": "e2e", "command": "sleep 1", "dependsOn": ["watch"] },
+    {
+      "label": "watch",
+      "isBackground": true,
+      "problemMatcher": {
+        "pattern": { "regexp": "^(.*):(\\d+): (.*)$", "file": 1, "line": 2, "message": 3 },
+        "background": { "beginsPattern": "^compiling", "endsPattern": "^compiled" }
+      },
+      "command": "echo compiling; echo compiled; sleep 0.2; echo compiling; echo compiled; sleep 30"
+    }
+  ]
+}`)
+	t.Chdir(dir)
+	all, err := tasks.GetTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := tasks.FindTask(all, "e2e")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var kinds []string
+	err = Run(task, Options{OnCycle: func(e CycleEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		if e.Task != "watch" {
+			t.Errorf("event of %q", e.Task)
+		}
+		kinds = append(kinds, e.Kind)
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"compiling", "ready", "recompiling", "ready"}; !slices.Equal(kinds, want) {
+		t.Fatalf("cycles %v, want %v", kinds, want)
+	}
+}
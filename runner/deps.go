@@ -0,0 +1,133 @@
+package runner
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// depIndex resolves dependsOn references to tasks, including references to tasks in
+// other folders of a multi-root (.code-workspace) workspace.
+type depIndex struct {
+	root    string
+	local   map[string]tasks.Task
+	ws      *tasks.Workspace
+	folders map[string]map[string]tasks.Task // folder path -> label -> task (loaded lazily)
+	r       *InputResolver
+}
+
+func newDepIndex(root string, local []tasks.Task, r *InputResolver) *depIndex {
+	ws, _ := tasks.FindWorkspace(root) // best effort; no workspace file means single-folder
+	return &depIndex{
+		root:    root,
+		local:   indexByLabel(local),
+		ws:      ws,
+		folders: map[string]map[string]tasks.Task{},
+		r:       r,
+	}
+}
+
+// lookup returns the task a reference points at and the workspace folder to run it in.
+//
+//   - {"label": "build", "folder": "api"} → task "build" in folder "api"
+//   - "build" → task "build" in the current folder
+//   - "api: build" → a task literally labeled "api: build" in the current folder if there is
+//     one, else task "build" in folder "api"
+func (d *depIndex) lookup(ref tasks.TaskRef) (tasks.Task, string, error) {
+	if ref.Folder != "" {
+		return d.lookupIn(ref.Folder, ref.Label)
+	}
+	if t, ok := d.local[ref.Label]; ok {
+		return t, d.root, nil
+	}
+	if folder, label, ok := strings.Cut(ref.Label, ": "); ok && d.ws.Folder(folder) != nil {
+		return d.lookupIn(folder, label)
+	}
+	return tasks.Task{}, "", fmt.Errorf("dependsOn: %w: %q", tasks.ErrTaskNotFound, ref.Label)
+}
+
+// lookupFrom is lookup for a reference made by a task in the workspace folder at path from:
+// plain labels name tasks in that folder.
+func (d *depIndex) lookupFrom(from string, ref tasks.TaskRef) (tasks.Task, string, error) {
+	if from == d.root || ref.Folder != "" {
+		return d.lookup(ref)
+	}
+	if f := d.folderAt(from); f != nil {
+		if idx, err := d.folderIndex(f); err == nil {
+			if t, ok := idx[ref.Label]; ok {
+				return t, f.Path, nil
+			}
+		}
+	}
+	if folder, label, ok := strings.Cut(ref.Label, ": "); ok && d.ws.Folder(folder) != nil {
+		return d.lookupIn(folder, label)
+	}
+	return tasks.Task{}, "", fmt.Errorf("dependsOn: %w: %q", tasks.ErrTaskNotFound, ref.Label)
+}
+
+// folderAt returns the workspace folder at path p, or nil.
+func (d *depIndex) folderAt(p string) *tasks.WorkspaceFolder {
+	if d.ws == nil {
+		return nil
+	}
+	for i := range d.ws.Folders {
+		if d.ws.Folders[i].Path == p {
+			return &d.ws.Folders[i]
+		}
+	}
+	return nil
+}
+
+// folderName is how tasks of the folder at path p are named in messages: "" for the
+// current folder, else the folder's name.
+func (d *depIndex) folderName(p string) string {
+	if p == d.root {
+		return ""
+	}
+	if f := d.folderAt(p); f != nil {
+		return f.Name
+	}
+	return filepath.Base(p)
+}
+
+func (d *depIndex) lookupIn(folder, label string) (tasks.Task, string, error) {
+	if d.ws == nil {
+		return tasks.Task{}, "", fmt.Errorf("dependsOn: task %q refers to folder %q, but no .code-workspace file was found", label, folder)
+	}
+	f := d.ws.Folder(folder)
+	if f == nil {
+		return tasks.Task{}, "", fmt.Errorf("dependsOn: workspace folder %q not found in %s", folder, d.ws.File)
+	}
+	if cur := d.ws.FolderFor(d.root); cur != nil && cur.Path == f.Path {
+		if t, ok := d.local[label]; ok {
+			return t, d.root, nil
+		}
+		return tasks.Task{}, "", fmt.Errorf("dependsOn: %w: %q", tasks.ErrTaskNotFound, label)
+	}
+	idx, err := d.folderIndex(f)
+	if err != nil {
+		return tasks.Task{}, "", err
+	}
+	t, ok := idx[label]
+	if !ok {
+		return tasks.Task{}, "", fmt.Errorf("dependsOn: %w: %q in folder %q", tasks.ErrTaskNotFound, label, f.Name)
+	}
+	return t, f.Path, nil
+}
+
+// folderIndex loads (once) the tasks of workspace folder f, adding its inputs to the resolver.
+func (d *depIndex) folderIndex(f *tasks.WorkspaceFolder) (map[string]tasks.Task, error) {
+	if idx, ok := d.folders[f.Path]; ok {
+		return idx, nil
+	}
+	file, err := tasks.LoadFolderTasks(*f)
+	if err != nil {
+		return nil, fmt.Errorf("dependsOn: folder %q: %w", f.Name, err)
+	}
+	idx := indexByLabel(file.Tasks)
+	d.folders[f.Path] = idx
+	d.r.addInputs(file.Inputs)
+	return idx, nil
+}
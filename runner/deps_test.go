@@ -0,0 +1,102 @@
+package runner
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func setupMultiRoot(t *testing.T) (api, web string) {
+	t.Helper()
+	t.Setenv("VSTASK_WORKSPACE", "")
+	dir := t.TempDir()
+	api = filepath.Join(dir, "api")
+	web = filepath.Join(dir, "web")
+	writeFile(t, filepath.Join(dir, "all.code-workspace"), `{"folders": [{"path": "api"}, {"path": "web", "name": "frontend"}]}`)
+	writeFile(t, filepath.Join(api, ".vscode", "tasks.json"), `{"tasks": [{"label": "build"}]}`)
+	writeFile(t, filepath.Join(web, ".vscode", "tasks.json"), `{
+		"tasks": [{"label": "build", "command": "${input:target}"}],
+		"inputs": [{"id": "target", "type": "promptString", "default": "web"}]
+	}`)
+	return api, web
+}
+
+func TestDepIndex_Lookup(t *testing.T) {
+	api, web := setupMultiRoot(t)
+	local := []tasks.Task{{Label: "build"}, {Label: "frontend: build", Command: "literal"}}
+	r := NewInputResolver(nil)
+	idx := newDepIndex(api, local, r)
+
+	cases := []struct {
+		ref        tasks.TaskRef
+		wantFolder string
+		wantCmd    string
+	}{
+		{tasks.TaskRef{Label: "build"}, api, ""},
+		// a task literally labeled "frontend: build" wins over the folder form
+		{tasks.TaskRef{Label: "frontend: build"}, api, "literal"},
+		{tasks.TaskRef{Label: "build", Folder: "frontend"}, web, "${input:target}"},
+		{tasks.TaskRef{Label: "build", Folder: "api"}, api, ""},
+	}
+	for _, c := range cases {
+		got, folder, err := idx.lookup(c.ref)
+		if err != nil {
+			t.Fatalf("%v: %v", c.ref, err)
+		}
+		if folder != c.wantFolder || got.Command != c.wantCmd {
+			t.Errorf("%v: got (%q, %q), want (%q, %q)", c.ref, folder, got.Command, c.wantFolder, c.wantCmd)
+		}
+	}
+
+	// The other folder's inputs become available to the resolver.
+	if _, ok := r.byID["target"]; !ok {
+		t.Fatal("inputs from the dependency's folder should be declared")
+	}
+}
+
+func TestDepIndex_FolderPrefix(t *testing.T) {
+	api, web := setupMultiRoot(t)
+	idx := newDepIndex(api, nil, NewInputResolver(nil))
+	_, folder, err := idx.lookup(tasks.TaskRef{Label: "frontend: build"})
+	if err != nil || folder != web {
+		t.Fatalf("got %q, %v; want %q", folder, err, web)
+	}
+}
+
+func TestDepIndex_Errors(t *testing.T) {
+	api, _ := setupMultiRoot(t)
+	idx := newDepIndex(api, nil, NewInputResolver(nil))
+	for _, ref := range []tasks.TaskRef{
+		{Label: "missing"},
+		{Label: "build", Folder: "nope"},
+		{Label: "missing", Folder: "frontend"},
+	} {
+		if _, _, err := idx.lookup(ref); err == nil {
+			t.Errorf("%v: expected error", ref)
+		}
+	}
+
+	// Without a workspace file, folder references are an error.
+	t.Setenv("VSTASK_WORKSPACE", "")
+	solo := newDepIndex(t.TempDir(), nil, NewInputResolver(nil))
+	if _, _, err := solo.lookup(tasks.TaskRef{Label: "build", Folder: "api"}); err == nil {
+		t.Fatal("expected error without a workspace")
+	}
+}
+
+func TestResolveTask_WorkspaceFolderByName(t *testing.T) {
+	isolatePMDetectionToDefault(t)
+	api, web := setupMultiRoot(t)
+	tk := tasks.Task{
+		Label:   "serve",
+		Command: "${workspaceFolder:frontend}/serve --api ${workspaceFolder:api} --name ${workspaceFolderBasename:frontend}",
+	}
+	rt, err := resolveTask(tk, api, NewInputResolver(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := web + "/serve --api " + api + " --name web"; rt.Task.Command != want {
+		t.Fatalf("command = %q, want %q", rt.Task.Command, want)
+	}
+}
@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+)
+
+// ErrCancelled is returned when a run is interrupted (e.g. by Ctrl-C) before it finishes.
+var ErrCancelled = errors.New("run cancelled")
+
+// asCancelled returns err as an ErrCancelled if it's a context's cancellation (a task stopped
+// by CTRL-C while it ran, say), so callers only check for ErrCancelled.
+func asCancelled(err error) error {
+	if errors.Is(err, context.Canceled) && !errors.Is(err, ErrCancelled) {
+		return fmt.Errorf("%w: %w", ErrCancelled, err)
+	}
+	return err
+}
+
+// DependencyFailedError is a dependency that failed, which stopped the task depending on it.
+// Err is the dependency's own error (see ExitCode).
+type DependencyFailedError struct {
+	Label string // the dependency reference: its label, or "folder: label" for another folder
+	Err   error
+}
+
+func (e *DependencyFailedError) Error() string {
+	return fmt.Sprintf("dependency %q failed: %v", e.Label, e.Err)
+}
+
+func (e *DependencyFailedError) Unwrap() error {
+	return e.Err
+}
+
+// SimulatedFailureError is a task reported as failed without running it (--fail).
+type SimulatedFailureError struct {
+	Label string
+}
+
+func (e *SimulatedFailureError) Error() string {
+	return fmt.Sprintf("task %q failed (simulated with --fail)", e.Label)
+}
+
+// PanicError is a task whose run panicked inside vstask (in a provider, a matcher, ...). The
+// run fails like it would for a failing task, instead of crashing.
+type PanicError struct {
+	Label string
+	Value any    // what was passed to panic
+	Stack []byte // the panicking goroutine's stack
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("task %q: internal error: %v", e.Label, e.Value)
+}
+
+// recoverPanic, deferred, turns a panic in a goroutine of the task label's run into a
+// *PanicError passed to report, its stack printed, instead of crashing vstask.
+func recoverPanic(label string, report func(*PanicError)) {
+	if v := recover(); v != nil {
+		pe := &PanicError{Label: label, Value: v, Stack: debug.Stack()}
+		fmt.Fprintf(os.Stderr, "vstask: %v\n%s", pe, pe.Stack)
+		report(pe)
+	}
+}
@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"syscall"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// exitStatusError is a task that exited with a non-zero status we only know the number of
+// (e.g. one run in a tmux pane).
+type exitStatusError struct {
+	code int
+}
+
+func (e *exitStatusError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+// ExitCode returns the exit code vstask should exit with after a run failed with err: the
+// failing task's own exit code, 128+N if it was killed by signal N (as shells report it),
+// 130 if the run was interrupted, 2 if the task or a dependency doesn't exist or a tasks file
+// can't be parsed, and 1 for any other failure. It's 0 for a nil err.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var ee *exec.ExitError
+	if errors.As(err, &ee) {
+		if code := ee.ExitCode(); code > 0 {
+			return code
+		}
+		if ws, ok := ee.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+			return 128 + int(ws.Signal())
+		}
+		return 1
+	}
+	var se *exitStatusError
+	if errors.As(err, &se) && se.code > 0 {
+		return se.code
+	}
+	if errors.Is(err, ErrCancelled) || errors.Is(err, context.Canceled) {
+		return 130
+	}
+	var pe *tasks.ParseError
+	if errors.Is(err, tasks.ErrTaskNotFound) || errors.As(err, &pe) {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,60 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"testing"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func TestExitCode(t *testing.T) {
+	if ExitCode(nil) != 0 {
+		t.Fatal("nil error should exit 0")
+	}
+	for name, tc := range map[string]struct {
+		err  error
+		want int
+	}{
+		"plain error":  {errors.New("boom"), 1},
+		"tmux pane":    {&exitStatusError{3}, 3},
+		"interrupted":  {fmt.Errorf("task: %w", context.Canceled), 130},
+		"wrapped pane": {&DependencyFailedError{Label: "lint", Err: &exitStatusError{4}}, 4},
+		"cancelled":    {&DependencyFailedError{Label: "lint", Err: ErrCancelled}, 130},
+		"no such task": {fmt.Errorf("dependsOn: %w: %q", tasks.ErrTaskNotFound, "x"), 2},
+		"parse error":  {&tasks.ParseError{File: "tasks.json", Line: 3, Err: errors.New("bad")}, 2},
+	} {
+		if got := ExitCode(tc.err); got != tc.want {
+			t.Errorf("%s: got %d, want %d", name, got, tc.want)
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell")
+	}
+	err := exec.Command("sh", "-c", "exit 7").Run()
+	if got := ExitCode(fmt.Errorf("dependency %q failed: %w", "build", err)); got != 7 {
+		t.Fatalf("exit 7: got %d (%v)", got, err)
+	}
+	err = exec.Command("sh", "-c", "kill -TERM $$").Run()
+	if got := ExitCode(err); got != 143 {
+		t.Fatalf("SIGTERM: got %d (%v)", got, err)
+	}
+}
+
+func TestAsCancelled(t *testing.T) {
+	err := asCancelled(&DependencyFailedError{Label: "lint", Err: context.Canceled})
+	var df *DependencyFailedError
+	if !errors.Is(err, ErrCancelled) || !errors.As(err, &df) || df.Label != "lint" {
+		t.Fatalf("err = %v; want ErrCancelled, the dependency's failure kept", err)
+	}
+	if err := asCancelled(ErrCancelled); err != ErrCancelled {
+		t.Fatalf("ErrCancelled rewrapped: %v", err)
+	}
+	if plain := errors.New("boom"); asCancelled(plain) != plain {
+		t.Fatal("other errors are left alone")
+	}
+}
@@ -0,0 +1,148 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// maxExportOutput bounds how much of a task's output is kept for exports.vars;
+// values are usually printed at the end, so the tail is what's kept.
+const maxExportOutput = 1 << 20
+
+// tailBuffer is an io.Writer keeping the last max bytes written to it.
+type tailBuffer struct {
+	max int
+	b   []byte
+}
+
+func (tb *tailBuffer) Write(p []byte) (int, error) {
+	tb.b = append(tb.b, p...)
+	if over := len(tb.b) - tb.max; over > 0 {
+		tb.b = tb.b[over:]
+	}
+	return len(p), nil
+}
+
+// capturesOutput reports whether t's output has to be read for its exports.
+func capturesOutput(t tasks.Task) bool {
+	return t.Exports != nil && len(t.Exports.Vars) > 0
+}
+
+// readyExports names a background task's readiness-line groups (see tasks.Exports.Ready):
+// VSTASK_READY_<LABEL>_<GROUP>, with GROUP the group's name, or number if it has none.
+func readyExports(label string, rx *regexp.Regexp, m []string) map[string]string {
+	if rx == nil || len(m) < 2 {
+		return nil
+	}
+	prefix := "VSTASK_READY_" + normalizeInputID(label) + "_"
+	names := rx.SubexpNames()
+	out := make(map[string]string, len(m)-1)
+	for i := 1; i < len(m); i++ {
+		group := names[i]
+		if group == "" {
+			group = strconv.Itoa(i)
+		}
+		out[prefix+normalizeInputID(group)] = m[i]
+	}
+	return out
+}
+
+// collectExports reads the variables a finished task exports: its dotenv file first,
+// then the vars matched in its output.
+func collectExports(t tasks.Task, output []byte) (map[string]string, error) {
+	x := t.Exports
+	if x == nil {
+		return nil, nil
+	}
+	out := map[string]string{}
+	if x.File != "" {
+		data, err := os.ReadFile(x.File)
+		if err != nil {
+			return nil, fmt.Errorf("task %q: exports file: %w", t.Label, err)
+		}
+		vars, err := parseDotenv(data)
+		if err != nil {
+			return nil, fmt.Errorf("task %q: exports file %s: %w", t.Label, x.File, err)
+		}
+		maps.Copy(out, vars)
+	}
+	for name, pattern := range x.Vars {
+		rx, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("task %q: exports.vars.%s: %w", t.Label, name, err)
+		}
+		found := false
+		for line := range strings.SplitSeq(strings.ReplaceAll(string(output), "\r\n", "\n"), "\n") {
+			if m := rx.FindStringSubmatch(line); m != nil {
+				if len(m) > 1 {
+					out[name] = m[1]
+				} else {
+					out[name] = m[0]
+				}
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("task %q: exports.vars.%s: no output line matches %q", t.Label, name, pattern)
+		}
+	}
+	return out, nil
+}
+
+// parseDotenv parses KEY=VALUE lines. Blank lines, # comments and a leading "export " are
+// allowed; values may be single-quoted (literal) or double-quoted (with \n, \" and \\ escapes).
+func parseDotenv(data []byte) (map[string]string, error) {
+	vars := map[string]string{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, val, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", n)
+		}
+		val = strings.TrimSpace(val)
+		switch {
+		case len(val) >= 2 && val[0] == '\'' && val[len(val)-1] == '\'':
+			val = val[1 : len(val)-1]
+		case len(val) >= 2 && val[0] == '"' && val[len(val)-1] == '"':
+			uq, err := strconv.Unquote(val)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			val = uq
+		}
+		vars[key] = val
+	}
+	return vars, sc.Err()
+}
+
+// inheritEnv adds the variables exported by a task's dependencies to its environment.
+// The task's own options.env still wins.
+func inheritEnv(env []string, t tasks.Task, inherited map[string]string) []string {
+	if len(inherited) == 0 {
+		return env
+	}
+	extra := make(map[string]string, len(inherited))
+	for k, v := range inherited {
+		if t.Options != nil {
+			if _, own := t.Options.Env[k]; own {
+				continue
+			}
+		}
+		extra[k] = v
+	}
+	return mergeEnv(env, extra)
+}
@@ -0,0 +1,131 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func TestParseDotenv(t *testing.T) {
+	got, err := parseDotenv([]byte(`
+# outputs
+API_URL=https://api.example.com
+export REGION = eu-west-1
+SINGLE='a $literal'
+DOUBLE="line\nbreak \"quoted\""
+EMPTY=
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"API_URL": "https://api.example.com",
+		"REGION":  "eu-west-1",
+		"SINGLE":  "a $literal",
+		"DOUBLE":  "line\nbreak \"quoted\"",
+		"EMPTY":   "",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v", got)
+	}
+
+	if _, err := parseDotenv([]byte("no equals sign")); err == nil {
+		t.Fatal("expected an error for a line without =")
+	}
+}
+
+func TestCollectExports(t *testing.T) {
+	output := []byte("Apply complete!\r\napi_url = \"https://old\"\napi_url = \"https://new\"\nregion: eu\n")
+
+	got, err := collectExports(tasks.Task{Label: "tf", Exports: &tasks.Exports{Vars: map[string]string{
+		"API_URL": `^api_url = "(.*)"$`,
+		"REGION":  `eu|us`,
+	}}}, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"API_URL": "https://new", "REGION": "eu"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v", got)
+	}
+
+	file := filepath.Join(t.TempDir(), "out.env")
+	writeFile(t, file, "API_URL=from-file\nTOKEN=abc\n")
+	got, err = collectExports(tasks.Task{Label: "tf", Exports: &tasks.Exports{
+		Vars: map[string]string{"API_URL": `^api_url = "(.*)"$`},
+		File: file,
+	}}, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"API_URL": "https://new", "TOKEN": "abc"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("vars should win over the file: %#v", got)
+	}
+
+	for name, x := range map[string]*tasks.Exports{
+		"no match":     {Vars: map[string]string{"X": `^nope$`}},
+		"bad regex":    {Vars: map[string]string{"X": `(`}},
+		"missing file": {File: filepath.Join(t.TempDir(), "missing.env")},
+	} {
+		if _, err := collectExports(tasks.Task{Label: "tf", Exports: x}, output); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	if got, err := collectExports(tasks.Task{}, output); err != nil || got != nil {
+		t.Fatalf("no exports: %v, %v", got, err)
+	}
+}
+
+func TestInheritEnv(t *testing.T) {
+	tk := tasks.Task{Options: &tasks.Options{Env: map[string]string{"REGION": "us"}}}
+	env := inheritEnv([]string{"HOME=/home/me", "REGION=us"}, tk, map[string]string{"API_URL": "https://x", "REGION": "eu"})
+	slices.Sort(env)
+	if want := []string{"API_URL=https://x", "HOME=/home/me", "REGION=us"}; !slices.Equal(env, want) {
+		t.Fatalf("got %v", env)
+	}
+}
+
+func TestExports_PassedToDependents(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".vscode", "tasks.json"), `{
+  "version": "2.0.0",
+  "tasks": [
+    { "label": "outputs", "type": "shell", "command": "echo 'api_url = https://api.example.com'",
+      "exports": { "vars": { "API_URL": "^api_url = (.*)$" } } },
+    { "label": "creds", "type": "shell", "command": "echo TOKEN=s3cret > creds.env",
+      "exports": { "file": "creds.env" } },
+    { "label": "deploy", "type": "shell", "command": "echo \"$API_URL $TOKEN\" > deployed.txt",
+      "dependsOn": ["outputs", "creds"] }
+  ]
+}`)
+	t.Chdir(dir)
+
+	all, err := tasks.GetTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	deploy, err := tasks.FindTask(all, "deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RunTask(deploy); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "deployed.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(b)); got != "https://api.example.com s3cret" {
+		t.Fatalf("deploy saw %q", got)
+	}
+}
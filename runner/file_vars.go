@@ -0,0 +1,59 @@
+package runner
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FileContext stands in for the editor's active file, which VS Code's ${file} family of
+// variables describes (see `vstask --file`).
+type FileContext struct {
+	Path         string // the "open" file; relative paths are relative to the process cwd
+	Line         int    // ${lineNumber}; 0 leaves it unset
+	SelectedText string // ${selectedText}; "" leaves it unset
+}
+
+// vars returns the ${file}-family variables for a task in workspace.
+func (fc FileContext) vars(workspace string) map[string]string {
+	vars := map[string]string{}
+	if fc.Path != "" {
+		file, err := filepath.Abs(fc.Path)
+		if err != nil {
+			file = fc.Path
+		}
+		dir := filepath.Dir(file)
+		base := filepath.Base(file)
+		ext := filepath.Ext(file)
+		vars["file"] = file
+		vars["fileBasename"] = base
+		vars["fileBasenameNoExtension"] = strings.TrimSuffix(base, ext)
+		vars["fileExtname"] = ext
+		vars["fileDirname"] = dir
+		vars["fileDirnameBasename"] = filepath.Base(dir)
+		if workspace != "" {
+			vars["fileWorkspaceFolder"] = workspace
+			if rel, err := filepath.Rel(workspace, file); err == nil {
+				vars["relativeFile"] = rel
+				vars["relativeFileDirname"] = filepath.Dir(rel)
+			}
+		}
+	}
+	if fc.Line > 0 {
+		vars["lineNumber"] = strconv.Itoa(fc.Line)
+	}
+	if fc.SelectedText != "" {
+		vars["selectedText"] = fc.SelectedText
+	}
+	return vars
+}
+
+// addFileVars sets the ${file}-family variables of the run's FileContext, if any.
+func (r *InputResolver) addFileVars(vars map[string]string, workspace string) {
+	if r == nil || r.file == nil {
+		return
+	}
+	for k, v := range r.file.vars(workspace) {
+		vars[k] = v
+	}
+}
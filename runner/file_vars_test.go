@@ -0,0 +1,71 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func TestFileContextVars(t *testing.T) {
+	ws := t.TempDir()
+	file := filepath.Join(ws, "src", "app", "main.test.go")
+	got := FileContext{Path: file, Line: 42, SelectedText: "TestMain"}.vars(ws)
+	want := map[string]string{
+		"file":                    file,
+		"fileBasename":            "main.test.go",
+		"fileBasenameNoExtension": "main.test",
+		"fileExtname":             ".go",
+		"fileDirname":             filepath.Join(ws, "src", "app"),
+		"fileDirnameBasename":     "app",
+		"fileWorkspaceFolder":     ws,
+		"relativeFile":            filepath.Join("src", "app", "main.test.go"),
+		"relativeFileDirname":     filepath.Join("src", "app"),
+		"lineNumber":              "42",
+		"selectedText":            "TestMain",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v", got)
+	}
+
+	if got := (FileContext{Line: 3}).vars(ws); !reflect.DeepEqual(got, map[string]string{"lineNumber": "3"}) {
+		t.Fatalf("line only: %#v", got)
+	}
+}
+
+func TestResolveTask_FileVars(t *testing.T) {
+	isolatePMDetectionToDefault(t)
+	ws := t.TempDir()
+	t.Chdir(ws)
+	if err := os.MkdirAll(filepath.Join(ws, "pkg"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	r := NewInputResolver(nil)
+	r.file = &FileContext{Path: filepath.Join("pkg", "x.go"), Line: 7}
+	tk := tasks.Task{
+		Label:   "test file",
+		Command: "go test ./${relativeFileDirname} -run ${fileBasenameNoExtension}:${lineNumber}",
+		Options: &tasks.Options{Cwd: "${fileDirname}"},
+	}
+	rt, err := resolveTask(tk, ws, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "go test ./pkg -run x:7"; rt.Task.Command != want {
+		t.Fatalf("command = %q, want %q", rt.Task.Command, want)
+	}
+	if got, _ := filepath.EvalSymlinks(rt.Cwd); got != mustEval(t, filepath.Join(ws, "pkg")) {
+		t.Fatalf("cwd = %q", rt.Cwd)
+	}
+}
+
+func mustEval(t *testing.T, p string) string {
+	t.Helper()
+	r, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
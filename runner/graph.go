@@ -0,0 +1,370 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// taskNode is one task of a run's dependency graph. Tasks reached through several
+// dependsOn paths share a node, so each runs at most once per run.
+type taskNode struct {
+	task   tasks.Task
+	folder string
+	name   string      // label, or "folder: label" for tasks of other workspace folders
+	deps   []*taskNode // in dependsOn order
+	opt    []bool      // deps[i] is optional: its failure doesn't stop n (see tasks.TaskRef)
+	order  []int       // the order parallel deps start in (see depIndex.scheduleOrder)
+	seq    bool        // deps run one after another (see taskGraph.sequential)
+
+	executable string        // the command's absolute path, in hermetic mode (see resolveExecutables)
+	resolved   *resolvedTask // the task resolved up front, in hermetic mode; run as is
+
+	claimed atomic.Bool   // set by the run that executes the node
+	done    chan struct{} // closed once it has run
+	exports map[string]string
+	err     error
+}
+
+// sequential reports whether n's dependencies run one after another.
+func (n *taskNode) sequential() bool {
+	return n.seq
+}
+
+// optional reports whether n's i-th dependency is optional.
+func (n *taskNode) optional(i int) bool {
+	return i < len(n.opt) && n.opt[i]
+}
+
+// taskGraph resolves a task's dependsOn references, recursively, into a graph of nodes.
+type taskGraph struct {
+	index  *depIndex
+	nodes  map[string]*taskNode // folder + "\x00" + label
+	adding map[string]bool      // nodes whose dependencies are being added
+
+	// dependsOrder, if set, is every task's dependsOrder for this run (--depends-order).
+	dependsOrder string
+}
+
+// sequential reports whether t's dependencies run one after another: its dependsOrder
+// ("parallel" is VS Code's default), unless the run overrides it.
+func (g *taskGraph) sequential(t tasks.Task) bool {
+	order := t.DependsOrder
+	if g.dependsOrder != "" {
+		order = g.dependsOrder
+	}
+	return strings.EqualFold(order, "sequence")
+}
+
+func newTaskGraph(index *depIndex) *taskGraph {
+	return &taskGraph{index: index, nodes: map[string]*taskNode{}, adding: map[string]bool{}}
+}
+
+// add adds t (in folder) and everything it depends on. path is the chain of tasks that led
+// to t, for reporting cycles.
+func (g *taskGraph) add(t tasks.Task, folder string, path []string) (*taskNode, error) {
+	key := folder + "\x00" + t.Label
+	name := t.Label
+	if f := g.index.folderName(folder); f != "" {
+		name = f + ": " + t.Label
+	}
+	if g.adding[key] {
+		return nil, fmt.Errorf("dependsOn: cycle: %s -> %s", strings.Join(path, " -> "), name)
+	}
+	if n, ok := g.nodes[key]; ok {
+		return n, nil
+	}
+	n := &taskNode{task: t, folder: folder, name: name, seq: g.sequential(t), done: make(chan struct{})}
+	g.nodes[key] = n
+	if t.DependsOn == nil || len(t.DependsOn.Tasks) == 0 {
+		return n, nil
+	}
+	g.adding[key] = true
+	defer delete(g.adding, key)
+	path = append(path, name)
+	deps := make([]*taskNode, 0, len(t.DependsOn.Tasks))
+	for _, ref := range t.DependsOn.Tasks {
+		n.opt = append(n.opt, ref.Optional)
+		dt, df, err := g.index.lookupFrom(folder, ref)
+		if err != nil {
+			return nil, err
+		}
+		d, err := g.add(dt, df, path)
+		if err != nil {
+			return nil, err
+		}
+		deps = append(deps, d)
+	}
+	n.deps = deps
+	if !n.sequential() {
+		ts := make([]tasks.Task, len(deps))
+		folders := make([]string, len(deps))
+		for i, d := range deps {
+			ts[i], folders[i] = d.task, d.folder
+		}
+		n.order = g.index.scheduleOrder(ts, folders)
+	}
+	return n, nil
+}
+
+// byFolder groups the graph's tasks by workspace folder (see checkTaskTypes).
+func (g *taskGraph) byFolder() map[string][]tasks.Task {
+	out := map[string][]tasks.Task{}
+	for _, n := range g.nodes {
+		out[n.folder] = append(out[n.folder], n.task)
+	}
+	return out
+}
+
+// graphRun executes a task graph: every node after its dependencies, each node once.
+type graphRun struct {
+	resolver *InputResolver
+	root     *taskNode
+	prev     *runResults // the previous run's results, when retrying (else nil)
+	results  *runResults
+	slots    *jobSlots            // limits concurrently running dependencies (see jobsLimit); nil = no limit
+	workers  int                  // goroutines running one task's parallel dependencies; 0 = one per dependency
+	skip     map[*taskNode]string // dependencies not run this time, and why (see selectSteps)
+	fail     map[*taskNode]bool   // tasks reported as failed without running (see selectFailures)
+	compound bool                 // root only groups its dependencies (see RunTasks)
+
+	background  *backgroundTasks // dependencies still running once ready, stopped when the run ends
+	problems    *problemLog      // what the tasks' problem matchers found, summarized when the run ends
+	progress    io.Writer        // where sequence steps are reported (see stepProgress); nil = not at all
+	prefix      outputPrefix     // what starts the tasks' output lines (--prefix-output, --timestamps)
+	interrupted atomic.Bool      // set on SIGINT/SIGTERM; no more tasks start
+}
+
+// run runs n, after its dependencies, unless another path through the graph already did,
+// and returns its error. queued is called once n holds its place in line for a job slot (or
+// doesn't need one), so parallel dependencies can be started in a deliberate order.
+func (r *graphRun) run(n *taskNode, queued func()) error {
+	var once sync.Once
+	q := func() { once.Do(queued) }
+	defer q()
+	if !n.claimed.CompareAndSwap(false, true) {
+		q()
+		<-n.done
+		return n.err
+	}
+	n.exports, n.err = r.safeExec(n, q)
+	close(n.done)
+	return n.err
+}
+
+// safeExec is exec, with a panic turned into n's failure (a *PanicError, its stack printed and
+// kept in the run's results), so the rest of the run winds down normally.
+func (r *graphRun) safeExec(n *taskNode, queued func()) (exports map[string]string, err error) {
+	defer recoverPanic(n.name, func(pe *PanicError) {
+		r.results.record(n.name, n.task, n.folder, nil, pe)
+		exports, err = nil, pe
+		if n != r.root {
+			err = &DependencyFailedError{Label: n.name, Err: pe}
+		}
+	})
+	return r.exec(n, queued)
+}
+
+func (r *graphRun) exec(n *taskNode, queued func()) (map[string]string, error) {
+	if why, ok := r.skip[n]; ok {
+		fmt.Printf("Skipping task: %s (%s)\n", n.name, why)
+		return nil, nil
+	}
+	if err := r.runDeps(n, queued); err != nil {
+		return nil, err
+	}
+	if r.fail[n] {
+		fmt.Printf("Failing task: %s (--fail)\n", n.name)
+		err := error(&SimulatedFailureError{Label: n.name})
+		r.results.record(n.name, n.task, n.folder, nil, err)
+		if n != r.root {
+			err = &DependencyFailedError{Label: n.name, Err: err}
+		}
+		return nil, err
+	}
+	// Each dependency's exports, merged in dependsOn order.
+	inherited := map[string]string{}
+	for _, d := range n.deps {
+		maps.Copy(inherited, d.exports)
+	}
+	t := n.task
+
+	if n == r.root && (r.compound || tasks.ApplyPlatformOverrides(t).IsCompound()) {
+		// It runs nothing itself. With only background members, the run lasts as long as
+		// they do (or until CTRL-C).
+		if !r.compound {
+			fmt.Printf("Finished task: %s (dependencies only)\n", n.name)
+		}
+		names := make([]string, len(n.deps))
+		for i, d := range n.deps {
+			names[i] = d.name
+		}
+		if r.background.tracks(names) {
+			fmt.Println("Waiting for background tasks to exit (CTRL-C to stop them)")
+			r.background.wait()
+		}
+		err := error(nil)
+		if r.interrupted.Load() {
+			err = ErrCancelled
+		}
+		if !r.compound {
+			r.results.record(n.name, n.task, n.folder, nil, err)
+		}
+		return nil, err
+	}
+	if n == r.root {
+		// Run fully (i.e., wait for process exit). It's recorded as failed up front, so an
+		// interrupted run is retried too.
+		r.results.record(n.name, n.task, n.folder, nil, ErrCancelled)
+		if r.interrupted.Load() {
+			return nil, ErrCancelled
+		}
+		exported, err := runTaskInternal(t, n.resolved, n.folder, r.resolver, r.prefix, false /* waitForReady */, inherited, r.background, r.problems)
+		r.results.record(n.name, n.task, n.folder, exported, err)
+		return exported, err
+	}
+
+	if r.prev.reusable(n.name, n.task, n.folder) {
+		fmt.Printf("Skipping task: %s (succeeded last run)\n", n.name)
+		exported := r.prev.Tasks[n.name].Exports
+		r.results.record(n.name, n.task, n.folder, exported, nil)
+		return exported, nil
+	}
+	if n.task.Deprecated != "" {
+		fmt.Fprintf(os.Stderr, "Warning: dependency %q is deprecated: %s\n", n.name, n.task.Deprecated)
+	}
+	if r.slots != nil {
+		r.slots.acquire(queued)
+		defer r.slots.release()
+	}
+	if r.interrupted.Load() {
+		return nil, ErrCancelled
+	}
+	exported, err := runTaskInternal(t, n.resolved, n.folder, r.resolver, r.prefix, true, inherited, r.background, r.problems)
+	r.results.record(n.name, n.task, n.folder, exported, err)
+	if err != nil {
+		return nil, &DependencyFailedError{Label: n.name, Err: err}
+	}
+	return exported, nil
+}
+
+// runDeps runs n's dependencies in order (see taskGraph.sequential).
+func (r *graphRun) runDeps(n *taskNode, queued func()) error {
+	if n.sequential() {
+		start := time.Now()
+		for i, d := range n.deps {
+			q := func() {}
+			if i == 0 {
+				q = queued // the first one holds n's place in line
+			}
+			if r.progress != nil && len(n.deps) > 1 {
+				fmt.Fprintln(r.progress, stepProgress(i, len(n.deps), d.name, time.Since(start)))
+			}
+			if err := r.depFailed(n, i, r.run(d, q)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	// A pool of workers takes them in order: longest (historical) chains first, so a jobs
+	// limit doesn't leave them for last. Each one is handed out once the previous one is in line.
+	type job struct {
+		i      int
+		inLine chan struct{}
+	}
+	workers := len(n.deps)
+	if r.workers > 0 && r.workers < workers {
+		workers = r.workers
+	}
+	jobs := make(chan job)
+	errs := make([]error, len(n.deps))
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				errs[j.i] = r.run(n.deps[j.i], func() { close(j.inLine) })
+			}
+		}()
+	}
+	for _, i := range n.order {
+		j := job{i, make(chan struct{})}
+		jobs <- j
+		<-j.inLine
+	}
+	close(jobs)
+	queued()
+	wg.Wait()
+	for i, err := range errs {
+		if err := r.depFailed(n, i, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// depFailed returns the error of n's i-th dependency that stops n: err, unless the dependency
+// is optional, in which case its failure is only reported. Cancelling the run (or the
+// dependency's process, see asCancelled) still stops n.
+func (r *graphRun) depFailed(n *taskNode, i int, err error) error {
+	if err == nil || !n.optional(i) || errors.Is(err, ErrCancelled) || errors.Is(err, context.Canceled) || r.interrupted.Load() {
+		return err
+	}
+	var dfe *DependencyFailedError
+	if errors.As(err, &dfe) {
+		err = dfe.Err
+	}
+	fmt.Fprintf(os.Stderr, "Warning: optional dependency %q failed; continuing: %v\n", n.deps[i].name, err)
+	return nil
+}
+
+// jobSlots is a semaphore handing out slots in the order they were asked for.
+type jobSlots struct {
+	mu    sync.Mutex
+	free  int
+	queue []chan struct{}
+}
+
+func newJobSlots(n int) *jobSlots {
+	if n <= 0 {
+		return nil
+	}
+	return &jobSlots{free: n}
+}
+
+// acquire waits for a slot, calling queued once it's in line.
+func (s *jobSlots) acquire(queued func()) {
+	s.mu.Lock()
+	if s.free > 0 {
+		s.free--
+		s.mu.Unlock()
+		queued()
+		return
+	}
+	ch := make(chan struct{})
+	s.queue = append(s.queue, ch)
+	s.mu.Unlock()
+	queued()
+	<-ch
+}
+
+func (s *jobSlots) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.queue) > 0 {
+		close(s.queue[0])
+		s.queue = s.queue[1:]
+		return
+	}
+	s.free++
+}
@@ -0,0 +1,425 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// diamondTasks is A → {B, C} → D, with every task appending its label to run.log.
+func diamondTasks(t *testing.T, order string) string {
+	t.Helper()
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".vscode", "tasks.json"), `{
+  "version": "2.0.0",
+  "tasks": [
+    { "label": "A", "command": "echo A >> run.log", "dependsOn": ["B", "C"], "dependsOrder": "`+order+`" },
+    { "label": "B", "command": "echo B >> run.log", "dependsOn": ["D"] },
+    { "label": "C", "command": "echo C >> run.log", "dependsOn": "D" },
+    { "label": "D", "command": "echo D >> run.log" }
+  ]
+}`)
+	t.Chdir(dir)
+	return dir
+}
+
+func runLabel(t *testing.T, label string) error {
+	t.Helper()
+	all, err := tasks.GetTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := tasks.FindTask(all, label)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return RunTask(task)
+}
+
+func TestRun_SharedDependencyRunsOnce(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	for _, order := range []string{"parallel", "sequence"} {
+		t.Run(order, func(t *testing.T) {
+			dir := diamondTasks(t, order)
+			if err := runLabel(t, "A"); err != nil {
+				t.Fatal(err)
+			}
+			b, err := os.ReadFile(filepath.Join(dir, "run.log"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := strings.Fields(string(b))
+			if len(got) != 4 || got[0] != "D" || got[3] != "A" {
+				t.Fatalf("ran %v; want D once, first, and A last", got)
+			}
+			if order == "sequence" && !slices.Equal(got, []string{"D", "B", "C", "A"}) {
+				t.Fatalf("ran %v", got)
+			}
+		})
+	}
+}
+
+func TestTaskGraph_Cycle(t *testing.T) {
+	ts := []tasks.Task{
+		{Label: "a", DependsOn: &tasks.DependsOn{Tasks: []tasks.TaskRef{{Label: "b"}}}},
+		{Label: "b", DependsOn: &tasks.DependsOn{Tasks: []tasks.TaskRef{{Label: "c"}}}},
+		{Label: "c", DependsOn: &tasks.DependsOn{Tasks: []tasks.TaskRef{{Label: "a"}}}},
+	}
+	root := t.TempDir()
+	g := newTaskGraph(newDepIndex(root, ts, NewInputResolver(nil)))
+	_, err := g.add(ts[0], root, nil)
+	if err == nil || !strings.Contains(err.Error(), "cycle: a -> b -> c -> a") {
+		t.Fatalf("expected a cycle error, got %v", err)
+	}
+}
+
+func TestTaskGraph_SharesNodes(t *testing.T) {
+	ref := func(l string) tasks.TaskRef { return tasks.TaskRef{Label: l} }
+	ts := []tasks.Task{
+		{Label: "a", DependsOn: &tasks.DependsOn{Tasks: []tasks.TaskRef{ref("b"), ref("c")}}},
+		{Label: "b", DependsOn: &tasks.DependsOn{Tasks: []tasks.TaskRef{ref("d")}}},
+		{Label: "c", DependsOn: &tasks.DependsOn{Tasks: []tasks.TaskRef{ref("d")}}},
+		{Label: "d"},
+	}
+	root := t.TempDir()
+	g := newTaskGraph(newDepIndex(root, ts, NewInputResolver(nil)))
+	a, err := g.add(ts[0], root, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.nodes) != 4 || a.deps[0].deps[0] != a.deps[1].deps[0] {
+		t.Fatalf("d should be one node shared by b and c (%d nodes)", len(g.nodes))
+	}
+}
+
+func TestJobSlots_FIFO(t *testing.T) {
+	s := newJobSlots(1)
+	s.acquire(func() {})
+
+	var mu sync.Mutex
+	var got []int
+	var wg sync.WaitGroup
+	for i := range 3 {
+		inLine := make(chan struct{})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.acquire(func() { close(inLine) })
+			mu.Lock()
+			got = append(got, i)
+			mu.Unlock()
+			s.release()
+		}()
+		<-inLine
+	}
+	s.release()
+	wg.Wait()
+	if !slices.Equal(got, []int{0, 1, 2}) {
+		t.Fatalf("slots handed out in order %v", got)
+	}
+	if newJobSlots(0) != nil {
+		t.Fatal("no limit should mean no slots")
+	}
+}
+
+func TestRun_MaxParallelLimitsDependencies(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	t.Setenv("VSTASK_JOBS", "")
+	dir := t.TempDir()
+	// Each dependency holds running.d while it runs; a second one at the same time can't create it.
+	dep := `"command": "mkdir running.d || echo overlap >> overlap.log; sleep 0.05; rmdir running.d"`
+	writeFile(t, filepath.Join(dir, ".vscode", "tasks.json"), `{
+  "version": "2.0.0",
+  "tasks": [
+    { "label": "all", "command": "true", "dependsOn": ["a", "b", "c", "d"] },
+    { "label": "a", `+dep+` },
+    { "label": "b", `+dep+` },
+    { "label": "c", `+dep+` },
+    { "label": "d", `+dep+` }
+  ]
+}`)
+	t.Chdir(dir)
+	all, err := tasks.GetTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := tasks.FindTask(all, "all")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(task, Options{MaxParallel: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(filepath.Join(dir, "overlap.log")); err == nil {
+		t.Fatalf("dependencies overlapped with --max-parallel 1: %s", b)
+	}
+}
+
+func TestRun_DependencyFailedError(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".vscode", "tasks.json"), `{
+  "version": "2.0.0",
+  "tasks": [
+    { "label": "A", "command": "echo A", "dependsOn": ["B"] },
+    { "label": "B", "command": "exit 3" },
+    { "label": "C", "command": "echo C", "dependsOn": ["missing"] }
+  ]
+}`)
+	t.Chdir(dir)
+
+	err := runLabel(t, "A")
+	var df *DependencyFailedError
+	if !errors.As(err, &df) || df.Label != "B" || ExitCode(err) != 3 {
+		t.Fatalf("err = %v (exit %d); want B's failure with its exit code", err, ExitCode(err))
+	}
+	if err := runLabel(t, "C"); !errors.Is(err, tasks.ErrTaskNotFound) {
+		t.Fatalf("missing dependency: err = %v", err)
+	}
+}
+
+func TestRun_PanicFailsTheTask(t *testing.T) {
+	// Without a resolver, resolving the input panics.
+	bad := &taskNode{task: tasks.Task{Label: "bad", Command: "echo ${input:x}"}, name: "bad", done: make(chan struct{})}
+	root := &taskNode{task: tasks.Task{Label: "root", Command: "echo"}, name: "root", deps: []*taskNode{bad}, order: []int{0}, done: make(chan struct{})}
+	run := &graphRun{root: root, results: newRunResults("root")}
+
+	err := run.run(root, func() {})
+	var df *DependencyFailedError
+	var pe *PanicError
+	if !errors.As(err, &df) || !errors.As(err, &pe) || pe.Label != "bad" || len(pe.Stack) == 0 {
+		t.Fatalf("err = %v; want bad's panic as a dependency failure", err)
+	}
+	if res := run.results.Tasks["bad"]; res.OK || !strings.Contains(res.Panic, "goroutine") {
+		t.Fatalf("results = %+v; want the stack trace recorded", res)
+	}
+}
+
+func TestRun_SequenceProgress(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	diamondTasks(t, "sequence")
+	all, err := tasks.GetTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := tasks.FindTask(all, "A")
+	root := t.TempDir()
+	g := newTaskGraph(newDepIndex(root, all, NewInputResolver(nil)))
+	node, err := g.add(a, root, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var progress strings.Builder
+	run := &graphRun{resolver: NewInputResolver(nil), root: node, results: newRunResults("A"), problems: &problemLog{}, progress: &progress}
+	if err := run.run(node, func() {}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(progress.String()), "\n")
+	if len(lines) != 2 || lines[0] != "step 1/2: B" || !strings.HasPrefix(lines[1], "step 2/2: C (prev steps ") {
+		t.Fatalf("progress:\n%s", progress.String())
+	}
+}
+
+func TestStepProgress(t *testing.T) {
+	if got := stepProgress(2, 7, "compile", 12_430*time.Millisecond); got != "step 3/7: compile (prev steps 12.4s)" {
+		t.Fatalf("got %q", got)
+	}
+	if got := stepProgress(0, 7, "lint", 0); got != "step 1/7: lint" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestRun_DependsOrderOverride(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	dir := diamondTasks(t, "parallel")
+	all, err := tasks.GetTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := tasks.FindTask(all, "A")
+	if err := Run(a, Options{DependsOrder: "sequence"}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "run.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Fields(string(b)); !slices.Equal(got, []string{"D", "B", "C", "A"}) {
+		t.Fatalf("ran %v; want B's chain, then C, then A", got)
+	}
+
+	root := t.TempDir()
+	g := newTaskGraph(newDepIndex(root, all, NewInputResolver(nil)))
+	g.dependsOrder = "parallel"
+	if n, err := g.add(tasks.Task{Label: "s", DependsOrder: "sequence"}, root, nil); err != nil || n.sequential() {
+		t.Fatalf("a sequence overridden to parallel: %v", err)
+	}
+}
+
+func TestRunTasks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	dir := diamondTasks(t, "parallel")
+	if err := RunTasks([]string{"C", "B"}, "sequence", Options{}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "run.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Fields(string(b)); !slices.Equal(got, []string{"D", "C", "B"}) {
+		t.Fatalf("ran %v; want D once, then C, then B", got)
+	}
+	if last, ok := LastRunLabel(dir); ok {
+		t.Fatalf("last run recorded as %q; there's no task to re-run", last)
+	}
+}
+
+func TestRunTasks_WaitsForBackgroundTasks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".vscode", "tasks.json"), `{
+  "version": "2.0.0",
+  "tasks": [
+    {
+      "label": "watch",
+      "isBackground": true,
+      "problemMatcher": "$tsc-watch",
+      "command": "printf 'Starting compilation in watch mode...\\n'; sleep 1; echo exited > watch.out"
+    }
+  ]
+}`)
+	t.Chdir(dir)
+	if err := RunTasks([]string{"watch"}, "parallel", Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "watch.out")); err != nil {
+		t.Fatal("the run ended before its only (background) task exited")
+	}
+}
+
+func TestRun_CompoundTask(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	dir := t.TempDir()
+	// "checks" has a cwd that doesn't exist: starting a shell there would fail.
+	writeFile(t, filepath.Join(dir, ".vscode", "tasks.json"), `{
+  "version": "2.0.0",
+  "tasks": [
+    { "label": "ci", "dependsOn": ["checks", "build"], "dependsOrder": "sequence" },
+    { "label": "checks", "dependsOn": ["lint"], "options": { "cwd": "missing" } },
+    { "label": "lint", "command": "echo lint >> run.log" },
+    { "label": "build", "command": "echo build >> run.log" },
+    {
+      "label": "watch",
+      "isBackground": true,
+      "problemMatcher": "$tsc-watch",
+      "command": "printf 'Starting compilation in watch mode...\\n'; sleep 1; echo exited > watch.out"
+    },
+    { "label": "dev", "dependsOn": ["watch"] }
+  ]
+}`)
+	t.Chdir(dir)
+	if err := runLabel(t, "ci"); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "run.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Fields(string(b)); !slices.Equal(got, []string{"lint", "build"}) {
+		t.Fatalf("ran %v", got)
+	}
+	if res := loadRunResults(dir); res == nil || !res.Tasks["ci"].OK || !res.Tasks["checks"].OK {
+		t.Fatalf("results %+v; want ci and checks recorded as succeeded", res)
+	}
+
+	// Like `vstask run`, a compound task of background tasks lasts as long as they do.
+	if err := runLabel(t, "dev"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "watch.out")); err != nil {
+		t.Fatal("the run ended before its only (background) dependency exited")
+	}
+}
+
+func TestRun_OptionalDependency(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	for _, order := range []string{"parallel", "sequence"} {
+		t.Run(order, func(t *testing.T) {
+			t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+			dir := t.TempDir()
+			writeFile(t, filepath.Join(dir, ".vscode", "tasks.json"), `{
+  "version": "2.0.0",
+  "tasks": [
+    { "label": "ci", "dependsOn": [{ "label": "lint", "optional": true }, "build"], "dependsOrder": "`+order+`" },
+    { "label": "strict", "dependsOn": [{ "label": "lint", "optional": true }, "release"], "dependsOrder": "`+order+`" },
+    { "label": "release", "command": "echo release >> run.log", "dependsOn": ["lint"] },
+    { "label": "lint", "command": "exit 2" },
+    { "label": "build", "command": "echo build >> run.log" }
+  ]
+}`)
+			t.Chdir(dir)
+			if err := runLabel(t, "ci"); err != nil {
+				t.Fatalf("an optional dependency's failure failed the task: %v", err)
+			}
+			if b, _ := os.ReadFile(filepath.Join(dir, "run.log")); strings.TrimSpace(string(b)) != "build" {
+				t.Fatalf("run.log = %q; want build to have run", b)
+			}
+
+			// Required by release, lint's failure stops it, and so strict.
+			err := runLabel(t, "strict")
+			var df *DependencyFailedError
+			if !errors.As(err, &df) || df.Label != "lint" || ExitCode(err) != 2 {
+				t.Fatalf("err = %v; want lint's failure", err)
+			}
+			if b, _ := os.ReadFile(filepath.Join(dir, "run.log")); strings.Contains(string(b), "release") {
+				t.Fatal("release ran after its required dependency failed")
+			}
+		})
+	}
+}
+
+func TestDepFailed_Cancelled(t *testing.T) {
+	n := &taskNode{name: "ci", deps: []*taskNode{{name: "lint"}}, opt: []bool{true}}
+	r := &graphRun{}
+	if err := r.depFailed(n, 0, &DependencyFailedError{Label: "lint", Err: errors.New("exit 2")}); err != nil {
+		t.Fatalf("an optional dependency's failure: %v", err)
+	}
+	for _, cancelled := range []error{ErrCancelled, fmt.Errorf("task: %w", context.Canceled)} {
+		if err := r.depFailed(n, 0, &DependencyFailedError{Label: "lint", Err: cancelled}); err == nil {
+			t.Fatalf("%v: cancelling an optional dependency didn't stop the task", cancelled)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+const (
+	defaultHealthInterval    = 10 * time.Second
+	defaultHealthRetries     = 3
+	defaultHealthMaxRestarts = 5
+	maxRecentLines           = 1000
+)
+
+// healthEvent is something the health check of a background task noticed or did.
+type healthEvent struct {
+	Task   string
+	Kind   string // "unhealthy" | "restarted" | "gave up"
+	Reason string
+	Time   time.Time
+}
+
+func (e healthEvent) String() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("Health: %s %s", e.Task, e.Kind)
+	}
+	return fmt.Sprintf("Health: %s %s: %s", e.Task, e.Kind, e.Reason)
+}
+
+// healthMonitor runs a background task's health check (see tasks.HealthCheck) and restarts
+// the task when it fails too often.
+type healthMonitor struct {
+	name    string
+	check   tasks.HealthCheck // with defaults applied
+	outRx   *regexp.Regexp    // UnhealthyPattern, compiled
+	cwd     string
+	env     []string
+	sandbox func(*exec.Cmd) (*exec.Cmd, error) // runs the check command like the task; nil for none
+	restart func(ctx context.Context) (*execCmdShim, error)
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newHealthMonitor(name string, hc tasks.HealthCheck, cwd string, env []string, sandbox func(*exec.Cmd) (*exec.Cmd, error), restart func(context.Context) (*execCmdShim, error)) (*healthMonitor, error) {
+	if hc.Interval <= 0 {
+		hc.Interval = tasks.Duration(defaultHealthInterval)
+	}
+	if hc.Retries <= 0 {
+		hc.Retries = defaultHealthRetries
+	}
+	if hc.MaxRestarts <= 0 {
+		hc.MaxRestarts = defaultHealthMaxRestarts
+	}
+	h := &healthMonitor{name: name, check: hc, cwd: cwd, env: env, sandbox: sandbox, restart: restart, done: make(chan struct{})}
+	if hc.UnhealthyPattern != "" {
+		rx, err := regexp.Compile(hc.UnhealthyPattern)
+		if err != nil {
+			return nil, fmt.Errorf("task %q: healthCheck.unhealthyPattern: %w", name, err)
+		}
+		h.outRx = rx
+	}
+	h.ctx, h.cancel = context.WithCancel(context.Background())
+	return h, nil
+}
+
+// watch checks p every interval until halted, restarting it after check.Retries failures in a
+// row. Events go to emit.
+func (h *healthMonitor) watch(p *backgroundProc, emit func(healthEvent)) {
+	defer close(h.done)
+	defer recoverPanic(h.name, func(pe *PanicError) {
+		emit(healthEvent{Task: h.name, Kind: "gave up", Reason: pe.Error(), Time: time.Now()})
+	})
+	ticker := time.NewTicker(time.Duration(h.check.Interval))
+	defer ticker.Stop()
+	failures, restarts := 0, 0
+	event := func(kind, reason string) {
+		emit(healthEvent{Task: h.name, Kind: kind, Reason: reason, Time: time.Now()})
+	}
+	for {
+		select {
+		case <-h.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		cmd := p.current()
+		reason := h.probe(cmd)
+		if reason == "" {
+			failures = 0
+			continue
+		}
+		failures++
+		event("unhealthy", fmt.Sprintf("%s (%d/%d)", reason, failures, h.check.Retries))
+		if failures < h.check.Retries {
+			continue
+		}
+		if restarts >= h.check.MaxRestarts {
+			event("gave up", fmt.Sprintf("restarted %d times", restarts))
+			return
+		}
+		restarts++
+		failures = 0
+		_ = terminateProcessTree(cmd.Cmd)
+		<-cmd.exited
+		next, err := h.restart(h.ctx)
+		if err != nil {
+			if h.ctx.Err() == nil {
+				event("gave up", "restart failed: "+err.Error())
+			}
+			return
+		}
+		p.replace(next)
+		event("restarted", fmt.Sprintf("%d/%d", restarts, h.check.MaxRestarts))
+	}
+}
+
+// halt stops the health check and waits for it to finish (including a restart in progress).
+func (h *healthMonitor) halt() {
+	h.cancel()
+	<-h.done
+}
+
+// probe runs the checks against cmd and returns why it's unhealthy, or "" if it's healthy.
+func (h *healthMonitor) probe(cmd *execCmdShim) string {
+	select {
+	case <-cmd.exited:
+		return "exited"
+	default:
+	}
+	timeout := time.Duration(h.check.Interval)
+	if p := h.check.Port; p > 0 {
+		conn, err := net.DialTimeout("tcp", net.JoinHostPort("localhost", strconv.Itoa(p)), timeout)
+		if err != nil {
+			return fmt.Sprintf("port %d: %v", p, err)
+		}
+		_ = conn.Close()
+	}
+	if c := h.check.Command; c != "" {
+		ctx, cancel := context.WithTimeout(h.ctx, timeout)
+		defer cancel()
+		exe, args := defaultShell()
+		probe := exec.Command(exe, shellArgsWithCommand(args, c)...)
+		cmdExeQuoting(probe)
+		probe.Dir, probe.Env = h.cwd, h.env
+		if h.sandbox != nil {
+			var err error
+			if probe, err = h.sandbox(probe); err != nil {
+				return fmt.Sprintf("command %q: %v", c, err)
+			}
+		}
+		// Not exec.CommandContext: the sandbox wraps it in a new command.
+		if err := probe.Start(); err != nil {
+			return fmt.Sprintf("command %q: %v", c, err)
+		}
+		stop := context.AfterFunc(ctx, func() { _ = probe.Process.Kill() })
+		err := probe.Wait()
+		stop()
+		if err != nil {
+			return fmt.Sprintf("command %q: %v", c, err)
+		}
+	}
+	if h.outRx != nil && cmd.recent != nil {
+		for _, line := range cmd.recent.take() {
+			if h.outRx.MatchString(line) {
+				return fmt.Sprintf("output: %q", line)
+			}
+		}
+	}
+	return ""
+}
+
+// recentOutput keeps a background task's output lines since the last health check.
+type recentOutput struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+// newRecentOutput returns a buffer if hc needs the task's output, else nil.
+func newRecentOutput(hc *tasks.HealthCheck) *recentOutput {
+	if hc == nil || hc.UnhealthyPattern == "" {
+		return nil
+	}
+	return &recentOutput{}
+}
+
+func (o *recentOutput) add(line string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.lines = append(o.lines, line)
+	if over := len(o.lines) - maxRecentLines; over > 0 {
+		o.lines = o.lines[over:]
+	}
+}
+
+// take returns the lines added since the last call.
+func (o *recentOutput) take() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	lines := o.lines
+	o.lines = nil
+	return lines
+}
+
+// printHealthEvent is the default event handler of backgroundTasks.
+func printHealthEvent(e healthEvent) {
+	fmt.Fprintln(os.Stderr, e)
+}
@@ -0,0 +1,109 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func TestHealthMonitor_RestartsUnhealthyTask(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	dir := t.TempDir()
+	start := func(ctx context.Context) (*execCmdShim, error) {
+		cmd := exec.Command("/bin/sh", "-c", "echo up; sleep 30")
+		cmd.Dir = dir
+		setProcessGroup(cmd)
+		shim := &execCmdShim{Cmd: cmd}
+		return shim, startAndWaitReady(ctx, shim, false, &tasks.BgMatcher{ActiveOnStart: true}, true)
+	}
+	first, err := start(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	hc := tasks.HealthCheck{Command: "exit 1", Interval: tasks.Duration(50 * time.Millisecond), Retries: 2, MaxRestarts: 1}
+	h, err := newHealthMonitor("web", hc, dir, os.Environ(), nil, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := make(chan healthEvent, 20)
+	b := &backgroundTasks{events: func(e healthEvent) { events <- e }}
+	b.add("web", "web", first, h, nil)
+	defer b.stopAll()
+
+	var kinds []string
+	timeout := time.After(10 * time.Second)
+	for len(kinds) == 0 || kinds[len(kinds)-1] != "gave up" {
+		select {
+		case e := <-events:
+			kinds = append(kinds, e.Kind)
+		case <-timeout:
+			t.Fatalf("no 'gave up' event; got %v", kinds)
+		}
+	}
+	want := []string{"unhealthy", "unhealthy", "restarted", "unhealthy", "unhealthy", "gave up"}
+	if !slices.Equal(kinds, want) {
+		t.Fatalf("events %v, want %v", kinds, want)
+	}
+	if cur := b.procs[0].current(); cur == first {
+		t.Fatal("the task should have been replaced by its restart")
+	}
+}
+
+func TestHealthMonitor_ProbeOutputPattern(t *testing.T) {
+	h, err := newHealthMonitor("web", tasks.HealthCheck{UnhealthyPattern: "EADDRINUSE"}, "", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := &execCmdShim{exited: make(chan struct{}), recent: newRecentOutput(&h.check)}
+	cmd.recent.add("listening")
+	if reason := h.probe(cmd); reason != "" {
+		t.Fatalf("healthy output reported as %q", reason)
+	}
+	cmd.recent.add("Error: listen EADDRINUSE :::3000")
+	if reason := h.probe(cmd); reason == "" {
+		t.Fatal("matching output should be unhealthy")
+	}
+	if reason := h.probe(cmd); reason != "" {
+		t.Fatalf("only output since the last check counts, got %q", reason)
+	}
+	close(cmd.exited)
+	if reason := h.probe(cmd); reason != "exited" {
+		t.Fatalf("reason = %q, want exited", reason)
+	}
+	if _, err := newHealthMonitor("web", tasks.HealthCheck{UnhealthyPattern: "("}, "", nil, nil, nil); err == nil {
+		t.Fatal("invalid pattern should be an error")
+	}
+}
+
+func TestHealthMonitor_ProbeSandboxed(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	var wrapped []string
+	sandbox := func(cmd *exec.Cmd) (*exec.Cmd, error) {
+		wrapped = cmd.Args
+		return nil, errors.New("bwrap not found")
+	}
+	hc := tasks.HealthCheck{Command: "true", Interval: tasks.Duration(time.Second)}
+	h, err := newHealthMonitor("web", hc, t.TempDir(), nil, sandbox, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reason := h.probe(&execCmdShim{exited: make(chan struct{})})
+	if len(wrapped) == 0 || wrapped[len(wrapped)-1] != "true" {
+		t.Fatalf("the check command wasn't sandboxed: %q", wrapped)
+	}
+	if !strings.Contains(reason, "bwrap not found") {
+		t.Fatalf("reason = %q: without the sandbox the check must fail, not run unsandboxed", reason)
+	}
+}
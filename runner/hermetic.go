@@ -0,0 +1,88 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// hermetic reports whether process tasks' commands are resolved to absolute paths before the
+// run starts (--hermetic or $VSTASK_HERMETIC=1).
+func hermetic(opts Options) bool {
+	return opts.Hermetic || os.Getenv("VSTASK_HERMETIC") == "1"
+}
+
+// resolveExecutables resolves the command of every process task in the graph to an absolute
+// path, before anything runs: each node then runs that exact file, and the run report records
+// it. A command that can't be found, or that PATH resolves to more than one file, fails the run.
+func (g *taskGraph) resolveExecutables(r *InputResolver) error {
+	nodes := make([]*taskNode, 0, len(g.nodes))
+	for _, n := range g.nodes {
+		if strings.EqualFold(strings.TrimSpace(n.task.Type), "process") {
+			nodes = append(nodes, n)
+		}
+	}
+	slices.SortFunc(nodes, func(a, b *taskNode) int { return strings.Compare(a.name, b.name) })
+	for _, n := range nodes {
+		rt, err := resolveTask(n.task, n.folder, r)
+		if err != nil {
+			return err
+		}
+		exe, err := lookPathHermetic(rt.Task.Command, rt.Cwd, rt.Env)
+		if err != nil {
+			return fmt.Errorf("hermetic: task %q: %w", n.name, err)
+		}
+		fmt.Printf("Resolved %s: %s\n", n.name, exe)
+		rt.Task.Command = exe
+		n.executable, n.resolved = exe, &rt
+	}
+	return nil
+}
+
+// lookPathHermetic finds the one executable name refers to, searching env's PATH. Unlike
+// exec.LookPath, it fails when more than one PATH directory has a (different) match.
+func lookPathHermetic(name, cwd string, env []string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("empty command")
+	}
+	if strings.ContainsAny(name, `/\`) {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(cwd, name)
+		}
+		return exec.LookPath(name)
+	}
+	var pathEnv string
+	for _, kv := range env {
+		if k, v, _ := strings.Cut(kv, "="); isPathKey(k) {
+			pathEnv = v
+		}
+	}
+	var found []string
+	var infos []os.FileInfo
+	for _, dir := range filepath.SplitList(pathEnv) {
+		if dir == "" || !filepath.IsAbs(dir) {
+			continue // a relative PATH entry depends on where the task runs
+		}
+		p, err := exec.LookPath(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		info, err := os.Stat(p)
+		if err != nil || slices.ContainsFunc(infos, func(i os.FileInfo) bool { return os.SameFile(i, info) }) {
+			continue // the same file through another directory, e.g. /bin → /usr/bin
+		}
+		found = append(found, p)
+		infos = append(infos, info)
+	}
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("%s: not found in PATH", name)
+	case 1:
+		return found[0], nil
+	default:
+		return "", fmt.Errorf("%s is ambiguous: %s", name, strings.Join(found, ", "))
+	}
+}
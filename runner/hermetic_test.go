@@ -0,0 +1,93 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// writeExecutable writes a trivial executable named name into dir and returns its path.
+func writeExecutable(t *testing.T, dir, name string) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	writeFile(t, p, "#!/bin/sh\necho "+name+"\n")
+	if err := os.Chmod(p, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestLookPathHermetic(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX executable bits")
+	}
+	a, b := t.TempDir(), t.TempDir()
+	tool := writeExecutable(t, a, "tool")
+	env := func(dirs ...string) []string {
+		return []string{"PATH=" + strings.Join(dirs, string(os.PathListSeparator))}
+	}
+
+	if got, err := lookPathHermetic("tool", "", env(a, b)); err != nil || got != tool {
+		t.Fatalf("got %q, %v; want %q", got, err, tool)
+	}
+	if _, err := lookPathHermetic("missing", "", env(a, b)); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("missing: err = %v", err)
+	}
+	if got, err := lookPathHermetic("./tool", a, nil); err != nil || got != tool {
+		t.Fatalf("relative: got %q, %v", got, err)
+	}
+
+	// The same file reached through a symlinked directory isn't ambiguous...
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(a, link); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lookPathHermetic("tool", "", env(a, link)); err != nil {
+		t.Fatalf("symlinked dir: %v", err)
+	}
+	// ...a different file of the same name is.
+	writeExecutable(t, b, "tool")
+	if _, err := lookPathHermetic("tool", "", env(a, b)); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Fatalf("two tools: err = %v", err)
+	}
+}
+
+func TestRun_HermeticRecordsExecutable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX executable bits")
+	}
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	dir := t.TempDir()
+	tool := writeExecutable(t, filepath.Join(dir, "bin"), "hermetic-tool")
+	writeFile(t, filepath.Join(dir, ".vscode", "tasks.json"), `{
+  "version": "2.0.0",
+  "tasks": [
+    { "label": "build", "type": "process", "command": "hermetic-tool", "options": { "path": ["bin"] } }
+  ]
+}`)
+	t.Chdir(dir)
+	t.Setenv("PATH", "")
+	all, err := tasks.GetTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := tasks.FindTask(all, "build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(task, Options{Hermetic: true}); err != nil {
+		t.Fatal(err)
+	}
+	root, err := tasks.ProjectRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := loadRunResults(root)
+	if rr == nil || rr.Executables["build"] != tool {
+		t.Fatalf("run report executables = %v, want build → %s", rr, tool)
+	}
+}
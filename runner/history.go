@@ -0,0 +1,104 @@
+package runner
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// historyWeight is how much a new run moves a task's expected duration
+// (an exponential moving average, so one slow run doesn't dominate).
+const historyWeight = 0.3
+
+// recordDuration adds a successful run of label to the workspace's run history.
+func recordDuration(workspace, label string, d time.Duration) error {
+	return tasks.UpdateHistory(workspace, label, func(s *tasks.TaskHistory) {
+		if s.Runs == 0 {
+			s.Expected = d
+		} else {
+			s.Expected = time.Duration(historyWeight*float64(d) + (1-historyWeight)*float64(s.Expected))
+		}
+		s.Runs++
+		s.Last = d
+		s.Updated = time.Now()
+	})
+}
+
+// ---- scheduling ----
+
+// jobsLimit returns the maximum number of tasks run concurrently in root: maxParallel (from
+// --max-parallel) if set, else the configured limit (see tasks.MaxParallel). 0 = unlimited.
+func jobsLimit(root string, maxParallel int) int {
+	if maxParallel > 0 {
+		return maxParallel
+	}
+	return tasks.MaxParallel(root)
+}
+
+// criticalPath estimates how long a task keeps the graph busy: its own expected duration plus
+// the longest chain through its dependencies. ok is false when any task on that chain has no
+// history yet.
+func (d *depIndex) criticalPath(t tasks.Task, folder string, hist map[string]map[string]tasks.TaskHistory, seen map[string]bool) (time.Duration, bool) {
+	key := folder + "\x00" + t.Label
+	if seen[key] {
+		return 0, true
+	}
+	seen[key] = true
+	defer delete(seen, key)
+
+	h, ok := hist[folder]
+	if !ok {
+		h = tasks.LoadHistory(folder)
+		hist[folder] = h
+	}
+	s, known := h[t.Label]
+	own := s.Expected
+
+	var longest time.Duration
+	if t.DependsOn != nil {
+		for _, ref := range t.DependsOn.Tasks {
+			dep, depFolder, err := d.lookupFrom(folder, ref)
+			if err != nil {
+				continue
+			}
+			cp, ok := d.criticalPath(dep, depFolder, hist, seen)
+			known = known && ok
+			longest = max(longest, cp)
+		}
+	}
+	return own + longest, known
+}
+
+// scheduleOrder returns the order in which parallel dependencies should be started so that,
+// under a jobs limit, the historically longest chains start first. Tasks without history are
+// treated as the longest (we can't tell, so don't leave them for last); ties keep the
+// declared order.
+func (d *depIndex) scheduleOrder(ts []tasks.Task, folders []string) []int {
+	type weight struct {
+		i     int
+		dur   time.Duration
+		known bool
+	}
+	hist := map[string]map[string]tasks.TaskHistory{}
+	ws := make([]weight, len(ts))
+	for i, t := range ts {
+		dur, known := d.criticalPath(t, folders[i], hist, map[string]bool{})
+		ws[i] = weight{i, dur, known}
+	}
+	slices.SortStableFunc(ws, func(a, b weight) int {
+		if a.known != b.known {
+			if !a.known {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(b.dur, a.dur)
+	})
+	order := make([]int, len(ws))
+	for i, w := range ws {
+		order[i] = w.i
+	}
+	return order
+}
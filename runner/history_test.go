@@ -0,0 +1,81 @@
+package runner
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func TestRecordDuration_MovingAverage(t *testing.T) {
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	ws := t.TempDir()
+
+	if err := recordDuration(ws, "build", 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := recordDuration(ws, "build", 20*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	s := tasks.LoadHistory(ws)["build"]
+	if s.Runs != 2 || s.Last != 20*time.Second {
+		t.Fatalf("unexpected stats: %+v", s)
+	}
+	if s.Expected != 13*time.Second {
+		t.Fatalf("expected moving average 13s, got %v", s.Expected)
+	}
+}
+
+func TestScheduleOrder_LongestFirst(t *testing.T) {
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	t.Setenv("VSTASK_WORKSPACE", "")
+	ws := t.TempDir()
+	for label, d := range map[string]time.Duration{
+		"short": time.Second,
+		"long":  time.Minute,
+		"mid":   10 * time.Second,
+		"gen":   40 * time.Second,
+	} {
+		if err := recordDuration(ws, label, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all := []tasks.Task{
+		{Label: "short"},
+		{Label: "long"},
+		{Label: "new"}, // no history
+		// mid's chain (mid + gen = 50s) outranks mid alone
+		{Label: "mid", DependsOn: &tasks.DependsOn{Tasks: []tasks.TaskRef{{Label: "gen"}}}},
+		{Label: "gen"},
+	}
+	idx := newDepIndex(ws, all, NewInputResolver(nil))
+	ts := all[:4]
+	folders := []string{ws, ws, ws, ws}
+
+	var got []string
+	for _, i := range idx.scheduleOrder(ts, folders) {
+		got = append(got, ts[i].Label)
+	}
+	want := []string{"new", "long", "mid", "short"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestJobsLimit(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	for in, want := range map[string]int{"": 0, "4": 4, "-1": 0, "x": 0} {
+		t.Setenv("VSTASK_JOBS", in)
+		if got := jobsLimit(root, 0); got != want {
+			t.Errorf("VSTASK_JOBS=%q: got %d, want %d", in, got, want)
+		}
+	}
+	t.Setenv("VSTASK_JOBS", "4")
+	if got := jobsLimit(root, 2); got != 2 {
+		t.Errorf("--max-parallel should beat VSTASK_JOBS: got %d, want 2", got)
+	}
+}
@@ -0,0 +1,40 @@
+package runner
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// expandHome expands a leading "~" (the current user's home) or "~name" (that user's home)
+// in a path, as shells do. Anything else, including an unknown user, is returned as is.
+func expandHome(p string) string {
+	if !strings.HasPrefix(p, "~") {
+		return p
+	}
+	name, rest := p[1:], ""
+	if i := strings.IndexFunc(name, isPathSep); i >= 0 {
+		name, rest = name[:i], name[i:]
+	}
+	var home string
+	if name == "" {
+		h, err := os.UserHomeDir()
+		if err != nil {
+			return p
+		}
+		home = h
+	} else {
+		u, err := user.Lookup(name)
+		if err != nil || u.HomeDir == "" {
+			return p
+		}
+		home = u.HomeDir
+	}
+	return filepath.Join(home, rest)
+}
+
+func isPathSep(r rune) bool {
+	return r == '/' || (runtime.GOOS == "windows" && r == '\\')
+}
@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"testing"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func TestExpandHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	for in, want := range map[string]string{
+		"~":                      home,
+		"~/src/app":              filepath.Join(home, "src", "app"),
+		"/abs/~/x":               "/abs/~/x",
+		"rel":                    "rel",
+		"~no-such-user-xyz/proj": "~no-such-user-xyz/proj",
+		"":                       "",
+	} {
+		if got := expandHome(in); got != want {
+			t.Errorf("expandHome(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	if u, err := user.Current(); err == nil && u.HomeDir != "" {
+		if got := expandHome("~" + u.Username + "/x"); got != filepath.Join(u.HomeDir, "x") {
+			t.Errorf("~%s/x = %q", u.Username, got)
+		}
+	}
+}
+
+func TestResolveTask_ExpandsHome(t *testing.T) {
+	isolatePMDetectionToDefault(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	if err := os.MkdirAll(filepath.Join(home, "proj"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	tk := tasks.Task{
+		Label:   "home",
+		Command: "echo ~", // commands are left to the shell
+		Options: &tasks.Options{
+			Cwd:   "~/proj",
+			Env:   map[string]string{"CACHE": "~/.cache/app"},
+			Shell: &tasks.ShellOptions{Executable: "~/bin/zsh"},
+		},
+	}
+	rt, err := resolveTask(tk, t.TempDir(), NewInputResolver(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rt.Cwd != filepath.Join(home, "proj") {
+		t.Errorf("cwd = %q", rt.Cwd)
+	}
+	if got := rt.Task.Options.Env["CACHE"]; got != filepath.Join(home, ".cache", "app") {
+		t.Errorf("env = %q", got)
+	}
+	if got := rt.Task.Options.Shell.Executable; got != filepath.Join(home, "bin", "zsh") {
+		t.Errorf("shell = %q", got)
+	}
+	if rt.Task.Command != "echo ~" {
+		t.Errorf("command = %q", rt.Task.Command)
+	}
+}
@@ -0,0 +1,184 @@
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chenasraf/vstask/tasks"
+	"github.com/chenasraf/vstask/utils"
+)
+
+const inputCacheFile = "input-cache.json"
+
+// inputCachePolicy is the parsed form of a command input's "cache" field:
+//   - "run" (default): reuse the value within a single vstask invocation
+//   - "session": persist the value for the current shell session ($VSTASK_SESSION, or the parent pid)
+//   - "ttl:<duration>": persist the value for the given duration, e.g. "ttl:5m"
+type inputCachePolicy struct {
+	kind string
+	ttl  time.Duration
+}
+
+func (p inputCachePolicy) persistent() bool {
+	return p.kind == "session" || p.kind == "ttl"
+}
+
+func parseInputCachePolicy(s string) (inputCachePolicy, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case s == "" || s == "run":
+		return inputCachePolicy{kind: "run"}, nil
+	case s == "session":
+		return inputCachePolicy{kind: "session"}, nil
+	case strings.HasPrefix(s, "ttl:"):
+		d, err := time.ParseDuration(strings.TrimPrefix(s, "ttl:"))
+		if err != nil || d <= 0 {
+			return inputCachePolicy{kind: "run"}, fmt.Errorf("invalid cache ttl %q", s)
+		}
+		return inputCachePolicy{kind: "ttl", ttl: d}, nil
+	default:
+		return inputCachePolicy{kind: "run"}, fmt.Errorf("invalid cache policy %q (want run, session or ttl:<duration>)", s)
+	}
+}
+
+type inputCacheEntry struct {
+	Key     string    `json:"key"`
+	Value   string    `json:"value"`
+	Session string    `json:"session,omitempty"`
+	PID     int       `json:"pid,omitempty"` // the session's shell, without $VSTASK_SESSION
+	Expires time.Time `json:"expires,omitzero"`
+}
+
+// stale reports whether e can't be used anymore: it expired, or its session's shell is gone.
+func (e inputCacheEntry) stale(now time.Time) bool {
+	return (!e.Expires.IsZero() && now.After(e.Expires)) || (e.PID > 0 && !processAlive(e.PID))
+}
+
+func inputSessionID() string {
+	if s := os.Getenv("VSTASK_SESSION"); s != "" {
+		return s
+	}
+	return strconv.Itoa(os.Getppid())
+}
+
+// inputSessionPID is the shell whose session inputSessionID is, or 0 for $VSTASK_SESSION
+// (which can't tell when it ends).
+func inputSessionPID() int {
+	if os.Getenv("VSTASK_SESSION") != "" {
+		return 0
+	}
+	return os.Getppid()
+}
+
+// pruneInputCache drops entries's stale entries, reporting whether there were any.
+func pruneInputCache(entries map[string]inputCacheEntry) bool {
+	pruned := false
+	now := time.Now()
+	for id, e := range entries {
+		if e.stale(now) {
+			delete(entries, id)
+			pruned = true
+		}
+	}
+	return pruned
+}
+
+// inputCacheKey fingerprints everything that affects a command input's output,
+// so editing the command (or its cwd/env) invalidates the cached value.
+func inputCacheKey(in tasks.Input) string {
+	h := sha256.New()
+	parts := []string{in.Command, in.Cwd, in.JSONPath, string(in.Args)}
+	keys := make([]string, 0, len(in.Env))
+	for k := range in.Env {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		parts = append(parts, k+"="+in.Env[k])
+	}
+	h.Write([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func inputCachePath(workspace string) (string, error) {
+	dir, err := utils.WorkspaceStateDir(workspace)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, inputCacheFile), nil
+}
+
+// loadCachedInput returns a persisted, still-valid value for a command input.
+func loadCachedInput(workspace string, in tasks.Input, p inputCachePolicy) (string, bool) {
+	if !p.persistent() {
+		return "", false
+	}
+	path, err := inputCachePath(workspace)
+	if err != nil {
+		return "", false
+	}
+	entries := map[string]inputCacheEntry{}
+	if err := utils.ReadJSONFile(path, &entries); err != nil {
+		return "", false
+	}
+	if pruneInputCache(maps.Clone(entries)) {
+		_ = utils.UpdatePrivateJSONFile(path, func(entries *map[string]inputCacheEntry) error {
+			pruneInputCache(*entries)
+			return nil
+		})
+	}
+	e, ok := entries[in.ID]
+	if !ok || e.Key != inputCacheKey(in) {
+		return "", false
+	}
+	switch p.kind {
+	case "session":
+		if e.Session != inputSessionID() {
+			return "", false
+		}
+	case "ttl":
+		if e.Expires.IsZero() || time.Now().After(e.Expires) {
+			return "", false
+		}
+	}
+	if e.stale(time.Now()) {
+		return "", false
+	}
+	return e.Value, true
+}
+
+// storeCachedInput persists a command input's value according to its policy, in a file only
+// the user can read. Stale entries are dropped while we're at it (loadCachedInput drops them
+// too).
+func storeCachedInput(workspace string, in tasks.Input, p inputCachePolicy, val string) error {
+	if !p.persistent() {
+		return nil
+	}
+	path, err := inputCachePath(workspace)
+	if err != nil {
+		return err
+	}
+	return utils.UpdatePrivateJSONFile(path, func(entries *map[string]inputCacheEntry) error {
+		if *entries == nil {
+			*entries = map[string]inputCacheEntry{}
+		}
+		pruneInputCache(*entries)
+		now := time.Now()
+		e := inputCacheEntry{Key: inputCacheKey(in), Value: val}
+		if p.kind == "session" {
+			e.Session, e.PID = inputSessionID(), inputSessionPID()
+		} else {
+			e.Expires = now.Add(p.ttl)
+		}
+		(*entries)[in.ID] = e
+		return nil
+	})
+}
@@ -0,0 +1,166 @@
+package runner
+
+import (
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/chenasraf/vstask/tasks"
+	"github.com/chenasraf/vstask/utils"
+)
+
+func TestParseInputCachePolicy(t *testing.T) {
+	cases := map[string]inputCachePolicy{
+		"":         {kind: "run"},
+		"run":      {kind: "run"},
+		"Session":  {kind: "session"},
+		"ttl:5m":   {kind: "ttl", ttl: 5 * time.Minute},
+		" ttl:1h ": {kind: "ttl", ttl: time.Hour},
+	}
+	for in, want := range cases {
+		got, err := parseInputCachePolicy(in)
+		if err != nil || got != want {
+			t.Errorf("parseInputCachePolicy(%q) = %+v, %v; want %+v", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"forever", "ttl:", "ttl:-1s", "ttl:abc"} {
+		if got, err := parseInputCachePolicy(bad); err == nil || got.kind != "run" {
+			t.Errorf("parseInputCachePolicy(%q) = %+v, %v; want error and run fallback", bad, got, err)
+		}
+	}
+}
+
+func TestResolve_CommandCachedAcrossRuns(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	ws := t.TempDir()
+	counter := filepath.Join(ws, "count")
+
+	in := tasks.Input{
+		ID:      "ctx",
+		Type:    "command",
+		Command: "echo x >> count; wc -l < count | tr -d ' '",
+		Cache:   "ttl:1m",
+	}
+	resolve := func() string {
+		r := NewInputResolver([]tasks.Input{in})
+		r.SetVars(map[string]string{"workspaceFolder": ws})
+		v, err := r.Resolve("ctx")
+		if err != nil {
+			t.Fatalf("Resolve err: %v", err)
+		}
+		return v
+	}
+
+	if got := resolve(); got != "1" {
+		t.Fatalf("first run = %q, want 1", got)
+	}
+	// A fresh resolver (i.e. a new vstask invocation) reuses the persisted value.
+	if got := resolve(); got != "1" {
+		t.Fatalf("second run = %q, want cached 1", got)
+	}
+	if b, _ := os.ReadFile(counter); string(b) != "x\n" {
+		t.Fatalf("command ran more than once: %q", b)
+	}
+
+	// Changing the command invalidates the cache.
+	in.Command = "printf changed"
+	if got := resolve(); got != "changed" {
+		t.Fatalf("after edit = %q, want changed", got)
+	}
+}
+
+func TestLoadCachedInput_SessionScoped(t *testing.T) {
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	ws := t.TempDir()
+	in := tasks.Input{ID: "x", Command: "whatever"}
+	p := inputCachePolicy{kind: "session"}
+
+	t.Setenv("VSTASK_SESSION", "a")
+	if err := storeCachedInput(ws, in, p, "val"); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	if v, ok := loadCachedInput(ws, in, p); !ok || v != "val" {
+		t.Fatalf("same session: %q, %v", v, ok)
+	}
+	t.Setenv("VSTASK_SESSION", "b")
+	if _, ok := loadCachedInput(ws, in, p); ok {
+		t.Fatal("value leaked into another session")
+	}
+}
+
+func TestStoreCachedInput_Private(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX permissions")
+	}
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	ws := t.TempDir()
+	if err := storeCachedInput(ws, tasks.Input{ID: "token", Command: "vault read"}, inputCachePolicy{kind: "ttl", ttl: time.Minute}, "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	path, _ := inputCachePath(ws)
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := fi.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("input cache mode %o, want 600", perm)
+	}
+}
+
+func TestInputCache_DropsEndedSessions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	t.Setenv("VSTASK_SESSION", "")
+	ws := t.TempDir()
+	ended := exec.Command("true")
+	if err := ended.Run(); err != nil {
+		t.Fatal(err)
+	}
+	path, _ := inputCachePath(ws)
+	in := tasks.Input{ID: "x", Command: "whatever"}
+	write := func() {
+		t.Helper()
+		if err := utils.WriteJSONFile(path, map[string]inputCacheEntry{
+			"gone":  {Key: "k", Value: "old", Session: strconv.Itoa(ended.Process.Pid), PID: ended.Process.Pid},
+			"named": {Key: "k", Value: "kept", Session: "ci"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ids := func() []string {
+		t.Helper()
+		entries := map[string]inputCacheEntry{}
+		if err := utils.ReadJSONFile(path, &entries); err != nil {
+			t.Fatal(err)
+		}
+		return slices.Sorted(maps.Keys(entries))
+	}
+
+	write()
+	loadCachedInput(ws, in, inputCachePolicy{kind: "session"})
+	if got := ids(); !slices.Equal(got, []string{"named"}) {
+		t.Fatalf("after load: %v", got)
+	}
+
+	write()
+	if err := storeCachedInput(ws, in, inputCachePolicy{kind: "session"}, "new"); err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(); !slices.Equal(got, []string{"named", "x"}) {
+		t.Fatalf("after store: %v", got)
+	}
+	if v, ok := loadCachedInput(ws, in, inputCachePolicy{kind: "session"}); !ok || v != "new" {
+		t.Fatalf("this session's value: %q, %v", v, ok)
+	}
+}
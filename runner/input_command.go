@@ -0,0 +1,222 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// defaultInputCommandTimeout bounds command inputs that don't declare a commandTimeout.
+const defaultInputCommandTimeout = 30 * time.Second
+
+// errInputCommand is a command input's command failing (or timing out), which stops the run
+// unless the input's onError is "fallback".
+var errInputCommand = errors.New("input command failed")
+
+// runInputCommand runs a command input through the default shell and returns its
+// trimmed stdout (or the value selected by jsonPath). The command runs in the
+// input's cwd (relative to the workspace folder; the workspace folder by default)
+// with its env merged over the process environment, and gets its args (see
+// commandInputArgs). On failure the error includes the command's stderr.
+func runInputCommand(in tasks.Input, workspace string) (string, error) {
+	script := strings.TrimSpace(in.Command)
+	if script == "" {
+		return "", nil
+	}
+	argv, stdin, err := commandInputArgs(in.Args)
+	if err != nil {
+		return "", err
+	}
+	script = buildCommandLine(script, argv)
+
+	timeout := in.CommandTimeout.Std()
+	if timeout <= 0 {
+		timeout = defaultInputCommandTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	exe, args := defaultShell()
+	cmd := exec.CommandContext(ctx, exe, append(args, script)...)
+	cmdExeQuoting(cmd)
+	cmd.WaitDelay = time.Second // don't hang on grandchildren holding the pipes after a timeout
+	cmd.Dir = workspace
+	if in.Cwd != "" {
+		if filepath.IsAbs(in.Cwd) || workspace == "" {
+			cmd.Dir = in.Cwd
+		} else {
+			cmd.Dir = filepath.Join(workspace, in.Cwd)
+		}
+	}
+	env := os.Environ()
+	if len(in.Env) > 0 {
+		env = mergeEnv(env, in.Env)
+	}
+	cmd.Env = env
+
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("command timed out after %s", timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+
+	out := strings.TrimSpace(stdout.String())
+	if in.JSONPath == "" || out == "" {
+		return out, nil
+	}
+	val, err := selectJSONPath([]byte(out), in.JSONPath)
+	if err != nil {
+		return "", fmt.Errorf("jsonPath %q: %w", in.JSONPath, err)
+	}
+	return val, nil
+}
+
+// commandInputArgs splits a command input's args payload into arguments appended to the
+// command (quoted for the shell) and data for its stdin. An array's elements are arguments,
+// as is a lone string, number or bool; an object is passed as JSON on stdin. Arguments that
+// aren't strings are given in their JSON form.
+func commandInputArgs(raw json.RawMessage) (argv []string, stdin []byte, err error) {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return nil, nil, nil
+	}
+	v, err := decodeJSON(raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("args: %w", err)
+	}
+	switch v := v.(type) {
+	case nil:
+		return nil, nil, nil
+	case map[string]any:
+		b, err := json.Marshal(v)
+		return nil, b, err
+	case []any:
+		for _, a := range v {
+			s, err := jsonArg(a)
+			if err != nil {
+				return nil, nil, err
+			}
+			argv = append(argv, s)
+		}
+		return argv, nil, nil
+	default:
+		s, err := jsonArg(v)
+		return []string{s}, nil, err
+	}
+}
+
+// decodeJSON decodes a JSON payload, keeping numbers as they were written.
+func decodeJSON(raw []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// jsonArg returns a decoded JSON value as a command argument: strings as-is, anything else
+// in its compact JSON form.
+func jsonArg(v any) (string, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// selectJSONPath extracts a value from a JSON document using a small jsonPath-style
+// selector: optional leading "$", dot-separated keys and [n] array indexes,
+// e.g. "$.items[0].name", "contexts[2]", ".current". Strings are returned as-is,
+// other scalars in their JSON form, objects/arrays as compact JSON.
+func selectJSONPath(data []byte, path string) (string, error) {
+	var cur any
+	if err := json.Unmarshal(data, &cur); err != nil {
+		return "", fmt.Errorf("output is not JSON: %w", err)
+	}
+
+	p := strings.TrimPrefix(strings.TrimSpace(path), "$")
+	for p != "" {
+		switch p[0] {
+		case '.':
+			p = p[1:]
+		case '[':
+			end := strings.IndexByte(p, ']')
+			if end < 0 {
+				return "", errors.New("unterminated [")
+			}
+			tok := strings.Trim(p[1:end], `"'`)
+			p = p[end+1:]
+			if idx, err := strconv.Atoi(tok); err == nil {
+				arr, ok := cur.([]any)
+				if !ok {
+					return "", fmt.Errorf("[%d]: not an array", idx)
+				}
+				if idx < 0 {
+					idx += len(arr)
+				}
+				if idx < 0 || idx >= len(arr) {
+					return "", fmt.Errorf("[%d]: index out of range", idx)
+				}
+				cur = arr[idx]
+				continue
+			}
+			// ["quoted key"]
+			obj, ok := cur.(map[string]any)
+			if !ok {
+				return "", fmt.Errorf("%q: not an object", tok)
+			}
+			if cur, ok = obj[tok]; !ok {
+				return "", fmt.Errorf("%q: key not found", tok)
+			}
+		default:
+			end := strings.IndexAny(p, ".[")
+			if end < 0 {
+				end = len(p)
+			}
+			key := p[:end]
+			p = p[end:]
+			obj, ok := cur.(map[string]any)
+			if !ok {
+				return "", fmt.Errorf("%q: not an object", key)
+			}
+			if cur, ok = obj[key]; !ok {
+				return "", fmt.Errorf("%q: key not found", key)
+			}
+		}
+	}
+
+	switch v := cur.(type) {
+	case string:
+		return v, nil
+	case nil:
+		return "", nil
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+}
@@ -0,0 +1,133 @@
+package runner
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func TestSelectJSONPath(t *testing.T) {
+	doc := []byte(`{"current":"prod","contexts":[{"name":"dev"},{"name":"prod","port":8080}],"a.b":{"ok":true}}`)
+	cases := map[string]string{
+		"current":               "prod",
+		"$.current":             "prod",
+		".contexts[0].name":     "dev",
+		"contexts[-1].port":     "8080",
+		`["a.b"].ok`:            "true",
+		"contexts[0]":           `{"name":"dev"}`,
+		"$.contexts[1]['name']": "prod",
+	}
+	for path, want := range cases {
+		got, err := selectJSONPath(doc, path)
+		if err != nil {
+			t.Errorf("selectJSONPath(%q) err: %v", path, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("selectJSONPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+
+	for _, bad := range []string{"missing", "contexts[5]", "current.x", "contexts[0"} {
+		if _, err := selectJSONPath(doc, bad); err == nil {
+			t.Errorf("selectJSONPath(%q) expected error", bad)
+		}
+	}
+	if _, err := selectJSONPath([]byte("not json"), "a"); err == nil {
+		t.Error("expected error for non-JSON output")
+	}
+}
+
+func TestRunInputCommand_CwdEnvAndJSONPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	ws := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(ws, "sub"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	out, err := runInputCommand(tasks.Input{
+		Command:  `printf '{"dir":"%s","name":"%s"}' "$(basename "$PWD")" "$NAME"`,
+		Cwd:      "sub",
+		Env:      map[string]string{"NAME": "vstask"},
+		JSONPath: "dir",
+	}, ws)
+	if err != nil {
+		t.Fatalf("runInputCommand err: %v", err)
+	}
+	if out != "sub" {
+		t.Fatalf("out = %q, want sub", out)
+	}
+}
+
+func TestRunInputCommand_ReportsStderrAndTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	_, err := runInputCommand(tasks.Input{Command: "echo boom >&2; exit 3"}, t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("err = %v, want stderr included", err)
+	}
+
+	start := time.Now()
+	_, err = runInputCommand(tasks.Input{Command: "sleep 5", CommandTimeout: tasks.Duration(100 * time.Millisecond)}, t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("err = %v, want timeout", err)
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Fatalf("timeout not enforced: %v", time.Since(start))
+	}
+}
+
+func TestCommandInputArgs(t *testing.T) {
+	cases := []struct {
+		raw   string
+		argv  []string
+		stdin string
+	}{
+		{``, nil, ""},
+		{`null`, nil, ""},
+		{`["--env", "prod", 3, true, {"a":1}]`, []string{"--env", "prod", "3", "true", `{"a":1}`}, ""},
+		{`"only"`, []string{"only"}, ""},
+		{`12345678901234567890`, []string{"12345678901234567890"}, ""},
+		{`{"env":"prod","n":1.50}`, nil, `{"env":"prod","n":1.50}`},
+	}
+	for _, c := range cases {
+		argv, stdin, err := commandInputArgs(json.RawMessage(c.raw))
+		if err != nil {
+			t.Errorf("commandInputArgs(%s) err: %v", c.raw, err)
+			continue
+		}
+		if !slices.Equal(argv, c.argv) || string(stdin) != c.stdin {
+			t.Errorf("commandInputArgs(%s) = %q, %q; want %q, %q", c.raw, argv, stdin, c.argv, c.stdin)
+		}
+	}
+	if _, _, err := commandInputArgs(json.RawMessage(`[1,`)); err == nil {
+		t.Error("expected error for invalid args")
+	}
+}
+
+func TestResolve_CommandArgs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	t.Setenv("VSTASK_INPUT_STAGE", "prod")
+	r := NewInputResolver([]tasks.Input{
+		{ID: "argv", Type: "command", Command: "printf '%s|'", Args: json.RawMessage(`["a b", "${input:stage}", "$HOME", 2]`)},
+		{ID: "stdin", Type: "command", Command: "cat", Args: json.RawMessage(`{"stage": "${input:stage}"}`)},
+	})
+	if got, err := r.Resolve("argv"); err != nil || got != "a b|prod|"+os.Getenv("HOME")+"|2|" {
+		t.Fatalf("argv = %q, %v", got, err)
+	}
+	if got, err := r.Resolve("stdin"); err != nil || got != `{"stage":"prod"}` {
+		t.Fatalf("stdin = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,51 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func TestInputResolver_ConcurrentResolve(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	ws := t.TempDir()
+	t.Setenv("VSTASK_INPUT_ENV", "prod")
+	r := NewInputResolver([]tasks.Input{
+		{ID: "sha", Type: "command", Command: "echo run >> runs.txt; echo abc123"},
+		{ID: "env", Type: "promptString"},
+		{ID: "tag", Type: "promptString", Default: "latest"},
+	})
+	r.SetVars(map[string]string{"workspaceFolder": ws})
+	r.noInput = true
+
+	want := map[string]string{"sha": "abc123", "env": "prod", "tag": "latest"}
+	var wg sync.WaitGroup
+	for range 16 {
+		for id, v := range want {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if got, err := r.Resolve(id); err != nil || got != v {
+					t.Errorf("%s = %q, %v; want %q", id, got, err, v)
+				}
+				_ = r.displayValue(id)
+			}()
+		}
+	}
+	wg.Wait()
+
+	runs, err := os.ReadFile(filepath.Join(ws, "runs.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(runs), "run\n"); n != 1 {
+		t.Fatalf("the input command ran %d times, want once", n)
+	}
+}
@@ -0,0 +1,81 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// errInputEnv is returned when VSTASK_INPUTS can't be parsed.
+var errInputEnv = errors.New("invalid VSTASK_INPUTS")
+
+// inputEnvName returns the per-input override variable for an input id:
+// "VSTASK_INPUT_" + the upper-cased id with every non-alphanumeric rune replaced by "_",
+// e.g. "db.host" → VSTASK_INPUT_DB_HOST, "my-id" → VSTASK_INPUT_MY_ID.
+func inputEnvName(id string) string {
+	return "VSTASK_INPUT_" + normalizeInputID(id)
+}
+
+func normalizeInputID(id string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, strings.ToUpper(strings.TrimSpace(id)))
+}
+
+// lookupInputEnv resolves an input value from the environment.
+// Precedence: VSTASK_INPUT_<ID> (matched case-insensitively), then the VSTASK_INPUTS
+// JSON object (exact id first, then normalized id). Values are trimmed; blank values
+// count as unset.
+func lookupInputEnv(id string) (string, bool, error) {
+	want := inputEnvName(id)
+	for _, kv := range os.Environ() {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || !strings.EqualFold(k, want) {
+			continue
+		}
+		if v = strings.TrimSpace(v); v != "" {
+			return v, true, nil
+		}
+	}
+
+	blob := strings.TrimSpace(os.Getenv("VSTASK_INPUTS"))
+	if blob == "" {
+		return "", false, nil
+	}
+	var values map[string]any
+	if err := json.Unmarshal([]byte(blob), &values); err != nil {
+		return "", false, fmt.Errorf("%w: %v", errInputEnv, err)
+	}
+	raw, ok := values[id]
+	if !ok {
+		norm := normalizeInputID(id)
+		for k, v := range values {
+			if normalizeInputID(k) == norm {
+				raw, ok = v, true
+				break
+			}
+		}
+	}
+	if !ok || raw == nil {
+		return "", false, nil
+	}
+	var val string
+	switch v := raw.(type) {
+	case string:
+		val = v
+	case float64, bool:
+		val = fmt.Sprint(v)
+	default:
+		b, _ := json.Marshal(v)
+		val = string(b)
+	}
+	if val = strings.TrimSpace(val); val == "" {
+		return "", false, nil
+	}
+	return val, true, nil
+}
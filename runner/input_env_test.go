@@ -0,0 +1,77 @@
+package runner
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func TestInputEnvName(t *testing.T) {
+	cases := map[string]string{
+		"env":      "VSTASK_INPUT_ENV",
+		"db.host":  "VSTASK_INPUT_DB_HOST",
+		"my-id":    "VSTASK_INPUT_MY_ID",
+		" spaced ": "VSTASK_INPUT_SPACED",
+	}
+	for id, want := range cases {
+		if got := inputEnvName(id); got != want {
+			t.Errorf("inputEnvName(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
+
+func TestLookupInputEnv_PerInputVar(t *testing.T) {
+	t.Setenv("VSTASK_INPUT_DB_HOST", "  db.local \n")
+	got, ok, err := lookupInputEnv("db.host")
+	if err != nil || !ok || got != "db.local" {
+		t.Fatalf("lookupInputEnv = %q, %v, %v; want trimmed db.local", got, ok, err)
+	}
+}
+
+func TestLookupInputEnv_CaseInsensitiveName(t *testing.T) {
+	t.Setenv("vstask_input_Env", "prod")
+	got, ok, _ := lookupInputEnv("env")
+	if !ok || got != "prod" {
+		t.Fatalf("lookupInputEnv = %q, %v; want prod", got, ok)
+	}
+}
+
+func TestLookupInputEnv_JSONBlob(t *testing.T) {
+	t.Setenv("VSTASK_INPUTS", `{"env":"staging","db-host":"x","port":8080,"flag":true,"empty":" "}`)
+
+	for id, want := range map[string]string{"env": "staging", "db.host": "x", "port": "8080", "flag": "true"} {
+		got, ok, err := lookupInputEnv(id)
+		if err != nil || !ok || got != want {
+			t.Errorf("lookupInputEnv(%q) = %q, %v, %v; want %q", id, got, ok, err, want)
+		}
+	}
+	if _, ok, _ := lookupInputEnv("empty"); ok {
+		t.Error("blank blob value should count as unset")
+	}
+	if _, ok, _ := lookupInputEnv("missing"); ok {
+		t.Error("missing id should not resolve")
+	}
+}
+
+func TestLookupInputEnv_PerInputVarWinsOverBlob(t *testing.T) {
+	t.Setenv("VSTASK_INPUTS", `{"env":"staging"}`)
+	t.Setenv("VSTASK_INPUT_ENV", "prod")
+	got, _, _ := lookupInputEnv("env")
+	if got != "prod" {
+		t.Fatalf("got %q, want prod (VSTASK_INPUT_ENV wins)", got)
+	}
+}
+
+func TestLookupInputEnv_InvalidBlob(t *testing.T) {
+	t.Setenv("VSTASK_INPUTS", `{not json`)
+	_, _, err := lookupInputEnv("env")
+	if !errors.Is(err, errInputEnv) {
+		t.Fatalf("err = %v, want errInputEnv", err)
+	}
+
+	r := NewInputResolver([]tasks.Input{{ID: "env", Type: "promptString"}})
+	if err := promptInputsForTask(tasks.Task{Command: "echo ${input:env}"}, r); !errors.Is(err, errInputEnv) {
+		t.Fatalf("promptInputsForTask err = %v, want errInputEnv", err)
+	}
+}
@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/chenasraf/vstask/tasks"
+	"github.com/manifoldco/promptui"
+)
+
+// defaultPickManySeparator joins a pickMany input's choices when it sets no separator.
+const defaultPickManySeparator = ","
+
+// pickManySeparator returns what in's choices are joined with.
+func pickManySeparator(in tasks.Input) string {
+	if in.Separator == "" {
+		return defaultPickManySeparator
+	}
+	return in.Separator
+}
+
+// promptPickMany asks for a pickMany input: its options with check boxes (the ones its default
+// lists checked at first), where choosing one toggles it and "Done" ends. It returns the values
+// of the checked options, in the options' order, joined with the separator.
+func promptPickMany(in tasks.Input, stdin io.ReadCloser) (string, error) {
+	sep := pickManySeparator(in)
+	var defs []string
+	for _, v := range strings.Split(in.Default, sep) {
+		if v = strings.TrimSpace(v); v != "" {
+			defs = append(defs, v)
+		}
+	}
+	checked := make([]bool, len(in.Options))
+	for i, o := range in.Options {
+		checked[i] = slices.Contains(defs, o.Value)
+	}
+
+	// The Selects share stdin, reading keys as they are typed.
+	cursor := 0
+	for {
+		items := make([]string, 0, len(in.Options)+1)
+		items = append(items, "Done")
+		for i, o := range in.Options {
+			box := "[ ] "
+			if checked[i] {
+				box = "[x] "
+			}
+			items = append(items, box+o.Display())
+		}
+		s := promptui.Select{
+			Label:        in.DescriptionOrFallback(),
+			Items:        items,
+			CursorPos:    cursor,
+			Size:         minInt(8, maxInt(3, len(items))),
+			HideSelected: true,
+			Stdin:        stdin,
+			Stdout:       bellFilter{os.Stdout},
+		}
+		i, _, err := s.Run()
+		if err != nil {
+			return "", err
+		}
+		if i == 0 {
+			break
+		}
+		checked[i-1] = !checked[i-1]
+		cursor = i
+	}
+
+	var vals []string
+	for i, o := range in.Options {
+		if checked[i] {
+			vals = append(vals, o.Value)
+		}
+	}
+	return strings.Join(vals, sep), nil
+}
@@ -0,0 +1,37 @@
+package runner
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func TestPromptPickMany(t *testing.T) {
+	in := tasks.Input{
+		ID:        "features",
+		Type:      "pickMany",
+		Options:   []tasks.PickOption{{Value: "a"}, {Label: "Bee", Value: "b"}, {Value: "c"}},
+		Default:   "c; b",
+		Separator: ";",
+	}
+	const down, up = "\x0e", "\x10"
+	// Check a, uncheck c, then Done; one byte per read, as from a terminal.
+	stdin := io.NopCloser(iotest.OneByteReader(strings.NewReader(down + "\r" + down + down + "\r" + up + up + up + "\r")))
+	got, err := promptPickMany(in, stdin)
+	if err != nil || got != "a;b" {
+		t.Fatalf("got %q, %v; want %q", got, err, "a;b")
+	}
+}
+
+func TestResolve_PickManyNonInteractive(t *testing.T) {
+	r := NewInputResolver([]tasks.Input{
+		{ID: "pkgs", Type: "pickMany", Options: []tasks.PickOption{{Value: "x"}, {Value: "y"}}, Default: "x,y"},
+	})
+	r.noInput = true
+	if got, err := r.Resolve("pkgs"); err != nil || got != "x,y" {
+		t.Fatalf("got %q, %v; want the default", got, err)
+	}
+}
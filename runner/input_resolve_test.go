@@ -0,0 +1,101 @@
+package runner
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func TestResolve_CommandReferencesOtherInputAndEnv(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	t.Setenv("VSTASK_INPUT_NAME", "world")
+	t.Setenv("GREETING", "hello")
+	r := NewInputResolver([]tasks.Input{
+		{ID: "msg", Type: "command", Command: "printf '%s %s' ${env:GREETING} ${input:name}"},
+	})
+	got, err := r.Resolve("msg")
+	if err != nil {
+		t.Fatalf("Resolve err: %v", err)
+	}
+	if got != "hello world" {
+		t.Fatalf("Resolve = %q, want %q", got, "hello world")
+	}
+}
+
+func TestResolve_DefaultUsesWorkspaceFolder(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	r := NewInputResolver([]tasks.Input{
+		// empty command output falls back to the default
+		{ID: "out", Type: "command", Command: "true", Default: "${workspaceFolder}/dist"},
+	})
+	ws := t.TempDir()
+	r.SetVars(map[string]string{"workspaceFolder": ws})
+	got, err := r.Resolve("out")
+	if err != nil {
+		t.Fatalf("Resolve err: %v", err)
+	}
+	if got != ws+"/dist" {
+		t.Fatalf("Resolve = %q, want %s/dist", got, ws)
+	}
+}
+
+func TestResolve_CommandFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	r := NewInputResolver([]tasks.Input{
+		{ID: "sha", Type: "command", Command: "exit 3", Default: "HEAD"},
+		{ID: "slow", Type: "command", Command: "sleep 5", CommandTimeout: tasks.Duration(50 * time.Millisecond), Timeout: tasks.Duration(time.Minute)},
+		{ID: "ref", Type: "command", Command: "exit 3", Default: "HEAD", OnError: "fallback"},
+	})
+	r.SetVars(map[string]string{"workspaceFolder": t.TempDir()})
+	for _, id := range []string{"sha", "slow"} {
+		if _, err := r.Resolve(id); !errors.Is(err, errInputCommand) || !abortsRun(err) {
+			t.Fatalf("%s: err = %v; want the run aborted", id, err)
+		}
+	}
+	if got, err := r.Resolve("ref"); err != nil || got != "HEAD" {
+		t.Fatalf("onError fallback: %q, %v; want the default", got, err)
+	}
+}
+
+func TestResolve_CycleDetected(t *testing.T) {
+	r := NewInputResolver([]tasks.Input{
+		{ID: "a", Type: "command", Command: "echo ${input:b}"},
+		{ID: "b", Type: "command", Command: "echo ${input:c}"},
+		{ID: "c", Type: "command", Default: "${input:a}"},
+	})
+	_, err := r.Resolve("a")
+	if !errors.Is(err, errInputCycle) {
+		t.Fatalf("err = %v, want errInputCycle", err)
+	}
+	if want := "input reference cycle: a -> b -> c -> a"; err.Error() != want {
+		t.Fatalf("err = %q, want %q", err.Error(), want)
+	}
+	if !abortsRun(err) {
+		t.Fatal("a reference cycle must abort the run")
+	}
+}
+
+func TestResolve_CommandLineValueWins(t *testing.T) {
+	t.Setenv("VSTASK_INPUT_ENV", "staging")
+	r := NewInputResolver([]tasks.Input{
+		{ID: "env", Type: "pickString", Options: []tasks.PickOption{{Value: "dev"}, {Value: "prod"}}},
+		{ID: "tag", Type: "promptString"},
+	})
+	r.overrides = map[string]string{"env": "prod", "tag": " v1 "}
+	r.noInput = true // would fail if anything still needed a prompt
+	if got, err := r.Resolve("env"); err != nil || got != "prod" {
+		t.Fatalf("env = %q, %v; want the --input value over the environment", got, err)
+	}
+	if got, err := r.Resolve("tag"); err != nil || got != " v1 " {
+		t.Fatalf("tag = %q, %v; want the value as given", got, err)
+	}
+}
@@ -0,0 +1,128 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// errPromptTimeout is returned when an input with onTimeout "fail" is not answered in time.
+var errPromptTimeout = errors.New("input prompt timed out")
+
+// promptFor runs prompt for the given input, honoring its timeout/onTimeout settings.
+// Without a timeout the prompt reads os.Stdin directly (stdin == nil).
+func promptFor(in tasks.Input, prompt func(stdin io.ReadCloser) (string, error)) (string, error) {
+	timeout := in.Timeout.Std()
+	if timeout <= 0 {
+		return prompt(nil)
+	}
+
+	stdin := newDetachableStdin(os.Stdin)
+	type result struct {
+		val string
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		v, err := prompt(stdin)
+		done <- result{v, err}
+	}()
+
+	select {
+	case res := <-done:
+		stdin.Detach()
+		return res.val, res.err
+	case <-time.After(timeout):
+		// Detaching makes the prompt see EOF, so it restores the terminal and returns.
+		stdin.Detach()
+		<-done
+		fmt.Println()
+		if strings.EqualFold(in.OnTimeout, "fail") {
+			return "", fmt.Errorf("input %q: %w after %s", in.ID, errPromptTimeout, timeout)
+		}
+		fmt.Printf("No answer for %q after %s, using default %q\n", in.ID, timeout, in.Default)
+		return in.Default, nil
+	}
+}
+
+// stdinPollInterval is how often a detachableStdin reading a file checks whether it was
+// detached while waiting for input.
+const stdinPollInterval = 50 * time.Millisecond
+
+// detachableStdin forwards reads from an underlying reader until detached,
+// after which Read returns io.EOF immediately.
+//
+// A blocking read on os.Stdin can't be interrupted, so when the reader is a file, the pump
+// goroutine only reads once there's input (see waitReadable): detached, it stops without
+// taking what's typed next from whatever reads stdin after the prompt.
+type detachableStdin struct {
+	data chan []byte
+	done chan struct{}
+	eof  chan struct{}
+	once sync.Once
+	buf  []byte
+}
+
+func newDetachableStdin(r io.Reader) *detachableStdin {
+	d := &detachableStdin{
+		data: make(chan []byte),
+		done: make(chan struct{}),
+		eof:  make(chan struct{}),
+	}
+	f, _ := r.(*os.File)
+	go func() {
+		p := make([]byte, 256)
+		for {
+			if f != nil && !waitReadable(f, d.done) {
+				return
+			}
+			n, err := r.Read(p)
+			if n > 0 {
+				chunk := append([]byte(nil), p[:n]...)
+				select {
+				case d.data <- chunk:
+				case <-d.done:
+					return
+				}
+			}
+			if err != nil {
+				close(d.eof)
+				return
+			}
+		}
+	}()
+	return d
+}
+
+func (d *detachableStdin) Read(p []byte) (int, error) {
+	if len(d.buf) == 0 {
+		select {
+		case chunk := <-d.data:
+			d.buf = chunk
+		case <-d.eof:
+			return 0, io.EOF
+		case <-d.done:
+			return 0, io.EOF
+		}
+	}
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+// Detach stops forwarding; pending and future reads return io.EOF.
+func (d *detachableStdin) Detach() {
+	d.once.Do(func() { close(d.done) })
+}
+
+// Close detaches; it never closes the underlying reader.
+func (d *detachableStdin) Close() error {
+	d.Detach()
+	return nil
+}
@@ -0,0 +1,107 @@
+package runner
+
+import (
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// blockStdin replaces os.Stdin with a pipe nobody writes to, simulating an unattended terminal.
+func blockStdin(t *testing.T) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	old := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() {
+		os.Stdin = old
+		_ = w.Close()
+		_ = r.Close()
+	})
+}
+
+func readAllPrompt(stdin io.ReadCloser) (string, error) {
+	b, err := io.ReadAll(stdin)
+	return string(b), err
+}
+
+func TestPromptFor_TimeoutUsesDefault(t *testing.T) {
+	blockStdin(t)
+	in := tasks.Input{ID: "env", Default: "dev", Timeout: tasks.Duration(50 * time.Millisecond)}
+
+	start := time.Now()
+	val, err := promptFor(in, readAllPrompt)
+	if err != nil {
+		t.Fatalf("promptFor err: %v", err)
+	}
+	if val != "dev" {
+		t.Fatalf("val = %q, want default %q", val, "dev")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("timeout took too long: %v", elapsed)
+	}
+}
+
+func TestPromptFor_TimeoutFails(t *testing.T) {
+	blockStdin(t)
+	in := tasks.Input{ID: "env", Default: "dev", OnTimeout: "fail", Timeout: tasks.Duration(50 * time.Millisecond)}
+
+	_, err := promptFor(in, readAllPrompt)
+	if !errors.Is(err, errPromptTimeout) {
+		t.Fatalf("err = %v, want errPromptTimeout", err)
+	}
+}
+
+func TestPromptFor_AnsweredBeforeTimeout(t *testing.T) {
+	in := tasks.Input{ID: "env", Default: "dev", Timeout: tasks.Duration(time.Second)}
+	val, err := promptFor(in, func(io.ReadCloser) (string, error) { return "prod", nil })
+	if err != nil || val != "prod" {
+		t.Fatalf("promptFor = %q, %v; want prod, nil", val, err)
+	}
+}
+
+func TestDetachableStdin_ForwardsUntilDetached(t *testing.T) {
+	r, w := io.Pipe()
+	d := newDetachableStdin(r)
+	go func() { _, _ = w.Write([]byte("hello")) }()
+
+	buf := make([]byte, 16)
+	n, err := d.Read(buf)
+	if err != nil || string(buf[:n]) != "hello" {
+		t.Fatalf("Read = %q, %v", buf[:n], err)
+	}
+
+	d.Detach()
+	if _, err := d.Read(buf); err != io.EOF {
+		t.Fatalf("Read after Detach err = %v, want io.EOF", err)
+	}
+	_ = w.Close()
+}
+
+func TestDetachableStdin_LeavesLaterInputAlone(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	d := newDetachableStdin(r)
+	d.Detach()
+	time.Sleep(3 * stdinPollInterval) // the pump notices
+
+	if _, err := w.Write([]byte("next")); err != nil {
+		t.Fatal(err)
+	}
+	_ = r.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 16)
+	n, err := r.Read(buf)
+	if err != nil || string(buf[:n]) != "next" {
+		t.Fatalf("input after Detach: %q, %v", buf[:n], err)
+	}
+}
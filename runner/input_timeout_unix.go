@@ -0,0 +1,34 @@
+//go:build !windows
+
+package runner
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// waitReadable waits until f has input to read, returning false if done is closed first. It
+// waits in rounds of stdinPollInterval, so a detached reader stops without reading. select
+// rather than poll: macOS's poll doesn't support terminals.
+func waitReadable(f *os.File, done <-chan struct{}) bool {
+	fd := int(f.Fd())
+	for {
+		select {
+		case <-done:
+			return false
+		default:
+		}
+		var fds unix.FdSet
+		fds.Set(fd)
+		tv := unix.NsecToTimeval(stdinPollInterval.Nanoseconds())
+		n, err := unix.Select(fd+1, &fds, nil, nil, &tv)
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil || n > 0 {
+			return true // readable, or Read reports the error
+		}
+	}
+}
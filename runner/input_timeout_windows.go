@@ -0,0 +1,26 @@
+//go:build windows
+
+package runner
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// waitReadable waits until f has input to read, returning false if done is closed first. It
+// waits in rounds of stdinPollInterval, so a detached reader stops without reading.
+func waitReadable(f *os.File, done <-chan struct{}) bool {
+	h := windows.Handle(f.Fd())
+	for {
+		select {
+		case <-done:
+			return false
+		default:
+		}
+		ev, err := windows.WaitForSingleObject(h, uint32(stdinPollInterval.Milliseconds()))
+		if err != nil || ev != uint32(windows.WAIT_TIMEOUT) {
+			return true // readable, or Read reports the error
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// errInputInvalid is returned when an input's value doesn't match its pattern and can't be
+// asked for again: it came from the command line, the environment or the input's default.
+var errInputInvalid = errors.New("invalid input value")
+
+// inputValidator returns the check a value of in must pass (its pattern), or nil if there's none.
+func inputValidator(in tasks.Input) (func(string) error, error) {
+	if in.Pattern == "" || !strings.EqualFold(in.Type, "promptString") {
+		return nil, nil
+	}
+	rx, err := regexp.Compile(in.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: input %q: pattern: %v", errInputInvalid, in.ID, err)
+	}
+	return func(v string) error {
+		if !rx.MatchString(v) {
+			return fmt.Errorf("must match %s", in.Pattern)
+		}
+		return nil
+	}, nil
+}
+
+// checkValue checks a value given for input id against the input's pattern, if it has one.
+func (r *InputResolver) checkValue(id, v string) error {
+	check, err := inputValidator(r.byID[id])
+	if err != nil || check == nil {
+		return err
+	}
+	if err := check(v); err != nil {
+		return fmt.Errorf("%w: input %q: %v", errInputInvalid, id, err)
+	}
+	return nil
+}
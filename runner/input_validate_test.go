@@ -0,0 +1,45 @@
+package runner
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func TestPromptString_ReasksUntilValid(t *testing.T) {
+	check, err := inputValidator(tasks.Input{ID: "version", Type: "promptString", Pattern: `^\d+\.\d+\.\d+$`})
+	if err != nil || check == nil {
+		t.Fatalf("validator: %v", err)
+	}
+	// Enter is refused on "v1", so the answer is deleted and retyped.
+	stdin := io.NopCloser(strings.NewReader("v1\r\x7f\x7f1.2.3\r"))
+	got, err := promptString("Version", "", false, check, stdin)
+	if err != nil || got != "1.2.3" {
+		t.Fatalf("got %q, %v; want the first valid answer", got, err)
+	}
+}
+
+func TestResolve_PatternRejectsGivenValues(t *testing.T) {
+	inputs := []tasks.Input{
+		{ID: "ticket", Type: "promptString", Pattern: `^[A-Z]+-\d+$`},
+		{ID: "version", Type: "promptString", Pattern: `^\d+$`, Default: "latest"},
+		{ID: "bad", Type: "promptString", Pattern: `(`},
+	}
+	r := NewInputResolver(inputs)
+	r.noInput = true
+	r.overrides = map[string]string{"ticket": "ABC-12"}
+	if got, err := r.Resolve("ticket"); err != nil || got != "ABC-12" {
+		t.Fatalf("ticket = %q, %v", got, err)
+	}
+	r = NewInputResolver(inputs)
+	r.noInput = true
+	t.Setenv("VSTASK_INPUT_TICKET", "abc")
+	for _, id := range []string{"ticket", "version", "bad"} {
+		if _, err := r.Resolve(id); !errors.Is(err, errInputInvalid) || !abortsRun(err) {
+			t.Errorf("%s: err = %v; want an invalid input error", id, err)
+		}
+	}
+}
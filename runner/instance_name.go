@@ -0,0 +1,50 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// maxInstanceValueLen caps each input value shown in an instance name.
+const maxInstanceValueLen = 24
+
+// instanceName derives the display name of one run of a task, so concurrent runs of the
+// same parameterized task can be told apart (in output, logs and the background registry).
+//
+//   - a label that references inputs gets them substituted: "deploy ${input:env}" → "deploy prod"
+//   - otherwise the resolved inputs are appended: "deploy" → "deploy [env=prod, tag=v2]"
+//
+// Password inputs are masked and long values truncated. Inputs must already be resolved.
+func instanceName(t tasks.Task, r *InputResolver) string {
+	if r == nil {
+		return t.Label
+	}
+	if strings.Contains(t.Label, "${input:") {
+		return reInput.ReplaceAllStringFunc(t.Label, func(m string) string {
+			return r.displayValue(reInput.FindStringSubmatch(m)[1])
+		})
+	}
+	refs := t.InputRefs()
+	if len(refs) == 0 {
+		return t.Label
+	}
+	parts := make([]string, 0, len(refs))
+	for _, id := range refs {
+		parts = append(parts, fmt.Sprintf("%s=%s", id, r.displayValue(id)))
+	}
+	return fmt.Sprintf("%s [%s]", t.Label, strings.Join(parts, ", "))
+}
+
+// displayValue returns a resolved input value suitable for display.
+func (r *InputResolver) displayValue(id string) string {
+	if in, ok := r.byID[id]; ok && in.Password {
+		return "***"
+	}
+	v, _ := r.cached(id)
+	if rs := []rune(v); len(rs) > maxInstanceValueLen {
+		v = string(rs[:maxInstanceValueLen-1]) + "…"
+	}
+	return v
+}
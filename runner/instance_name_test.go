@@ -0,0 +1,54 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func TestInstanceName(t *testing.T) {
+	r := NewInputResolver([]tasks.Input{{ID: "token", Password: true}})
+	r.cache["env"] = "prod"
+	r.cache["tag"] = "v2"
+	r.cache["token"] = "s3cr3t"
+	r.cache["long"] = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+	cases := []struct {
+		task tasks.Task
+		want string
+	}{
+		{tasks.Task{Label: "build", Command: "make"}, "build"},
+		{tasks.Task{Label: "deploy ${input:env}", Command: "./deploy ${input:env} ${input:tag}"}, "deploy prod"},
+		{tasks.Task{Label: "deploy", Command: "./deploy ${input:tag} ${input:env}"}, "deploy [env=prod, tag=v2]"},
+		{tasks.Task{Label: "login", Options: &tasks.Options{Env: map[string]string{"T": "${input:token}"}}}, "login [token=***]"},
+		{tasks.Task{Label: "x", Args: []string{"${input:long}"}}, "x [long=abcdefghijklmnopqrstuvw…]"},
+	}
+	for _, c := range cases {
+		if got := instanceName(c.task, r); got != c.want {
+			t.Errorf("instanceName(%q) = %q, want %q", c.task.Label, got, c.want)
+		}
+	}
+}
+
+func TestResolveTask_InstanceName(t *testing.T) {
+	isolatePMDetectionToDefault(t)
+	t.Setenv("VSTASK_INPUT_ENV", "prod")
+	workspace := t.TempDir()
+	cases := []struct {
+		task tasks.Task
+		want string
+	}{
+		{tasks.Task{Label: "deploy", Command: "./deploy ${input:env}"}, "deploy [env=prod]"},
+		{tasks.Task{Label: "deploy ${input:env}", Command: "./deploy ${input:env}"}, "deploy prod"},
+		{tasks.Task{Label: "build", Command: "make"}, "build"},
+	}
+	for _, c := range cases {
+		rt, err := resolveTask(c.task, workspace, NewInputResolver(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rt.Name != c.want {
+			t.Errorf("%q: name = %q, want %q", c.task.Label, rt.Name, c.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// keychainService is the service (or target prefix) vstask's secrets are stored under.
+const keychainService = "vstask"
+
+// errSecretNotFound is returned by a secretStore that has no secret for an account.
+var errSecretNotFound = errors.New("no stored secret")
+
+// secretStore is the OS credential store: the macOS Keychain, the Windows Credential Manager
+// (DPAPI-encrypted) or, elsewhere, the Secret Service (GNOME Keyring, KWallet) via secret-tool.
+type secretStore interface {
+	Get(account string) (string, error) // errSecretNotFound if there's none
+	Set(account, secret string) error
+	Delete(account string) error // errSecretNotFound if there's none
+}
+
+// keychain is the store secret inputs use; tests replace it.
+var keychain secretStore = osKeychain{}
+
+// secretAccount is the account a secret input's value is stored under: one per workspace folder
+// and input id.
+func secretAccount(workspace, id string) string {
+	return id + "@" + workspace
+}
+
+// secret resolves a secret input: the value stored in the keychain, or else one asked for
+// (masked) and then stored, so it's only typed once per workspace. It never touches the disk
+// otherwise. When the keychain can't be used, the value is asked for on every run.
+func (r *InputResolver) secret(in tasks.Input) (string, error) {
+	account := secretAccount(r.vars["workspaceFolder"], in.ID)
+	v, err := keychain.Get(account)
+	if err == nil {
+		return v, nil
+	}
+	if !errors.Is(err, errSecretNotFound) {
+		fmt.Fprintf(os.Stderr, "Input %q: keychain: %v\n", in.ID, err)
+	}
+	lbl := in.Description
+	if strings.TrimSpace(lbl) == "" {
+		lbl = fmt.Sprintf("Enter %s", in.ID)
+	}
+	v, err = r.ask(in, func(stdin io.ReadCloser) (string, error) {
+		return promptString(lbl, "", true, nil, stdin)
+	})
+	if err != nil || v == "" || v == in.Default {
+		return v, err
+	}
+	if err := keychain.Set(account, v); err != nil {
+		fmt.Fprintf(os.Stderr, "Input %q: not saved to the keychain: %v\n", in.ID, err)
+	}
+	return v, nil
+}
+
+// ForgetSecret removes the stored value of the secret input id of the workspace folder root,
+// so the next run asks for it again. It reports whether there was one.
+func ForgetSecret(root, id string) (bool, error) {
+	err := keychain.Delete(secretAccount(root, id))
+	if errors.Is(err, errSecretNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,55 @@
+//go:build darwin
+
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// osKeychain uses the macOS Keychain through the security command.
+type osKeychain struct{}
+
+// securityNotFound is security's exit code for an item that doesn't exist.
+const securityNotFound = 44
+
+func (osKeychain) Get(account string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", keychainService, "-a", account, "-w").Output()
+	if err != nil {
+		return "", securityError(err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func (osKeychain) Set(account, secret string) error {
+	// In interactive mode the secret goes through stdin, not the command line (which ps shows).
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+		securityQuote(keychainService), securityQuote(account), securityQuote(secret)))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s", securityError(err), strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func (osKeychain) Delete(account string) error {
+	return securityError(exec.Command("security", "delete-generic-password", "-s", keychainService, "-a", account).Run())
+}
+
+func securityError(err error) error {
+	var ee *exec.ExitError
+	if errors.As(err, &ee) && ee.ExitCode() == securityNotFound {
+		return errSecretNotFound
+	}
+	return err
+}
+
+// securityQuote quotes s for security's interactive mode, which splits lines like a shell.
+func securityQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
@@ -0,0 +1,103 @@
+package runner
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// memKeychain is a secretStore in memory; failing makes every call fail.
+type memKeychain struct {
+	secrets map[string]string
+	failing bool
+}
+
+func (m *memKeychain) Get(account string) (string, error) {
+	if m.failing {
+		return "", errors.New("locked")
+	}
+	v, ok := m.secrets[account]
+	if !ok {
+		return "", errSecretNotFound
+	}
+	return v, nil
+}
+
+func (m *memKeychain) Set(account, secret string) error {
+	if m.failing {
+		return errors.New("locked")
+	}
+	m.secrets[account] = secret
+	return nil
+}
+
+func (m *memKeychain) Delete(account string) error {
+	if _, err := m.Get(account); err != nil {
+		return err
+	}
+	delete(m.secrets, account)
+	return nil
+}
+
+func useMemKeychain(t *testing.T) *memKeychain {
+	t.Helper()
+	m := &memKeychain{secrets: map[string]string{}}
+	prev := keychain
+	keychain = m
+	t.Cleanup(func() { keychain = prev })
+	return m
+}
+
+func TestResolve_SecretFromKeychain(t *testing.T) {
+	kc := useMemKeychain(t)
+	kc.secrets[secretAccount("/ws", "token")] = "s3cret"
+	inputs := []tasks.Input{{ID: "token", Type: "secret"}}
+
+	r := NewInputResolver(inputs)
+	r.SetVars(map[string]string{"workspaceFolder": "/ws"})
+	r.noInput = true
+	if got, err := r.Resolve("token"); err != nil || got != "s3cret" {
+		t.Fatalf("got %q, %v; want the stored secret", got, err)
+	}
+
+	// Another workspace folder has its own.
+	r = NewInputResolver(inputs)
+	r.SetVars(map[string]string{"workspaceFolder": "/other"})
+	r.noInput = true
+	var missing *missingInputError
+	if _, err := r.Resolve("token"); !errors.As(err, &missing) {
+		t.Fatalf("err = %v; want a missing input error", err)
+	}
+
+	if found, err := ForgetSecret("/ws", "token"); err != nil || !found {
+		t.Fatalf("ForgetSecret = %v, %v", found, err)
+	}
+	if found, err := ForgetSecret("/ws", "token"); err != nil || found {
+		t.Fatalf("ForgetSecret again = %v, %v; want nothing to forget", found, err)
+	}
+}
+
+func TestResolve_SecretFromEnvIsNotStored(t *testing.T) {
+	kc := useMemKeychain(t)
+	t.Setenv("VSTASK_INPUT_TOKEN", "from-env")
+	r := NewInputResolver([]tasks.Input{{ID: "token", Type: "secret"}})
+	r.SetVars(map[string]string{"workspaceFolder": "/ws"})
+	if got, err := r.Resolve("token"); err != nil || got != "from-env" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+	if len(kc.secrets) != 0 {
+		t.Fatalf("keychain = %v; want nothing stored", kc.secrets)
+	}
+}
+
+func TestResolve_SecretKeychainUnavailable(t *testing.T) {
+	kc := useMemKeychain(t)
+	kc.failing = true
+	r := NewInputResolver([]tasks.Input{{ID: "token", Type: "secret", Default: "dev-token"}})
+	r.SetVars(map[string]string{"workspaceFolder": "/ws"})
+	r.noInput = true
+	if got, err := r.Resolve("token"); err != nil || got != "dev-token" {
+		t.Fatalf("got %q, %v; want the default", got, err)
+	}
+}
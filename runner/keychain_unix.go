@@ -0,0 +1,62 @@
+//go:build !darwin && !windows
+
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// osKeychain uses the Secret Service (GNOME Keyring, KWallet) through secret-tool, from
+// libsecret.
+type osKeychain struct{}
+
+func (osKeychain) Get(account string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", "lookup", "service", keychainService, "account", account)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", secretToolError(err, &stderr)
+	}
+	return string(out), nil
+}
+
+func (osKeychain) Set(account, secret string) error {
+	// The secret goes through stdin, not the command line (which ps shows).
+	var stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", "store", "--label", "vstask: "+account, "service", keychainService, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+	cmd.Stderr = &stderr
+	return secretToolError(cmd.Run(), &stderr)
+}
+
+func (osKeychain) Delete(account string) error {
+	if _, err := (osKeychain{}).Get(account); err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", "clear", "service", keychainService, "account", account)
+	cmd.Stderr = &stderr
+	return secretToolError(cmd.Run(), &stderr)
+}
+
+// secretToolError turns secret-tool's failures into errors: a lookup that finds nothing exits
+// with 1 and prints nothing.
+func secretToolError(err error, stderr *bytes.Buffer) error {
+	var ee *exec.ExitError
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, exec.ErrNotFound):
+		return errors.New("secret-tool not found (install libsecret-tools)")
+	case errors.As(err, &ee) && ee.ExitCode() == 1 && stderr.Len() == 0:
+		return errSecretNotFound
+	case stderr.Len() > 0:
+		return fmt.Errorf("secret-tool: %s", strings.TrimSpace(stderr.String()))
+	}
+	return err
+}
@@ -0,0 +1,60 @@
+package runner
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/chenasraf/vstask/utils"
+)
+
+const recentRunsFile = "recent-runs.json"
+
+// maxRecentRuns is how many runs RecentRuns remembers.
+const maxRecentRuns = 50
+
+// RecentRun is one task the user ran.
+type RecentRun struct {
+	Label string    `json:"label"`
+	Time  time.Time `json:"time"`
+}
+
+func statePath(workspace, name string) (string, error) {
+	dir, err := utils.WorkspaceStateDir(workspace)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// recordLastRun remembers the task the user ran (not its dependencies) in workspace.
+func recordLastRun(workspace, label string) error {
+	p, err := statePath(workspace, recentRunsFile)
+	if err != nil {
+		return err
+	}
+	return utils.UpdateJSONFile(p, func(runs *[]RecentRun) error {
+		*runs = append([]RecentRun{{Label: label, Time: time.Now()}}, *runs...)
+		if len(*runs) > maxRecentRuns {
+			*runs = (*runs)[:maxRecentRuns]
+		}
+		return nil
+	})
+}
+
+// RecentRuns returns the tasks last run in workspace, most recent first.
+func RecentRuns(workspace string) []RecentRun {
+	var runs []RecentRun
+	if p, err := statePath(workspace, recentRunsFile); err == nil {
+		_ = utils.ReadJSONFile(p, &runs)
+	}
+	return runs
+}
+
+// LastRunLabel returns the label of the last task run in workspace, if any.
+func LastRunLabel(workspace string) (string, bool) {
+	runs := RecentRuns(workspace)
+	if len(runs) == 0 {
+		return "", false
+	}
+	return runs[0].Label, true
+}
@@ -0,0 +1,37 @@
+package runner
+
+import "testing"
+
+func TestLastRunLabel(t *testing.T) {
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	ws := t.TempDir()
+	if _, ok := LastRunLabel(ws); ok {
+		t.Fatal("no run recorded yet")
+	}
+	if err := recordLastRun(ws, "build"); err != nil {
+		t.Fatal(err)
+	}
+	if err := recordLastRun(ws, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := LastRunLabel(ws); !ok || got != "test" {
+		t.Fatalf("got %q, %v", got, ok)
+	}
+	runs := RecentRuns(ws)
+	if len(runs) != 2 || runs[1].Label != "build" {
+		t.Fatalf("RecentRuns = %v", runs)
+	}
+}
+
+func TestRecentRuns_Bounded(t *testing.T) {
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	ws := t.TempDir()
+	for range maxRecentRuns + 5 {
+		if err := recordLastRun(ws, "build"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := len(RecentRuns(ws)); got != maxRecentRuns {
+		t.Fatalf("kept %d runs, want %d", got, maxRecentRuns)
+	}
+}
@@ -0,0 +1,100 @@
+package runner
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// outputPrefix is what starts each line of a task's output: its name (--prefix-output), the
+// time (--timestamps), or both. The zero value leaves the output as it is.
+type outputPrefix struct {
+	name  bool
+	stamp bool
+}
+
+// writers returns where the output of the task named name goes: stdout and stderr, each
+// through a linePrefixer when p adds anything.
+func (p outputPrefix) writers(name string) (stdout, stderr io.Writer) {
+	if !p.name && !p.stamp {
+		return os.Stdout, os.Stderr
+	}
+	return p.writer(os.Stdout, name), p.writer(os.Stderr, name)
+}
+
+func (p outputPrefix) writer(w io.Writer, name string) *linePrefixer {
+	lp := &linePrefixer{w: w, stamp: p.stamp}
+	if p.name {
+		lp.name = []byte("[" + name + "] ")
+	}
+	return lp
+}
+
+// maxPooledPrefixBuf is the largest buffer put back in prefixBufs; one grown by a huge write
+// is left to the garbage collector.
+const maxPooledPrefixBuf = 64 * 1024
+
+// prefixBufs are linePrefixer's write buffers, shared so chatty tasks running in parallel
+// don't each hold their own.
+var prefixBufs = sync.Pool{New: func() any {
+	b := make([]byte, 0, 4096)
+	return &b
+}}
+
+// linePrefixer writes to w with a prefix at the start of each line. Everything a task prints
+// goes through it, so a write allocates nothing: the prefixed lines are put together in a
+// pooled buffer and written at once. Like the stream exec copies to it, it isn't safe for
+// concurrent writes.
+type linePrefixer struct {
+	w     io.Writer
+	name  []byte // "[name] ", or nil
+	stamp bool   // the time of day, e.g. "15:04:05.000 "
+	mid   bool   // the last write ended mid-line
+}
+
+func (lp *linePrefixer) Write(p []byte) (int, error) {
+	bp := prefixBufs.Get().(*[]byte)
+	buf := (*bp)[:0]
+	// The lines of one write all came out at once: the time is formatted once for them.
+	var stampBuf [13]byte
+	var stamp []byte
+	if lp.stamp {
+		stamp = appendClock(stampBuf[:0], time.Now())
+	}
+	for rest := p; len(rest) > 0; {
+		if !lp.mid {
+			buf = append(buf, lp.name...)
+			buf = append(buf, stamp...)
+		}
+		line := rest
+		if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+			line = rest[:i+1]
+		}
+		buf = append(buf, line...)
+		rest = rest[len(line):]
+		lp.mid = line[len(line)-1] != '\n'
+	}
+	_, err := lp.w.Write(buf)
+	if cap(buf) <= maxPooledPrefixBuf {
+		*bp = buf
+		prefixBufs.Put(bp)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// appendClock appends t's time of day as "15:04:05.000 " to b. (time.Time.AppendFormat
+// allocates.)
+func appendClock(b []byte, t time.Time) []byte {
+	h, m, sec := t.Clock()
+	ms := t.Nanosecond() / int(time.Millisecond)
+	two := func(b []byte, n int) []byte { return append(b, byte('0'+n/10), byte('0'+n%10)) }
+	b = append(two(b, h), ':')
+	b = append(two(b, m), ':')
+	b = append(two(b, sec), '.')
+	return append(b, byte('0'+ms/100), byte('0'+ms/10%10), byte('0'+ms%10), ' ')
+}
@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLinePrefixer(t *testing.T) {
+	var out bytes.Buffer
+	lp := outputPrefix{name: true}.writer(&out, "build")
+	for _, s := range []string{"one\ntw", "o\n", "", "three\nfour"} {
+		if n, err := lp.Write([]byte(s)); err != nil || n != len(s) {
+			t.Fatalf("Write(%q) = %d, %v", s, n, err)
+		}
+	}
+	if want := "[build] one\n[build] two\n[build] three\n[build] four"; out.String() != want {
+		t.Fatalf("got %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	lp = outputPrefix{name: true, stamp: true}.writer(&out, "api")
+	_, _ = lp.Write([]byte("ready\r\n"))
+	if !regexp.MustCompile(`^\[api\] \d\d:\d\d:\d\d\.\d{3} ready\r\n$`).MatchString(out.String()) {
+		t.Fatalf("got %q", out.String())
+	}
+}
+
+func TestLinePrefixer_NoAllocs(t *testing.T) {
+	lp := outputPrefix{name: true, stamp: true}.writer(io.Discard, "build")
+	chunk := []byte(strings.Repeat("compiling src/some/package/file.go\n", 100))
+	if n := testing.AllocsPerRun(100, func() { _, _ = lp.Write(chunk) }); n != 0 {
+		t.Fatalf("%v allocations per write", n)
+	}
+}
+
+// buildOutput is 32 KiB (what exec copies at once) of a verbose build's output lines.
+var buildOutput = []byte(strings.Repeat("[ 42%] Building CXX object src/CMakeFiles/core.dir/parser.cpp.o\n", 32*1024/64))
+
+// devNull stands in for the terminal: a file, written with a syscall per write.
+func devNull(b *testing.B) *os.File {
+	f, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { _ = f.Close() })
+	return f
+}
+
+func BenchmarkOutputDirect(b *testing.B) {
+	f := devNull(b)
+	b.SetBytes(int64(len(buildOutput)))
+	b.ReportAllocs()
+	for b.Loop() {
+		_, _ = f.Write(buildOutput)
+	}
+}
+
+func BenchmarkOutputPrefixed(b *testing.B) {
+	lp := outputPrefix{name: true}.writer(devNull(b), "build")
+	b.SetBytes(int64(len(buildOutput)))
+	b.ReportAllocs()
+	for b.Loop() {
+		_, _ = lp.Write(buildOutput)
+	}
+}
+
+func BenchmarkOutputTimestamped(b *testing.B) {
+	lp := outputPrefix{name: true, stamp: true}.writer(devNull(b), "build")
+	b.SetBytes(int64(len(buildOutput)))
+	b.ReportAllocs()
+	for b.Loop() {
+		_, _ = lp.Write(buildOutput)
+	}
+}
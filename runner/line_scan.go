@@ -0,0 +1,71 @@
+package runner
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// maxScanLine is how much of a line the readiness scanner holds at once. Longer lines (a
+// minified bundle printed by a dev server, say) are still mirrored in full, but they're
+// matched in chunks of this size rather than buffered whole.
+const maxScanLine = 64 * 1024
+
+// lineChunk is a piece of a task's output line, as matched by the readiness scanner. A line
+// that fits in the buffer is a single chunk; a longer one is split, each chunk after the first
+// starting with the tail of the one before, so matches spanning the split are still found.
+type lineChunk struct {
+	text  string // without the line break
+	start bool   // text begins at the start of the line
+	end   bool   // text ends at the end of the line
+}
+
+// scanLines copies r to w, calling fn with each line, in chunks of at most size bytes (plus
+// the overlap carried from the previous chunk) so memory doesn't grow with the line length.
+func scanLines(r io.Reader, w io.Writer, size int, fn func(lineChunk)) {
+	br := bufio.NewReaderSize(r, size)
+	overlap := size / 4
+	var tail string // end of the previous chunk of an unfinished line
+	for {
+		b, err := br.ReadSlice('\n')
+		if len(b) > 0 {
+			_, _ = w.Write(b)
+			full := err != bufio.ErrBufferFull
+			text := string(b)
+			if full {
+				text = strings.TrimRight(text, "\r\n")
+			}
+			fn(lineChunk{text: tail + text, start: tail == "", end: full})
+			tail = ""
+			if !full {
+				tail = text[max(0, len(text)-overlap):]
+			}
+		}
+		if err != nil && err != bufio.ErrBufferFull {
+			return
+		}
+	}
+}
+
+// match returns rx's submatches in c, or nil. In a chunk of a longer line, a match that
+// touches a split isn't one: ^ or $ would match there, and a longer match may continue past
+// it. Matches next to a split are found whole in the neighbouring chunk instead.
+func (c lineChunk) match(rx *regexp.Regexp) []string {
+	if c.start && c.end {
+		return rx.FindStringSubmatch(c.text)
+	}
+	for _, loc := range rx.FindAllStringSubmatchIndex(c.text, -1) {
+		if (!c.start && loc[0] == 0) || (!c.end && loc[1] == len(c.text)) {
+			continue
+		}
+		m := make([]string, len(loc)/2)
+		for i := range m {
+			if loc[2*i] >= 0 {
+				m[i] = c.text[loc[2*i]:loc[2*i+1]]
+			}
+		}
+		return m
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package runner
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func scanAll(t *testing.T, in string, size int) ([]lineChunk, string) {
+	t.Helper()
+	var out bytes.Buffer
+	var chunks []lineChunk
+	scanLines(strings.NewReader(in), &out, size, func(c lineChunk) {
+		if len(c.text) > size+size/4 {
+			t.Fatalf("chunk of %d bytes, want at most %d", len(c.text), size+size/4)
+		}
+		chunks = append(chunks, c)
+	})
+	return chunks, out.String()
+}
+
+func TestScanLines_MirrorsAndSplitsLongLines(t *testing.T) {
+	long := strings.Repeat("x", 100) + "READY on :3000" + strings.Repeat("y", 100)
+	in := "short\r\n" + long + "\nlast"
+	chunks, out := scanAll(t, in, 32)
+	if out != in {
+		t.Fatalf("output not mirrored as is:\n%q\nwant\n%q", out, in)
+	}
+	if c := chunks[0]; c.text != "short" || !c.start || !c.end {
+		t.Fatalf("first chunk = %+v", c)
+	}
+	if c := chunks[len(chunks)-1]; c.text != "last" || !c.start || !c.end {
+		t.Fatalf("last chunk = %+v", c)
+	}
+
+	rx := regexp.MustCompile(`READY on :(\d+)`)
+	var found []string
+	for _, c := range chunks[1 : len(chunks)-1] {
+		if m := c.match(rx); m != nil && found == nil {
+			found = m
+		}
+	}
+	if found == nil || found[1] != "3000" {
+		t.Fatalf("match across chunks = %v, want port 3000", found)
+	}
+}
+
+func TestLineChunkMatch_AnchorsOnlyAtLineEdges(t *testing.T) {
+	chunks, _ := scanAll(t, strings.Repeat("ab", 40)+"\n", 16)
+	start, end := regexp.MustCompile(`^ab`), regexp.MustCompile(`ab$`)
+	var starts, ends int
+	for _, c := range chunks {
+		if c.match(start) != nil {
+			starts++
+		}
+		if c.match(end) != nil {
+			ends++
+		}
+	}
+	if starts != 1 || ends != 1 {
+		t.Fatalf("^ matched %d chunks and $ %d, want 1 each", starts, ends)
+	}
+}
@@ -0,0 +1,151 @@
+package runner
+
+import (
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/chenasraf/vstask/tasks"
+	"github.com/chenasraf/vstask/utils"
+)
+
+// taskShell returns the shell a shell task runs in, and its args: options.shell, or the
+// platform default. On Windows, a bare "bash" or "sh" means Git Bash when it's installed (see
+// findGitBash). A shell without args gets those of its kind ("-c", "-Command" or "/C").
+func taskShell(t tasks.Task) (exe string, args []string) {
+	exe, args = defaultShell()
+	if t.Options == nil || t.Options.Shell == nil || t.Options.Shell.Executable == "" {
+		return exe, args
+	}
+	exe = gitBashFor(t.Options.Shell.Executable)
+	switch {
+	case len(t.Options.Shell.Args) > 0:
+		args = append([]string(nil), t.Options.Shell.Args...)
+	case runtime.GOOS == "windows" || kindOfShell(exe) == shellPowerShell:
+		args = shellArgsFor(kindOfShell(exe))
+	}
+	return exe, args
+}
+
+// isShellTask reports whether t runs through a shell ("shell" is VS Code's default type).
+func isShellTask(t tasks.Task) bool {
+	typ := strings.ToLower(strings.TrimSpace(t.Type))
+	return typ == "" || typ == "shell"
+}
+
+// gitBashFor resolves a bare "bash" or "sh" to Git Bash on Windows, where the one on PATH is
+// often WSL's. Anything else is returned as is.
+func gitBashFor(exe string) string {
+	if runtime.GOOS != "windows" || strings.ContainsAny(exe, `/\`) {
+		return exe
+	}
+	if name := shellName(exe); name != "bash" && name != "sh" {
+		return exe
+	}
+	if p := findGitBash(); p != "" {
+		utils.Tracef("shell %s: using Git Bash at %s", exe, p)
+		return p
+	}
+	utils.Tracef("shell %s: no Git Bash found; using the one on PATH", exe)
+	return exe
+}
+
+// findGitBash looks for the bash of Git for Windows (next to the git on PATH, or in its
+// default install locations), then MSYS2's. It returns "" when there's none.
+func findGitBash() string {
+	var candidates []string
+	if git, err := exec.LookPath("git"); err == nil {
+		// <root>\cmd\git.exe, <root>\bin\git.exe or <root>\mingw64\bin\git.exe
+		dir := filepath.Dir(git)
+		for range 3 {
+			candidates = append(candidates, filepath.Join(dir, "bin", "bash.exe"))
+			dir = filepath.Dir(dir)
+		}
+	}
+	for _, env := range []string{"ProgramFiles", "ProgramW6432", "ProgramFiles(x86)"} {
+		if base := os.Getenv(env); base != "" {
+			candidates = append(candidates, filepath.Join(base, "Git", "bin", "bash.exe"))
+		}
+	}
+	if base := os.Getenv("LOCALAPPDATA"); base != "" {
+		candidates = append(candidates, filepath.Join(base, "Programs", "Git", "bin", "bash.exe"))
+	}
+	candidates = append(candidates, `C:\msys64\usr\bin\bash.exe`)
+	for _, c := range candidates {
+		if info, err := os.Stat(c); err == nil && !info.IsDir() {
+			return c
+		}
+	}
+	return ""
+}
+
+// msysShell reports whether exe is a POSIX shell on Windows that isn't WSL's, i.e. Git Bash,
+// MSYS2 or Cygwin: one that expects /c/-style paths and POSIX quoting.
+func msysShell(exe string) bool {
+	if runtime.GOOS != "windows" {
+		return false
+	}
+	switch shellName(exe) {
+	case "bash", "sh", "zsh", "dash":
+	default:
+		return false
+	}
+	p := exe
+	if !strings.ContainsAny(exe, `/\`) {
+		var err error
+		if p, err = exec.LookPath(exe); err != nil {
+			return false
+		}
+	}
+	return !isWSLLauncher(p)
+}
+
+// isWSLLauncher reports whether p is the bash.exe that starts WSL (in System32 or WindowsApps).
+func isWSLLauncher(p string) bool {
+	p = strings.ToLower(filepath.Clean(p))
+	if root := os.Getenv("SystemRoot"); root != "" &&
+		strings.HasPrefix(p, strings.ToLower(filepath.Join(root, "System32"))+`\`) {
+		return true
+	}
+	return strings.Contains(p, `\windowsapps\`)
+}
+
+// shellName is exe's lower-cased base name, without ".exe".
+func shellName(exe string) string {
+	name := exe
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.TrimSuffix(strings.ToLower(name), ".exe")
+}
+
+// msysPath translates a Windows path for MSYS shells: C:\src\app becomes /c/src/app and
+// \\server\share becomes //server/share. Other paths just get forward slashes.
+func msysPath(p string) string {
+	if len(p) >= 2 && p[1] == ':' && isDriveLetter(p[0]) {
+		return "/" + strings.ToLower(p[:1]) + strings.ReplaceAll(p[2:], `\`, "/")
+	}
+	return strings.ReplaceAll(p, `\`, "/")
+}
+
+func isDriveLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// msysVars returns a copy of vars with the path variables (and their "Posix" variants, see
+// addPosixVars) translated by msysPath, and ${/} and ${pathSeparator} as "/".
+func msysVars(vars map[string]string) map[string]string {
+	out := maps.Clone(vars)
+	for k, v := range vars {
+		name, _, _ := strings.Cut(k, ":")
+		if isPathVar(strings.TrimSuffix(name, "Posix")) {
+			out[k] = msysPath(v)
+		}
+	}
+	out["/"] = "/"
+	out["pathSeparator"] = "/"
+	return out
+}
@@ -0,0 +1,126 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMSYSPath(t *testing.T) {
+	cases := map[string]string{
+		`C:\src\app`:          "/c/src/app",
+		`d:/work/x y`:         "/d/work/x y",
+		`C:\`:                 "/c/",
+		`\\server\share\dir`:  "//server/share/dir",
+		`src\main.go`:         "src/main.go",
+		"/already/posix/path": "/already/posix/path",
+	}
+	for in, want := range cases {
+		if got := msysPath(in); got != want {
+			t.Errorf("msysPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestMSYSVarsTranslatesPathVars(t *testing.T) {
+	vars := map[string]string{
+		"workspaceFolder":         `C:\ws`,
+		"workspaceFolderPosix":    "C:/ws",
+		"workspaceFolder:api":     `D:\api`,
+		"relativeFile":            `src\a.go`,
+		"workspaceFolderBasename": "ws",
+		"/":                       `\`,
+	}
+	got := msysVars(vars)
+	want := map[string]string{
+		"workspaceFolder":         "/c/ws",
+		"workspaceFolderPosix":    "/c/ws",
+		"workspaceFolder:api":     "/d/api",
+		"relativeFile":            "src/a.go",
+		"workspaceFolderBasename": "ws",
+		"/":                       "/",
+		"pathSeparator":           "/",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+	if vars["workspaceFolder"] != `C:\ws` {
+		t.Errorf("msysVars modified its input")
+	}
+}
+
+func TestCommandLineQuoting(t *testing.T) {
+	args := []string{"hello world", `C:\Program Files\x`, `a"b`, "plain"}
+
+	// Git Bash on Windows gets POSIX quoting, like any other POSIX shell.
+	if got, want := commandLine("echo", args, shellPOSIX), `echo "hello world" "C:\\Program Files\\x" "a\"b" plain`; got != want {
+		t.Errorf("posix: %s\n want %s", got, want)
+	}
+	if got, want := commandLine("echo", args, shellCmd), `echo "hello world" "C:\Program Files\x" "a""b" plain`; got != want {
+		t.Errorf("cmd.exe: %s\n want %s", got, want)
+	}
+
+	// Backslashes before a quote are escapes to the program, so they're doubled.
+	for in, want := range map[string]string{`C:\x y\`: `"C:\x y\\"`, `a\"b`: `"a\\""b"`, `a\b c`: `"a\b c"`} {
+		if got := winQuote(in); got != want {
+			t.Errorf("winQuote(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestFindGitBashInProgramFiles(t *testing.T) {
+	pf := t.TempDir()
+	bash := filepath.Join(pf, "Git", "bin", "bash.exe")
+	writeFile(t, bash, "")
+	t.Setenv("PATH", "")
+	t.Setenv("ProgramFiles", pf)
+	t.Setenv("ProgramW6432", "")
+	t.Setenv("ProgramFiles(x86)", "")
+	t.Setenv("LOCALAPPDATA", "")
+
+	if got := findGitBash(); got != bash {
+		t.Fatalf("findGitBash() = %q, want %q", got, bash)
+	}
+	if err := os.Remove(bash); err != nil {
+		t.Fatal(err)
+	}
+	if got := findGitBash(); got != "" && got != `C:\msys64\usr\bin\bash.exe` {
+		t.Fatalf("findGitBash() = %q with no Git installed", got)
+	}
+}
+
+func TestShellName(t *testing.T) {
+	for in, want := range map[string]string{
+		`C:\Program Files\Git\bin\bash.exe`: "bash",
+		"/usr/bin/zsh":                      "zsh",
+		"SH.EXE":                            "sh",
+		"pwsh":                              "pwsh",
+	} {
+		if got := shellName(in); got != want {
+			t.Errorf("shellName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCmdExeLine(t *testing.T) {
+	cases := []struct {
+		args []string
+		want string // "" if not a cmd.exe command
+	}{
+		{[]string{"cmd.exe", "/C", `"C:\x y\a.exe" "b c"`}, `cmd.exe /S /C ""C:\x y\a.exe" "b c""`},
+		{[]string{"cmd", "/d", "/s", "/c", "echo hi"}, `cmd /d /s /c "echo hi"`},
+		{[]string{`C:\Windows System\cmd.exe`, "/K", "dir"}, `"C:\Windows System\cmd.exe" /S /K "dir"`},
+		{[]string{"cmd.exe", "/C"}, ""},
+		{[]string{"cmd.exe", "-c", "echo"}, ""},
+		{[]string{"cmd.exe", "/C", "echo", "extra"}, ""},
+		{[]string{"bash", "-c", "echo"}, ""},
+	}
+	for _, c := range cases {
+		got, ok := cmdExeLine(c.args)
+		if got != c.want || ok != (c.want != "") {
+			t.Errorf("cmdExeLine(%q) = %q, %v; want %q", c.args, got, ok, c.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// missingInputError is returned in non-interactive mode for an input that has no value in
+// the environment and no default.
+type missingInputError struct {
+	ID string
+}
+
+func (e *missingInputError) Error() string {
+	return fmt.Sprintf("input %q has no value (non-interactive)", e.ID)
+}
+
+// noInput reports whether inputs must be resolved without prompting (--no-input or
+// $VSTASK_NO_INPUT=1).
+func noInput(opts Options) bool {
+	return opts.NoInput || os.Getenv("VSTASK_NO_INPUT") == "1"
+}
+
+// ask prompts for in, or in non-interactive mode uses its default, failing without one.
+func (r *InputResolver) ask(in tasks.Input, prompt func(stdin io.ReadCloser) (string, error)) (string, error) {
+	if !r.noInput {
+		return promptFor(in, prompt)
+	}
+	if in.Default != "" {
+		return in.Default, nil
+	}
+	return "", &missingInputError{ID: in.ID}
+}
+
+// checkInputs resolves the inputs of every task in the graph before anything runs, and fails
+// with all the ones that have no value, rather than the first one the run would reach.
+func (g *taskGraph) checkInputs(r *InputResolver) error {
+	nodes := slices.Collect(maps.Values(g.nodes))
+	slices.SortFunc(nodes, func(a, b *taskNode) int { return strings.Compare(a.name, b.name) })
+	var missing []string
+	for _, n := range nodes {
+		for _, id := range tasks.ApplyPlatformOverrides(n.task).InputRefs() {
+			_, err := r.Resolve(id)
+			var me *missingInputError
+			switch {
+			case errors.As(err, &me):
+				if !slices.Contains(missing, me.ID) {
+					missing = append(missing, me.ID)
+				}
+			case abortsRun(err):
+				return err
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	names := make([]string, len(missing))
+	for i, id := range missing {
+		names[i] = inputEnvName(id)
+	}
+	return fmt.Errorf("missing inputs: %s (set %s or VSTASK_INPUTS, or give them a default)",
+		strings.Join(missing, ", "), strings.Join(names, ", "))
+}
@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func TestResolve_NoInputUsesDefaultsAndEnv(t *testing.T) {
+	t.Setenv("VSTASK_INPUT_REGION", "eu")
+	r := NewInputResolver([]tasks.Input{
+		{ID: "env", Type: "pickString", Options: []tasks.PickOption{{Value: "dev"}, {Value: "prod"}}, Default: "dev"},
+		{ID: "region", Type: "promptString"},
+		{ID: "name", Type: "promptString"},
+	})
+	r.noInput = true
+
+	if v, err := r.Resolve("env"); err != nil || v != "dev" {
+		t.Fatalf("env = %q, %v; want the default", v, err)
+	}
+	if v, err := r.Resolve("region"); err != nil || v != "eu" {
+		t.Fatalf("region = %q, %v; want the env value", v, err)
+	}
+	for _, id := range []string{"name", "undeclared"} {
+		if _, err := r.Resolve(id); !abortsRun(err) {
+			t.Fatalf("%s: err = %v; want a missing input error", id, err)
+		}
+	}
+}
+
+func TestRun_NoInputListsMissingInputs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".vscode", "tasks.json"), `{
+  "version": "2.0.0",
+  "tasks": [
+    { "label": "deploy", "command": "echo ${input:env} ${input:tag} > out.txt", "dependsOn": ["login"] },
+    { "label": "login", "command": "echo ${input:user} ${input:tag}" }
+  ],
+  "inputs": [
+    { "id": "env", "type": "promptString", "default": "dev" },
+    { "id": "tag", "type": "promptString" },
+    { "id": "user", "type": "promptString", "default": "${input:token}" }
+  ]
+}`)
+	t.Chdir(dir)
+	all, err := tasks.GetTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := tasks.FindTask(all, "deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Run(task, Options{NoInput: true})
+	if err == nil || !strings.Contains(err.Error(), "missing inputs: tag, token") ||
+		!strings.Contains(err.Error(), "VSTASK_INPUT_TAG, VSTASK_INPUT_TOKEN") {
+		t.Fatalf("err = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out.txt")); err == nil {
+		t.Fatal("deploy ran despite missing inputs")
+	}
+
+	t.Setenv("VSTASK_INPUTS", `{"tag": "v1", "token": "t"}`)
+	if err := Run(task, Options{NoInput: true}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "out.txt"))
+	if err != nil || strings.TrimSpace(string(b)) != "dev v1" {
+		t.Fatalf("out.txt = %q, %v", b, err)
+	}
+}
@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// rePassthroughPlaceholder matches a placeholder of a tasks.TypeCommands template, with the
+// whitespace before it.
+var rePassthroughPlaceholder = regexp.MustCompile(`(\s*)\$\{(command|args|script|label|type)\}`)
+
+// passthroughTask turns a task of an extension type (e.g. "func") into a shell task using
+// its command template from tasks.TypeCommands. Native types are returned unchanged.
+//
+// The template and the values are kept as they are (quoted whitespace included); only a
+// placeholder with no value takes the whitespace before it along.
+func passthroughTask(t tasks.Task, typeCommands map[string]string) (tasks.Task, error) {
+	if tasks.IsNativeType(t.Type) {
+		return t, nil
+	}
+	tmpl, ok := typeCommands[strings.ToLower(strings.TrimSpace(t.Type))]
+	if !ok {
+		return t, unsupportedTypesError(map[string][]string{t.Type: {t.Label}})
+	}
+	vals := map[string]string{
+		"command": t.Command,
+		"args":    buildCommandLine("", t.Args),
+		"script":  t.Script,
+		"label":   t.Label,
+		"type":    t.Type,
+	}
+	line := rePassthroughPlaceholder.ReplaceAllStringFunc(tmpl, func(m string) string {
+		sub := rePassthroughPlaceholder.FindStringSubmatch(m)
+		v := strings.TrimSpace(vals[sub[2]])
+		if v == "" {
+			return ""
+		}
+		return sub[1] + v
+	})
+	t.Type = "shell"
+	t.Command = strings.TrimSpace(line)
+	t.Args = nil
+	t.Script = ""
+	return t, nil
+}
+
+// checkTaskTypes reports every task (grouped by workspace folder) whose type is neither native
+// nor mapped in that folder's tasks.TypeCommands.
+func checkTaskTypes(byFolder map[string][]tasks.Task) error {
+	all := map[string][]string{}
+	for folder, ts := range byFolder {
+		for typ, labels := range tasks.UnsupportedTypes(ts, tasks.TypeCommands(folder)) {
+			all[typ] = append(all[typ], labels...)
+		}
+	}
+	if len(all) == 0 {
+		return nil
+	}
+	return unsupportedTypesError(all)
+}
+
+// unsupportedTypesError lists every unsupported task type and the tasks using it.
+func unsupportedTypesError(byType map[string][]string) error {
+	var b strings.Builder
+	b.WriteString(`unsupported task type(s); map them to a command with "vstask.taskTypes" in settings.json:`)
+	for _, typ := range slices.Sorted(maps.Keys(byType)) {
+		labels := slices.Sorted(slices.Values(byType[typ]))
+		quoted := make([]string, len(labels))
+		for i, l := range labels {
+			quoted[i] = fmt.Sprintf("%q", l)
+		}
+		fmt.Fprintf(&b, "\n  %s: used by %s", typ, strings.Join(quoted, ", "))
+	}
+	return fmt.Errorf("%s", b.String())
+}
@@ -0,0 +1,76 @@
+package runner
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func TestPassthroughTask(t *testing.T) {
+	m := map[string]string{
+		"func":  "func ${command} ${args}",
+		"gulp":  "npx gulp ${script}",
+		"empty": "tool ${command}   ${args}",
+	}
+
+	got, err := passthroughTask(tasks.Task{Label: "host", Type: "func", Command: "host start", Args: []string{"--port", "7071"}}, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "func host start --port 7071"
+	if runtime.GOOS == "windows" {
+		want = `func host start "--port" "7071"`
+	}
+	if got.Type != "shell" || got.Command != want || got.Args != nil {
+		t.Fatalf("got %+v, want command %q", got, want)
+	}
+
+	got, _ = passthroughTask(tasks.Task{Type: "EMPTY"}, m)
+	if got.Command != "tool" {
+		t.Fatalf("blank placeholders should collapse, got %q", got.Command)
+	}
+	got, _ = passthroughTask(tasks.Task{Type: "func", Command: ` echo "a   b" `}, m)
+	if want := `func echo "a   b"`; got.Command != want {
+		t.Fatalf("got %q, want %q (whitespace within the command kept)", got.Command, want)
+	}
+
+	native := tasks.Task{Type: "process", Command: "go"}
+	if got, err := passthroughTask(native, m); err != nil || got.Type != "process" {
+		t.Fatalf("native types are untouched: %+v, %v", got, err)
+	}
+
+	if _, err := passthroughTask(tasks.Task{Label: "x", Type: "bazel"}, m); err == nil || !strings.Contains(err.Error(), `bazel: used by "x"`) {
+		t.Fatalf("expected unsupported type error, got %v", err)
+	}
+}
+
+func TestCheckTaskTypes_ListsAllUnsupported(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".vscode", "settings.json"), `{"vstask.taskTypes": {"gradle": "./gradlew ${script}"}}`)
+
+	err := checkTaskTypes(map[string][]tasks.Task{root: {
+		{Label: "main", Type: "shell"},
+		{Label: "b", Type: "bazel"},
+		{Label: "a", Type: "bazel"},
+		{Label: "g", Type: "gradle"},
+		{Label: "f", Type: "func"}, // built-in mapping
+		{Label: "w", Type: "wasm"},
+	}})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	msg := err.Error()
+	for _, want := range []string{`bazel: used by "a", "b"`, `wasm: used by "w"`, "vstask.taskTypes"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error should contain %q:\n%s", want, msg)
+		}
+	}
+	if strings.Contains(msg, "gradle") || strings.Contains(msg, "func") {
+		t.Errorf("mapped types should not be listed:\n%s", msg)
+	}
+}
@@ -0,0 +1,64 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// pathVarNames are the variables holding paths; each also has a forward-slash variant named
+// with a "Posix" suffix, e.g. ${workspaceFolderPosix} (and ${workspaceFolderPosix:Name}).
+var pathVarNames = []string{
+	"workspaceFolder", "cwd", "userHome", "execPath", "file", "fileDirname",
+	"fileWorkspaceFolder", "relativeFile", "relativeFileDirname", "runTemp", "taskTemp",
+}
+
+// forwardSlashes reports whether t's path variables should use forward slashes everywhere:
+// its "forwardSlashes" extension field, or $VSTASK_FORWARD_SLASHES=1. It only makes a
+// difference on Windows.
+func forwardSlashes(t tasks.Task) bool {
+	return t.ForwardSlashes || os.Getenv("VSTASK_FORWARD_SLASHES") == "1"
+}
+
+// addPosixVars adds the "Posix" variants of the path variables in vars. With forward, the
+// path variables themselves, ${/} and ${pathSeparator} use forward slashes too.
+func addPosixVars(vars map[string]string, forward bool) {
+	for k, v := range vars {
+		name, folder, scoped := strings.Cut(k, ":")
+		if !isPathVar(name) {
+			continue
+		}
+		posix := name + "Posix"
+		if scoped {
+			posix += ":" + folder
+		}
+		vars[posix] = filepath.ToSlash(v)
+		if forward {
+			vars[k] = filepath.ToSlash(v)
+		}
+	}
+	if forward {
+		vars["/"] = "/"
+		vars["pathSeparator"] = "/"
+	}
+}
+
+func isPathVar(name string) bool {
+	for _, n := range pathVarNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// posixSeparators makes ${/} and ${pathSeparator} forward slashes in a field that uses a
+// "Posix" path variable, so composites like ${workspaceFolderPosix}${/}src stay consistent.
+func posixSeparators(s string) string {
+	if !strings.Contains(s, "Posix}") && !strings.Contains(s, "Posix:") {
+		return s
+	}
+	return strings.NewReplacer("${/}", "/", "${pathSeparator}", "/").Replace(s)
+}
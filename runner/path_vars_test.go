@@ -0,0 +1,58 @@
+package runner
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func TestAddPosixVars(t *testing.T) {
+	vars := map[string]string{
+		"workspaceFolder":     filepath.FromSlash("/ws/app"),
+		"workspaceFolder:api": filepath.FromSlash("/ws/api"),
+		"fileBasename":        "x.go",
+		"/":                   string(filepath.Separator),
+	}
+	addPosixVars(vars, false)
+	if vars["workspaceFolderPosix"] != "/ws/app" || vars["workspaceFolderPosix:api"] != "/ws/api" {
+		t.Fatalf("posix variants: %v", vars)
+	}
+	if _, ok := vars["fileBasenamePosix"]; ok {
+		t.Fatal("only path variables get a Posix variant")
+	}
+	if vars["/"] != string(filepath.Separator) {
+		t.Fatalf("${/} changed without forward: %q", vars["/"])
+	}
+
+	addPosixVars(vars, true)
+	if vars["workspaceFolder"] != "/ws/app" || vars["/"] != "/" || vars["pathSeparator"] != "/" {
+		t.Fatalf("forward slashes: %v", vars)
+	}
+}
+
+func TestSubstituteVars_PosixComposite(t *testing.T) {
+	vars := map[string]string{"workspaceFolderPosix": "C:/ws", "/": `\`, "workspaceFolder": `C:\ws`}
+	if got := substituteVars("${workspaceFolderPosix}${/}src", vars); got != "C:/ws/src" {
+		t.Fatalf("posix composite = %q", got)
+	}
+	if got := substituteVars("${workspaceFolder}${/}src", vars); got != `C:\ws\src` {
+		t.Fatalf("native composite = %q", got)
+	}
+}
+
+func TestResolveTask_PosixVars(t *testing.T) {
+	isolatePMDetectionToDefault(t)
+	ws := t.TempDir()
+	tk := tasks.Task{Label: "p", Command: "node ${workspaceFolderPosix}${/}index.js", ForwardSlashes: true, Args: []string{"${cwd}"}}
+	rt, err := resolveTask(tk, ws, NewInputResolver(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "node " + filepath.ToSlash(ws) + "/index.js"; rt.Task.Command != want {
+		t.Fatalf("command = %q, want %q", rt.Task.Command, want)
+	}
+	if rt.Task.Args[0] != filepath.ToSlash(ws) {
+		t.Fatalf("forwardSlashes cwd = %q", rt.Task.Args[0])
+	}
+}
@@ -0,0 +1,512 @@
+package runner
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// problem is something a task's problem matcher found in its output.
+type problem struct {
+	Task      string `json:"task"`
+	Owner     string `json:"owner,omitempty"`
+	Source    string `json:"source,omitempty"`
+	File      string `json:"file,omitempty"` // absolute, once resolved (see problemMatcher.resolveFile)
+	Line      int    `json:"line,omitempty"`
+	Column    int    `json:"column,omitempty"`
+	EndLine   int    `json:"endLine,omitempty"`
+	EndColumn int    `json:"endColumn,omitempty"`
+	Severity  string `json:"severity"` // "error" | "warning" | "info"
+	Code      string `json:"code,omitempty"`
+	Message   string `json:"message"`
+}
+
+// problemsError fails a run whose tasks succeeded, but whose problem matchers found errors
+// (see Options.FailOnProblems).
+type problemsError struct {
+	errors int
+}
+
+func (e *problemsError) Error() string {
+	return fmt.Sprintf("problem matchers found %s", plural(e.errors, "error"))
+}
+
+// failOnProblems reports whether errors found by problem matchers fail the run
+// (--fail-on-problems or $VSTASK_FAIL_ON_PROBLEMS=1).
+func failOnProblems(opts Options) bool {
+	return opts.FailOnProblems || os.Getenv("VSTASK_FAIL_ON_PROBLEMS") == "1"
+}
+
+// problemMatcher is a compiled problem matcher object (see tasks.ProblemMatcherObject).
+type problemMatcher struct {
+	owner, source string
+	severity      string // of problems whose pattern doesn't capture one
+	fileLocation  string // "absolute" | "relative" | "autodetect"
+	base          string // what relative file names are relative to
+	patterns      []problemPattern
+}
+
+type problemPattern struct {
+	tasks.ProblemMatcherPattern
+	rx *regexp.Regexp
+}
+
+// compileProblemMatchers compiles the pattern matchers of t, built-in ones included
+// (background-only matchers and those vstask doesn't know have no pattern to match). Relative
+// file names are relative to the workspace folder unless fileLocation says otherwise; vars
+// expand in its directory.
+func compileProblemMatchers(t tasks.Task, workspace string, vars map[string]string) ([]*problemMatcher, error) {
+	if t.ProblemMatcher == nil {
+		return nil, nil
+	}
+	objs, err := t.ProblemMatcher.Matchers()
+	if err != nil {
+		return nil, fmt.Errorf("task %q: %w", t.Label, err)
+	}
+	var out []*problemMatcher
+	for _, obj := range objs {
+		if len(obj.Pattern) == 0 {
+			continue
+		}
+		m, err := compileProblemMatcher(obj, workspace, vars)
+		if err != nil {
+			return nil, fmt.Errorf("task %q: problemMatcher: %w", t.Label, err)
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+func compileProblemMatcher(obj tasks.ProblemMatcherObject, workspace string, vars map[string]string) (*problemMatcher, error) {
+	m := &problemMatcher{
+		owner:        obj.Owner,
+		source:       obj.Source,
+		severity:     normalizeSeverity(obj.Severity),
+		fileLocation: "relative",
+		base:         workspace,
+	}
+	if m.severity == "" {
+		m.severity = "error"
+	}
+	switch loc := obj.FileLocation.(type) {
+	case nil:
+	case string:
+		m.fileLocation = strings.ToLower(loc)
+	case []any:
+		if len(loc) > 0 {
+			m.fileLocation, _ = loc[0].(string)
+			m.fileLocation = strings.ToLower(m.fileLocation)
+		}
+		if len(loc) > 1 {
+			if dir, _ := loc[1].(string); dir != "" {
+				m.base = substituteEnv(substituteVars(dir, vars))
+			}
+		}
+	default:
+		return nil, fmt.Errorf("fileLocation: invalid value %v", loc)
+	}
+	switch m.fileLocation {
+	case "absolute", "relative", "autodetect":
+	case "search":
+		m.fileLocation = "autodetect" // close enough: the file is looked for under the base directory
+	default:
+		return nil, fmt.Errorf("fileLocation: unknown kind %q", m.fileLocation)
+	}
+
+	for i, p := range obj.Pattern {
+		if p.Regexp == "" {
+			return nil, fmt.Errorf("pattern %d: missing regexp", i+1)
+		}
+		rx, err := regexp.Compile(p.Regexp)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %d: %w", i+1, err)
+		}
+		if len(obj.Pattern) == 1 {
+			p = withPatternDefaults(p)
+		}
+		m.patterns = append(m.patterns, problemPattern{ProblemMatcherPattern: p, rx: rx})
+	}
+	return m, nil
+}
+
+// withPatternDefaults fills in the groups a lone pattern leaves out, like VS Code: the file
+// in group 1, the line and column in 2 and 3, and the whole match as the message.
+func withPatternDefaults(p tasks.ProblemMatcherPattern) tasks.ProblemMatcherPattern {
+	if p.File == 0 {
+		p.File = 1
+	}
+	if p.Location == 0 && p.Line == 0 && !strings.EqualFold(p.Kind, "file") {
+		p.Line, p.Column = 2, 3
+	}
+	return p
+}
+
+// normalizeSeverity maps the severities tools print to "error", "warning" or "info" (or "").
+func normalizeSeverity(s string) string {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "error", "err", "fatal", "fatal error", "e":
+		return "error"
+	case "warning", "warn", "w":
+		return "warning"
+	case "info", "information", "note", "hint", "i":
+		return "info"
+	}
+	return ""
+}
+
+// resolveFile makes a file name from the output absolute, per the matcher's fileLocation.
+func (m *problemMatcher) resolveFile(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" || filepath.IsAbs(name) || m.fileLocation == "absolute" || m.base == "" {
+		return name
+	}
+	joined := filepath.Join(m.base, name)
+	if m.fileLocation == "autodetect" {
+		if _, err := os.Stat(joined); err != nil {
+			return name
+		}
+	}
+	return joined
+}
+
+// matchState follows one matcher through a task's output lines.
+type matchState struct {
+	m       *problemMatcher
+	next    int     // the pattern the next line must match; 0 starts over
+	partial problem // what the patterns before next captured
+}
+
+// feed matches line, returning the problems it completes.
+func (s *matchState) feed(line string) []problem {
+	pats := s.m.patterns
+	if s.next > 0 {
+		p := pats[s.next]
+		if groups := p.rx.FindStringSubmatch(line); groups != nil {
+			got := s.partial
+			s.m.capture(&got, p, groups)
+			switch {
+			case s.next < len(pats)-1:
+				s.partial = got
+				s.next++
+				return nil
+			case !p.Loop:
+				s.next = 0
+			}
+			return s.m.emit(got)
+		}
+		s.next = 0
+	}
+	groups := pats[0].rx.FindStringSubmatch(line)
+	if groups == nil {
+		return nil
+	}
+	s.partial = problem{}
+	s.m.capture(&s.partial, pats[0], groups)
+	if len(pats) > 1 {
+		s.next = 1
+		return nil
+	}
+	return s.m.emit(s.partial)
+}
+
+// capture fills in what pattern p's groups hold.
+func (m *problemMatcher) capture(pr *problem, p problemPattern, groups []string) {
+	group := func(i int) string {
+		if i <= 0 || i >= len(groups) {
+			return ""
+		}
+		return strings.TrimSpace(groups[i])
+	}
+	num := func(i int, dst *int) {
+		if n, err := strconv.Atoi(group(i)); err == nil {
+			*dst = n
+		}
+	}
+	if f := group(p.File); f != "" {
+		pr.File = f
+	}
+	if loc := group(p.Location); loc != "" {
+		// "line", "line,column" or "line,column,endLine,endColumn", maybe in parentheses
+		parts := strings.FieldsFunc(strings.Trim(loc, "()"), func(r rune) bool { return r == ',' || r == ':' })
+		for i, dst := range []*int{&pr.Line, &pr.Column, &pr.EndLine, &pr.EndColumn} {
+			if i < len(parts) {
+				*dst, _ = strconv.Atoi(strings.TrimSpace(parts[i]))
+			}
+		}
+	}
+	num(p.Line, &pr.Line)
+	num(p.Column, &pr.Column)
+	num(p.EndLine, &pr.EndLine)
+	num(p.EndColumn, &pr.EndColumn)
+	if sev := normalizeSeverity(group(p.Severity)); sev != "" {
+		pr.Severity = sev
+	}
+	if c := group(p.Code); c != "" {
+		pr.Code = c
+	}
+	switch {
+	case p.Message > 0:
+		if msg := group(p.Message); msg != "" {
+			pr.Message = msg
+		}
+	case len(m.patterns) == 1:
+		pr.Message = strings.TrimSpace(groups[0])
+	}
+	if strings.EqualFold(p.Kind, "file") {
+		pr.Line, pr.Column, pr.EndLine, pr.EndColumn = 0, 0, 0, 0
+	}
+}
+
+// emit finishes a matched problem, or drops it if it has no message.
+func (m *problemMatcher) emit(pr problem) []problem {
+	if pr.Message == "" {
+		return nil
+	}
+	pr.Owner, pr.Source = m.owner, m.source
+	if pr.Severity == "" {
+		pr.Severity = m.severity
+	}
+	pr.File = m.resolveFile(pr.File)
+	return []problem{pr}
+}
+
+// reANSI matches terminal escape sequences (colors, cursor movement, titles), which tools
+// print around their messages when they see a terminal.
+var reANSI = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+
+// problemScanner matches a task's output lines against its problem matchers and adds what
+// they find to a run's problems.
+type problemScanner struct {
+	task     string
+	log      *problemLog
+	mu       sync.Mutex // one line at a time, whichever stream it's from
+	states   []*matchState
+	panicked *PanicError // a matcher's panic, which stopped the matching
+}
+
+func newProblemScanner(task string, matchers []*problemMatcher, log *problemLog) *problemScanner {
+	if len(matchers) == 0 || log == nil {
+		return nil
+	}
+	s := &problemScanner{task: task, log: log}
+	for _, m := range matchers {
+		s.states = append(s.states, &matchState{m: m})
+	}
+	return s
+}
+
+// line matches one line of output, as printed (escape sequences and all).
+func (s *problemScanner) line(line string) {
+	line = strings.TrimRight(line, "\r\n")
+	if i := strings.LastIndexByte(line, '\r'); i >= 0 {
+		line = line[i+1:] // what a terminal shows after a carriage return
+	}
+	if strings.IndexByte(line, 0x1b) >= 0 {
+		line = reANSI.ReplaceAllString(line, "")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// It runs on the goroutines copying the output, where a panic would crash vstask.
+	defer recoverPanic(s.task, func(pe *PanicError) {
+		s.panicked, s.states = pe, nil
+	})
+	for _, st := range s.states {
+		for _, pr := range st.feed(line) {
+			pr.Task = s.task
+			s.log.add(pr)
+		}
+	}
+}
+
+// err returns the panic a problem matcher raised while matching, if any, as the task's failure.
+func (s *problemScanner) err() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.panicked == nil {
+		return nil
+	}
+	return s.panicked
+}
+
+// tee sends cmd's output to stdout and stderr (the terminal, its lines maybe prefixed) and,
+// with a scanner, to s too. Call the returned func once cmd is done.
+func (s *problemScanner) tee(cmd *exec.Cmd, stdout, stderr io.Writer) (done func()) {
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	if s == nil {
+		return func() {}
+	}
+	out, errOut := s.writer(), s.writer()
+	cmd.Stdout = io.MultiWriter(stdout, out)
+	cmd.Stderr = io.MultiWriter(stderr, errOut)
+	return func() {
+		_ = out.Close()
+		_ = errOut.Close()
+	}
+}
+
+// writer returns an io.Writer scanning one output stream; close it once the stream ends,
+// for a last line without a line break.
+func (s *problemScanner) writer() *problemWriter {
+	return &problemWriter{s: s}
+}
+
+// problemWriter splits an output stream into lines for a problemScanner, keeping at most
+// maxScanLine bytes of a line.
+type problemWriter struct {
+	s       *problemScanner
+	buf     []byte
+	partial bool // buf was cut short; the rest of its line is dropped
+}
+
+func (w *problemWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			w.add(p)
+			break
+		}
+		w.add(p[:i])
+		w.flush()
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+func (w *problemWriter) add(p []byte) {
+	if room := maxScanLine - len(w.buf); len(p) > room {
+		p = p[:max(0, room)]
+		w.partial = true
+	}
+	w.buf = append(w.buf, p...)
+}
+
+func (w *problemWriter) flush() {
+	if len(w.buf) > 0 || w.partial {
+		w.s.line(string(w.buf))
+	}
+	w.buf, w.partial = w.buf[:0], false
+}
+
+// Close matches what's left of the last line.
+func (w *problemWriter) Close() error {
+	w.flush()
+	return nil
+}
+
+// problemLog collects a run's problems, from all its tasks.
+type problemLog struct {
+	mu   sync.Mutex
+	list []problem
+}
+
+// add records pr, unless it's already there (watchers report the same problems on each rebuild).
+func (l *problemLog) add(pr problem) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !slices.Contains(l.list, pr) {
+		l.list = append(l.list, pr)
+	}
+}
+
+// failure returns a *problemsError if errors were found, else nil.
+func (l *problemLog) failure() error {
+	n := 0
+	for _, p := range l.problems() {
+		if p.Severity == "error" {
+			n++
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+	return &problemsError{errors: n}
+}
+
+func (l *problemLog) problems() []problem {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.list)
+}
+
+// printProblemSummary prints the problems found, by file, with paths relative to dir where
+// they're inside it. Nothing is printed without problems.
+func printProblemSummary(w io.Writer, problems []problem, dir string) {
+	if len(problems) == 0 {
+		return
+	}
+	counts := map[string]int{}
+	for _, p := range problems {
+		counts[p.Severity]++
+	}
+	var parts []string
+	for _, sev := range []string{"error", "warning", "info"} {
+		if n := counts[sev]; n > 0 {
+			parts = append(parts, plural(n, severityNoun[sev]))
+		}
+	}
+	fmt.Fprintf(w, "\nProblems: %s\n", strings.Join(parts, ", "))
+
+	byFile := map[string][]problem{}
+	for _, p := range problems {
+		byFile[p.File] = append(byFile[p.File], p)
+	}
+	for _, f := range slices.Sorted(maps.Keys(byFile)) {
+		list := byFile[f]
+		slices.SortStableFunc(list, func(a, b problem) int {
+			return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+		})
+		name := f
+		if name == "" {
+			name = "(no file)"
+		} else if rel, err := filepath.Rel(dir, f); err == nil && filepath.IsAbs(f) && !strings.HasPrefix(rel, "..") {
+			name = rel
+		}
+		fmt.Fprintf(w, "  %s\n", name)
+		for _, p := range list {
+			loc := ""
+			if p.Line > 0 {
+				loc = strconv.Itoa(p.Line)
+				if p.Column > 0 {
+					loc += ":" + strconv.Itoa(p.Column)
+				}
+			}
+			msg := p.Message
+			if p.Code != "" {
+				msg += " (" + p.Code + ")"
+			}
+			if src := cmp.Or(p.Source, p.Owner); src != "" {
+				msg += " [" + src + "]"
+			}
+			fmt.Fprintf(w, "    %-8s %-7s %s\n", loc, p.Severity, msg)
+		}
+	}
+}
+
+var severityNoun = map[string]string{"error": "error", "warning": "warning", "info": "info message"}
+
+// plural returns "1 error" or "2 errors".
+func plural(n int, what string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, what)
+	}
+	return fmt.Sprintf("%d %ss", n, what)
+}
@@ -0,0 +1,251 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func mustMatcher(t *testing.T, workspace, js string) *problemMatcher {
+	t.Helper()
+	var obj tasks.ProblemMatcherObject
+	if err := json.Unmarshal([]byte(js), &obj); err != nil {
+		t.Fatal(err)
+	}
+	m, err := compileProblemMatcher(obj, workspace, map[string]string{"workspaceFolder": workspace})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func scanProblems(m *problemMatcher, output string) []problem {
+	log := &problemLog{}
+	s := newProblemScanner("task", []*problemMatcher{m}, log)
+	w := s.writer()
+	for _, b := range []byte(output) { // a byte at a time, so lines span writes
+		_, _ = w.Write([]byte{b})
+	}
+	_ = w.Close()
+	return log.problems()
+}
+
+func TestProblemMatcher_LonePatternDefaults(t *testing.T) {
+	ws := t.TempDir()
+	m := mustMatcher(t, ws, `{ "pattern": { "regexp": "^(\\S+):(\\d+):(\\d+) .*$" }, "severity": "warning" }`)
+	got := scanProblems(m, "\x1b[1mmain.go:3:7 bad thing\x1b[0m\r\nnoise\nprogress 10%\rmain.go:9:1 other")
+	if len(got) != 2 {
+		t.Fatalf("problems = %+v, want 2", got)
+	}
+	p := got[0]
+	if p.File != filepath.Join(ws, "main.go") || p.Line != 3 || p.Column != 7 || p.Message != "main.go:3:7 bad thing" || p.Severity != "warning" {
+		t.Fatalf("problem = %+v", p)
+	}
+	if got[1].Line != 9 || got[1].Message != "main.go:9:1 other" {
+		t.Fatalf("problem after a carriage return = %+v", got[1])
+	}
+}
+
+func TestProblemMatcher_LocationGroupAndFileLocation(t *testing.T) {
+	ws := t.TempDir()
+	writeFile(t, filepath.Join(ws, "pkg", "a.ts"), "")
+	m := mustMatcher(t, ws, `{
+	  "fileLocation": ["autoDetect", "${workspaceFolder}/pkg"],
+	  "pattern": { "regexp": "^(.+)\\((\\d+(?:,\\d+)*)\\): (error|warning) (TS\\d+): (.*)$",
+	               "file": 1, "location": 2, "severity": 3, "code": 4, "message": 5 }
+	}`)
+	got := scanProblems(m, "a.ts(3,5): error TS2322: no\nmissing.ts(1,2,1,9): warning TS1: hmm\n/abs/b.ts(4): error TS7: yes\n")
+	want := []problem{
+		{Task: "task", File: filepath.Join(ws, "pkg", "a.ts"), Line: 3, Column: 5, Severity: "error", Code: "TS2322", Message: "no"},
+		{Task: "task", File: "missing.ts", Line: 1, Column: 2, EndLine: 1, EndColumn: 9, Severity: "warning", Code: "TS1", Message: "hmm"},
+		{Task: "task", File: "/abs/b.ts", Line: 4, Severity: "error", Code: "TS7", Message: "yes"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("problems = %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("problem %d = %+v\nwant %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestProblemMatcher_MultiLine(t *testing.T) {
+	m := mustMatcher(t, "", `{ "owner": "x", "pattern": [
+	  { "regexp": "^In (.*):$", "file": 1 },
+	  { "regexp": "^line (\\d+): (.*)$", "line": 1, "message": 2 }
+	] }`)
+	got := scanProblems(m, "In a.c:\nline 3: first\nline 4: not part of it\nIn b.c:\nunrelated\nline 5: orphan\nIn c.c:\nline 6: third\n")
+	if len(got) != 2 || got[0].File != "a.c" || got[0].Line != 3 || got[1].File != "c.c" || got[1].Message != "third" || got[1].Owner != "x" {
+		t.Fatalf("problems = %+v", got)
+	}
+
+	// The same problem reported twice (a watcher rebuilding) is kept once.
+	if got := scanProblems(m, "In a.c:\nline 3: first\nIn a.c:\nline 3: first\n"); len(got) != 1 {
+		t.Fatalf("duplicates kept: %+v", got)
+	}
+}
+
+func compileTaskMatchers(t *testing.T, ws, pm string) []*problemMatcher {
+	t.Helper()
+	var task tasks.Task
+	if err := json.Unmarshal([]byte(`{"label": "task", "problemMatcher": `+pm+`}`), &task); err != nil {
+		t.Fatal(err)
+	}
+	ms, err := compileProblemMatchers(task, ws, map[string]string{"workspaceFolder": ws, "cwd": ws})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ms
+}
+
+func TestProblemMatcher_Builtins(t *testing.T) {
+	ws := t.TempDir()
+	for _, tc := range []struct {
+		name, output string
+		want         problem
+	}{
+		{"$tsc", "src/a.ts(3,5): error TS2322: Type 'x' is not assignable.\n",
+			problem{File: filepath.Join(ws, "src", "a.ts"), Line: 3, Column: 5, Severity: "error", Code: "2322", Message: "Type 'x' is not assignable."}},
+		{"$tsc-watch", "src/a.ts:3:5 - warning TS6133: 'y' is declared but never used.\n",
+			problem{File: filepath.Join(ws, "src", "a.ts"), Line: 3, Column: 5, Severity: "warning", Code: "6133", Message: "'y' is declared but never used."}},
+		{"$gcc", "/src/main.c:4:10: fatal error: foo.h: No such file or directory\n",
+			problem{File: "/src/main.c", Line: 4, Column: 10, Severity: "error", Message: "foo.h: No such file or directory"}},
+		{"$go", "./main.go:7:2: undefined: x\n",
+			problem{File: filepath.Join(ws, "main.go"), Line: 7, Column: 2, Severity: "error", Message: "undefined: x"}},
+		{"$eslint-stylish", "/src/a.js\n  1:10  error  'x' is defined but never used  no-unused-vars\n",
+			problem{File: "/src/a.js", Line: 1, Column: 10, Severity: "error", Code: "no-unused-vars", Message: "'x' is defined but never used"}},
+		{"$eslint-compact", "/src/a.js: line 1, col 2, Warning - Unexpected console statement. (no-console)\n",
+			problem{File: "/src/a.js", Line: 1, Column: 2, Severity: "warning", Code: "no-console", Message: "Unexpected console statement."}},
+		{"$jshint", "/src/a.js: line 1, col 5, Missing semicolon. (W033)\n",
+			problem{File: "/src/a.js", Line: 1, Column: 5, Severity: "warning", Code: "033", Message: "Missing semicolon."}},
+		{"$jshint-stylish", "/src/a.js\n  line 1  col 5  Missing semicolon. (W033)\n",
+			problem{File: "/src/a.js", Line: 1, Column: 5, Severity: "warning", Code: "033", Message: "Missing semicolon."}},
+		{"$msCompile", "1>/src/a.cs(10,5): error CS1002: ; expected\n",
+			problem{File: "/src/a.cs", Line: 10, Column: 5, Severity: "error", Code: "CS1002", Message: "; expected"}},
+		{"$lessCompile", "Unrecognised input in file /src/a.less line no. 3\n",
+			problem{File: "/src/a.less", Line: 3, Severity: "error", Message: "Unrecognised input"}},
+		{"$gulp-tsc", "src/a.ts(1,2): 2304 Cannot find name 'z'.\n",
+			problem{File: filepath.Join(ws, "src", "a.ts"), Line: 1, Column: 2, Severity: "error", Code: "2304", Message: "Cannot find name 'z'."}},
+		{"$rustc", "error[E0308]: mismatched types\n  --> /src/main.rs:2:5\n",
+			problem{File: "/src/main.rs", Line: 2, Column: 5, Severity: "error", Code: "E0308", Message: "mismatched types"}},
+	} {
+		ms := compileTaskMatchers(t, ws, `"`+tc.name+`"`)
+		if len(ms) != 1 {
+			t.Errorf("%s: %d matchers", tc.name, len(ms))
+			continue
+		}
+		got := scanProblems(ms[0], tc.output)
+		if len(got) != 1 {
+			t.Errorf("%s: problems = %+v", tc.name, got)
+			continue
+		}
+		want := tc.want
+		want.Task, want.Owner, want.Source = "task", ms[0].owner, ms[0].source
+		if got[0] != want {
+			t.Errorf("%s: problem = %+v\nwant %+v", tc.name, got[0], want)
+		}
+	}
+
+	// Names vstask doesn't know (other extensions' matchers) are skipped.
+	if ms := compileTaskMatchers(t, ws, `["$esbuild-watch", "$go"]`); len(ms) != 1 || ms[0].owner != "go" {
+		t.Fatalf("unknown name: %+v", ms)
+	}
+}
+
+func TestProblemMatcher_BaseAndNamedPattern(t *testing.T) {
+	ws := t.TempDir()
+	ms := compileTaskMatchers(t, ws, `[
+	  { "base": "$tsc", "owner": "mine", "fileLocation": "absolute" },
+	  { "owner": "c", "pattern": "$gcc", "severity": "info" }
+	]`)
+	if len(ms) != 2 {
+		t.Fatalf("matchers = %+v", ms)
+	}
+	if m := ms[0]; m.owner != "mine" || m.source != "ts" || m.fileLocation != "absolute" || len(m.patterns) != 1 {
+		t.Fatalf("base not applied: %+v", m)
+	}
+	if got := scanProblems(ms[0], "src/a.ts(1,1): error TS1: x\n"); len(got) != 1 || got[0].File != "src/a.ts" {
+		t.Fatalf("base matcher problems = %+v", got)
+	}
+	if got := scanProblems(ms[1], "a.c:1:2: warning: w\n"); len(got) != 1 || got[0].Owner != "c" || got[0].Severity != "warning" {
+		t.Fatalf("named pattern problems = %+v", got)
+	}
+
+	// Extending a built-in leaves it alone.
+	if m, _ := tasks.BuiltinProblemMatcher("$tsc"); m.Owner != "typescript" {
+		t.Fatalf("built-in modified: %+v", m)
+	}
+}
+
+func TestProblemMatcher_Errors(t *testing.T) {
+	for _, js := range []string{
+		`{ "pattern": { "regexp": "(" } }`,
+		`{ "pattern": { "file": 1 } }`,
+		`{ "fileLocation": "nowhere", "pattern": { "regexp": "x" } }`,
+	} {
+		var obj tasks.ProblemMatcherObject
+		if err := json.Unmarshal([]byte(js), &obj); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := compileProblemMatcher(obj, "", nil); err == nil {
+			t.Errorf("%s: expected an error", js)
+		}
+	}
+}
+
+func TestProblemLog_Failure(t *testing.T) {
+	log := &problemLog{}
+	log.add(problem{File: "a.go", Severity: "warning", Message: "w"})
+	if err := log.failure(); err != nil {
+		t.Fatalf("warnings only: %v", err)
+	}
+	log.add(problem{File: "a.go", Severity: "error", Message: "e1"})
+	log.add(problem{File: "b.go", Severity: "error", Message: "e2"})
+	if err := log.failure(); err == nil || err.Error() != "problem matchers found 2 errors" || ExitCode(err) != 1 {
+		t.Fatalf("failure = %v", err)
+	}
+}
+
+func TestPrintProblemSummary(t *testing.T) {
+	dir := t.TempDir()
+	var b strings.Builder
+	printProblemSummary(&b, nil, dir)
+	if b.Len() != 0 {
+		t.Fatalf("summary without problems: %q", b.String())
+	}
+	printProblemSummary(&b, []problem{
+		{File: filepath.Join(dir, "b.go"), Line: 9, Severity: "warning", Message: "later", Source: "vet"},
+		{File: filepath.Join(dir, "b.go"), Line: 2, Column: 4, Severity: "error", Message: "first", Code: "E1"},
+		{File: "/elsewhere/a.go", Severity: "info", Message: "whole file"},
+	}, dir)
+	want := `
+Problems: 1 error, 1 warning, 1 info message
+  /elsewhere/a.go
+             info    whole file
+  b.go
+    2:4      error   first (E1)
+    9        warning later [vet]
+`
+	if got := strings.ReplaceAll(b.String(), string(filepath.Separator), "/"); got != want {
+		t.Fatalf("summary:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestProblemScanner_PanicFailsTheTask(t *testing.T) {
+	// A pattern without its regexp panics on the first line.
+	s := newProblemScanner("build", []*problemMatcher{{patterns: []problemPattern{{}}}}, &problemLog{})
+	w := s.writer()
+	if _, err := w.Write([]byte("one\ntwo\n")); err != nil {
+		t.Fatal(err)
+	}
+	_ = w.Close()
+	var pe *PanicError
+	if err := s.err(); !errors.As(err, &pe) || pe.Label != "build" || len(pe.Stack) == 0 {
+		t.Fatalf("err = %v; want the matcher's panic", err)
+	}
+}
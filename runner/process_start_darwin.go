@@ -0,0 +1,14 @@
+package runner
+
+import "golang.org/x/sys/unix"
+
+// processStart returns when the process pid started (see sameProcess), in microseconds since
+// the epoch, or 0 if that can't be told.
+func processStart(pid int) uint64 {
+	kp, err := unix.SysctlKinfoProc("kern.proc.pid", pid)
+	if err != nil || kp.Proc.P_pid != int32(pid) {
+		return 0
+	}
+	t := kp.Proc.P_starttime
+	return uint64(t.Sec)*1e6 + uint64(t.Usec)
+}
@@ -0,0 +1,24 @@
+package runner
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// processStart returns when the process pid started (see sameProcess), in clock ticks since
+// boot, or 0 if that can't be told.
+func processStart(pid int) uint64 {
+	b, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return 0
+	}
+	// The command name, in parentheses, may hold spaces; starttime is the 20th field after it.
+	s := string(b)
+	fields := strings.Fields(s[strings.LastIndexByte(s, ')')+1:])
+	if len(fields) < 20 {
+		return 0
+	}
+	n, _ := strconv.ParseUint(fields[19], 10, 64)
+	return n
+}
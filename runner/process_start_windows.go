@@ -0,0 +1,18 @@
+package runner
+
+import "syscall"
+
+// processStart returns when the process pid started (see sameProcess), as a FILETIME, or 0 if
+// that can't be told.
+func processStart(pid int) uint64 {
+	h, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return 0
+	}
+	defer syscall.CloseHandle(h)
+	var created, exited, kernel, user syscall.Filetime
+	if syscall.GetProcessTimes(h, &created, &exited, &kernel, &user) != nil {
+		return 0
+	}
+	return uint64(created.HighDateTime)<<32 | uint64(created.LowDateTime)
+}
@@ -0,0 +1,22 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// quiet reports whether progress lines (sequence steps, watchers' compile cycles) are left out
+// (--quiet or $VSTASK_QUIET=1).
+func quiet(opts Options) bool {
+	return opts.Quiet || os.Getenv("VSTASK_QUIET") == "1"
+}
+
+// stepProgress is the progress line of step i (from 0) of a sequence of n dependencies, prev
+// being how long the steps before it took: "step 3/7: compile (prev steps 12.4s)".
+func stepProgress(i, n int, label string, prev time.Duration) string {
+	if i == 0 {
+		return fmt.Sprintf("step 1/%d: %s", n, label)
+	}
+	return fmt.Sprintf("step %d/%d: %s (prev steps %s)", i+1, n, label, roundDuration(prev))
+}
@@ -0,0 +1,92 @@
+package runner
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+var quoteSeeds = []string{
+	"", "plain", "hello world", "a\"b", `C:\Program Files\x`, `trailing\`, "tab\there",
+	"line\nbreak", "it's", "#hash", "~tilde", "a;b&c|d", "(p)", "<in>", "*?[x]", "x=y", "-flag",
+	"^caret", "!bang", "é ünïcode",
+}
+
+// FuzzPosixQuoteForShell checks that an argument quoted by posixQuoteForShell reaches the
+// program as it was. $ and ` are left for the shell to expand, on purpose (see commandLine),
+// so arguments holding them are skipped.
+func FuzzPosixQuoteForShell(f *testing.F) {
+	if runtime.GOOS == "windows" {
+		f.Skip("POSIX shell integration")
+	}
+	for _, s := range quoteSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		if strings.ContainsAny(s, "$`\x00") {
+			t.Skip()
+		}
+		q := posixQuoteForShell(s)
+		out, err := exec.Command("/bin/sh", "-c", "printf '%s' "+q).Output()
+		if err != nil {
+			t.Fatalf("sh -c %q: %v", "printf '%s' "+q, err)
+		}
+		if string(out) != s {
+			t.Fatalf("posixQuoteForShell(%q) = %s, which the shell reads as %q", s, q, out)
+		}
+	})
+}
+
+// FuzzWinQuote checks that an argument quoted by winQuote reaches the program as it was,
+// through cmd.exe. %VAR% expands even inside quotes, so arguments holding % are skipped, as
+// are line breaks, which end the command.
+func FuzzWinQuote(f *testing.F) {
+	if runtime.GOOS != "windows" {
+		f.Skip("cmd.exe integration")
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, s := range quoteSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		if strings.ContainsAny(s, "%\r\n\x00") {
+			t.Skip()
+		}
+		line := commandLine(exe, []string{"-test.run=^TestArgsHelper$", "--", s}, shellCmd)
+		cmd := exec.Command("cmd.exe", "/C", line)
+		cmdExeQuoting(cmd)
+		cmd.Env = append(os.Environ(), "VSTASK_ARGS_HELPER=1")
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+		var got []string
+		if err := json.Unmarshal(out, &got); err != nil {
+			t.Fatalf("helper output %q: %v", out, err)
+		}
+		if len(got) != 1 || got[0] != s {
+			t.Fatalf("winQuote(%q) = %s, which the program reads as %q", s, winQuote(s), got)
+		}
+	})
+}
+
+// TestArgsHelper isn't a test: FuzzWinQuote runs the test binary as a program that prints the
+// arguments after "--" as a JSON array.
+func TestArgsHelper(t *testing.T) {
+	if os.Getenv("VSTASK_ARGS_HELPER") != "1" {
+		t.Skip("helper process")
+	}
+	for i, a := range os.Args {
+		if a == "--" {
+			_ = json.NewEncoder(os.Stdout).Encode(os.Args[i+1:])
+			break
+		}
+	}
+	os.Exit(0)
+}
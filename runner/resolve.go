@@ -0,0 +1,239 @@
+package runner
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// resolvedTask is a task after the resolution pipeline, ready for buildCmd.
+type resolvedTask struct {
+	Task tasks.Task // effective task with every string field substituted
+	Name string     // instance display name, e.g. "deploy [env=prod]" (see instanceName)
+	Cwd  string     // absolute working directory
+	Env  []string   // process env merged with options.env
+}
+
+// resolveTask runs the single, ordered resolution pipeline used for every task field
+// (cwd, env, path, command, args, script, shell options, exports.file, sandbox.writable and
+// healthCheck.command):
+//
+//  1. platform merge: apply windows/osx/linux overrides
+//  2. inputs: prompt for all ${input:*} references up front, then substitute them
+//  3. variables: substitute VS Code variables (${workspaceFolder}, ${cwd}, ...) and ${env:*}
+//     (a leading "~" or "~user" in cwd, env values and paths then expands to the home directory)
+//  4. validation: the cwd and options.path entries must exist; unresolved ${...} references
+//     are reported
+//
+// Input defaults/commands go through the same input → variable order (see InputResolver.interpolate).
+// The cwd is resolved first (with ${cwd} = the process cwd) so the other fields can see the
+// task's effective ${cwd}.
+func resolveTask(t tasks.Task, workspace string, r *InputResolver) (resolvedTask, error) {
+	// 1) platform merge (copying slices/maps so the caller's task is never mutated)
+	eff := cloneTask(tasks.ApplyPlatformOverrides(t))
+
+	// 2) inputs
+	if err := promptInputsForTask(eff, r); err != nil {
+		return resolvedTask{}, err
+	}
+	name := instanceName(eff, r) // from the ${input:*} references, before they're substituted
+
+	// 3) variables — cwd first
+	cwd := workspace
+	if eff.Options != nil && eff.Options.Cwd != "" {
+		pre := buildVSCodeVarMapWithCWD(workspace, mustGetwd())
+		r.addTempVars(pre, t.Label)
+		r.addFileVars(pre, workspace)
+		addPosixVars(pre, forwardSlashes(eff))
+		cwdr := expandHome(resolveField(eff.Options.Cwd, r, pre))
+		if filepath.IsAbs(cwdr) {
+			cwd = cwdr
+		} else {
+			cwd = filepath.Join(workspace, cwdr)
+		}
+		eff.Options.Cwd = cwd
+	}
+	vars := buildVSCodeVarMapWithCWD(workspace, cwd)
+	r.addTempVars(vars, t.Label)
+	r.addFileVars(vars, workspace)
+	addPosixVars(vars, forwardSlashes(eff))
+
+	if eff.Options != nil {
+		if sh := eff.Options.Shell; sh != nil {
+			sh.Executable = expandHome(resolveField(sh.Executable, r, vars))
+			for i := range sh.Args {
+				sh.Args[i] = resolveField(sh.Args[i], r, vars)
+			}
+		}
+	}
+	// Git Bash and MSYS2 want /c/-style paths on their command lines.
+	cmdVars := vars
+	if isShellTask(eff) {
+		if exe, _ := taskShell(eff); msysShell(exe) {
+			cmdVars = msysVars(vars)
+		}
+	}
+
+	eff.Command = resolveField(eff.Command, r, cmdVars)
+	eff.Script = resolveField(eff.Script, r, vars)
+	for i := range eff.Args {
+		eff.Args[i] = resolveField(eff.Args[i], r, cmdVars)
+	}
+	env := os.Environ()
+	if vars["runTemp"] != "" {
+		// Scripts get the scratch directories without having to pass them as arguments.
+		env = mergeEnv(env, map[string]string{"VSTASK_RUN_TEMP": vars["runTemp"], "VSTASK_TASK_TEMP": vars["taskTemp"]})
+	}
+	if eff.Options != nil {
+		if len(eff.Options.Env) > 0 {
+			for k, v := range eff.Options.Env {
+				eff.Options.Env[k] = expandHome(resolveField(v, r, vars))
+			}
+			env = mergeEnv(env, eff.Options.Env)
+		}
+		for i, p := range eff.Options.Path {
+			if p = expandHome(resolveField(p, r, vars)); !filepath.IsAbs(p) {
+				p = filepath.Join(cwd, p)
+			}
+			eff.Options.Path[i] = p
+		}
+		env = prependPath(env, eff.Options.Path)
+	}
+
+	if sb := eff.Sandbox; sb != nil {
+		for i, w := range sb.Writable {
+			if w = expandHome(resolveField(w, r, vars)); !filepath.IsAbs(w) {
+				w = filepath.Join(cwd, w)
+			}
+			sb.Writable[i] = w
+		}
+	}
+	if hc := eff.HealthCheck; hc != nil {
+		hc.Command = resolveField(hc.Command, r, vars)
+	}
+	if x := eff.Exports; x != nil && x.File != "" {
+		x.File = expandHome(resolveField(x.File, r, vars))
+		if !filepath.IsAbs(x.File) {
+			x.File = filepath.Join(cwd, x.File)
+		}
+	}
+
+	// 4) validation
+	if info, err := os.Stat(cwd); err != nil || !info.IsDir() {
+		return resolvedTask{}, fmt.Errorf("task %q: working directory does not exist: %s", t.Label, cwd)
+	}
+	if eff.Options != nil {
+		for _, p := range eff.Options.Path {
+			if info, err := os.Stat(p); err != nil || !info.IsDir() {
+				return resolvedTask{}, fmt.Errorf("task %q: options.path: not a directory: %s", t.Label, p)
+			}
+		}
+	}
+	if unresolved := unresolvedVars(eff); len(unresolved) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: task %q has unresolved variables: %s\n", t.Label, strings.Join(unresolved, ", "))
+	}
+
+	return resolvedTask{Task: eff, Name: name, Cwd: cwd, Env: env}, nil
+}
+
+// resolveField applies pipeline stages 2 and 3 to one string field.
+func resolveField(s string, r *InputResolver, vars map[string]string) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+	s = replaceInputs(s, r)
+	s = substituteVars(s, vars)
+	return substituteEnv(s)
+}
+
+var reAnyVar = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// vscodeVarNames are VS Code's predefined variables; left over after substitution,
+// they mean the value isn't available outside the editor.
+var vscodeVarNames = map[string]bool{
+	"workspaceFolder": true, "workspaceFolderBasename": true, "file": true,
+	"fileWorkspaceFolder": true, "relativeFile": true, "relativeFileDirname": true,
+	"fileBasename": true, "fileBasenameNoExtension": true, "fileExtname": true,
+	"fileDirname": true, "fileDirnameBasename": true, "cwd": true, "lineNumber": true,
+	"selectedText": true, "execPath": true, "defaultBuildTask": true,
+	"pathSeparator": true, "userHome": true, "runTemp": true, "taskTemp": true,
+}
+
+func init() {
+	for _, n := range pathVarNames {
+		vscodeVarNames[n+"Posix"] = true
+	}
+}
+
+// unresolvedVars lists VS Code references (predefined names or ${ns:*} forms) still
+// present in a resolved task's fields. Plain ${NAME} is left alone: it's usually
+// shell parameter expansion.
+func unresolvedVars(t tasks.Task) []string {
+	seen := map[string]struct{}{}
+	grab := func(s string) {
+		for _, m := range reAnyVar.FindAllStringSubmatch(s, -1) {
+			if strings.Contains(m[1], ":") || vscodeVarNames[m[1]] {
+				seen[m[0]] = struct{}{}
+			}
+		}
+	}
+	grab(t.Command)
+	grab(t.Script)
+	for _, a := range t.Args {
+		grab(a)
+	}
+	if t.Options != nil {
+		grab(t.Options.Cwd)
+		for _, v := range t.Options.Env {
+			grab(v)
+		}
+		for _, p := range t.Options.Path {
+			grab(p)
+		}
+		if t.Options.Shell != nil {
+			grab(t.Options.Shell.Executable)
+			for _, a := range t.Options.Shell.Args {
+				grab(a)
+			}
+		}
+	}
+	out := slices.Collect(maps.Keys(seen))
+	slices.Sort(out)
+	return out
+}
+
+// cloneTask copies the parts of a task the pipeline rewrites (args, options, env, shell, exports, sandbox).
+func cloneTask(t tasks.Task) tasks.Task {
+	t.Args = slices.Clone(t.Args)
+	if t.Options != nil {
+		opts := *t.Options
+		opts.Env = maps.Clone(opts.Env)
+		opts.Path = slices.Clone(opts.Path)
+		if opts.Shell != nil {
+			sh := *opts.Shell
+			sh.Args = slices.Clone(sh.Args)
+			opts.Shell = &sh
+		}
+		t.Options = &opts
+	}
+	if t.Exports != nil {
+		x := *t.Exports
+		t.Exports = &x
+	}
+	if t.HealthCheck != nil {
+		hc := *t.HealthCheck
+		t.HealthCheck = &hc
+	}
+	if t.Sandbox != nil {
+		sb := *t.Sandbox
+		sb.Writable = slices.Clone(sb.Writable)
+		t.Sandbox = &sb
+	}
+	return t
+}
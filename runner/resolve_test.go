@@ -0,0 +1,104 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// Every field goes through the same pipeline, so the same template must resolve
+// to the same value wherever it appears.
+func TestResolveTask_IdenticalSemanticsForEveryField(t *testing.T) {
+	isolatePMDetectionToDefault(t)
+	t.Setenv("VSTASK_INPUT_X", "val")
+	t.Setenv("FOO", "bar")
+
+	workspace := filepath.Join(t.TempDir(), "ws")
+	const tmpl = "${input:x}-${workspaceFolderBasename}-${env:FOO}"
+	const want = "val-ws-bar"
+	if err := os.MkdirAll(filepath.Join(workspace, want), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	tk := tasks.Task{
+		Label:   "all-fields",
+		Command: tmpl,
+		Script:  tmpl,
+		Args:    []string{tmpl},
+		Options: &tasks.Options{
+			Cwd:   tmpl,
+			Env:   map[string]string{"V": tmpl},
+			Shell: &tasks.ShellOptions{Executable: tmpl, Args: []string{tmpl}},
+		},
+	}
+
+	rt, err := resolveTask(tk, workspace, NewInputResolver(nil))
+	if err != nil {
+		t.Fatalf("resolveTask err: %v", err)
+	}
+
+	fields := map[string]string{
+		"command":       rt.Task.Command,
+		"script":        rt.Task.Script,
+		"args[0]":       rt.Task.Args[0],
+		"cwd":           filepath.Base(rt.Cwd),
+		"options.cwd":   filepath.Base(rt.Task.Options.Cwd),
+		"env.V":         rt.Task.Options.Env["V"],
+		"process env V": envToMap(rt.Env)["V"],
+		"shell.exe":     rt.Task.Options.Shell.Executable,
+		"shell.args[0]": rt.Task.Options.Shell.Args[0],
+	}
+	for name, got := range fields {
+		if got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	// The caller's task is left untouched.
+	if tk.Args[0] != tmpl || tk.Options.Env["V"] != tmpl || tk.Options.Shell.Args[0] != tmpl {
+		t.Fatalf("resolveTask mutated its input: %+v", tk)
+	}
+}
+
+func TestResolveTask_CwdSeesProcessCwdOtherFieldsSeeTaskCwd(t *testing.T) {
+	workspace := t.TempDir()
+	sub := filepath.Join(workspace, "sub")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	rt, err := resolveTask(tasks.Task{
+		Command: "echo ${cwd}",
+		Options: &tasks.Options{Cwd: "sub"},
+	}, workspace, NewInputResolver(nil))
+	if err != nil {
+		t.Fatalf("resolveTask err: %v", err)
+	}
+	if rt.Task.Command != "echo "+sub {
+		t.Fatalf("command = %q, want ${cwd} = task cwd %q", rt.Task.Command, sub)
+	}
+}
+
+func TestResolveTask_MissingCwd(t *testing.T) {
+	_, err := resolveTask(tasks.Task{
+		Label:   "bad",
+		Options: &tasks.Options{Cwd: "does/not/exist"},
+	}, t.TempDir(), NewInputResolver(nil))
+	if err == nil || !strings.Contains(err.Error(), "working directory does not exist") {
+		t.Fatalf("err = %v, want missing cwd error", err)
+	}
+}
+
+func TestUnresolvedVars(t *testing.T) {
+	got := unresolvedVars(tasks.Task{
+		Command: "echo ${HOME} ${file} ${config:editor.tabSize}",
+		Args:    []string{"${file}"},
+	})
+	want := []string{"${config:editor.tabSize}", "${file}"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("unresolvedVars = %v, want %v", got, want)
+	}
+}
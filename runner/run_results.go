@@ -0,0 +1,115 @@
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/chenasraf/vstask/tasks"
+	"github.com/chenasraf/vstask/utils"
+)
+
+const runResultsFile = "last-results.json"
+
+// taskResult is the outcome of one task in a run.
+type taskResult struct {
+	OK          bool              `json:"ok"`
+	Fingerprint string            `json:"fingerprint,omitempty"`
+	Exports     map[string]string `json:"exports,omitempty"` // reused along with the result
+	Panic       string            `json:"panic,omitempty"`   // the stack trace, if vstask panicked running it
+}
+
+// runResults records how each task of the last top-level run (dependencies included) ended.
+// Tasks are keyed by their dependency reference ("folder: label" for other folders).
+type runResults struct {
+	Label string                `json:"label"`
+	Time  time.Time             `json:"time"`
+	Tasks map[string]taskResult `json:"tasks"`
+	// Executables are the process tasks' commands as resolved in hermetic mode.
+	Executables map[string]string `json:"executables,omitempty"`
+
+	mu sync.Mutex
+}
+
+func newRunResults(label string) *runResults {
+	return &runResults{Label: label, Time: time.Now(), Tasks: map[string]taskResult{}, Executables: map[string]string{}}
+}
+
+func (rr *runResults) record(name string, t tasks.Task, folder string, exports map[string]string, err error) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	res := taskResult{OK: err == nil, Fingerprint: fingerprint(t, folder), Exports: exports}
+	var pe *PanicError
+	if errors.As(err, &pe) {
+		res.Panic = fmt.Sprintf("%v\n%s", pe.Value, pe.Stack)
+	}
+	rr.Tasks[name] = res
+}
+
+// reusable reports whether name succeeded last time with the same definition, so a retry can skip it.
+// Tasks that prompt for inputs are never reused: their result depends on the answers.
+func (rr *runResults) reusable(name string, t tasks.Task, folder string) bool {
+	if rr == nil || len(tasks.ApplyPlatformOverrides(t).InputRefs()) > 0 {
+		return false
+	}
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	res, ok := rr.Tasks[name]
+	return ok && res.OK && res.Fingerprint == fingerprint(t, folder)
+}
+
+// failed reports whether any task of the run failed or never finished.
+func (rr *runResults) failed() bool {
+	for _, res := range rr.Tasks {
+		if !res.OK {
+			return true
+		}
+	}
+	return false
+}
+
+// fingerprint identifies a task definition in a folder; any edit to the task changes it.
+func fingerprint(t tasks.Task, folder string) string {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(append([]byte(folder+"\x00"), b...))
+	return hex.EncodeToString(sum[:])
+}
+
+func runResultsPath(workspace string) (string, error) {
+	dir, err := utils.WorkspaceStateDir(workspace)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, runResultsFile), nil
+}
+
+func saveRunResults(workspace string, rr *runResults) error {
+	p, err := runResultsPath(workspace)
+	if err != nil {
+		return err
+	}
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	return utils.WriteJSONFile(p, rr)
+}
+
+// loadRunResults returns the results of the last run in workspace, or nil if none was recorded.
+func loadRunResults(workspace string) *runResults {
+	p, err := runResultsPath(workspace)
+	if err != nil {
+		return nil
+	}
+	var rr runResults
+	if err := utils.ReadJSONFile(p, &rr); err != nil || rr.Label == "" {
+		return nil
+	}
+	return &rr
+}
@@ -0,0 +1,111 @@
+package runner
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func TestRunResults_Reusable(t *testing.T) {
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	ws := t.TempDir()
+	lint := tasks.Task{Label: "lint", Command: "golangci-lint run"}
+	test := tasks.Task{Label: "test", Command: "go test ./..."}
+	ask := tasks.Task{Label: "ask", Command: "echo ${input:name}"}
+
+	rr := newRunResults("ci")
+	rr.record("lint", lint, ws, nil, nil)
+	rr.record("test", test, ws, nil, errors.New("exit status 1"))
+	rr.record("ask", ask, ws, nil, nil)
+	if !rr.failed() {
+		t.Fatal("expected a failed run")
+	}
+	if err := saveRunResults(ws, rr); err != nil {
+		t.Fatal(err)
+	}
+
+	prev := loadRunResults(ws)
+	if prev == nil || prev.Label != "ci" {
+		t.Fatalf("loaded %+v", prev)
+	}
+	if !prev.reusable("lint", lint, ws) {
+		t.Error("lint succeeded and is unchanged")
+	}
+	if prev.reusable("test", test, ws) {
+		t.Error("test failed")
+	}
+	if prev.reusable("ask", ask, ws) {
+		t.Error("tasks with inputs are never reused")
+	}
+	changed := lint
+	changed.Args = []string{"--fix"}
+	if prev.reusable("lint", changed, ws) {
+		t.Error("lint changed since it succeeded")
+	}
+	if prev.reusable("lint", lint, t.TempDir()) {
+		t.Error("lint ran in another folder")
+	}
+	if prev.reusable("build", lint, ws) {
+		t.Error("build never ran")
+	}
+	var none *runResults
+	if none.reusable("lint", lint, ws) {
+		t.Error("nil results reuse nothing")
+	}
+}
+
+func TestRetryFailed(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".vscode", "tasks.json"), `{
+  "version": "2.0.0",
+  "tasks": [
+    { "label": "lint", "type": "shell", "command": "echo lint >> runs.log" },
+    { "label": "test", "type": "shell", "command": "echo test >> runs.log; test ! -e fail" },
+    { "label": "ci", "type": "shell", "command": "echo ci >> runs.log", "dependsOn": ["lint", "test"], "dependsOrder": "sequence" }
+  ]
+}`)
+	writeFile(t, filepath.Join(dir, "fail"), "")
+	t.Chdir(dir)
+
+	if ran, err := RetryFailed(Options{}); err == nil || ran {
+		t.Fatalf("nothing ran yet: %v, %v", ran, err)
+	}
+	all, err := tasks.GetTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ci, err := tasks.FindTask(all, "ci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RunTask(ci); err == nil {
+		t.Fatal("expected test to fail")
+	}
+
+	if err := os.Remove(filepath.Join(dir, "fail")); err != nil {
+		t.Fatal(err)
+	}
+	if ran, err := RetryFailed(Options{}); err != nil || !ran {
+		t.Fatalf("retry: %v, %v", ran, err)
+	}
+	if ran, err := RetryFailed(Options{}); err != nil || ran {
+		t.Fatalf("the retry succeeded, nothing left: %v, %v", ran, err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "runs.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Fields(string(b)), []string{"lint", "test", "test", "ci"}; strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("runs = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,63 @@
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/chenasraf/vstask/utils"
+)
+
+// runTempDirName is the directory under the workspace state dir holding per-run scratch dirs.
+// Leftovers (e.g. from a killed run) are removed by `vstask clean`.
+const runTempDirName = "tmp"
+
+// newRunTemp creates the scratch directory of one run (${runTemp}), shared by every task in
+// the run. The returned func removes it, unless $VSTASK_KEEP_TEMP=1.
+func newRunTemp(workspace string) (string, func(), error) {
+	base := os.TempDir()
+	if dir, err := utils.WorkspaceStateDir(workspace); err == nil {
+		base = filepath.Join(dir, runTempDirName)
+	}
+	if err := os.MkdirAll(base, 0o755); err != nil {
+		return "", nil, err
+	}
+	dir, err := os.MkdirTemp(base, "run-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() {
+		if os.Getenv("VSTASK_KEEP_TEMP") == "1" {
+			utils.Debugf("kept run temp dir %s", dir)
+			return
+		}
+		_ = os.RemoveAll(dir)
+	}
+	return dir, cleanup, nil
+}
+
+var reUnsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// taskTempDir creates (if needed) and returns the task's own directory inside the run's
+// scratch directory (${taskTemp}). Its name is the label made file-safe plus a short hash of
+// it, so labels that differ only in unsafe characters get different directories.
+func taskTempDir(runTemp, label string) (string, error) {
+	sum := sha256.Sum256([]byte(label))
+	name := reUnsafeFileChars.ReplaceAllString(label, "_") + "-" + hex.EncodeToString(sum[:])[:8]
+	dir := filepath.Join(runTemp, name)
+	return dir, os.MkdirAll(dir, 0o755)
+}
+
+// addTempVars sets ${runTemp} and ${taskTemp} for the task labelled label, creating its
+// directory. It does nothing outside a run.
+func (r *InputResolver) addTempVars(vars map[string]string, label string) {
+	if r == nil || r.runTemp == "" {
+		return
+	}
+	vars["runTemp"] = r.runTemp
+	if dir, err := taskTempDir(r.runTemp, label); err == nil {
+		vars["taskTemp"] = dir
+	}
+}
@@ -0,0 +1,88 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func TestNewRunTemp(t *testing.T) {
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	ws := t.TempDir()
+
+	dir, cleanup, err := newRunTemp(ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(filepath.Dir(dir)) != runTempDirName {
+		t.Fatalf("run temp %s is not under the workspace state dir", dir)
+	}
+	task, err := taskTempDir(dir, "build: api/v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(task) != dir || !strings.HasPrefix(filepath.Base(task), "build_api_v2-") {
+		t.Fatalf("task temp = %s, want %s/build_api_v2-<hash>", task, dir)
+	}
+	if other, err := taskTempDir(dir, "build/api/v2"); err != nil || other == task {
+		t.Fatalf("labels differing in unsafe characters share %s (%v)", other, err)
+	}
+	cleanup()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("run temp not removed: %v", err)
+	}
+
+	t.Setenv("VSTASK_KEEP_TEMP", "1")
+	dir, cleanup, err = newRunTemp(ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleanup()
+	if _, err := os.Stat(dir); err != nil {
+		t.Fatalf("VSTASK_KEEP_TEMP=1 should keep the run temp: %v", err)
+	}
+}
+
+func TestRunTemp_SharedAcrossDependencies(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".vscode", "tasks.json"), `{
+  "version": "2.0.0",
+  "tasks": [
+    { "label": "fetch", "type": "shell", "command": "echo data > ${runTemp}/shared.txt && test -d ${taskTemp}" },
+    { "label": "use", "type": "shell", "command": "cat \"$VSTASK_RUN_TEMP/shared.txt\" > out.txt && echo ${runTemp} > where.txt",
+      "dependsOn": ["fetch"] }
+  ]
+}`)
+	t.Chdir(dir)
+
+	all, err := tasks.GetTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	use, err := tasks.FindTask(all, "use")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RunTask(use); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "out.txt")); strings.TrimSpace(string(b)) != "data" {
+		t.Fatalf("out.txt = %q", b)
+	}
+	b, _ := os.ReadFile(filepath.Join(dir, "where.txt"))
+	runTemp := strings.TrimSpace(string(b))
+	if runTemp == "" {
+		t.Fatal("${runTemp} not substituted")
+	}
+	if _, err := os.Stat(runTemp); !os.IsNotExist(err) {
+		t.Fatalf("run temp %s not cleaned up: %v", runTemp, err)
+	}
+}
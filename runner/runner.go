@@ -1,31 +1,107 @@
 package runner
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"os/signal"
-	"path/filepath"
 	"regexp"
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/chenasraf/vstask/tasks"
-	"github.com/chenasraf/vstask/utils"
 )
 
+// Options adjust how Run executes a task. The zero value is a plain run.
+type Options struct {
+	// RetryFailed skips dependencies that succeeded in the previous run of the same task
+	// and haven't changed since, so only what failed (or never ran) runs again.
+	RetryFailed bool
+	// File, if set, provides ${file}, ${relativeFile}, ${lineNumber}, ... as if the file were
+	// open in the editor.
+	File *FileContext
+	// MaxParallel limits how many dependencies run at once (--max-parallel). 0 uses the
+	// configured limit ($VSTASK_JOBS or "vstask.maxParallel"), if any.
+	MaxParallel int
+	// DependsOrder, if set ("sequence" or "parallel"), is every task's dependsOrder for this
+	// run, whatever tasks.json says (--depends-order).
+	DependsOrder string
+	// StartAt, if set, skips the steps before this task in every sequence (a dependsOn with
+	// "dependsOrder": "sequence") leading to it, to resume a pipeline that failed there
+	// (--start-at; see selectSteps).
+	StartAt string
+	// Skip are dependencies not to run, wherever they're depended on (--skip).
+	Skip []string
+	// Fail are tasks (the one run or its dependencies) reported as failed without running them,
+	// once their own dependencies have run, to try out how a pipeline handles failures (--fail).
+	// Such a run isn't kept for --retry-failed or --last.
+	Fail []string
+	// Hermetic resolves process tasks' commands to absolute paths before anything runs, and
+	// fails if one is missing or ambiguous (--hermetic; see resolveExecutables).
+	Hermetic bool
+	// NoInput resolves ${input:*} without prompting, from the environment or the inputs'
+	// defaults, and fails listing the inputs that have neither (--no-input; see checkInputs).
+	NoInput bool
+	// Inputs are ${input:*} values by id (--input id=value). They take precedence over the
+	// environment and prompts.
+	Inputs map[string]string
+	// FailOnProblems fails a run whose tasks succeeded when their problem matchers found
+	// errors (--fail-on-problems; see problemsError).
+	FailOnProblems bool
+	// SARIF, if set, is the file the problems found are written to as a SARIF log when the
+	// run ends, whether it succeeded or not (--sarif; see writeSARIF).
+	SARIF string
+	// Quiet leaves out progress lines: the steps of sequence dependencies and, unless OnCycle
+	// is set, watchers' compile cycles (--quiet).
+	Quiet bool
+	// PrefixOutput starts each line of a task's output with its name, to tell apart tasks
+	// running in parallel (--prefix-output).
+	PrefixOutput bool
+	// Timestamps starts each line of a task's output with the time it was printed (--timestamps).
+	Timestamps bool
+	// OnCycle, if set, receives the compile cycles of background dependencies with begins and
+	// ends patterns (watchers) instead of them being printed, e.g. to log or notify them.
+	OnCycle func(CycleEvent)
+}
+
 // RunTask executes a task, resolving its dependsOn (sequence/parallel) and prompting for ${input:*}.
 func RunTask(task tasks.Task) error {
+	return Run(task, Options{})
+}
+
+// Run is RunTask with options.
+func Run(task tasks.Task, opts Options) error {
+	return runRoot(task, opts, false)
+}
+
+// RunTasks runs the tasks labeled labels in one run, as the dependencies of a task doing
+// nothing itself, in order ("sequence" or "parallel", the default): each task once, background
+// ones gating the rest until ready. When they're all background tasks, it returns once they've
+// exited (or on CTRL-C). opts are as for Run.
+func RunTasks(labels []string, order string, opts Options) error {
+	if len(labels) == 0 {
+		return errors.New("no tasks to run")
+	}
+	// Labeled so it's none of them (the graph's nodes are keyed by label).
+	t := tasks.Task{Label: "run " + strings.Join(labels, " + "), DependsOn: &tasks.DependsOn{}, DependsOrder: order}
+	for _, l := range labels {
+		t.DependsOn.Tasks = append(t.DependsOn.Tasks, tasks.TaskRef{Label: l})
+	}
+	return runRoot(t, opts, true)
+}
+
+// runRoot runs task; with compound, it only groups its dependencies (see RunTasks).
+func runRoot(task tasks.Task, opts Options, compound bool) (err error) {
 	// Load all tasks so we can resolve dependsOn by label.
 	all, err := tasks.GetTasks()
 	if err != nil {
 		return err
 	}
-	index := indexByLabel(all)
 
 	// Load inputs (best effort; if not present we'll fallback to generic prompting).
 	var inputs []tasks.Input
@@ -35,53 +111,136 @@ func RunTask(task tasks.Task) error {
 	resolver := NewInputResolver(inputs)
 
 	// Figure out workspace folder for substitutions.
-	root, err := utils.FindProjectRoot()
+	root, err := tasks.ProjectRoot()
 	if err != nil {
 		return err
 	}
+	runTemp, cleanupTemp, err := newRunTemp(root)
+	if err != nil {
+		return err
+	}
+	defer cleanupTemp()
+	resolver.runTemp = runTemp
+	resolver.file = opts.File
+	resolver.noInput = noInput(opts)
+	resolver.overrides = opts.Inputs
+	vars := buildVSCodeVarMapWithCWD(root, mustGetwd())
+	vars["runTemp"] = runTemp
+	resolver.addFileVars(vars, root)
+	addPosixVars(vars, forwardSlashes(task))
+	resolver.SetVars(vars)
+	_ = tasks.RecordSeenTasks(root) // best effort; only used by `vstask diff`
+	if compound {
+		for _, ref := range task.DependsOn.Tasks {
+			_ = tasks.RecordUsage(root, ref.Label) // best effort; shown in the picker and `vstask list --verbose`
+		}
+	} else {
+		if len(opts.Fail) == 0 { // a rehearsal isn't what to run again
+			_ = recordLastRun(root, task.Label) // best effort; for --last and the "last" default action
+		}
+		_ = tasks.RecordUsage(root, task.Label) // best effort; shown in the picker and `vstask list --verbose`
+	}
+	index := newDepIndex(root, all, resolver)
 
-	// Execute dependencies (if any), then this task.
-	if task.DependsOn != nil && len(task.DependsOn.Tasks) > 0 {
-		switch strings.ToLower(task.DependsOrder) {
-		case "sequence":
-			for _, lbl := range task.DependsOn.Tasks {
-				dep, ok := index[lbl]
-				if !ok {
-					return fmt.Errorf("dependsOn: task %q not found", lbl)
-				}
-				if err := runTaskInternal(dep, root, resolver, true); err != nil {
-					return fmt.Errorf("dependency %q failed: %w", lbl, err)
-				}
-			}
-		default: // parallel is VS Code's default
-			var wg sync.WaitGroup
-			errCh := make(chan error, len(task.DependsOn.Tasks))
-			for _, lbl := range task.DependsOn.Tasks {
-				depLbl := lbl
-				dep, ok := index[depLbl]
-				if !ok {
-					return fmt.Errorf("dependsOn: task %q not found", depLbl)
-				}
-				wg.Add(1)
-				go func(tp tasks.Task, name string) {
-					defer wg.Done()
-					if err := runTaskInternal(tp, root, resolver, true); err != nil {
-						errCh <- fmt.Errorf("dependency %q failed: %w", name, err)
-					}
-				}(dep, depLbl)
-			}
-			wg.Wait()
-			close(errCh)
-			for e := range errCh {
-				if e != nil {
-					return e
-				}
-			}
+	// Resolve the whole dependency graph up front so problems surface before anything runs.
+	graph := newTaskGraph(index)
+	graph.dependsOrder = opts.DependsOrder
+	node, err := graph.add(task, root, nil)
+	if err != nil {
+		return err
+	}
+	if err := checkTaskTypes(graph.byFolder()); err != nil {
+		return err
+	}
+	skip, err := selectSteps(node, opts.StartAt, opts.Skip)
+	if err != nil {
+		return err
+	}
+	fail, err := selectFailures(node, opts.Fail)
+	if err != nil {
+		return err
+	}
+	if resolver.noInput {
+		if err := graph.checkInputs(resolver); err != nil {
+			return err
+		}
+	}
+	if hermetic(opts) {
+		if err := graph.resolveExecutables(resolver); err != nil {
+			return err
+		}
+	}
+
+	var prev *runResults
+	if opts.RetryFailed {
+		if prev = loadRunResults(root); prev != nil && prev.Label != task.Label {
+			prev = nil
+		}
+	}
+	results := newRunResults(task.Label)
+	for _, n := range graph.nodes {
+		if n.executable != "" {
+			results.Executables[n.name] = n.executable
 		}
 	}
+	if !compound && len(opts.Fail) == 0 { // there's no task to retry (or no real failure)
+		defer func() { _ = saveRunResults(root, results) }() // best effort; only used by --retry-failed
+	}
 
-	// Now run the main task fully (i.e., wait for process exit).
-	return runTaskInternal(task, root, resolver, false /* waitForReady */)
+	// Dependencies run before their dependents, each task once even if several depend on it.
+	limit := jobsLimit(root, opts.MaxParallel)
+	cycles := opts.OnCycle
+	if cycles == nil && quiet(opts) {
+		cycles = func(CycleEvent) {}
+	}
+	run := &graphRun{resolver: resolver, root: node, prev: prev, results: results, slots: newJobSlots(limit), workers: limit, skip: skip, fail: fail, compound: compound, background: &backgroundTasks{cycles: cycles, workspace: root}, problems: &problemLog{}, prefix: outputPrefix{name: opts.PrefixOutput, stamp: opts.Timestamps}}
+	if !quiet(opts) {
+		run.progress = os.Stderr
+	}
+	defer func() { printProblemSummary(os.Stdout, run.problems.problems(), mustGetwd()) }()
+	if opts.SARIF != "" {
+		defer func() {
+			if serr := writeSARIF(opts.SARIF, run.problems.problems(), root); serr != nil && err == nil {
+				err = fmt.Errorf("writing %s: %w", opts.SARIF, serr)
+			}
+		}()
+	}
+	defer run.background.stopAll()
+	defer run.handleSignals()()
+	if err := run.run(node, func() {}); err != nil {
+		return asCancelled(err)
+	}
+	if failOnProblems(opts) {
+		return run.problems.failure()
+	}
+	return nil
+}
+
+// RetryFailed re-runs the last task run in the current project, skipping the dependencies
+// that succeeded last time (see Options.RetryFailed). It reports whether anything ran:
+// when the last run had no failures there is nothing to retry. opts are as for Run.
+func RetryFailed(opts Options) (bool, error) {
+	root, err := tasks.ProjectRoot()
+	if err != nil {
+		return false, err
+	}
+	prev := loadRunResults(root)
+	if prev == nil {
+		return false, errors.New("no previous run to retry")
+	}
+	if !prev.failed() {
+		return false, nil
+	}
+	all, err := tasks.GetTasks()
+	if err != nil {
+		return false, err
+	}
+	task, err := tasks.FindTask(all, prev.Label)
+	if err != nil {
+		return false, err
+	}
+	opts.RetryFailed = true
+	return true, Run(task, opts)
 }
 
 // ----- Internal helpers -----
@@ -89,7 +248,7 @@ func RunTask(task tasks.Task) error {
 // startAndWaitReady starts cmd, mirrors output to the user's terminal, and:
 //   - if bg == nil: waits for process exit and returns its error (normal task).
 //   - if bg != nil and waitForReady == true: returns when "ready" (ActiveOnStart or BeginsRx match).
-//     The process continues running in the background.
+//     The process continues running in the background; cmd.exited is closed once it exits.
 //   - if bg != nil and waitForReady == false: behaves like a normal task (waits for exit).
 func startAndWaitReady(ctx context.Context, cmd *execCmdShim, interactive bool, bg *tasks.BgMatcher, waitForReady bool) error {
 	// If no background matcher is involved, defer to the existing path (PTY where possible).
@@ -114,42 +273,61 @@ func startAndWaitReady(ctx context.Context, cmd *execCmdShim, interactive bool,
 
 	readyCh := make(chan struct{})
 	once := sync.Once{}
+	panicked := make(chan *PanicError, 2) // one per stream
+	cycles := newCycleTracker(cmd.name, bg.BeginsRx, bg.EndsRx, bg.ActiveOnStart, cmd.cycles)
 
-	// Echo+scan a single stream.
+	// Echo+scan a single stream, a bounded chunk at a time (see scanLines).
 	scan := func(r io.Reader, w io.Writer) {
-		br := bufio.NewReader(r)
-		for {
-			line, err := br.ReadString('\n')
-			if len(line) > 0 {
-				// Mirror to user terminal
-				_, _ = io.WriteString(w, line)
-				// Check patterns for readiness
-				if bg != nil {
-					if bg.ActiveOnStart {
-						once.Do(func() { close(readyCh) })
-					} else if bg.BeginsRx != nil && bg.BeginsRx.MatchString(line) {
-						once.Do(func() { close(readyCh) })
-					}
-					// EndsRx is informative for cycles; not required to signal readiness.
-				}
+		if cmd.problems != nil {
+			pw := cmd.problems.writer()
+			defer func() { _ = pw.Close() }()
+			w = io.MultiWriter(w, pw)
+		}
+		if cmd.log != nil {
+			w = io.MultiWriter(w, cmd.log)
+		}
+		defer recoverPanic(cmd.name, func(pe *PanicError) {
+			panicked <- pe
+			_, _ = io.Copy(w, r) // the rest of the output still goes through, unscanned
+		})
+		scanLines(r, w, maxScanLine, func(c lineChunk) {
+			if cmd.recent != nil && c.start {
+				cmd.recent.add(c.text) // a long line's first chunk
 			}
-			if err != nil {
-				return
+			// Check patterns for readiness
+			var m []string
+			if bg.BeginsRx != nil {
+				m = c.match(bg.BeginsRx)
 			}
-		}
+			if bg.ActiveOnStart || m != nil {
+				once.Do(func() {
+					cmd.readyMatch = m
+					close(readyCh)
+				})
+			}
+			// EndsRx isn't needed for readiness; it ends the compile cycles reported once ready
+			// (and before: the initial compile).
+			cycles.chunk(c)
+		})
 	}
 
 	// Stream both pipes
-	go scan(stdout, os.Stdout)
-	go scan(stderr, os.Stderr)
+	out, errOut := cmd.stdout, cmd.stderr
+	if out == nil {
+		out, errOut = os.Stdout, os.Stderr
+	}
+	go scan(stdout, out)
+	go scan(stderr, errOut)
 
 	// If ActiveOnStart is set, the scanner will close readyCh immediately on first read loop tick.
 	// However, ensure we don't hang in case the tool prints nothing at all: still rely on ActiveOnStart.
 
 	// Wait until the context is done, process exits, or we become "ready"
 	waitErrCh := make(chan error, 1)
+	exited := make(chan struct{})
 	go func() {
 		waitErrCh <- cmd.Cmd.Wait()
+		close(exited)
 	}()
 
 	select {
@@ -160,75 +338,85 @@ func startAndWaitReady(ctx context.Context, cmd *execCmdShim, interactive bool,
 	case err := <-waitErrCh:
 		// Process exited before readiness; for a dep this means failure/finish.
 		return err
+	case pe := <-panicked:
+		// Readiness can't be told anymore. (Once ready, the task keeps running unscanned.)
+		_ = terminateProcessTree(cmd.Cmd)
+		<-waitErrCh
+		return pe
 	case <-readyCh:
-		// Deps: we are ready; do NOT wait for exit. Let it keep running.
+		// Deps: we are ready; do NOT wait for exit. Let it keep running (until the run ends,
+		// see backgroundTasks).
 		// NOTE: we intentionally DO NOT return the eventual exit code.
+		cmd.exited = exited
 		return nil
 	}
 }
 
-func runTaskInternal(t tasks.Task, workspace string, resolver *InputResolver, waitForReady bool) error {
-	eff := applyPlatformOverrides(t)
-
-	// ---- Prompt for all inputs referenced by this effective task BEFORE doing anything else ----
-	promptInputsForTask(eff, resolver)
-
-	// Prelim vars (process cwd)
-	preVars := buildVSCodeVarMapWithCWD(workspace, mustGetwd())
-
-	// Resolve the task's effective cwd (support ${input:*} + ${vscodeVar})
-	cwd := workspace
-	if eff.Options != nil && eff.Options.Cwd != "" {
-		cwdr := replaceInputs(eff.Options.Cwd, resolver)
-		cwdr = substituteVars(cwdr, preVars)
-		if filepath.IsAbs(cwdr) {
-			cwd = cwdr
-		} else {
-			cwd = filepath.Join(workspace, cwdr)
+// runTaskInternal runs one task with the env vars its dependencies exported (inherited)
+// and returns the ones it exports itself. pre is t already resolved (in hermetic mode, see
+// resolveExecutables), or nil to resolve it here. Its output lines start with prefix. A
+// readiness-gated task left running is added to bgs, and what its problem matchers find in its
+// output to problems.
+func runTaskInternal(t tasks.Task, pre *resolvedTask, workspace string, resolver *InputResolver, prefix outputPrefix, waitForReady bool, inherited map[string]string, bgs *backgroundTasks, problems *problemLog) (map[string]string, error) {
+	if tasks.ApplyPlatformOverrides(t).IsCompound() {
+		// Its dependencies ran; there's no command line to start.
+		fmt.Printf("Finished task: %s (dependencies only)\n", t.Label)
+		return nil, nil
+	}
+	var rt resolvedTask
+	if pre != nil {
+		rt = *pre
+	} else {
+		var err error
+		if rt, err = resolveTask(t, workspace, resolver); err != nil {
+			return nil, err
 		}
 	}
-
-	// Final vars with the effective cwd
-	vars := buildVSCodeVarMapWithCWD(workspace, cwd)
-
-	// Substitute inputs then vscode vars in command/args
-	eff.Command = replaceInputs(eff.Command, resolver)
-	eff.Command = substituteVars(eff.Command, vars)
-
-	for i := range eff.Args {
-		eff.Args[i] = replaceInputs(eff.Args[i], resolver)
-		eff.Args[i] = substituteVars(eff.Args[i], vars)
+	eff, err := passthroughTask(rt.Task, tasks.TypeCommands(workspace))
+	if err != nil {
+		return nil, err
 	}
-
-	// Environment
-	env := os.Environ()
-	if eff.Options != nil && len(eff.Options.Env) > 0 {
-		merged := make(map[string]string, len(eff.Options.Env))
-		for k, v := range eff.Options.Env {
-			val := replaceInputs(v, resolver)
-			val = substituteVars(val, vars)
-			merged[k] = val
-		}
-		env = mergeEnv(env, merged)
+	matchers, err := compileProblemMatchers(eff, workspace, buildVSCodeVarMapWithCWD(workspace, rt.Cwd))
+	if err != nil {
+		return nil, err
 	}
+	scanner := newProblemScanner(rt.Name, matchers, problems)
+	stdout, stderr := prefix.writers(rt.Name)
 
 	// Build the command and a cleanup hook
-	cmd, cleanup, err := buildCmd(eff, cwd, env)
-	if err != nil {
-		return err
+	env := inheritEnv(rt.Env, eff, inherited)
+	sandbox := func(cmd *exec.Cmd) (*exec.Cmd, error) {
+		cmd, err := sandboxCmd(cmd, eff.Sandbox, sandboxWritable(workspace, rt.Cwd, resolver.runTemp)...)
+		if err != nil {
+			return nil, fmt.Errorf("task %q: %w", t.Label, err)
+		}
+		return cmd, nil
 	}
+	prepare := func() (*exec.Cmd, func(), error) {
+		cmd, cleanup, err := buildCmd(eff, rt.Cwd, env)
+		if err != nil {
+			return nil, cleanup, err
+		}
+		if cmd, err = sandbox(cmd); err != nil {
+			return nil, cleanup, err
+		}
+		// Separate process group (Unix) so we can kill children too.
+		if runtime.GOOS != "windows" {
+			setProcessGroup(cmd)
+		}
+		return cmd, cleanup, nil
+	}
+	cmd, cleanup, err := prepare()
 	defer cleanup()
+	if err != nil {
+		return nil, err
+	}
 
 	// Make a context that cancels on SIGINT/SIGTERM.
 	ctx, stop := signal.NotifyContext(context.Background(), trapSignals()...)
 	defer stop()
 
-	// Separate process group (Unix) so we can kill children too.
-	if runtime.GOOS != "windows" {
-		setProcessGroup(cmd)
-	}
-
-	fmt.Printf("Running task: %s\n", t.Label)
+	fmt.Printf("Running task: %s\n", rt.Name)
 
 	// Extract background matcher (if any)
 	bg := extractBgMatcher(eff)
@@ -237,21 +425,91 @@ func runTaskInternal(t tasks.Task, workspace string, resolver *InputResolver, wa
 	// Otherwise use the standard startAndWait (PTY-enabled).
 	if bg != nil && waitForReady {
 		// We launch in stream/pipe mode to observe output; PTY is skipped for reliability.
-		return startAndWaitReady(ctx, &execCmdShim{Cmd: cmd}, false, bg, true)
+		// It hasn't finished when its dependents start, so it only exports what's in the line
+		// that made it ready (exports.ready).
+		// Its output is also logged, for `vstask logs`.
+		log := bgs.openLog(rt.Name)
+		shim := &execCmdShim{Cmd: cmd, recent: newRecentOutput(eff.HealthCheck), problems: scanner, name: rt.Name, cycles: bgs.cycleSink(), log: log, stdout: stdout, stderr: stderr}
+		if err := startAndWaitReady(ctx, shim, false, bg, true); err != nil {
+			if log != nil {
+				_ = log.Close()
+			}
+			return nil, err
+		}
+		var health *healthMonitor
+		if hc := eff.HealthCheck; hc != nil {
+			last := shim
+			restart := func(ctx context.Context) (*execCmdShim, error) {
+				if bgs.stoppedElsewhere(rt.Name, last) {
+					return nil, errStoppedElsewhere
+				}
+				cmd, _, err := prepare()
+				if err != nil {
+					return nil, err
+				}
+				fmt.Printf("Restarting task: %s\n", rt.Name)
+				shim := &execCmdShim{Cmd: cmd, recent: newRecentOutput(hc), problems: scanner, name: rt.Name, cycles: bgs.cycleSink(), log: log, stdout: stdout, stderr: stderr}
+				if err := startAndWaitReady(ctx, shim, false, bg, true); err != nil {
+					return shim, err
+				}
+				last = shim
+				bgs.register(rt.Name, t.Label, shim, log)
+				return shim, nil
+			}
+			if health, err = newHealthMonitor(rt.Name, *hc, rt.Cwd, env, sandbox, restart); err != nil {
+				_ = terminateProcessTree(cmd)
+				if log != nil {
+					_ = log.Close()
+				}
+				return nil, err
+			}
+		}
+		bgs.add(rt.Name, t.Label, shim, health, log)
+		if eff.Exports == nil || !eff.Exports.Ready {
+			return nil, nil
+		}
+		return readyExports(t.Label, bg.BeginsRx, shim.readyMatch), nil
 	}
 
-	// Normal path: try interactive (PTY) first if possible; else stdio.
-	err = startAndWait(ctx, cmd, true)
+	start := time.Now()
+	endScan := scanner.tee(cmd, stdout, stderr)
+	defer endScan()
+	var output *tailBuffer
+	if capturesOutput(eff) {
+		// exports.vars reads the output, so it's piped (no PTY, no tmux pane).
+		output = &tailBuffer{max: maxExportOutput}
+		cmd.Stdout = io.MultiWriter(cmd.Stdout, output)
+		err = startAndWait(ctx, cmd, false)
+	} else if panel := tmuxPanel(eff); panel != "" {
+		// presentation.panel "dedicated"/"new" under tmux: run in a pane of its own.
+		focus := eff.Presentation != nil && eff.Presentation.Focus
+		err = runInTmuxPane(ctx, cmd, panel, t.Label, workspace, focus)
+	} else {
+		tmuxFocus(ctx, eff)
+		// Normal path: try interactive (PTY) first if possible; else stdio.
+		err = startAndWait(ctx, cmd, true)
+		// If bash was blocked, retry with /bin/sh (see shellFallback)
+		if err != nil {
+			var shCmd *exec.Cmd
+			if shCmd, err = shellFallback(cmd, err); shCmd != nil {
+				err = startAndWait(ctx, shCmd, true)
+			}
+		}
+	}
+	endScan()
 	if err == nil {
-		return nil
+		err = scanner.err()
 	}
-	// If bash was blocked, retry with /bin/sh
-	if shouldFallbackToSh(cmd, err) {
-		if shCmd := rebuildWithSh(cmd); shCmd != nil {
-			return startAndWait(ctx, shCmd, true)
-		}
+	if err != nil {
+		return nil, err
+	}
+	// Durations feed the scheduler's run history; failing to record them isn't fatal.
+	_ = recordDuration(workspace, t.Label, time.Since(start))
+	var out []byte
+	if output != nil {
+		out = output.b
 	}
-	return err
+	return collectExports(eff, out)
 }
 
 // Background readiness matcher (VS Code parity)
@@ -293,4 +551,20 @@ func extractBgMatcher(t tasks.Task) *tasks.BgMatcher {
 
 type execCmdShim struct {
 	Cmd *exec.Cmd
+	// exited is closed once Cmd exits, for a process startAndWaitReady left running.
+	exited chan struct{}
+	// readyMatch is the beginsPattern match (with its groups) of the line that made it ready.
+	readyMatch []string
+	// recent, if set, collects its output lines for a health check (see healthMonitor).
+	recent *recentOutput
+	// problems, if set, matches its output against its problem matchers.
+	problems *problemScanner
+	// name and cycles, if set, report its compile cycles (see cycleTracker).
+	name   string
+	cycles func(CycleEvent)
+	// log, if set, gets a copy of its output (see BackgroundTasks).
+	log *backgroundLog
+	// stdout and stderr are where its output goes (both set or neither); os.Stdout and
+	// os.Stderr if nil.
+	stdout, stderr io.Writer
 }
@@ -3,15 +3,18 @@ package runner
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"maps"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/chenasraf/vstask/tasks"
 	"github.com/manifoldco/promptui"
@@ -25,58 +28,6 @@ func indexByLabel(ts []tasks.Task) map[string]tasks.Task {
 	return m
 }
 
-func applyPlatformOverrides(t tasks.Task) tasks.Task {
-	eff := t
-	switch runtime.GOOS {
-	case "windows":
-		if t.Windows != nil {
-			if t.Windows.Command != "" {
-				eff.Command = t.Windows.Command
-			}
-			if t.Windows.Args != nil {
-				eff.Args = append([]string(nil), t.Windows.Args...)
-			}
-			if t.Windows.Options != nil {
-				eff.Options = t.Windows.Options
-			}
-			if t.Windows.Presentation != nil {
-				eff.Presentation = t.Windows.Presentation
-			}
-		}
-	case "darwin":
-		if t.Osx != nil {
-			if t.Osx.Command != "" {
-				eff.Command = t.Osx.Command
-			}
-			if t.Osx.Args != nil {
-				eff.Args = append([]string(nil), t.Osx.Args...)
-			}
-			if t.Osx.Options != nil {
-				eff.Options = t.Osx.Options
-			}
-			if t.Osx.Presentation != nil {
-				eff.Presentation = t.Osx.Presentation
-			}
-		}
-	case "linux":
-		if t.Linux != nil {
-			if t.Linux.Command != "" {
-				eff.Command = t.Linux.Command
-			}
-			if t.Linux.Args != nil {
-				eff.Args = append([]string(nil), t.Linux.Args...)
-			}
-			if t.Linux.Options != nil {
-				eff.Options = t.Linux.Options
-			}
-			if t.Linux.Presentation != nil {
-				eff.Presentation = t.Linux.Presentation
-			}
-		}
-	}
-	return eff
-}
-
 // ----------------- Input resolution -----------------
 
 // Expectation for tasks.Input:
@@ -86,13 +37,26 @@ func applyPlatformOverrides(t tasks.Task) tasks.Task {
 //   Description string   `json:"description"`
 //   Default     string   `json:"default"`
 //   Password    bool     `json:"password"` // promptString only
-//   Options     []string `json:"options"`   // pickString only
+//   Options     []string `json:"options"`   // pickString/pickMany only
 //   Command     string   `json:"command"`   // command only
 // }
 
+// InputResolver resolves input values for a run. It's safe for concurrent use: parallel
+// dependencies resolve their inputs one at a time, so two prompts never share the terminal
+// and an input is never asked for (or its command run) twice.
 type InputResolver struct {
-	byID  map[string]tasks.Input
-	cache map[string]string
+	byID map[string]tasks.Input
+	vars map[string]string // VS Code variables available to input defaults/commands
+
+	mu     sync.Mutex // guards cache
+	cache  map[string]string
+	asking sync.Mutex // held while resolving, which may prompt
+
+	runTemp string       // the run's scratch directory (${runTemp}); empty outside Run
+	file    *FileContext // the run's ${file} and friends (see Options.File)
+	noInput bool         // never prompt: use defaults, or fail (see Options.NoInput)
+
+	overrides map[string]string // values given on the command line (see Options.Inputs)
 }
 
 func NewInputResolver(inputs []tasks.Input) *InputResolver {
@@ -106,42 +70,43 @@ func NewInputResolver(inputs []tasks.Input) *InputResolver {
 	}
 }
 
+// addInputs declares inputs from another workspace folder's tasks.json.
+// Ids already declared keep their definition.
+func (r *InputResolver) addInputs(inputs []tasks.Input) {
+	for _, in := range inputs {
+		if _, ok := r.byID[in.ID]; !ok {
+			r.byID[in.ID] = in
+		}
+	}
+}
+
 var reInput = regexp.MustCompile(`\$\{input:([^}]+)\}`)
 
+// errInputCycle is returned when inputs reference each other in a loop.
+var errInputCycle = errors.New("input reference cycle")
+
 // promptInputsForTask scans the effective task for ${input:*} and resolves all before running.
-func promptInputsForTask(t tasks.Task, r *InputResolver) {
+// Returns the first error that should abort the run (e.g. a prompt timing out with onTimeout "fail").
+func promptInputsForTask(t tasks.Task, r *InputResolver) error {
 	ids := collectInputRefsFromTask(t)
 	for _, id := range ids {
-		_, _ = r.Resolve(id) // cache it
+		if _, err := r.Resolve(id); abortsRun(err) {
+			return err
+		}
 	}
+	return nil
 }
 
-func collectInputRefsFromTask(t tasks.Task) []string {
-	seen := make(map[string]struct{})
-
-	grab := func(s string) {
-		for _, m := range reInput.FindAllStringSubmatch(s, -1) {
-			if len(m) == 2 {
-				seen[m[1]] = struct{}{}
-			}
-		}
-	}
+// abortsRun reports whether an input resolution error must stop the run, as opposed to
+// prompt errors which leave the value empty.
+func abortsRun(err error) bool {
+	var missing *missingInputError
+	return errors.Is(err, errPromptTimeout) || errors.Is(err, errInputEnv) || errors.Is(err, errInputCycle) ||
+		errors.Is(err, errInputInvalid) || errors.Is(err, errInputCommand) || errors.As(err, &missing)
+}
 
-	grab(t.Command)
-	for _, a := range t.Args {
-		grab(a)
-	}
-	if t.Options != nil {
-		grab(t.Options.Cwd)
-		for _, v := range t.Options.Env {
-			grab(v)
-		}
-	}
-	out := make([]string, 0, len(seen))
-	for id := range seen {
-		out = append(out, id)
-	}
-	return out
+func collectInputRefsFromTask(t tasks.Task) []string {
+	return t.InputRefs()
 }
 
 func replaceInputs(s string, r *InputResolver) string {
@@ -158,41 +123,127 @@ func replaceInputs(s string, r *InputResolver) string {
 	})
 }
 
+// SetVars sets the VS Code variables (e.g. ${workspaceFolder}) that input defaults
+// and command-input commands may reference.
+func (r *InputResolver) SetVars(vars map[string]string) {
+	r.vars = vars
+}
+
 // Resolve returns a value for an input id, prompting if necessary.
 // Caches values so the same id is only prompted once.
 func (r *InputResolver) Resolve(id string) (string, error) {
-	if v, ok := r.cache[id]; ok {
+	if v, ok := r.cached(id); ok {
+		return v, nil
+	}
+	r.asking.Lock()
+	defer r.asking.Unlock()
+	return r.resolve(id, nil)
+}
+
+// cached returns the value input id was resolved to, if it has been.
+func (r *InputResolver) cached(id string) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	v, ok := r.cache[id]
+	return v, ok
+}
+
+// remember records the value input id was resolved to.
+func (r *InputResolver) remember(id, v string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cache[id] = v
+}
+
+// resolve does the work of Resolve; stack holds the ids currently being resolved,
+// so inputs referencing each other through their defaults/commands can't loop.
+func (r *InputResolver) resolve(id string, stack []string) (string, error) {
+	if v, ok := r.cached(id); ok {
+		return v, nil
+	}
+	if slices.Contains(stack, id) {
+		return "", fmt.Errorf("%w: %s", errInputCycle, strings.Join(append(stack, id), " -> "))
+	}
+	stack = append(stack, id)
+
+	// Command-line value (--input id=value)
+	if v, ok := r.overrides[id]; ok {
+		if err := r.checkValue(id, v); err != nil {
+			return "", err
+		}
+		r.remember(id, v)
 		return v, nil
 	}
 
-	// Env override (handy for CI): VSTASK_INPUT_<UPPER_ID>
-	if env := os.Getenv("VSTASK_INPUT_" + strings.ToUpper(id)); env != "" {
-		r.cache[id] = env
+	// Env override (handy for CI): VSTASK_INPUT_<ID> or the VSTASK_INPUTS JSON object
+	if env, ok, err := lookupInputEnv(id); err != nil {
+		return "", err
+	} else if ok {
+		if err := r.checkValue(id, env); err != nil {
+			return "", err
+		}
+		r.remember(id, env)
 		return env, nil
 	}
 
 	in, ok := r.byID[id]
 	if !ok {
 		// Unknown input: fallback to simple line prompt.
+		if r.noInput {
+			return "", &missingInputError{ID: id}
+		}
 		val, err := simpleLinePrompt(fmt.Sprintf("Enter value for %s", id), "")
 		if err != nil {
 			return "", err
 		}
-		r.cache[id] = val
+		r.remember(id, val)
 		return val, nil
 	}
 
+	// Defaults and commands may reference variables and other inputs.
+	var err error
+	if in.Default, err = r.interpolate(in.Default, stack); err != nil {
+		return "", err
+	}
+	if in.Command, err = r.interpolate(in.Command, stack); err != nil {
+		return "", err
+	}
+	if in.Cwd, err = r.interpolate(in.Cwd, stack); err != nil {
+		return "", err
+	}
+	if in.Args, err = r.interpolateJSON(in.Args, stack); err != nil {
+		return "", err
+	}
+	if len(in.Env) > 0 {
+		env := make(map[string]string, len(in.Env))
+		for k, v := range in.Env {
+			if env[k], err = r.interpolate(v, stack); err != nil {
+				return "", err
+			}
+		}
+		in.Env = env
+	}
+
 	switch strings.ToLower(in.Type) {
 	case "promptstring":
 		lbl := in.Description
 		if strings.TrimSpace(lbl) == "" {
 			lbl = fmt.Sprintf("Enter %s", in.ID)
 		}
-		val, err := promptString(lbl, in.Default, in.Password)
+		check, err := inputValidator(in)
 		if err != nil {
 			return "", err
 		}
-		r.cache[id] = val
+		val, err := r.ask(in, func(stdin io.ReadCloser) (string, error) {
+			return promptString(lbl, in.Default, in.Password, check, stdin)
+		})
+		if err != nil {
+			return "", err
+		}
+		if err := r.checkValue(id, val); err != nil {
+			return "", err // the default, used without asking
+		}
+		r.remember(id, val)
 		return val, nil
 
 	case "pickstring":
@@ -202,49 +253,94 @@ func (r *InputResolver) Resolve(id string) (string, error) {
 			if strings.TrimSpace(lbl) == "" {
 				lbl = fmt.Sprintf("Enter %s", in.ID)
 			}
-			val, err := promptString(lbl, in.Default, false)
+			val, err := r.ask(in, func(stdin io.ReadCloser) (string, error) {
+				return promptString(lbl, in.Default, false, nil, stdin)
+			})
 			if err != nil {
 				return "", err
 			}
-			r.cache[id] = val
+			r.remember(id, val)
 			return val, nil
 		}
-		val, err := promptSelect(in.DescriptionOrFallback(), in.Options, in.Default)
+		val, err := r.ask(in, func(stdin io.ReadCloser) (string, error) {
+			return promptSelect(in.DescriptionOrFallback(), in.Options, in.Default, stdin)
+		})
 		if err != nil {
 			return "", err
 		}
-		r.cache[id] = val
+		r.remember(id, val)
+		return val, nil
+
+	case "secret":
+		val, err := r.secret(in)
+		if err != nil {
+			return "", err
+		}
+		r.remember(id, val)
+		return val, nil
+
+	case "pickmany":
+		val, err := r.ask(in, func(stdin io.ReadCloser) (string, error) {
+			return promptPickMany(in, stdin)
+		})
+		if err != nil {
+			return "", err
+		}
+		r.remember(id, val)
 		return val, nil
 
 	case "command":
-		out := strings.TrimSpace(runInputShell(in.Command))
+		workspace := r.vars["workspaceFolder"]
+		policy, err := parseInputCachePolicy(in.Cache)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Input %q: %v\n", in.ID, err)
+		}
+		if val, ok := loadCachedInput(workspace, in, policy); ok {
+			r.remember(id, val)
+			return val, nil
+		}
+		out, err := runInputCommand(in, workspace)
+		if err != nil {
+			if !strings.EqualFold(in.OnError, "fallback") {
+				return "", fmt.Errorf("input %q: %w: %v", in.ID, errInputCommand, err)
+			}
+			fmt.Fprintf(os.Stderr, "Input %q: %v\n", in.ID, err)
+		} else if out != "" {
+			if err := storeCachedInput(workspace, in, policy, out); err != nil {
+				fmt.Fprintf(os.Stderr, "Input %q: caching failed: %v\n", in.ID, err)
+			}
+		}
 		if out == "" {
 			// Fallback to default or prompt
 			if in.Default != "" {
-				r.cache[id] = in.Default
+				r.remember(id, in.Default)
 				return in.Default, nil
 			}
 			lbl := in.Description
 			if strings.TrimSpace(lbl) == "" {
 				lbl = fmt.Sprintf("Enter %s", in.ID)
 			}
-			val, err := promptString(lbl, "", false)
+			val, err := r.ask(in, func(stdin io.ReadCloser) (string, error) {
+				return promptString(lbl, "", false, nil, stdin)
+			})
 			if err != nil {
 				return "", err
 			}
-			r.cache[id] = val
+			r.remember(id, val)
 			return val, nil
 		}
-		r.cache[id] = out
+		r.remember(id, out)
 		return out, nil
 
 	default:
 		// Unknown type → prompt
-		val, err := promptString(fmt.Sprintf("Enter %s", in.ID), in.Default, false)
+		val, err := r.ask(in, func(stdin io.ReadCloser) (string, error) {
+			return promptString(fmt.Sprintf("Enter %s", in.ID), in.Default, false, nil, stdin)
+		})
 		if err != nil {
 			return "", err
 		}
-		r.cache[id] = val
+		r.remember(id, val)
 		return val, nil
 	}
 }
@@ -262,11 +358,14 @@ func (b bellFilter) Write(p []byte) (int, error) {
 
 func (b bellFilter) Close() error { return nil }
 
-func promptString(label, def string, password bool) (string, error) {
+// promptString asks for a line of text; validate, if set, keeps asking until it passes.
+func promptString(label, def string, password bool, validate func(string) error, stdin io.ReadCloser) (string, error) {
 	p := promptui.Prompt{
-		Label:   label,
-		Default: def,
-		Stdout:  bellFilter{os.Stdout},
+		Label:    label,
+		Default:  def,
+		Validate: validate,
+		Stdin:    stdin,
+		Stdout:   bellFilter{os.Stdout},
 	}
 	if password {
 		p.Mask = '*'
@@ -274,25 +373,26 @@ func promptString(label, def string, password bool) (string, error) {
 	return p.Run()
 }
 
-func promptSelect(label string, options []string, def string) (string, error) {
-	idx := 0
-	if def != "" {
-		for i, o := range options {
-			if o == def {
-				idx = i
-				break
-			}
-		}
+// promptSelect shows the options' labels and returns the chosen one's value; def is a value.
+func promptSelect(label string, options []tasks.PickOption, def string, stdin io.ReadCloser) (string, error) {
+	items := make([]string, len(options))
+	for i, o := range options {
+		items[i] = o.Display()
 	}
+	idx := max(0, slices.IndexFunc(options, func(o tasks.PickOption) bool { return def != "" && o.Value == def }))
 	s := promptui.Select{
 		Label:     label,
-		Items:     options,
+		Items:     items,
 		CursorPos: idx,
 		Size:      minInt(8, maxInt(3, len(options))), // small window; never fullscreen
+		Stdin:     stdin,
 		Stdout:    bellFilter{os.Stdout},
 	}
-	_, val, err := s.Run()
-	return val, err
+	i, _, err := s.Run()
+	if err != nil {
+		return "", err
+	}
+	return options[i].Value, nil
 }
 
 func minInt(a, b int) int {
@@ -308,6 +408,63 @@ func maxInt(a, b int) int {
 	return b
 }
 
+// interpolate expands ${input:*}, VS Code variables and ${env:*} in an input field.
+func (r *InputResolver) interpolate(s string, stack []string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var firstErr error
+	s = reInput.ReplaceAllStringFunc(s, func(m string) string {
+		sub := reInput.FindStringSubmatch(m)
+		val, err := r.resolve(sub[1], stack)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		return val
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+	s = substituteVars(s, r.vars)
+	return substituteEnv(s), nil
+}
+
+// interpolateJSON interpolates the strings in a JSON payload (such as a command input's args).
+func (r *InputResolver) interpolateJSON(raw json.RawMessage, stack []string) (json.RawMessage, error) {
+	if !bytes.Contains(raw, []byte("${")) {
+		return raw, nil
+	}
+	v, err := decodeJSON(raw)
+	if err != nil {
+		return raw, nil // reported when the payload is used
+	}
+	var walk func(v any) (any, error)
+	walk = func(v any) (any, error) {
+		var err error
+		switch v := v.(type) {
+		case string:
+			return r.interpolate(v, stack)
+		case []any:
+			for i := range v {
+				if v[i], err = walk(v[i]); err != nil {
+					return nil, err
+				}
+			}
+		case map[string]any:
+			for k := range v {
+				if v[k], err = walk(v[k]); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return v, nil
+	}
+	if v, err = walk(v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
 func simpleLinePrompt(label, def string) (string, error) {
 	if def != "" {
 		fmt.Printf("%s [%s]: ", label, def)
@@ -326,71 +483,125 @@ func simpleLinePrompt(label, def string) (string, error) {
 	return s, nil
 }
 
-func runInputShell(script string) string {
-	if strings.TrimSpace(script) == "" {
-		return ""
-	}
-	exe, args := defaultShell()
-	cmd := exec.Command(exe, append(args, script)...)
-	// Inherit env and CWD; capture stdout
-	out, err := cmd.Output()
-	if err != nil {
-		return ""
-	}
-	return string(out)
-}
-
 // ----------------- existing helpers -----------------
 
 func substituteVars(s string, vars map[string]string) string {
 	if s == "" {
 		return s
 	}
-	out := s
+	out := posixSeparators(s)
 	for k, v := range vars {
 		out = strings.ReplaceAll(out, "${"+k+"}", v)
 	}
 	return out
 }
 
+var reEnvVar = regexp.MustCompile(`\$\{env:([^}]+)\}`)
+
+// substituteEnv replaces ${env:NAME} with the value of NAME (empty if unset), like VS Code.
+func substituteEnv(s string) string {
+	if !strings.Contains(s, "${env:") {
+		return s
+	}
+	return reEnvVar.ReplaceAllStringFunc(s, func(m string) string {
+		return os.Getenv(reEnvVar.FindStringSubmatch(m)[1])
+	})
+}
+
+// mergeEnv sets extra's variables in base, overriding those already set. On Windows, where
+// variable names are case-insensitive, "PATH" overrides "Path" but keeps its spelling (see
+// mergeEnvFold).
 func mergeEnv(base []string, extra map[string]string) []string {
-	// Convert base to map
-	m := map[string]string{}
+	return mergeEnvFold(base, extra, runtime.GOOS == "windows")
+}
+
+// mergeEnvFold is mergeEnv, matching names case-insensitively if fold is set. base's order is
+// kept, and a variable set twice in it (in two cases, with fold) is kept once, at its first
+// place and name with its last value, as Windows' own lookup would find only one of them.
+// extra's new variables follow, sorted.
+func mergeEnvFold(base []string, extra map[string]string, fold bool) []string {
+	key := func(k string) string {
+		if fold {
+			return strings.ToUpper(k)
+		}
+		return k
+	}
+	names := map[string]string{} // key -> name as first spelled
+	vals := map[string]string{}
+	var order []string
+	set := func(k, v string) {
+		kk := key(k)
+		if _, ok := names[kk]; !ok {
+			names[kk] = k
+			order = append(order, kk)
+		}
+		vals[kk] = v
+	}
 	for _, kv := range base {
-		if i := strings.IndexByte(kv, '='); i >= 0 {
-			m[kv[:i]] = kv[i+1:]
+		if k, v, ok := cutEnv(kv); ok {
+			set(k, v)
 		}
 	}
-	maps.Copy(m, extra)
-	// Back to slice
-	out := make([]string, 0, len(m))
-	for k, v := range m {
-		out = append(out, k+"="+v)
+	for _, k := range slices.Sorted(maps.Keys(extra)) {
+		set(k, extra[k])
+	}
+	out := make([]string, 0, len(order))
+	for _, kk := range order {
+		out = append(out, names[kk]+"="+vals[kk])
 	}
 	return out
 }
 
+// cutEnv splits an environment entry into its name and value. Windows' per-drive current
+// directories ("=C:=C:\src") have names starting with "=".
+func cutEnv(kv string) (k, v string, ok bool) {
+	i := strings.IndexByte(kv, '=')
+	if i == 0 {
+		if j := strings.IndexByte(kv[1:], '='); j >= 0 {
+			i = j + 1
+		}
+	}
+	if i < 0 {
+		return "", "", false
+	}
+	return kv[:i], kv[i+1:], true
+}
+
+// envKeyEqual reports whether a and b name the same variable (case-insensitively on Windows).
+func envKeyEqual(a, b string) bool {
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
 // appendEnvIfMissing adds key=value to env only if key is not already set.
 func appendEnvIfMissing(env []string, key, value string) []string {
-	prefix := key + "="
 	for _, kv := range env {
-		if strings.HasPrefix(kv, prefix) {
+		if k, _, ok := cutEnv(kv); ok && envKeyEqual(k, key) {
 			return env
 		}
 	}
-	return append(env, prefix+value)
+	return append(env, key+"="+value)
 }
 
 func defaultShell() (exe string, args []string) {
 	if runtime.GOOS == "windows" {
-		return "cmd.exe", []string{"/C"}
+		return windowsShell()
 	}
 	// Prefer bash if present? Keeping /bin/sh for portability.
 	return "/bin/sh", []string{"-c"}
 }
 
+// buildCommandLine is commandLine for the default shell.
 func buildCommandLine(cmd string, args []string) string {
-	if runtime.GOOS == "windows" {
+	exe, _ := defaultShell()
+	return commandLine(cmd, args, kindOfShell(exe))
+}
+
+// commandLine joins cmd and args for a shell of kind.
+func commandLine(cmd string, args []string, kind shellKind) string {
+	if kind == shellCmd {
 		parts := make([]string, 0, 1+len(args))
 		if cmd != "" {
 			parts = append(parts, winQuote(cmd))
@@ -401,7 +612,11 @@ func buildCommandLine(cmd string, args []string) string {
 		return strings.Join(parts, " ")
 	}
 
-	// POSIX: prefer double-quoting so $(...) and $VAR still expand.
+	// POSIX: prefer double-quoting so $(...) and $VAR still expand. PowerShell: single quotes.
+	quote := posixQuoteForShell
+	if kind == shellPowerShell {
+		quote = psQuote
+	}
 	if len(args) == 0 {
 		// Let shell parse/expand everything in command (e.g., $(...), pipes, etc.)
 		return cmd
@@ -414,7 +629,7 @@ func buildCommandLine(cmd string, args []string) string {
 		if b.Len() > 0 {
 			b.WriteByte(' ')
 		}
-		b.WriteString(posixQuoteForShell(a)) // quote only args
+		b.WriteString(quote(a)) // quote only args
 	}
 	return b.String()
 }
@@ -423,8 +638,8 @@ func posixQuoteForShell(s string) string {
 	if s == "" {
 		return `""`
 	}
-	// Quote if it has whitespace or shell metachars (including quotes).
-	if containsAnyRunes(s, " \t\n\r;&|()<>[]{}*?!~`$\\\"'") {
+	// Quote if it has whitespace or shell metachars (including quotes and # comments).
+	if containsAnyRunes(s, " \t\n\r;&|()<>[]{}*?!~#`$\\\"'") {
 		// Escape backslashes and double quotes inside double quotes.
 		esc := strings.ReplaceAll(s, `\`, `\\`)
 		esc = strings.ReplaceAll(esc, `"`, `\"`)
@@ -450,12 +665,16 @@ func winQuote(s string) string {
 	if strings.IndexFunc(s, func(r rune) bool {
 		return r <= ' ' || strings.ContainsRune(`"^&|<>()%!`, r)
 	}) >= 0 {
-		// escape " by doubling
-		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+		// Backslashes right before a quote (or the closing one) are escapes to the program
+		// reading its arguments, so they're doubled; a " is escaped by doubling.
+		esc := reBackslashesBeforeQuote.ReplaceAllString(s, `${1}${1}${2}`)
+		return `"` + strings.ReplaceAll(esc, `"`, `""`) + `"`
 	}
 	return s
 }
 
+var reBackslashesBeforeQuote = regexp.MustCompile(`(\\+)("|$)`)
+
 func mustGetwd() string {
 	if wd, err := os.Getwd(); err == nil {
 		return wd
@@ -476,6 +695,16 @@ func buildVSCodeVarMapWithCWD(workspace, cwd string) map[string]string {
 	return vars
 }
 
+// folderVars returns the folder-scoped variables of the named roots of a multi-root workspace.
+func folderVars(folders []tasks.WorkspaceFolder) map[string]string {
+	vars := make(map[string]string, 2*len(folders))
+	for _, f := range folders {
+		vars["workspaceFolder:"+f.Name] = f.Path
+		vars["workspaceFolderBasename:"+f.Name] = filepath.Base(f.Path)
+	}
+	return vars
+}
+
 // buildVSCodeVarMap constructs all built-in VS Code substitutions.
 // Many editor-specific values are best-effort via env fallbacks.
 func buildVSCodeVarMap(workspace string) map[string]string {
@@ -490,6 +719,10 @@ func buildVSCodeVarMap(workspace string) map[string]string {
 	if workspace != "" {
 		vars["workspaceFolder"] = workspace
 		vars["workspaceFolderBasename"] = filepath.Base(workspace)
+		// ${workspaceFolder:Name}, ${workspaceFolderBasename:Name} in a multi-root workspace
+		if ws, _ := tasks.FindWorkspace(workspace); ws != nil {
+			maps.Copy(vars, folderVars(ws.Folders))
+		}
 	}
 
 	// ${cwd}  (best effort: current process dir)
@@ -497,10 +730,8 @@ func buildVSCodeVarMap(workspace string) map[string]string {
 		vars["cwd"] = wd
 	}
 
-	// ${execPath} (best effort: env or 'code' on PATH)
-	if v := os.Getenv("VSCODE_EXEC_PATH"); v != "" {
-		vars["execPath"] = v
-	} else if p, _ := exec.LookPath("code"); p != "" {
+	// ${execPath} (best effort: env, or an editor launcher on PATH or where it's installed)
+	if p := tasks.EditorExecPath(); p != "" {
 		vars["execPath"] = p
 	}
 
@@ -3,6 +3,7 @@ package runner
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"os/exec"
@@ -11,6 +12,8 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/chenasraf/vstask/tasks"
+	"github.com/chenasraf/vstask/utils"
 	"golang.org/x/term"
 )
 
@@ -19,48 +22,110 @@ import (
 //  1. PTY + current SysProcAttr
 //  2. PTY + no SysProcAttr
 //  3. stdio + no SysProcAttr
-//  4. (if bash) stdio + no SysProcAttr + swap to /bin/sh
-func startAndWait(ctx context.Context, cmd *exec.Cmd, interactive bool) (retErr error) {
+//  4. (if bash) stdio + no SysProcAttr + swap to /bin/sh (see shellFallback)
+//
+// A cmd.Stdout (or Stderr) set by the caller, to tee the output, gets the PTY's output.
+// Each fallback taken is traced (see utils.Tracef), with the error that led to it.
+func startAndWait(ctx context.Context, cmd *exec.Cmd, interactive bool) error {
+	if interactive && !canUsePTY() {
+		utils.Tracef("%s: no PTY (%s); using stdio", cmdName(cmd), noPTYReason())
+	}
 	// Try PTY path first if permitted
 	if interactive && canUsePTY() {
+		// The PTY is the command's stdout and stderr; what they were set to is restored after.
+		out, errOut := cmd.Stdout, cmd.Stderr
+		restore := func(c *exec.Cmd) *exec.Cmd {
+			c.Stdout, c.Stderr = out, errOut
+			return c
+		}
+		defer restore(cmd)
+		cmd.Stdout, cmd.Stderr = nil, nil
 		// (1) PTY + current SysProcAttr
 		if ptmx, ok, err := maybeStartWithPTY(cmd); err == nil && ok && ptmx != nil {
-			return waitWithPTY(ctx, cmd, ptmx)
+			return waitWithPTY(ctx, cmd, ptmx, out)
 		} else if isExecPermissionError(err) {
 			// (2) PTY + NO SysProcAttr
+			utils.Tracef("%s: starting under a PTY failed (%v); retrying without process attributes", cmdName(cmd), err)
 			clone := cloneCmdNoSysProc(cmd)
-			if ptmx2, ok2, err2 := maybeStartWithPTY(clone); err2 == nil && ok2 && ptmx2 != nil {
-				return waitWithPTY(ctx, clone, ptmx2)
+			ptmx2, ok2, err2 := maybeStartWithPTY(clone)
+			if err2 == nil && ok2 && ptmx2 != nil {
+				return waitWithPTY(ctx, clone, ptmx2, out)
 			}
 			// (3) stdio + NO SysProcAttr
-			if err3 := startAndWaitStdio(ctx, clone); err3 == nil {
+			utils.Tracef("%s: starting under a PTY without process attributes failed (%v); using stdio without them", cmdName(cmd), err2)
+			clone = restore(cloneCmdNoSysProc(cmd))
+			err3 := startAndWaitStdio(ctx, clone)
+			if err3 == nil {
 				return nil
-			} else if shouldFallbackToSh(clone, err3) {
-				// (4) stdio + NO SysProcAttr + swap to /bin/sh
-				return startAndWaitStdio(ctx, rebuildWithSh(clone))
-			} else {
+			}
+			// (4) stdio + NO SysProcAttr + swap to /bin/sh
+			sh, err3 := shellFallback(clone, err3)
+			if sh == nil {
 				return err3
 			}
+			return startAndWaitStdio(ctx, sh)
+		} else {
+			utils.Tracef("%s: starting under a PTY failed (%v); using stdio", cmdName(cmd), err)
 		}
 		// If PTY failed for any other reason, fall through to stdio with the original cmd.
+		restore(cmd)
 	}
 
 	// Stdio path (original cmd + current SysProcAttr)
-	if err := startAndWaitStdio(ctx, cmd); err == nil {
+	err := startAndWaitStdio(ctx, cmd)
+	if err == nil {
 		return nil
 	}
 	// Fallback: /bin/bash -> /bin/sh swap if appropriate
-	if shouldFallbackToSh(cmd, retErr) {
-		return startAndWaitStdio(ctx, rebuildWithSh(cmd))
+	sh, err := shellFallback(cmd, err)
+	if sh == nil {
+		return err
+	}
+	return startAndWaitStdio(ctx, sh)
+}
+
+// errShellFallbackOff is added to the error of a bash that couldn't start when the fallback is
+// off, so it isn't retried further up either.
+var errShellFallbackOff = errors.New(`not retried with another shell: "vstask.shellFallback" is "off"`)
+
+// shellFallback returns cmd rebuilt to run with the fallback shell (/bin/sh unless configured,
+// see tasks.ShellFallback) after it failed to start with err, or nil when that doesn't apply
+// (see shouldFallbackToSh). With the fallback off, err then says so.
+func shellFallback(cmd *exec.Cmd, err error) (*exec.Cmd, error) {
+	if errors.Is(err, errShellFallbackOff) || !shouldFallbackToSh(cmd, err) {
+		return nil, err
+	}
+	root, _ := tasks.ProjectRoot()
+	sh, ok := tasks.ShellFallback(root)
+	if !ok {
+		utils.Tracef("%s: starting failed (%v); not retrying, the shell fallback is off", cmdName(cmd), err)
+		return nil, fmt.Errorf("%w (%w)", err, errShellFallbackOff)
+	}
+	c := rebuildWithSh(cmd, sh)
+	if c == nil {
+		return nil, err
 	}
-	return retErr
+	utils.Tracef("%s: starting failed (%v); retrying with %s", cmdName(cmd), err, sh)
+	return c, nil
+}
+
+// cmdName is how traces name cmd: its executable.
+func cmdName(cmd *exec.Cmd) string {
+	if cmd.Path == "" && len(cmd.Args) > 0 {
+		return cmd.Args[0]
+	}
+	return cmd.Path
 }
 
 // startAndWaitStdio runs the command with plain stdio and cancel/kill logic.
 func startAndWaitStdio(ctx context.Context, cmd *exec.Cmd) error {
 	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	if cmd.Stdout == nil {
+		cmd.Stdout = os.Stdout // callers may tee the output (see exports)
+	}
+	if cmd.Stderr == nil {
+		cmd.Stderr = os.Stderr
+	}
 
 	if err := cmd.Start(); err != nil {
 		return err
@@ -84,9 +149,10 @@ func startAndWaitStdio(ctx context.Context, cmd *exec.Cmd) error {
 }
 
 // waitWithPTY waits for an already-started PTY command and wires io + resize + raw mode.
+// The output goes to out (os.Stdout if nil).
 // IMPORTANT: we DO NOT wait for the stdin->PTY copier to finish, to avoid
 // needing an extra keypress after the child exits.
-func waitWithPTY(ctx context.Context, cmd *exec.Cmd, ptmx *os.File) error {
+func waitWithPTY(ctx context.Context, cmd *exec.Cmd, ptmx *os.File, out io.Writer) error {
 	defer func() { _ = ptmx.Close() }()
 
 	// Keep PTY sized to our terminal
@@ -118,8 +184,11 @@ func waitWithPTY(ctx context.Context, cmd *exec.Cmd, ptmx *os.File) error {
 	go func() { _, _ = io.Copy(ptmx, os.Stdin) }()
 
 	// PTY -> stdout (we'll give this a brief chance to flush)
+	if out == nil {
+		out = os.Stdout
+	}
 	outDone := make(chan struct{})
-	go func() { _, _ = io.Copy(os.Stdout, ptmx); close(outDone) }()
+	go func() { _, _ = io.Copy(out, ptmx); close(outDone) }()
 
 	// Wait in a goroutine so we can cancel.
 	waitErr := make(chan error, 1)
@@ -147,6 +216,14 @@ func waitWithPTY(ctx context.Context, cmd *exec.Cmd, ptmx *os.File) error {
 	}
 }
 
+// noPTYReason is why canUsePTY is false, for traces.
+func noPTYReason() string {
+	if os.Getenv("VSTASK_DISABLE_PTY") == "1" {
+		return "VSTASK_DISABLE_PTY=1"
+	}
+	return "stdin or stdout isn't a terminal"
+}
+
 func canUsePTY() bool {
 	if os.Getenv("VSTASK_DISABLE_PTY") == "1" {
 		return false
@@ -178,8 +255,9 @@ func shouldFallbackToSh(cmd *exec.Cmd, startErr error) bool {
 		strings.Contains(strings.ToLower(startErr.Error()), "operation not permitted")
 }
 
-// rebuildWithSh reconstructs cmd to use /bin/sh while preserving args/env/cwd and SysProcAttr.
-func rebuildWithSh(orig *exec.Cmd) *exec.Cmd {
+// rebuildWithSh reconstructs cmd to use sh (e.g. /bin/sh) while preserving args/env/cwd and
+// SysProcAttr.
+func rebuildWithSh(orig *exec.Cmd, sh string) *exec.Cmd {
 	if orig == nil {
 		return nil
 	}
@@ -187,11 +265,12 @@ func rebuildWithSh(orig *exec.Cmd) *exec.Cmd {
 	if len(args) == 0 {
 		return nil
 	}
-	// swap executable to /bin/sh, keep the rest of the args the same
-	args[0] = "/bin/sh"
+	// swap executable to sh, keep the rest of the args the same
+	args[0] = sh
 	c := exec.Command(args[0], args[1:]...)
 	c.Dir = orig.Dir
 	c.Env = orig.Env
+	c.Stdout, c.Stderr = orig.Stdout, orig.Stderr // a tee, if the caller set one
 	c.SysProcAttr = orig.SysProcAttr
 	return c
 }
@@ -0,0 +1,73 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestStartAndWait_TracesShFallback(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	t.Setenv("VSTASK_TRACE", "1")
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	t.Cleanup(func() { os.Stderr = stderr })
+
+	cmd := exec.Command(t.TempDir()+"/bash", "-c", "exit 0") // no such bash: falls back to /bin/sh
+	cmd.Stdout = io.Discard
+	err = startAndWait(context.Background(), cmd, false)
+	os.Stderr = stderr
+	_ = w.Close()
+	if err != nil {
+		t.Fatalf("the /bin/sh fallback failed: %v", err)
+	}
+	out, _ := io.ReadAll(r)
+	if !strings.Contains(string(out), "vstask: trace: ") || !strings.Contains(string(out), "retrying with /bin/sh") || !strings.Contains(string(out), "no such file") {
+		t.Fatalf("trace = %q; want the fallback and the error that led to it", out)
+	}
+}
+
+func TestStartAndWait_ShellFallback(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	t.Chdir(t.TempDir())
+	missing := func() *exec.Cmd {
+		cmd := exec.Command(t.TempDir()+"/bash", "-c", "echo $0 > shell.out")
+		cmd.Stdout = io.Discard
+		return cmd
+	}
+
+	t.Setenv("VSTASK_SHELL_FALLBACK", "off")
+	err := startAndWait(context.Background(), missing(), false)
+	if !errors.Is(err, errShellFallbackOff) || !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("with the fallback off: %v; want the start error, saying it wasn't retried", err)
+	}
+	if _, err := os.Stat("shell.out"); err == nil {
+		t.Fatal("retried with the fallback off")
+	}
+
+	dash, err := exec.LookPath("dash")
+	if err != nil {
+		t.Skip("no dash to fall back to")
+	}
+	t.Setenv("VSTASK_SHELL_FALLBACK", dash)
+	if err := startAndWait(context.Background(), missing(), false); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile("shell.out"); strings.TrimSpace(string(b)) != dash {
+		t.Fatalf("ran with %q, want %s", b, dash)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"slices"
 	"strings"
@@ -159,6 +160,34 @@ func TestBuildCmd_Shell_CustomArgsOverride(t *testing.T) {
 	}
 }
 
+func TestBuildCmd_Shell_CommandPlaceholder(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell semantics test on POSIX")
+	}
+	tk := tasks.Task{
+		Type:    "shell",
+		Command: "echo ok",
+		Options: &tasks.Options{
+			Shell: &tasks.ShellOptions{
+				Executable: "/bin/bash",
+				Args:       []string{"-c", "${command}", "--norc"},
+			},
+		},
+	}
+	cmd, _, err := buildCmd(tk, "/", os.Environ())
+	if err != nil {
+		t.Fatalf("buildCmd err: %v", err)
+	}
+	if want := []string{"/bin/bash", "-c", "echo ok", "--norc"}; !slices.Equal(cmd.Args, want) {
+		t.Fatalf("got %v, want %v", cmd.Args, want)
+	}
+
+	// Placeholders inside a larger arg are replaced in place.
+	if got := shellArgsWithCommand([]string{"/s", "/c", `"${command}"`}, "dir"); !slices.Equal(got, []string{"/s", "/c", `"dir"`}) {
+		t.Fatalf("got %v", got)
+	}
+}
+
 func TestMergeEnv(t *testing.T) {
 	base := []string{"A=1", "B=2"}
 	extra := map[string]string{"B": "3", "C": "4"}
@@ -169,6 +198,23 @@ func TestMergeEnv(t *testing.T) {
 	}
 }
 
+func TestMergeEnvFold(t *testing.T) {
+	base := []string{"=C:=C:\\src", "Path=C:\\Windows", "HOME=h", "PATH=C:\\other", "TEMP=t"}
+	extra := map[string]string{"PATH": "C:\\bin", "temp": "x", "Foo": "1"}
+
+	got := mergeEnvFold(base, extra, true)
+	want := []string{"=C:=C:\\src", "Path=C:\\bin", "HOME=h", "TEMP=x", "Foo=1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("case-insensitive:\n got %q\nwant %q", got, want)
+	}
+
+	got = mergeEnvFold(base, extra, false)
+	want = []string{"=C:=C:\\src", "Path=C:\\Windows", "HOME=h", "PATH=C:\\bin", "TEMP=t", "Foo=1", "temp=x"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("case-sensitive:\n got %q\nwant %q", got, want)
+	}
+}
+
 func envToMap(env []string) map[string]string {
 	m := map[string]string{}
 	for _, kv := range env {
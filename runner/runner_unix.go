@@ -3,6 +3,7 @@
 package runner
 
 import (
+	"errors"
 	"os"
 	"os/exec"
 	"syscall"
@@ -22,6 +23,9 @@ func setProcessGroup(cmd *exec.Cmd) {
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 }
 
+// setCmdLine is Windows only (see cmdExeQuoting).
+func setCmdLine(*exec.Cmd, string) {}
+
 func killTree(p *os.Process) {
 	if p == nil {
 		return
@@ -71,3 +75,23 @@ func terminateProcessTree(cmd *exec.Cmd) error {
 	_ = cmd.Process.Kill()
 	return nil
 }
+
+// processAlive reports whether the process pid exists.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// forceKillTree kills the process group of pid (see setProcessGroup) outright.
+func forceKillTree(pid int) {
+	_ = syscall.Kill(-pid, syscall.SIGKILL)
+}
+
+// interruptProcess asks p, a vstask child process, to stop: with SIGTERM, which it handles by
+// stopping its own tasks.
+func interruptProcess(p *os.Process) {
+	_ = p.Signal(syscall.SIGTERM)
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/exec"
 	"strconv"
+	"syscall"
 )
 
 func trapSignals() []os.Signal {
@@ -19,6 +20,14 @@ func setProcessGroup(cmd *exec.Cmd) {
 	// Nothing to do on Windows here.
 }
 
+// setCmdLine makes cmd start with exactly this command line (see cmdExeQuoting).
+func setCmdLine(cmd *exec.Cmd, line string) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CmdLine = line
+}
+
 func killTree(p *os.Process) {
 	if p == nil {
 		return
@@ -45,3 +54,31 @@ func terminateProcessTree(cmd *exec.Cmd) error {
 	_ = exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
 	return nil
 }
+
+// stillActive is the exit code of a process that hasn't exited (STILL_ACTIVE).
+const stillActive = 259
+
+// processAlive reports whether the process pid exists and hasn't exited.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	h, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer syscall.CloseHandle(h)
+	var code uint32
+	return syscall.GetExitCodeProcess(h, &code) == nil && code == stillActive
+}
+
+// forceKillTree kills the process tree of pid outright.
+func forceKillTree(pid int) {
+	_ = exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(pid)).Run()
+}
+
+// interruptProcess stops p, a vstask child process, and its tasks: Windows has no SIGTERM to
+// ask it with, so the tree is killed.
+func interruptProcess(p *os.Process) {
+	killTree(p)
+}
@@ -0,0 +1,118 @@
+package runner
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// sandboxPolicy is what a sandboxed task may touch.
+type sandboxPolicy struct {
+	writable []string // absolute, symlink-free paths
+	network  bool
+}
+
+// sandboxWritable returns the paths every sandboxed task may write to: its workspace folder,
+// the run's scratch directory, and its cwd when that's in the workspace folder (options.cwd is
+// up to tasks.json, and could be ${userHome}; sandbox.writable says so explicitly).
+func sandboxWritable(workspace, cwd, runTemp string) []string {
+	out := []string{workspace, runTemp}
+	if workspace == "" || cwd == "" {
+		return out
+	}
+	ws, dir := workspace, cwd
+	if r, err := filepath.EvalSymlinks(ws); err == nil {
+		ws = r
+	}
+	if r, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = r
+	}
+	if rel, err := filepath.Rel(ws, dir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		out = append(out, cwd)
+	}
+	return out
+}
+
+// sandboxCmd wraps cmd to run in the sandbox sb configures (see tasks.Sandbox). writable adds
+// the paths every sandboxed task may write to (see sandboxWritable). When the sandbox tool is
+// unavailable it fails rather than run the task unsandboxed.
+func sandboxCmd(cmd *exec.Cmd, sb *tasks.Sandbox, writable ...string) (*exec.Cmd, error) {
+	if sb == nil || !sb.Enabled {
+		return cmd, nil
+	}
+	if cmd.Err != nil {
+		return nil, cmd.Err
+	}
+	pol := sandboxPolicy{network: sb.Network}
+	for _, w := range append(writable, sb.Writable...) {
+		if w == "" {
+			continue
+		}
+		if r, err := filepath.EvalSymlinks(w); err == nil {
+			w = r
+		}
+		if !slices.Contains(pol.writable, w) {
+			pol.writable = append(pol.writable, w)
+		}
+	}
+
+	var tool string
+	var args []string
+	switch runtime.GOOS {
+	case "linux":
+		tool, args = "bwrap", append(bwrapArgs(pol), "--")
+	case "darwin":
+		tool, args = "sandbox-exec", []string{"-p", seatbeltProfile(pol)}
+	default:
+		return nil, fmt.Errorf("sandbox: not supported on %s", runtime.GOOS)
+	}
+	path, err := exec.LookPath(tool)
+	if err != nil {
+		return nil, fmt.Errorf("sandbox: %s not found on PATH; refusing to run the task unsandboxed", tool)
+	}
+
+	wrapped := exec.Command(path, append(append(args, cmd.Path), cmd.Args[1:]...)...)
+	wrapped.Dir = cmd.Dir
+	wrapped.Env = cmd.Env
+	wrapped.SysProcAttr = cmd.SysProcAttr
+	return wrapped, nil
+}
+
+// bwrapArgs mounts the host read-only, with a private /tmp, fresh /dev and /proc, and the
+// writable paths bound read-write on top.
+func bwrapArgs(pol sandboxPolicy) []string {
+	args := []string{"--ro-bind", "/", "/", "--dev", "/dev", "--proc", "/proc", "--tmpfs", "/tmp"}
+	for _, w := range pol.writable {
+		args = append(args, "--bind-try", w, w)
+	}
+	if !pol.network {
+		args = append(args, "--unshare-net")
+	}
+	return append(args, "--die-with-parent")
+}
+
+// seatbeltProfile is a sandbox-exec profile denying writes outside the writable paths, the
+// temp directories and terminals, and (unless allowed) IP networking; local sockets keep working.
+func seatbeltProfile(pol sandboxPolicy) string {
+	var b strings.Builder
+	b.WriteString("(version 1)\n(allow default)\n(deny file-write*)\n")
+	b.WriteString(`(allow file-write* (literal "/dev/null") (literal "/dev/zero") (subpath "/dev/fd") (regex #"^/dev/tty")` +
+		` (regex #"^/dev/pty") (subpath "/private/tmp") (subpath "/private/var/folders")`)
+	for _, w := range pol.writable {
+		fmt.Fprintf(&b, " (subpath %s)", seatbeltString(w))
+	}
+	b.WriteString(")\n")
+	if !pol.network {
+		b.WriteString("(deny network-outbound (remote ip))\n(deny network-inbound (local ip))\n")
+	}
+	return b.String()
+}
+
+func seatbeltString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,134 @@
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func TestBwrapArgs(t *testing.T) {
+	args := bwrapArgs(sandboxPolicy{writable: []string{"/work"}})
+	joined := strings.Join(args, " ")
+	for _, want := range []string{"--ro-bind / /", "--tmpfs /tmp", "--bind-try /work /work", "--unshare-net"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("args %q lack %q", joined, want)
+		}
+	}
+	// writable binds must come after the read-only root and the private /tmp
+	if strings.Index(joined, "--bind-try") < strings.Index(joined, "--tmpfs") {
+		t.Errorf("writable paths are mounted too early: %q", joined)
+	}
+	if slices.Contains(bwrapArgs(sandboxPolicy{network: true}), "--unshare-net") {
+		t.Error("network: true must keep the network")
+	}
+}
+
+func TestSeatbeltProfile(t *testing.T) {
+	p := seatbeltProfile(sandboxPolicy{writable: []string{`/Users/me/my "app"`}})
+	for _, want := range []string{"(deny file-write*)", `(subpath "/Users/me/my \"app\"")`, "(deny network-outbound (remote ip))"} {
+		if !strings.Contains(p, want) {
+			t.Errorf("profile lacks %s:\n%s", want, p)
+		}
+	}
+	if strings.Contains(seatbeltProfile(sandboxPolicy{network: true}), "network") {
+		t.Error("network: true must not deny networking")
+	}
+}
+
+func TestSandboxCmd(t *testing.T) {
+	cmd := exec.Command("echo", "hi")
+	if got, err := sandboxCmd(cmd, nil); err != nil || got != cmd {
+		t.Fatalf("no sandbox: %v, %v", got, err)
+	}
+	if got, err := sandboxCmd(cmd, &tasks.Sandbox{}); err != nil || got != cmd {
+		t.Fatalf("sandbox: false: %v, %v", got, err)
+	}
+
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		if _, err := sandboxCmd(cmd, &tasks.Sandbox{Enabled: true}); err == nil {
+			t.Fatal("expected unsupported platform error")
+		}
+		return
+	}
+
+	// The tool is required: never fall back to running unsandboxed.
+	t.Setenv("PATH", t.TempDir())
+	if _, err := sandboxCmd(cmd, &tasks.Sandbox{Enabled: true}); err == nil {
+		t.Fatal("expected an error without the sandbox tool")
+	}
+
+	tool := "bwrap"
+	if runtime.GOOS == "darwin" {
+		tool = "sandbox-exec"
+	}
+	bin := t.TempDir()
+	writeFile(t, filepath.Join(bin, tool), "#!/bin/sh\n")
+	if err := os.Chmod(filepath.Join(bin, tool), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+	cmd.Dir = "/work"
+	cmd.Env = []string{"A=1"}
+	got, err := sandboxCmd(cmd, &tasks.Sandbox{Enabled: true}, "/work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Path != filepath.Join(bin, tool) || got.Dir != "/work" || !slices.Equal(got.Env, cmd.Env) {
+		t.Fatalf("wrapped = %s in %s with %v", got.Path, got.Dir, got.Env)
+	}
+	if n := len(got.Args); n < 2 || got.Args[n-2] != cmd.Path || got.Args[n-1] != "hi" {
+		t.Fatalf("wrapped args %q don't end with the task's command", got.Args)
+	}
+}
+
+func TestSandboxWritable(t *testing.T) {
+	ws, home := t.TempDir(), t.TempDir()
+	sub := filepath.Join(ws, "app")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if got := sandboxWritable(ws, sub, "/tmp/run"); !slices.Contains(got, sub) || !slices.Contains(got, ws) || !slices.Contains(got, "/tmp/run") {
+		t.Fatalf("a cwd in the workspace folder: %q", got)
+	}
+	// options.cwd: "${userHome}" mustn't make the home directory writable
+	if got := sandboxWritable(ws, home, "/tmp/run"); slices.Contains(got, home) {
+		t.Fatalf("a cwd outside the workspace folder was made writable: %q", got)
+	}
+	link := filepath.Join(ws, "home")
+	if err := os.Symlink(home, link); err == nil {
+		if got := sandboxWritable(ws, link, ""); slices.Contains(got, link) {
+			t.Fatalf("a symlink out of the workspace folder was made writable: %q", got)
+		}
+	}
+}
+
+func TestSandbox_Bwrap(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("bwrap is Linux only")
+	}
+	if _, err := exec.LookPath("bwrap"); err != nil {
+		t.Skip("bwrap not installed")
+	}
+	if err := exec.Command("bwrap", "--ro-bind", "/", "/", "true").Run(); err != nil {
+		t.Skipf("bwrap unusable here: %v", err)
+	}
+	work, outside := t.TempDir(), t.TempDir()
+	cmd := exec.Command("sh", "-c", "touch ok && ! touch "+filepath.Join(outside, "nope"))
+	cmd.Dir = work
+	wrapped, err := sandboxCmd(cmd, &tasks.Sandbox{Enabled: true}, work)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, err := wrapped.CombinedOutput(); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "nope")); err == nil {
+		t.Fatal("wrote outside the writable paths")
+	}
+}
@@ -0,0 +1,152 @@
+package runner
+
+import (
+	"cmp"
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/chenasraf/vstask/utils"
+)
+
+// The little of SARIF 2.1.0 (https://docs.oasis-open.org/sarif/sarif/v2.1.0/) needed to report
+// problems: one run, by vstask, with a rule per problem code.
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                   `json:"tool"`
+	OriginalURIBaseIDs map[string]sarifArtifactLoc `json:"originalUriBaseIds,omitempty"`
+	Results            []sarifResult               `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	Level      string            `json:"level"` // "error" | "warning" | "note"
+	Message    sarifMessage      `json:"message"`
+	Locations  []sarifLocation   `json:"locations,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLoc `json:"artifactLocation"`
+	Region           *sarifRegion     `json:"region,omitempty"`
+}
+
+type sarifArtifactLoc struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+// sarifLevels maps problem severities to SARIF levels.
+var sarifLevels = map[string]string{"error": "error", "warning": "warning", "info": "note"}
+
+// writeSARIF writes problems to the file at p as a SARIF log (--sarif). Files inside root are
+// relative to it (the %SRCROOT% base, as code scanning expects); others are absolute file URIs.
+func writeSARIF(p string, problems []problem, root string) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "vstask",
+			Version:        utils.AppVersion,
+			InformationURI: "https://github.com/chenasraf/vstask",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	if root != "" {
+		run.OriginalURIBaseIDs = map[string]sarifArtifactLoc{"%SRCROOT%": {URI: fileURI(root) + "/"}}
+	}
+	var rules []string
+	for _, pr := range problems {
+		rule := cmp.Or(pr.Code, pr.Source, pr.Owner, "problem")
+		if !slices.Contains(rules, rule) {
+			rules = append(rules, rule)
+		}
+		res := sarifResult{
+			RuleID:     rule,
+			Level:      cmp.Or(sarifLevels[pr.Severity], "error"),
+			Message:    sarifMessage{Text: pr.Message},
+			Properties: map[string]string{"task": pr.Task},
+		}
+		if pr.Owner != "" {
+			res.Properties["owner"] = pr.Owner
+		}
+		if pr.File != "" {
+			loc := sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation(pr.File, root)}
+			if pr.Line > 0 {
+				loc.Region = &sarifRegion{StartLine: pr.Line, StartColumn: pr.Column, EndLine: pr.EndLine, EndColumn: pr.EndColumn}
+			}
+			res.Locations = []sarifLocation{{PhysicalLocation: loc}}
+		}
+		run.Results = append(run.Results, res)
+	}
+	for _, r := range rules {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: r})
+	}
+	b, err := json.MarshalIndent(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(p, append(b, '\n'), 0o644)
+}
+
+// sarifArtifactLocation is where file is: relative to root if it's inside it, else an absolute
+// URI (or, for a name no matcher could resolve, the name as is).
+func sarifArtifactLocation(file, root string) sarifArtifactLoc {
+	if !filepath.IsAbs(file) {
+		return sarifArtifactLoc{URI: filepath.ToSlash(file)}
+	}
+	if rel, err := filepath.Rel(root, file); root != "" && err == nil && !strings.HasPrefix(rel, "..") {
+		return sarifArtifactLoc{URI: (&url.URL{Path: filepath.ToSlash(rel)}).EscapedPath(), URIBaseID: "%SRCROOT%"}
+	}
+	return sarifArtifactLoc{URI: fileURI(file)}
+}
+
+// fileURI returns the file:// URI of an absolute path.
+func fileURI(p string) string {
+	p = filepath.ToSlash(p)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p // C:/x → /C:/x
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
@@ -0,0 +1,64 @@
+package runner
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	root := t.TempDir()
+	out := filepath.Join(t.TempDir(), "out.sarif")
+	err := writeSARIF(out, []problem{
+		{Task: "build", Owner: "gcc", File: filepath.Join(root, "src", "main file.c"), Line: 12, Column: 5, Severity: "error", Message: "expected ';'"},
+		{Task: "lint", Owner: "eslint", File: "elsewhere.js", Line: 3, Severity: "info", Code: "no-console", Message: "console"},
+		{Task: "lint", Severity: "warning", Message: "no file"},
+	}, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("log = %+v", log)
+	}
+	run := log.Runs[0]
+	if rules := run.Tool.Driver.Rules; len(rules) != 3 || rules[0].ID != "gcc" || rules[1].ID != "no-console" || rules[2].ID != "problem" {
+		t.Fatalf("rules = %+v", rules)
+	}
+	if len(run.Results) != 3 {
+		t.Fatalf("results = %+v", run.Results)
+	}
+	r := run.Results[0]
+	loc := r.Locations[0].PhysicalLocation
+	if r.Level != "error" || r.Properties["task"] != "build" || loc.ArtifactLocation.URI != "src/main%20file.c" ||
+		loc.ArtifactLocation.URIBaseID != "%SRCROOT%" || loc.Region == nil || loc.Region.StartLine != 12 || loc.Region.StartColumn != 5 {
+		t.Fatalf("result 0 = %+v", r)
+	}
+	if r := run.Results[1]; r.Level != "note" || r.Locations[0].PhysicalLocation.ArtifactLocation.URI != "elsewhere.js" {
+		t.Fatalf("result 1 = %+v", r)
+	}
+	if r := run.Results[2]; r.Level != "warning" || len(r.Locations) != 0 {
+		t.Fatalf("result 2 = %+v", r)
+	}
+
+	// An empty log still lists the tool, with no results.
+	if err := writeSARIF(out, nil, root); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(out)
+	var empty map[string]any
+	if err := json.Unmarshal(data, &empty); err != nil {
+		t.Fatal(err)
+	}
+	if results := empty["runs"].([]any)[0].(map[string]any)["results"]; results == nil || len(results.([]any)) != 0 {
+		t.Fatalf("empty log: %s", data)
+	}
+}
@@ -0,0 +1,111 @@
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/chenasraf/vstask/tasks"
+	"github.com/chenasraf/vstask/utils"
+)
+
+// shellKind is how a shell's command line is quoted (see commandLine).
+type shellKind int
+
+const (
+	shellCmd        shellKind = iota // cmd.exe
+	shellPOSIX                       // sh, bash, ...; on Windows, Git Bash, MSYS2's and Cygwin's
+	shellPowerShell                  // pwsh, or Windows PowerShell
+)
+
+// kindOfShell returns the kind of the shell exe.
+func kindOfShell(exe string) shellKind {
+	switch name := shellName(exe); {
+	case name == "pwsh" || name == "powershell":
+		return shellPowerShell
+	case runtime.GOOS != "windows" || msysShell(exe):
+		return shellPOSIX
+	}
+	return shellCmd
+}
+
+// shellArgsFor are the args running a command line in a shell of kind, before the line.
+func shellArgsFor(kind shellKind) []string {
+	switch kind {
+	case shellPOSIX:
+		return []string{"-c"}
+	case shellPowerShell:
+		return []string{"-Command"}
+	}
+	return []string{"/C"}
+}
+
+// windowsShell returns the default shell on Windows, and its args: the one configured (see
+// tasks.WindowsShell), else cmd.exe (%ComSpec%, or the one on PATH), else PowerShell (pwsh,
+// then Windows PowerShell) where there's no cmd.exe, as in stripped-down containers.
+func windowsShell() (exe string, args []string) {
+	root, _ := tasks.ProjectRoot()
+	switch s := tasks.WindowsShell(root); strings.ToLower(s) {
+	case "":
+	case "cmd":
+		if c := comSpec(); c != "" {
+			return c, shellArgsFor(shellCmd)
+		}
+		return "cmd.exe", shellArgsFor(shellCmd)
+	case "pwsh", "powershell":
+		if p, err := exec.LookPath(s); err == nil {
+			return p, shellArgsFor(shellPowerShell)
+		}
+		utils.Tracef("vstask.windowsShell %s: not found on PATH; using the default shell", s)
+	default:
+		return s, shellArgsFor(kindOfShell(s))
+	}
+	if c := comSpec(); c != "" {
+		return c, shellArgsFor(shellCmd)
+	}
+	for _, ps := range []string{"pwsh", "powershell"} {
+		if p, err := exec.LookPath(ps); err == nil {
+			utils.Tracef("no cmd.exe (%%ComSpec%% or on PATH); using %s", p)
+			return p, shellArgsFor(shellPowerShell)
+		}
+	}
+	return "cmd.exe", shellArgsFor(shellCmd)
+}
+
+// comSpec returns cmd.exe's path: %ComSpec% if it exists, else the cmd.exe on PATH, else "".
+func comSpec() string {
+	if c := os.Getenv("ComSpec"); c != "" {
+		if utils.FileExists(c) {
+			return c
+		}
+		utils.Tracef("%%ComSpec%% %s: no such file", c)
+	}
+	if p, err := exec.LookPath("cmd.exe"); err == nil {
+		return p
+	}
+	return ""
+}
+
+// psQuote quotes s for PowerShell when needed: in single quotes, in which nothing expands and
+// a quote is doubled (PowerShell takes the typographic single quotes for ' too).
+func psQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	if strings.IndexFunc(s, func(r rune) bool {
+		return r <= ' ' || strings.ContainsRune("'\"`$;&|(){}[]<>@#,‘’‚‛“”", r)
+	}) < 0 {
+		return s
+	}
+	var b strings.Builder
+	b.WriteByte('\'')
+	for _, r := range s {
+		if strings.ContainsRune("'‘’‚‛", r) {
+			b.WriteRune(r)
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
@@ -0,0 +1,81 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestPSQuote(t *testing.T) {
+	for in, want := range map[string]string{
+		"":                   "''",
+		"plain":              "plain",
+		"hello world":        "'hello world'",
+		"it's":               "'it''s'",
+		"$env:HOME":          "'$env:HOME'",
+		"a;b":                "'a;b'",
+		`C:\Program Files\x`: `'C:\Program Files\x'`,
+		"‘quoted’":           "'‘‘quoted’’'",
+	} {
+		if got := psQuote(in); got != want {
+			t.Errorf("psQuote(%q) = %s, want %s", in, got, want)
+		}
+	}
+	if got, want := commandLine("Write-Output", []string{"hello world", "$x"}, shellPowerShell), "Write-Output 'hello world' '$x'"; got != want {
+		t.Errorf("commandLine = %s, want %s", got, want)
+	}
+}
+
+func TestKindOfShell(t *testing.T) {
+	for _, exe := range []string{"pwsh", `C:\Program Files\PowerShell\7\pwsh.exe`, "powershell.exe"} {
+		if got := kindOfShell(exe); got != shellPowerShell {
+			t.Errorf("kindOfShell(%q) = %v, want PowerShell", exe, got)
+		}
+	}
+	want := shellPOSIX
+	if runtime.GOOS == "windows" {
+		want = shellCmd
+	}
+	if got := kindOfShell("cmd.exe"); got != want {
+		t.Errorf("kindOfShell(cmd.exe) = %v, want %v", got, want)
+	}
+}
+
+func TestWindowsShell(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+	bin := t.TempDir()
+	for _, name := range []string{"pwsh", "pwsh.exe"} {
+		writeFile(t, filepath.Join(bin, name), "")
+		if err := os.Chmod(filepath.Join(bin, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", bin)
+	t.Setenv("VSTASK_WINDOWS_SHELL", "")
+
+	comspec := filepath.Join(t.TempDir(), "cmd.exe")
+	writeFile(t, comspec, "")
+	t.Setenv("ComSpec", comspec)
+	if exe, args := windowsShell(); exe != comspec || !slices.Equal(args, []string{"/C"}) {
+		t.Errorf("with %%ComSpec%%: %s %v", exe, args)
+	}
+
+	t.Setenv("ComSpec", filepath.Join(bin, "missing", "cmd.exe"))
+	if exe, args := windowsShell(); filepath.Dir(exe) != bin || !slices.Equal(args, []string{"-Command"}) {
+		t.Errorf("without cmd.exe: %s %v, want pwsh", exe, args)
+	}
+
+	t.Setenv("ComSpec", comspec)
+	t.Setenv("VSTASK_WINDOWS_SHELL", "pwsh")
+	if exe, args := windowsShell(); filepath.Dir(exe) != bin || !slices.Equal(args, []string{"-Command"}) {
+		t.Errorf("configured pwsh: %s %v", exe, args)
+	}
+	t.Setenv("VSTASK_WINDOWS_SHELL", "powershell") // not installed
+	if exe, _ := windowsShell(); exe != comspec {
+		t.Errorf("configured but missing powershell: %s, want the default", exe)
+	}
+}
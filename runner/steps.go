@@ -0,0 +1,110 @@
+package runner
+
+import (
+	"fmt"
+)
+
+// selectSteps returns the dependencies in root's graph not to run this time, and why: with
+// startAt, the steps before it in every sequence leading to it (--start-at, to resume a failed
+// pipeline), and the dependencies skip names (--skip). Tasks are named by label or, for other
+// workspace folders, "folder: label".
+func selectSteps(root *taskNode, startAt string, skip []string) (map[*taskNode]string, error) {
+	out := map[*taskNode]string{}
+	nodes := graphNodes(root)
+	matches := func(n *taskNode, name string) bool {
+		return n.name == name || n.task.Label == name
+	}
+
+	if startAt != "" {
+		if matches(root, startAt) {
+			return nil, fmt.Errorf("--start-at: %q is the task being run", startAt)
+		}
+		// reaches[n]: n is startAt or depends on it, directly or not.
+		reaches := map[*taskNode]bool{}
+		var reach func(n *taskNode) bool
+		reach = func(n *taskNode) bool {
+			if r, ok := reaches[n]; ok {
+				return r
+			}
+			reaches[n] = false // the graph has no cycles; this just ends the recursion
+			r := matches(n, startAt)
+			for _, d := range n.deps {
+				r = reach(d) || r
+			}
+			reaches[n] = r
+			return r
+		}
+		found := false
+		for _, n := range nodes {
+			if !n.sequential() {
+				continue
+			}
+			for i, d := range n.deps {
+				if reach(d) {
+					found = true
+					for _, before := range n.deps[:i] {
+						out[before] = "--start-at " + startAt
+					}
+					break
+				}
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("--start-at: %q isn't a step of a sequence (\"dependsOrder\": \"sequence\") in %s's dependencies", startAt, root.name)
+		}
+	}
+
+	for _, name := range skip {
+		found := false
+		for _, n := range nodes {
+			if n != root && matches(n, name) {
+				out[n] = "--skip"
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("--skip: %q isn't one of %s's dependencies", name, root.name)
+		}
+	}
+	return out, nil
+}
+
+// selectFailures returns the tasks in root's graph (root included) to report as failed without
+// running them (--fail), to try out how a pipeline handles a failure. Tasks are named as for
+// selectSteps.
+func selectFailures(root *taskNode, names []string) (map[*taskNode]bool, error) {
+	out := map[*taskNode]bool{}
+	nodes := graphNodes(root)
+	for _, name := range names {
+		found := false
+		for _, n := range nodes {
+			if n.name == name || n.task.Label == name {
+				out[n] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("--fail: %q is neither %s nor one of its dependencies", name, root.name)
+		}
+	}
+	return out, nil
+}
+
+// graphNodes returns root and everything it depends on, each once, dependencies first.
+func graphNodes(root *taskNode) []*taskNode {
+	var out []*taskNode
+	seen := map[*taskNode]bool{}
+	var walk func(n *taskNode)
+	walk = func(n *taskNode) {
+		if seen[n] {
+			return
+		}
+		seen[n] = true
+		for _, d := range n.deps {
+			walk(d)
+		}
+		out = append(out, n)
+	}
+	walk(root)
+	return out
+}
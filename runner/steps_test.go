@@ -0,0 +1,140 @@
+package runner
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func TestSelectSteps(t *testing.T) {
+	ref := func(l string) tasks.TaskRef { return tasks.TaskRef{Label: l} }
+	seq := func(label string, deps ...string) tasks.Task {
+		t := tasks.Task{Label: label, DependsOrder: "sequence", DependsOn: &tasks.DependsOn{}}
+		for _, d := range deps {
+			t.DependsOn.Tasks = append(t.DependsOn.Tasks, ref(d))
+		}
+		return t
+	}
+	// ci runs lint, build (compile, then package) and test in sequence; test needs gen.
+	ts := []tasks.Task{
+		seq("ci", "lint", "build", "test"),
+		seq("build", "compile", "package"),
+		{Label: "test", DependsOn: &tasks.DependsOn{Tasks: []tasks.TaskRef{ref("gen")}}},
+		{Label: "lint"}, {Label: "compile"}, {Label: "package"}, {Label: "gen"},
+	}
+	root := t.TempDir()
+	g := newTaskGraph(newDepIndex(root, ts, NewInputResolver(nil)))
+	ci, err := g.add(ts[0], root, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skipped := func(startAt string, skip ...string) []string {
+		t.Helper()
+		sel, err := selectSteps(ci, startAt, skip)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for n := range sel {
+			out = append(out, n.name)
+		}
+		slices.Sort(out)
+		return out
+	}
+	if got := skipped("package"); !slices.Equal(got, []string{"compile", "lint"}) {
+		t.Errorf("--start-at package skips %v", got)
+	}
+	if got := skipped("test", "gen"); !slices.Equal(got, []string{"build", "gen", "lint"}) {
+		t.Errorf("--start-at test --skip gen skips %v", got)
+	}
+	if got := skipped("lint"); len(got) != 0 {
+		t.Errorf("--start-at the first step skips %v", got)
+	}
+	if got := skipped("gen"); !slices.Equal(got, []string{"build", "lint"}) {
+		t.Errorf("--start-at a step's dependency skips %v", got)
+	}
+	test, _ := g.add(ts[2], root, nil)
+	if _, err := selectSteps(test, "gen", nil); err == nil || !strings.Contains(err.Error(), "isn't a step of a sequence") {
+		t.Errorf("--start-at without a sequence: %v", err)
+	}
+	for _, c := range []struct {
+		startAt string
+		skip    []string
+		err     string
+	}{
+		{"ci", nil, "is the task being run"},
+		{"nope", nil, "isn't a step of a sequence"},
+		{"", []string{"ci"}, "isn't one of ci's dependencies"},
+	} {
+		if _, err := selectSteps(ci, c.startAt, c.skip); err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("selectSteps(%q, %q): %v, want %q", c.startAt, c.skip, err, c.err)
+		}
+	}
+}
+
+func TestRun_StartAt(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	dir := diamondTasks(t, "sequence")
+	all, err := tasks.GetTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := tasks.FindTask(all, "A")
+	if err := Run(a, Options{StartAt: "C", Quiet: true}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "run.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Fields(string(b)); !slices.Equal(got, []string{"D", "C", "A"}) {
+		t.Fatalf("ran %v; want B skipped, D still run for C", got)
+	}
+}
+
+func TestRun_Fail(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	dir := diamondTasks(t, "sequence")
+	all, err := tasks.GetTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := tasks.FindTask(all, "A")
+	err = Run(a, Options{Fail: []string{"C"}, Quiet: true})
+	var de *DependencyFailedError
+	var se *SimulatedFailureError
+	if !errors.As(err, &de) || de.Label != "C" || !errors.As(err, &se) {
+		t.Fatalf("err = %v; want C's simulated failure", err)
+	}
+	if code := ExitCode(err); code != 1 {
+		t.Errorf("ExitCode = %d, want 1", code)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "run.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Fields(string(b)); !slices.Equal(got, []string{"D", "B"}) {
+		t.Fatalf("ran %v; want C's dependency and the step before it, then nothing", got)
+	}
+	root, _ := tasks.ProjectRoot()
+	if loadRunResults(root) != nil {
+		t.Error("a run with --fail was kept for --retry-failed")
+	}
+	if label, ok := LastRunLabel(root); ok {
+		t.Errorf("a run with --fail was kept for --last: %q", label)
+	}
+
+	if err := Run(a, Options{Fail: []string{"nope"}}); err == nil || !strings.Contains(err.Error(), "is neither A nor one of its dependencies") {
+		t.Fatalf("--fail nope: %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// prependPath puts dirs (a task's options.path) in front of env's PATH.
+func prependPath(env []string, dirs []string) []string {
+	if len(dirs) == 0 {
+		return env
+	}
+	prefix := strings.Join(dirs, string(os.PathListSeparator))
+	for i, kv := range env {
+		k, v, _ := strings.Cut(kv, "=")
+		if isPathKey(k) {
+			out := append([]string(nil), env...)
+			if v != "" {
+				v = prefix + string(os.PathListSeparator) + v
+			} else {
+				v = prefix
+			}
+			out[i] = k + "=" + v
+			return out
+		}
+	}
+	return append(env, "PATH="+prefix)
+}
+
+// isPathKey reports whether k names PATH (case-insensitively on Windows, where it's "Path").
+func isPathKey(k string) bool {
+	return envKeyEqual(k, "PATH")
+}
+
+// lookPathIn finds a process task's command in its options.path directories, which the
+// process's own PATH lookup doesn't see. Anything else is returned as is.
+func lookPathIn(name string, t tasks.Task) string {
+	if t.Options == nil || strings.ContainsAny(name, `/\`) {
+		return name
+	}
+	for _, dir := range t.Options.Path {
+		if p, err := exec.LookPath(filepath.Join(dir, name)); err == nil {
+			return p
+		}
+	}
+	return name
+}
@@ -0,0 +1,81 @@
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func TestResolveTask_OptionsPathPrependsToPATH(t *testing.T) {
+	workspace := t.TempDir()
+	for _, d := range []string{"bin", "tools"} {
+		if err := os.MkdirAll(filepath.Join(workspace, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", "/usr/bin")
+	rt, err := resolveTask(tasks.Task{
+		Label:   "p",
+		Options: &tasks.Options{Path: []string{"./bin", "${workspaceFolder}/tools"}},
+	}, workspace, NewInputResolver(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sep := string(os.PathListSeparator)
+	want := filepath.Join(workspace, "bin") + sep + filepath.Join(workspace, "tools") + sep + "/usr/bin"
+	var got string
+	for _, kv := range rt.Env {
+		if k, v, _ := strings.Cut(kv, "="); isPathKey(k) {
+			got = v
+		}
+	}
+	if got != want {
+		t.Fatalf("PATH = %q, want %q", got, want)
+	}
+}
+
+func TestResolveTask_OptionsPathMustExist(t *testing.T) {
+	_, err := resolveTask(tasks.Task{
+		Label:   "p",
+		Options: &tasks.Options{Path: []string{"missing"}},
+	}, t.TempDir(), NewInputResolver(nil))
+	if err == nil || !strings.Contains(err.Error(), "options.path: not a directory") {
+		t.Fatalf("err = %v, want a missing path error", err)
+	}
+}
+
+func TestPrependPath_AddsPATHWhenMissing(t *testing.T) {
+	got := prependPath([]string{"HOME=/h"}, []string{"/a", "/b"})
+	want := "PATH=/a" + string(os.PathListSeparator) + "/b"
+	if len(got) != 2 || got[1] != want {
+		t.Fatalf("got %v, want %q added", got, want)
+	}
+}
+
+func TestProcessTaskFindsCommandInOptionsPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX executable bits")
+	}
+	bin := t.TempDir()
+	writeFile(t, filepath.Join(bin, "hello-tool"), "#!/bin/sh\necho hi\n")
+	if err := os.Chmod(filepath.Join(bin, "hello-tool"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := exec.LookPath("hello-tool"); err == nil {
+		t.Skip("hello-tool is on PATH already")
+	}
+	tk := tasks.Task{Type: "process", Command: "hello-tool", Options: &tasks.Options{Path: []string{bin}}}
+	cmd, _, err := buildCmd(tk, bin, os.Environ())
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := cmd.Output()
+	if err != nil || strings.TrimSpace(string(out)) != "hi" {
+		t.Fatalf("output %q, err %v", out, err)
+	}
+}
@@ -0,0 +1,225 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/chenasraf/vstask/tasks"
+	"github.com/chenasraf/vstask/utils"
+)
+
+// With VSTASK_TERMINAL=tmux (inside a tmux session), presentation.panel gets its VS Code meaning:
+//   - "shared" (default): the task streams into the current terminal, as usual
+//   - "dedicated": the task runs in a pane of its own, reused by later runs of the same task
+//   - "new": every run opens a new pane
+//
+// presentation.focus selects the task's pane (its own, or vstask's pane for shared tasks) when
+// it starts. Outside tmux mode focus has no meaning and is ignored.
+//
+// Panes keep a shell open after the task exits so its output stays visible. Tasks that need
+// readiness detection (background dependencies) always run in the current terminal, since
+// vstask has to watch their output.
+
+const tmuxPanesFile = "tmux-panes.json"
+
+// tmuxCommand runs tmux; tests replace it.
+var tmuxCommand = func(ctx context.Context, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, "tmux", args...).Output()
+}
+
+// tmuxPanel returns the panel mode to run t with under tmux, or "" to run it in the
+// current terminal.
+func tmuxPanel(t tasks.Task) string {
+	if os.Getenv("VSTASK_TERMINAL") != "tmux" || os.Getenv("TMUX") == "" || t.Presentation == nil {
+		return ""
+	}
+	switch p := strings.ToLower(t.Presentation.Panel); p {
+	case "dedicated", "new":
+		return p
+	default:
+		return ""
+	}
+}
+
+// runInTmuxPane runs cmd in a tmux pane and waits for it to finish, returning an error if it
+// exits non-zero. label identifies the pane reused by "dedicated" tasks within workspace;
+// focus selects the pane once the task starts.
+func runInTmuxPane(ctx context.Context, cmd *exec.Cmd, panel, label, workspace string, focus bool) error {
+	dir, err := os.MkdirTemp("", "vstask-tmux-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	statusFile := filepath.Join(dir, "status")
+	channel := "vstask-" + filepath.Base(dir)
+
+	script := tmuxScript(cmd, statusFile, channel, label)
+	var pane string
+	if panel == "dedicated" {
+		pane = dedicatedPane(ctx, workspace, label)
+	}
+	if pane != "" {
+		_, err = tmuxCommand(ctx, "respawn-pane", "-k", "-t", pane, "-c", cmd.Dir, script)
+	} else {
+		args := []string{"split-window", "-d", "-P", "-F", "#{pane_id}", "-c", cmd.Dir}
+		if target := os.Getenv("TMUX_PANE"); target != "" {
+			args = append(args, "-t", target)
+		}
+		var out []byte
+		out, err = tmuxCommand(ctx, append(args, script)...)
+		pane = strings.TrimSpace(string(out))
+	}
+	if err != nil {
+		return fmt.Errorf("tmux: %w", err)
+	}
+	if panel == "dedicated" {
+		_ = rememberPane(workspace, label, pane)
+	}
+	if focus {
+		focusTmuxPane(ctx, pane)
+	}
+
+	// Block until the pane signals completion; on cancel, interrupt the task.
+	waitCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, err := tmuxCommand(waitCtx, "wait-for", channel)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("tmux: %w", err)
+		}
+	case <-ctx.Done():
+		_, _ = tmuxCommand(context.Background(), "send-keys", "-t", pane, "C-c")
+		return ctx.Err()
+	}
+
+	b, err := os.ReadFile(statusFile)
+	if err != nil {
+		return fmt.Errorf("tmux: task status unavailable: %w", err)
+	}
+	code, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		return fmt.Errorf("tmux: bad task status %q", b)
+	}
+	if code != 0 {
+		return &exitStatusError{code}
+	}
+	return nil
+}
+
+// tmuxFocus handles presentation.focus for a task running in the current terminal: under
+// tmux mode, vstask's own pane is selected (it may have been left for another task's pane).
+func tmuxFocus(ctx context.Context, t tasks.Task) {
+	if t.Presentation == nil || !t.Presentation.Focus || os.Getenv("VSTASK_TERMINAL") != "tmux" || os.Getenv("TMUX") == "" {
+		return
+	}
+	if pane := os.Getenv("TMUX_PANE"); pane != "" {
+		focusTmuxPane(ctx, pane)
+	}
+}
+
+// focusTmuxPane selects pane and its window. Failures are ignored: focus is cosmetic.
+func focusTmuxPane(ctx context.Context, pane string) {
+	_, _ = tmuxCommand(ctx, "select-window", "-t", pane)
+	_, _ = tmuxCommand(ctx, "select-pane", "-t", pane)
+}
+
+// tmuxScript is the shell command run in the pane: the task (with the env vstask computed),
+// then its status is reported back and the pane stays open on an interactive shell.
+func tmuxScript(cmd *exec.Cmd, statusFile, channel, label string) string {
+	parts := []string{"env"}
+	for _, kv := range envDiff(os.Environ(), cmd.Env) {
+		parts = append(parts, shellQuote(kv))
+	}
+	for _, a := range cmd.Args {
+		parts = append(parts, shellQuote(a))
+	}
+	return strings.Join(parts, " ") +
+		fmt.Sprintf("; s=$?; echo $s > %s; tmux wait-for -S %s; ", shellQuote(statusFile), channel) +
+		fmt.Sprintf(`printf '\n[vstask] %%s exited with status %%s\n' %s "$s"; exec "${SHELL:-/bin/sh}"`, shellQuote(label))
+}
+
+// envDiff returns the entries of env that aren't in base (new or changed variables), plus PATH:
+// the pane's shell inherits the tmux server's environment, not ours.
+func envDiff(base, env []string) []string {
+	if env == nil {
+		env = base
+	}
+	var out []string
+	for _, kv := range env {
+		if strings.HasPrefix(kv, "PATH=") || !slices.Contains(base, kv) {
+			out = append(out, kv)
+		}
+	}
+	return out
+}
+
+// shellQuote single-quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r == '/' || r == '.' || r == '-' || r == '_' || r == '=' || r == ':' || r == ',' ||
+			(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ---- dedicated pane registry ----
+
+func panesPath(workspace string) (string, error) {
+	dir, err := utils.WorkspaceStateDir(workspace)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, tmuxPanesFile), nil
+}
+
+// dedicatedPane returns the live pane previously used for label, or "".
+func dedicatedPane(ctx context.Context, workspace, label string) string {
+	p, err := panesPath(workspace)
+	if err != nil {
+		return ""
+	}
+	panes := map[string]string{}
+	if err := utils.ReadJSONFile(p, &panes); err != nil {
+		return ""
+	}
+	pane := panes[label]
+	if pane == "" {
+		return ""
+	}
+	out, err := tmuxCommand(ctx, "display-message", "-p", "-t", pane, "#{pane_id}")
+	if err != nil || strings.TrimSpace(string(out)) != pane {
+		return ""
+	}
+	return pane
+}
+
+func rememberPane(workspace, label, pane string) error {
+	if pane == "" {
+		return errors.New("no pane id")
+	}
+	p, err := panesPath(workspace)
+	if err != nil {
+		return err
+	}
+	return utils.UpdateJSONFile(p, func(panes *map[string]string) error {
+		if *panes == nil {
+			*panes = map[string]string{}
+		}
+		(*panes)[label] = pane
+		return nil
+	})
+}
@@ -0,0 +1,141 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+func TestTmuxPanel(t *testing.T) {
+	dedicated := tasks.Task{Presentation: &tasks.Presentation{Panel: "dedicated"}}
+	shared := tasks.Task{Presentation: &tasks.Presentation{Panel: "shared"}}
+
+	t.Setenv("TMUX", "")
+	t.Setenv("VSTASK_TERMINAL", "tmux")
+	if got := tmuxPanel(dedicated); got != "" {
+		t.Fatalf("outside tmux: got %q", got)
+	}
+	t.Setenv("TMUX", "/tmp/sock,1,0")
+	if got := tmuxPanel(dedicated); got != "dedicated" {
+		t.Fatalf("got %q", got)
+	}
+	if got := tmuxPanel(shared); got != "" {
+		t.Fatalf("shared streams into the current terminal, got %q", got)
+	}
+	if got := tmuxPanel(tasks.Task{}); got != "" {
+		t.Fatalf("no presentation: got %q", got)
+	}
+	t.Setenv("VSTASK_TERMINAL", "")
+	if got := tmuxPanel(dedicated); got != "" {
+		t.Fatalf("tmux mode is opt-in, got %q", got)
+	}
+}
+
+func TestTmuxScript(t *testing.T) {
+	t.Setenv("VSTASK_TMUX_TEST", "same")
+	cmd := exec.Command("/bin/sh", "-c", "echo 'hi'")
+	cmd.Env = append(os.Environ(), "NEW=a b")
+	s := tmuxScript(cmd, "/tmp/st", "chan", "my task")
+	for _, want := range []string{`env `, `'NEW=a b'`, `/bin/sh -c 'echo '\''hi'\'''`, "echo $s > /tmp/st", "tmux wait-for -S chan", `'my task'`} {
+		if !strings.Contains(s, want) {
+			t.Errorf("script should contain %q:\n%s", want, s)
+		}
+	}
+	if strings.Contains(s, "VSTASK_TMUX_TEST") {
+		t.Errorf("unchanged variables should not be repeated:\n%s", s)
+	}
+}
+
+// startTmux runs a private, detached tmux server and points $TMUX at it.
+func startTmux(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("tmux")
+	}
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux not installed")
+	}
+	sock := filepath.Join(t.TempDir(), "tmux.sock")
+	if out, err := exec.Command("tmux", "-S", sock, "new-session", "-d", "-x", "120", "-y", "40").CombinedOutput(); err != nil {
+		t.Skipf("cannot start tmux: %v (%s)", err, out)
+	}
+	t.Cleanup(func() { _ = exec.Command("tmux", "-S", sock, "kill-server").Run() })
+	out, err := exec.Command("tmux", "-S", sock, "display-message", "-p", "#{pane_id}").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TMUX", sock+",0,0")
+	t.Setenv("TMUX_PANE", strings.TrimSpace(string(out)))
+	t.Setenv("VSTASK_TERMINAL", "tmux")
+}
+
+func TestRunInTmuxPane(t *testing.T) {
+	startTmux(t)
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	ws := t.TempDir()
+	ctx := context.Background()
+
+	cmd := exec.Command("/bin/sh", "-c", "exit 0")
+	cmd.Dir = ws
+	if err := runInTmuxPane(ctx, cmd, "new", "ok", ws, false); err != nil {
+		t.Fatalf("new pane: %v", err)
+	}
+
+	fail := func() *exec.Cmd {
+		c := exec.Command("/bin/sh", "-c", "exit 3")
+		c.Dir = ws
+		return c
+	}
+	if err := runInTmuxPane(ctx, fail(), "dedicated", "lint", ws, false); err == nil || err.Error() != "exit status 3" {
+		t.Fatalf("expected exit status 3, got %v", err)
+	}
+	first := dedicatedPane(ctx, ws, "lint")
+	if first == "" {
+		t.Fatal("dedicated pane should be remembered")
+	}
+	if err := runInTmuxPane(ctx, fail(), "dedicated", "lint", ws, false); err == nil {
+		t.Fatal("expected failure on re-run")
+	}
+	if again := dedicatedPane(ctx, ws, "lint"); again != first {
+		t.Fatalf("dedicated pane should be reused: %q then %q", first, again)
+	}
+}
+
+func TestRunInTmuxPane_Focus(t *testing.T) {
+	startTmux(t)
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	ws := t.TempDir()
+	ctx := context.Background()
+	main := os.Getenv("TMUX_PANE")
+
+	active := func() string {
+		out, err := tmuxCommand(ctx, "display-message", "-p", "-t", main, "#{window_active}:#{pane_id}:#{pane_active}")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	cmd := exec.Command("/bin/sh", "-c", "exit 0")
+	cmd.Dir = ws
+	if err := runInTmuxPane(ctx, cmd, "dedicated", "focused", ws, true); err != nil {
+		t.Fatal(err)
+	}
+	pane := dedicatedPane(ctx, ws, "focused")
+	out, _ := tmuxCommand(ctx, "display-message", "-p", "-t", pane, "#{pane_active}")
+	if strings.TrimSpace(string(out)) != "1" {
+		t.Fatalf("focused task's pane should be active (main: %s)", active())
+	}
+
+	// A shared task asking for focus brings vstask's own pane back.
+	tmuxFocus(ctx, tasks.Task{Presentation: &tasks.Presentation{Focus: true}})
+	if got := active(); !strings.HasSuffix(got, ":1") {
+		t.Fatalf("main pane should be active again, got %s", got)
+	}
+}
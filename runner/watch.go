@@ -0,0 +1,308 @@
+package runner
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultWatchDebounce is how long `vstask watch` lets changes settle before rerunning.
+const DefaultWatchDebounce = 300 * time.Millisecond
+
+// watchStopGrace is how long a run stopped for a rerun gets to clean up (stop its own tasks)
+// before it's killed.
+const watchStopGrace = 5 * time.Second
+
+// maxWatchedDirs caps how many directories WatchTask watches, well under the usual inotify limit.
+const maxWatchedDirs = 4096
+
+// maxSelfReruns is how many reruns in a row WatchTask lets changes made while the task was
+// running set off before it takes them for the task's own output and waits for another change.
+const maxSelfReruns = 3
+
+// unwatchedDirs are the directories watchTree leaves out (besides hidden ones): what's in them
+// is fetched or generated, and there's often a lot of it.
+var unwatchedDirs = []string{"node_modules", "vendor", "bower_components", "__pycache__"}
+
+// WatchTask runs argv (vstask running the task label) and reruns it whenever files under root
+// matching globs (see matchGlob) change, once no change came in for debounce. A run still going
+// then is stopped first. It returns once ctx is done and the current run has exited.
+//
+// A change that leaves a file as it was last seen doesn't count, so a task rewriting its output
+// unchanged doesn't rerun itself; nor, after maxSelfReruns in a row, do changes made only while
+// it ran (output that differs each time, say).
+func WatchTask(ctx context.Context, label, root string, globs []string, debounce time.Duration, argv []string) error {
+	for _, g := range globs {
+		if _, err := path.Match(g, ""); err != nil {
+			return fmt.Errorf("glob %q: %w", g, err)
+		}
+	}
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	for _, g := range globs {
+		if err := watchTree(w, existingParent(filepath.Join(root, filepath.FromSlash(globBase(g))))); err != nil {
+			return err
+		}
+	}
+
+	run := startWatchRun(argv)
+	done := run.done
+	var changed []string
+	var settle <-chan time.Time
+	sums := map[string]string{} // what changed files held when last seen
+	var ended time.Time         // when the last run exited
+	idle := false               // whether a change came in while no run was going
+	selfReruns := 0             // reruns in a row set off only by changes made while the task ran
+	note := func(p string) {
+		rel, err := filepath.Rel(root, p)
+		if rel = filepath.ToSlash(rel); err != nil || !matchAnyGlob(globs, rel) {
+			return
+		}
+		// Output a run writes as it exits may come in a little later.
+		if done == nil && time.Since(ended) > debounce {
+			idle = true
+		}
+		if !slices.Contains(changed, rel) {
+			changed = append(changed, rel)
+		}
+		settle = time.After(debounce)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			run.stop()
+			return nil
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if ev.Op == fsnotify.Chmod {
+				continue
+			}
+			note(ev.Name)
+			if fi, err := os.Stat(ev.Name); err == nil && fi.IsDir() && ev.Has(fsnotify.Create) && !skipWatchDir(fi.Name()) {
+				// What was created in it before it was watched changed too.
+				_ = watchTree(w, ev.Name)
+				_ = filepath.WalkDir(ev.Name, func(p string, d fs.DirEntry, err error) error {
+					switch {
+					case err != nil:
+					case d.IsDir() && p != ev.Name && skipWatchDir(d.Name()):
+						return filepath.SkipDir
+					case !d.IsDir():
+						note(p)
+					}
+					return nil
+				})
+			}
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "Warning: watching files: %v\n", err)
+		case <-settle:
+			settle = nil
+			var diff []string // the changed files that aren't as they were last seen
+			for _, rel := range changed {
+				sum := fileSum(filepath.Join(root, filepath.FromSlash(rel)))
+				if old, ok := sums[rel]; !ok || old != sum {
+					sums[rel] = sum
+					diff = append(diff, rel)
+				}
+			}
+			changed = nil
+			if len(diff) == 0 {
+				idle = false
+				continue
+			}
+			what := diff[0]
+			if len(diff) > 1 {
+				what += fmt.Sprintf(" and %d more", len(diff)-1)
+			}
+			if idle {
+				selfReruns = 0
+			} else if selfReruns++; selfReruns > maxSelfReruns {
+				fmt.Fprintf(os.Stderr, "Watch: %s changed again while %s ran (its own output?); not rerunning until something else changes\n", what, label)
+				continue
+			}
+			idle = false
+			fmt.Fprintf(os.Stderr, "Watch: %s changed; rerunning %s\n", what, label)
+			run.stop()
+			run = startWatchRun(argv)
+			done = run.done
+		case <-done:
+			done, ended = nil, time.Now()
+			if run.err != nil {
+				fmt.Fprintf(os.Stderr, "Watch: %s failed (%v); waiting for changes…\n", label, run.err)
+			} else {
+				fmt.Fprintf(os.Stderr, "Watch: %s succeeded; waiting for changes…\n", label)
+			}
+		}
+	}
+}
+
+// watchRun is one run of the watched task, in a child process.
+type watchRun struct {
+	cmd  *exec.Cmd
+	done chan struct{} // closed once it has exited
+	err  error
+}
+
+func startWatchRun(argv []string) *watchRun {
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	r := &watchRun{cmd: cmd, done: make(chan struct{})}
+	if r.err = cmd.Start(); r.err != nil {
+		close(r.done)
+		return r
+	}
+	go func() {
+		r.err = cmd.Wait()
+		close(r.done)
+	}()
+	return r
+}
+
+// stop stops the run, if it's still going, and waits for it to exit. It's asked to stop first
+// (on Unix; vstask then stops its tasks, process trees and all) and killed after a grace period.
+func (r *watchRun) stop() {
+	select {
+	case <-r.done:
+		return
+	default:
+	}
+	interruptProcess(r.cmd.Process)
+	select {
+	case <-r.done:
+	case <-time.After(watchStopGrace):
+		_ = r.cmd.Process.Kill()
+		<-r.done
+	}
+}
+
+// watchTree watches dir and the directories under it, except hidden ones and unwatchedDirs
+// (unless dir is one), up to maxWatchedDirs in all.
+func watchTree(w *fsnotify.Watcher, dir string) error {
+	n := len(w.WatchList())
+	if n >= maxWatchedDirs {
+		return nil // warned about already
+	}
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == dir {
+				return err
+			}
+			return nil // e.g. removed meanwhile
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if p != dir && skipWatchDir(d.Name()) {
+			return filepath.SkipDir
+		}
+		if n >= maxWatchedDirs {
+			fmt.Fprintf(os.Stderr, "Warning: watching files: over %d directories; not watching %s and the rest (narrow --glob)\n", maxWatchedDirs, p)
+			return filepath.SkipAll
+		}
+		n++
+		return w.Add(p)
+	})
+}
+
+// skipWatchDir reports whether watchTree leaves out directories named name.
+func skipWatchDir(name string) bool {
+	return strings.HasPrefix(name, ".") || slices.Contains(unwatchedDirs, name)
+}
+
+// fileSum returns a hash of the file p's contents, or "" if it can't be read (removed, say, or
+// a directory).
+func fileSum(p string) string {
+	f, err := os.Open(p)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// existingParent returns p or its closest parent that exists, to watch for p to appear.
+func existingParent(p string) string {
+	for {
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+		parent := filepath.Dir(p)
+		if parent == p {
+			return p
+		}
+		p = parent
+	}
+}
+
+// globBase returns the directories glob starts with before any wildcard ("src" for
+// "src/**/*.go", "." for "*.go"): only what's under it can match.
+func globBase(glob string) string {
+	segs := strings.Split(glob, "/")
+	i := 0
+	for i < len(segs)-1 && !strings.ContainsAny(segs[i], `*?[\`) {
+		i++
+	}
+	if i == 0 {
+		return "."
+	}
+	return path.Join(segs[:i]...)
+}
+
+func matchAnyGlob(globs []string, name string) bool {
+	for _, g := range globs {
+		if matchGlob(g, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob reports whether the "/"-separated path name matches glob, whose segments match
+// name's as in path.Match, except "**", which matches any number of segments (none included).
+func matchGlob(glob, name string) bool {
+	return matchSegments(strings.Split(glob, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(glob, name []string) bool {
+	for len(glob) > 0 {
+		if glob[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(glob[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(glob[0], name[0]); !ok {
+			return false
+		}
+		glob, name = glob[1:], name[1:]
+	}
+	return len(name) == 0
+}
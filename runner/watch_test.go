@@ -0,0 +1,121 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatchGlob(t *testing.T) {
+	for _, c := range []struct {
+		glob, name string
+		want       bool
+	}{
+		{"src/**/*.go", "src/main.go", true},
+		{"src/**/*.go", "src/a/b/c.go", true},
+		{"src/**/*.go", "src/a/b/c.ts", false},
+		{"src/**/*.go", "lib/main.go", false},
+		{"**/*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"go.mod", "go.mod", true},
+		{"src/**", "src/a/b", true},
+		{"docs/*.md", "docs/a/b.md", false},
+	} {
+		if got := matchGlob(c.glob, c.name); got != c.want {
+			t.Errorf("matchGlob(%q, %q) = %v", c.glob, c.name, got)
+		}
+	}
+	for glob, want := range map[string]string{"src/**/*.go": "src", "*.go": ".", "src/cmd/main.go": "src/cmd", "a/b*/c": "a"} {
+		if got := globBase(glob); got != want {
+			t.Errorf("globBase(%q) = %q, want %q", glob, got, want)
+		}
+	}
+}
+
+func TestWatchTask(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	root := t.TempDir()
+	log := filepath.Join(root, "run.log")
+	// Each run logs its start, then its end unless it's stopped first.
+	script := `echo start >> run.log; [ -e slow ] && exec sleep 30; echo end >> run.log`
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	t.Chdir(root)
+	go func() {
+		done <- WatchTask(ctx, "test", root, []string{"src/**/*.txt"}, 50*time.Millisecond, []string{"sh", "-c", script})
+	}()
+	waitFor := func(want string) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(20 * time.Millisecond) {
+			b, _ := os.ReadFile(log)
+			if got := strings.Join(strings.Fields(string(b)), " "); got == want {
+				return
+			} else if time.Now().After(deadline) {
+				t.Fatalf("run.log: %q, want %q", got, want)
+			}
+		}
+	}
+	waitFor("start end")
+
+	writeFile(t, filepath.Join(root, "notes.md"), "not watched")
+	time.Sleep(200 * time.Millisecond)
+	writeFile(t, filepath.Join(root, "slow"), "")
+	writeFile(t, filepath.Join(root, "src", "a", "b.txt"), "new directory, new file")
+	waitFor("start end start")
+
+	// A change while it runs stops it before it reruns.
+	if err := os.Remove(filepath.Join(root, "slow")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(root, "src", "a", "b.txt"), "changed")
+	waitFor("start end start start end")
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("WatchTask didn't return once cancelled")
+	}
+}
+
+func TestWatchTask_OwnOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	for name, c := range map[string]struct {
+		write string
+		runs  int
+	}{
+		"unchanged": {"echo same > src/out.txt", 2},                   // the first write is new
+		"changing":  {"cat run.log > src/out.txt", 1 + maxSelfReruns}, // differs each run
+	} {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			writeFile(t, filepath.Join(root, "src", "keep"), "")
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			t.Chdir(root)
+			go func() {
+				done <- WatchTask(ctx, "build", root, []string{"src/*.txt"}, 50*time.Millisecond, []string{"sh", "-c", "echo run >> run.log; " + c.write})
+			}()
+			time.Sleep(1500 * time.Millisecond)
+			cancel()
+			if err := <-done; err != nil {
+				t.Fatal(err)
+			}
+			b, _ := os.ReadFile(filepath.Join(root, "run.log"))
+			if got := len(strings.Fields(string(b))); got != c.runs {
+				t.Fatalf("%d runs, want %d", got, c.runs)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+//go:build windows
+
+package runner
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chenasraf/vstask/tasks"
+)
+
+// buildArgsHelper builds a tiny program that prints its arguments as a JSON array. Run as
+// "helper spawn" it instead starts a copy of itself that sleeps, prints "CHILD=<pid>" and
+// waits for it.
+func buildArgsHelper(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	src := filepath.Join(dir, "helper.go")
+	bin := filepath.Join(dir, "helper.exe")
+
+	code := `package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "os/exec"
+    "time"
+)
+
+func main() {
+    if len(os.Args) > 1 && os.Args[1] == "sleep" {
+        time.Sleep(60 * time.Second)
+        return
+    }
+    if len(os.Args) > 1 && os.Args[1] == "spawn" {
+        child := exec.Command(os.Args[0], "sleep")
+        if err := child.Start(); err != nil {
+            fmt.Printf("ERR: %v\n", err)
+            os.Exit(1)
+        }
+        fmt.Printf("CHILD=%d\n", child.Process.Pid)
+        _ = child.Wait()
+        return
+    }
+    _ = json.NewEncoder(os.Stdout).Encode(os.Args[1:])
+}
+`
+	writeFile(t, src, code)
+	build := exec.Command("go", "build", "-o", bin, src)
+	build.Stdout, build.Stderr = os.Stdout, os.Stderr
+	if err := build.Run(); err != nil {
+		t.Fatalf("go build helper: %v", err)
+	}
+	return bin
+}
+
+// shellArgs runs a shell task that calls helper with args and returns the args it got.
+func shellArgs(t *testing.T, task tasks.Task) []string {
+	t.Helper()
+	cmd, cleanup, err := buildCmd(task, t.TempDir(), os.Environ())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("%s: %v", cmd.String(), err)
+	}
+	var got []string
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("helper output %q: %v", out, err)
+	}
+	return got
+}
+
+func TestWindows_CmdExeQuoting(t *testing.T) {
+	helper := buildArgsHelper(t)
+	args := []string{"hello world", `C:\Program Files\x`, "a&b", "x|y", "(p)", "<in>", "plain"}
+
+	got := shellArgs(t, tasks.Task{Type: "shell", Command: helper, Args: args})
+	if !slices.Equal(got, args) {
+		t.Fatalf("cmd.exe args = %q, want %q", got, args)
+	}
+
+	// A helper path with a space needs quoting too, so the command line starts with a quote.
+	spaced := filepath.Join(t.TempDir(), "with space", "helper.exe")
+	data, err := os.ReadFile(helper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, spaced, string(data))
+	got = shellArgs(t, tasks.Task{Type: "shell", Command: spaced, Args: args[:2]})
+	if !slices.Equal(got, args[:2]) {
+		t.Fatalf("cmd.exe args (quoted command) = %q, want %q", got, args[:2])
+	}
+}
+
+func TestWindows_PowerShellQuoting(t *testing.T) {
+	ps, err := exec.LookPath("powershell")
+	if err != nil {
+		t.Skip("powershell not found")
+	}
+	helper := buildArgsHelper(t)
+	if strings.ContainsAny(helper, " \t") {
+		t.Skip("temp dir path has spaces")
+	}
+	args := []string{"hello world", `C:\Program Files\x`, "$HOME", "it's", "a;b", "plain"}
+	got := shellArgs(t, tasks.Task{
+		Type:    "shell",
+		Command: helper,
+		Args:    args,
+		Options: &tasks.Options{Shell: &tasks.ShellOptions{Executable: ps, Args: []string{"-NoProfile", "-Command"}}},
+	})
+	if !slices.Equal(got, args) {
+		t.Fatalf("powershell args = %q, want %q", got, args)
+	}
+}
+
+func TestWindows_TerminateProcessTreeKillsChildren(t *testing.T) {
+	helper := buildArgsHelper(t)
+	cmd := exec.Command(helper, "spawn")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start helper: %v", err)
+	}
+	done := make(chan error, 1)
+
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	childPID, convErr := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "CHILD=")))
+	if err != nil || convErr != nil {
+		_ = cmd.Process.Kill()
+		t.Fatalf("failed to read child PID: %q (%v)", line, err)
+	}
+	go func() { done <- cmd.Wait() }()
+
+	if err := terminateProcessTree(cmd); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		_ = cmd.Process.Kill()
+		t.Fatal("helper did not exit after terminateProcessTree")
+	}
+
+	child, err := os.FindProcess(childPID)
+	if err != nil {
+		return // already gone
+	}
+	exited := make(chan struct{})
+	go func() {
+		_, _ = child.Wait()
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		_ = child.Kill()
+		t.Fatalf("child PID %d still running after terminateProcessTree", childPID)
+	}
+}
@@ -0,0 +1,90 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chenasraf/vstask/utils"
+)
+
+// NewTask is a task for AddTask, its fields in the order they're written.
+type NewTask struct {
+	Label     string   `json:"label"`
+	Type      string   `json:"type,omitempty"`
+	Command   string   `json:"command,omitempty"`
+	Script    string   `json:"script,omitempty"` // npm tasks
+	Group     *Group   `json:"group,omitempty"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// newTasksFile is the tasks.json AddTask starts from when the workspace has none.
+const newTasksFile = `{
+  // See https://go.microsoft.com/fwlink/?LinkId=733558
+  // for the documentation about the tasks.json format
+  "version": "2.0.0",
+  "tasks": []
+}
+`
+
+// AddTaskToFile appends t to the tasks of the tasks.json data. Comments and layout are kept;
+// the new task goes last, indented like the others. A task already labeled t.Label is an error.
+func AddTaskToFile(name string, data []byte, t NewTask) ([]byte, error) {
+	if strings.TrimSpace(t.Label) == "" {
+		return nil, errors.New("a task needs a label")
+	}
+	doc, err := parseJSONCDoc(data)
+	if err != nil {
+		return nil, &ParseError{File: name, Err: err}
+	}
+	root, err := doc.root()
+	if err != nil {
+		return nil, &ParseError{File: name, Err: err}
+	}
+	for _, o := range root.objects("tasks") {
+		var label string
+		if o.decode("label", &label) || o.decode("taskName", &label) {
+			if label == t.Label {
+				return nil, fmt.Errorf("%s already has a task labeled %q", filepath.Base(name), t.Label)
+			}
+		}
+	}
+	if err := root.append("tasks", t); err != nil {
+		return nil, err
+	}
+	out := doc.pack()
+	var f File
+	if err := unmarshalJSONC(name, out, &f); err != nil {
+		return nil, fmt.Errorf("edited file doesn't parse: %w", err)
+	}
+	return out, nil
+}
+
+// AddTask adds t to root's tasks.json (see AddTaskToFile), writing it through a symlink to its
+// target, or creating it if the workspace has none. It returns the file's path.
+func AddTask(root string, t NewTask) (string, error) {
+	p := TasksFilePath(root)
+	if isYAMLFile(p) {
+		return p, fmt.Errorf("%s: only tasks.json files can be added to; edit it by hand", filepath.Base(p))
+	}
+	if TasksFileGenerator(root) != "" {
+		return p, errors.New(`tasks.json is generated ("vstask.tasksFile.generate"): add the task to what generates it`)
+	}
+	data, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		data = []byte(newTasksFile)
+	} else if err != nil {
+		return p, err
+	}
+	out, err := AddTaskToFile(p, data, t)
+	if err != nil {
+		return p, err
+	}
+	target := p
+	if t, err := filepath.EvalSymlinks(p); err == nil {
+		target = t
+	}
+	return p, utils.WriteFileAtomic(target, out, 0o644)
+}
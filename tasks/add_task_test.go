@@ -0,0 +1,82 @@
+package tasks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAddTaskToFile(t *testing.T) {
+	in := `{
+	"version": "2.0.0",
+	"tasks": [
+		{ "label": "build", "command": "make" } // the usual
+	]
+}
+`
+	want := `{
+	"version": "2.0.0",
+	"tasks": [
+		{ "label": "build", "command": "make" }, // the usual
+		{
+			"label": "lint",
+			"type": "npm",
+			"script": "lint",
+			"group": "test",
+			"dependsOn": ["build"]
+		}
+	]
+}
+`
+	out, err := AddTaskToFile("tasks.json", []byte(in), NewTask{Label: "lint", Type: "npm", Script: "lint", Group: &Group{Kind: "test"}, DependsOn: []string{"build"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", out, want)
+	}
+
+	if _, err := AddTaskToFile("tasks.json", []byte(in), NewTask{Label: "build", Command: "make all"}); err == nil || !strings.Contains(err.Error(), `already has a task labeled "build"`) {
+		t.Fatalf("a duplicate label: err = %v", err)
+	}
+}
+
+func TestAddTaskToFile_NoTasks(t *testing.T) {
+	out, err := AddTaskToFile("tasks.json", []byte(`{ "version": "2.0.0" }`), NewTask{Label: "build", Type: "shell", Command: "make"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := parseTasksFile("tasks.json", out)
+	if err != nil {
+		t.Fatalf("%v:\n%s", err, out)
+	}
+	if len(f.Tasks) != 1 || f.Tasks[0].Label != "build" || f.Tasks[0].Command != "make" {
+		t.Fatalf("tasks %+v:\n%s", f.Tasks, out)
+	}
+}
+
+func TestAddTask(t *testing.T) {
+	isolateTasksFile(t)
+	root := t.TempDir()
+	p, err := AddTask(root, NewTask{Label: "build", Type: "shell", Command: "make"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != filepath.Join(root, ".vscode", "tasks.json") {
+		t.Fatalf("path %s", p)
+	}
+	ts, err := LoadTasksFile(p)
+	if err != nil || len(ts) != 1 || ts[0].Label != "build" {
+		t.Fatalf("a new tasks.json: %+v, %v", ts, err)
+	}
+
+	yamlRoot := t.TempDir()
+	writeTestFile(t, filepath.Join(yamlRoot, ".vscode", "tasks.yaml"), "version: 2.0.0\ntasks: []\n")
+	if _, err := AddTask(yamlRoot, NewTask{Label: "build", Command: "make"}); err == nil || !strings.Contains(err.Error(), "only tasks.json") {
+		t.Fatalf("tasks.yaml: err = %v", err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(yamlRoot, ".vscode", "tasks.yaml")); string(b) != "version: 2.0.0\ntasks: []\n" {
+		t.Fatalf("tasks.yaml changed:\n%s", b)
+	}
+}
@@ -0,0 +1,85 @@
+package tasks
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// DefaultAliasPrefix starts the names of the functions `vstask aliases` defines, so a task
+// labeled "test" or "ls" doesn't shadow the command.
+const DefaultAliasPrefix = "t_"
+
+// AliasName converts a task label into a shell-safe function name, e.g.
+// ("t_", "Run: Demo (sequence)") → "t_run_demo_sequence".
+// Returns "" when the label has no usable characters.
+func AliasName(prefix, label string) string {
+	var b strings.Builder
+	pendingSep := false
+	for _, r := range strings.ToLower(label) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if pendingSep && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			pendingSep = false
+			b.WriteRune(r)
+			continue
+		}
+		pendingSep = true
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return prefix + b.String()
+}
+
+// GenerateAliases emits shell function definitions that run each task via `vstask run`, so a
+// label like a vstask command (clean, list, ...) still reaches the task, suitable for
+// `eval "$(vstask aliases --prefix t_)"`. A function's arguments go before run, as global flags.
+// Supported shells: "sh" (bash/zsh/POSIX sh) and "fish".
+// Labels mapping to the same name get a numeric suffix (_2, _3, ...), skipping names already
+// defined for other labels.
+func GenerateAliases(taskList []Task, prefix, shell string) (string, error) {
+	var def func(name, label string) string
+	switch strings.ToLower(shell) {
+	case "", "sh", "bash", "zsh":
+		def = func(name, label string) string {
+			return fmt.Sprintf("%s() { vstask \"$@\" run %s; }\n", name, shSingleQuote(label))
+		}
+	case "fish":
+		def = func(name, label string) string {
+			return fmt.Sprintf("function %s; vstask $argv run %s; end\n", name, fishSingleQuote(label))
+		}
+	default:
+		return "", fmt.Errorf("unsupported shell: %q", shell)
+	}
+
+	var b strings.Builder
+	defined := map[string]bool{}
+	for _, t := range taskList {
+		name := AliasName(prefix, t.Label)
+		if name == "" {
+			continue
+		}
+		// Shell function names can't start with a digit.
+		if unicode.IsDigit(rune(name[0])) {
+			name = "_" + name
+		}
+		for n, base := 2, name; defined[name]; n++ {
+			name = fmt.Sprintf("%s_%d", base, n)
+		}
+		defined[name] = true
+		b.WriteString(def(name, t.Label))
+	}
+	return b.String(), nil
+}
+
+func shSingleQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func fishSingleQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "'", `\'`)
+	return "'" + s + "'"
+}
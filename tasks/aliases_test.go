@@ -0,0 +1,76 @@
+package tasks
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestAliasName(t *testing.T) {
+	cases := map[string]string{
+		"build":                "t_build",
+		"Run: Demo (sequence)": "t_run_demo_sequence",
+		"🚀 Deploy":             "t_deploy",
+		"lint--fix":            "t_lint_fix",
+		"🚀":                    "",
+	}
+	for label, want := range cases {
+		if got := AliasName("t_", label); got != want {
+			t.Errorf("AliasName(%q) = %q, want %q", label, got, want)
+		}
+	}
+}
+
+func TestGenerateAliases_Sh(t *testing.T) {
+	out, err := GenerateAliases([]Task{
+		{Label: "build"},
+		{Label: "Build"},
+		{Label: "it's"},
+		{Label: "01 prep"},
+		{Label: "lint"},
+		{Label: "Lint"},
+		{Label: "lint 2"},
+		{Label: "clean"},
+	}, "", "zsh")
+	if err != nil {
+		t.Fatalf("GenerateAliases err: %v", err)
+	}
+	for _, want := range []string{
+		`build() { vstask "$@" run 'build'; }`,
+		`build_2() { vstask "$@" run 'Build'; }`,
+		`it_s() { vstask "$@" run 'it'\''s'; }`,
+		`_01_prep() { vstask "$@" run '01 prep'; }`,
+		`lint_2() { vstask "$@" run 'Lint'; }`,
+		`lint_2_2() { vstask "$@" run 'lint 2'; }`,
+		`clean() { vstask "$@" run 'clean'; }`, // the task, not `vstask clean`
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	// The generated script must be valid POSIX shell.
+	if err := exec.Command("/bin/sh", "-n", "-c", out).Run(); err != nil {
+		t.Fatalf("generated script does not parse: %v\n%s", err, out)
+	}
+}
+
+func TestGenerateAliases_Fish(t *testing.T) {
+	out, err := GenerateAliases([]Task{{Label: "it's"}}, "t_", "fish")
+	if err != nil {
+		t.Fatalf("GenerateAliases err: %v", err)
+	}
+	if want := `function t_it_s; vstask $argv run 'it\'s'; end`; !strings.Contains(out, want) {
+		t.Fatalf("missing %q in:\n%s", want, out)
+	}
+}
+
+func TestGenerateAliases_UnknownShell(t *testing.T) {
+	if _, err := GenerateAliases(nil, "", "tcsh"); err == nil {
+		t.Fatal("expected error for unsupported shell")
+	}
+}
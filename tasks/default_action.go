@@ -0,0 +1,64 @@
+package tasks
+
+import (
+	"os"
+	"strings"
+)
+
+// What a bare `vstask` (no arguments) does.
+const (
+	ActionPicker = "picker" // open the task picker (default)
+	ActionBuild  = "build"  // run the default build task
+	ActionLast   = "last"   // re-run the last task run in this workspace
+)
+
+// DefaultAction returns the configured action for a bare `vstask`: $VSTASK_DEFAULT_ACTION,
+// then rc's "defaultAction", then "vstask.defaultAction" from the workspace and user settings.
+// Unknown values fall back to ActionPicker.
+func DefaultAction(root string, rc RC) string {
+	candidates := []string{os.Getenv("VSTASK_DEFAULT_ACTION"), rc.DefaultAction}
+	if root != "" {
+		if s, ok := readSettingsFile(workspaceSettingsPath(root)); ok {
+			candidates = append(candidates, s.DefaultAction)
+		}
+	}
+	for _, p := range userSettingsCandidates() {
+		if s, ok := readSettingsFile(p); ok && s.DefaultAction != "" {
+			candidates = append(candidates, s.DefaultAction)
+			break
+		}
+	}
+	for _, c := range candidates {
+		switch a := strings.ToLower(strings.TrimSpace(c)); a {
+		case ActionPicker, ActionBuild, ActionLast:
+			return a
+		}
+	}
+	return ActionPicker
+}
+
+// DefaultTaskForGroup returns the task marked {"kind": group, "isDefault": true}, or the only
+// task in the group if there's just one.
+func DefaultTaskForGroup(ts []Task, group string) (Task, bool) {
+	members := GroupTasks(ts, group)
+	for _, t := range members {
+		if t.Group.IsDefault {
+			return t, true
+		}
+	}
+	if len(members) == 1 {
+		return members[0], true
+	}
+	return Task{}, false
+}
+
+// GroupTasks returns the tasks in group (their "group" kind, compared case-insensitively).
+func GroupTasks(ts []Task, group string) []Task {
+	var out []Task
+	for _, t := range ts {
+		if t.Group != nil && strings.EqualFold(t.Group.Kind, group) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+package tasks
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaultAction_Precedence(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("VSTASK_DEFAULT_ACTION", "")
+	root := t.TempDir()
+
+	if got := DefaultAction(root, RC{}); got != ActionPicker {
+		t.Fatalf("default: got %q", got)
+	}
+	writeTestFile(t, filepath.Join(root, ".vscode", "settings.json"), `{"vstask.defaultAction": "Build"}`)
+	if got := DefaultAction(root, RC{}); got != ActionBuild {
+		t.Fatalf("settings: got %q", got)
+	}
+	if got := DefaultAction(root, RC{DefaultAction: "last"}); got != ActionLast {
+		t.Fatalf(".vstaskrc should beat settings: got %q", got)
+	}
+	t.Setenv("VSTASK_DEFAULT_ACTION", "picker")
+	if got := DefaultAction(root, RC{DefaultAction: "last"}); got != ActionPicker {
+		t.Fatalf("env should win: got %q", got)
+	}
+	t.Setenv("VSTASK_DEFAULT_ACTION", "bogus")
+	if got := DefaultAction(root, RC{DefaultAction: "last"}); got != ActionLast {
+		t.Fatalf("unknown values are skipped: got %q", got)
+	}
+}
+
+func TestDefaultTaskForGroup(t *testing.T) {
+	ts := []Task{
+		{Label: "test", Group: &Group{Kind: "test"}},
+		{Label: "build:dev", Group: &Group{Kind: "build"}},
+		{Label: "build:prod", Group: &Group{Kind: "build", IsDefault: true}},
+	}
+	if got, ok := DefaultTaskForGroup(ts, "build"); !ok || got.Label != "build:prod" {
+		t.Fatalf("isDefault should win: got %q, %v", got.Label, ok)
+	}
+	if got, ok := DefaultTaskForGroup(ts, "test"); !ok || got.Label != "test" {
+		t.Fatalf("single member: got %q, %v", got.Label, ok)
+	}
+	if _, ok := DefaultTaskForGroup(ts[1:2:2], "test"); ok {
+		t.Fatal("no test tasks")
+	}
+	ambiguous := []Task{{Label: "a", Group: &Group{Kind: "build"}}, {Label: "b", Group: &Group{Kind: "build"}}}
+	if _, ok := DefaultTaskForGroup(ambiguous, "build"); ok {
+		t.Fatal("several non-default members should not pick one")
+	}
+	if got := GroupTasks(ts, "Build"); len(got) != 2 || got[0].Label != "build:dev" || got[1].Label != "build:prod" {
+		t.Fatalf("GroupTasks(build) = %v", got)
+	}
+}
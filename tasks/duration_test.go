@@ -0,0 +1,32 @@
+package tasks
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDuration_Unmarshal(t *testing.T) {
+	cases := map[string]time.Duration{
+		`"30s"`:   30 * time.Second,
+		`"1m30s"`: 90 * time.Second,
+		`5`:       5 * time.Second,
+		`1.5`:     1500 * time.Millisecond,
+		`""`:      0,
+		`null`:    0,
+	}
+	for in, want := range cases {
+		var d Duration
+		if err := json.Unmarshal([]byte(in), &d); err != nil {
+			t.Fatalf("unmarshal %s: %v", in, err)
+		}
+		if d.Std() != want {
+			t.Fatalf("unmarshal %s = %v, want %v", in, d.Std(), want)
+		}
+	}
+
+	var d Duration
+	if err := json.Unmarshal([]byte(`"soon"`), &d); err == nil {
+		t.Fatal("expected error for invalid duration")
+	}
+}
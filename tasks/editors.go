@@ -0,0 +1,104 @@
+package tasks
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/chenasraf/vstask/utils"
+)
+
+// Editor is a VS Code distribution vstask reads settings from and resolves ${execPath} to.
+type Editor struct {
+	Name    string // as used in $VSTASK_EDITORS
+	Binary  string // command-line launcher, e.g. "code-insiders"
+	DataDir string // name of its user data directory, e.g. "Code - Insiders"
+	MacApp  string // macOS app bundle, e.g. "Visual Studio Code.app"
+	Install string // install directory name on Windows
+}
+
+var knownEditors = []Editor{
+	{Name: "code", Binary: "code", DataDir: "Code", MacApp: "Visual Studio Code.app", Install: "Microsoft VS Code"},
+	{Name: "insiders", Binary: "code-insiders", DataDir: "Code - Insiders", MacApp: "Visual Studio Code - Insiders.app", Install: "Microsoft VS Code Insiders"},
+	{Name: "codium", Binary: "codium", DataDir: "VSCodium", MacApp: "VSCodium.app", Install: "VSCodium"},
+	{Name: "cursor", Binary: "cursor", DataDir: "Cursor", MacApp: "Cursor.app", Install: "cursor"},
+}
+
+// Editors returns the known editors in priority order: the ones named in $VSTASK_EDITORS
+// (comma-separated, e.g. "cursor,code"), in that order, then the rest as VS Code, Insiders,
+// VSCodium, Cursor. Settings from an earlier editor win.
+func Editors() []Editor {
+	var out []Editor
+	for name := range strings.SplitSeq(os.Getenv("VSTASK_EDITORS"), ",") {
+		name = strings.TrimSpace(name)
+		i := slices.IndexFunc(knownEditors, func(e Editor) bool { return strings.EqualFold(e.Name, name) })
+		if i < 0 {
+			if name != "" {
+				utils.Debugf("VSTASK_EDITORS: unknown editor %q", name)
+			}
+			continue
+		}
+		if !slices.Contains(out, knownEditors[i]) {
+			out = append(out, knownEditors[i])
+		}
+	}
+	for _, e := range knownEditors {
+		if !slices.Contains(out, e) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// EditorExecPath returns the editor launcher ${execPath} resolves to: $VSCODE_EXEC_PATH, else
+// the first editor (see Editors) found on PATH or in its standard install location, else "".
+func EditorExecPath() string {
+	if v := os.Getenv("VSCODE_EXEC_PATH"); v != "" {
+		return v
+	}
+	for _, e := range Editors() {
+		if p, err := exec.LookPath(e.Binary); err == nil {
+			return p
+		}
+		for _, p := range e.installPaths() {
+			if utils.FileExists(p) {
+				return p
+			}
+		}
+	}
+	return ""
+}
+
+// installPaths are the standard locations of e's launcher on this OS.
+func (e Editor) installPaths() []string {
+	switch runtime.GOOS {
+	case "darwin":
+		var out []string
+		apps := []string{"/Applications"}
+		if home, err := os.UserHomeDir(); err == nil {
+			apps = append(apps, filepath.Join(home, "Applications"))
+		}
+		for _, dir := range apps {
+			out = append(out, filepath.Join(dir, e.MacApp, "Contents", "Resources", "app", "bin", e.Binary))
+		}
+		return out
+	case "windows":
+		var out []string
+		for _, dir := range []string{filepath.Join(os.Getenv("LOCALAPPDATA"), "Programs"), os.Getenv("ProgramFiles")} {
+			if dir != "" && dir != "Programs" {
+				out = append(out,
+					filepath.Join(dir, e.Install, "bin", e.Binary+".cmd"),
+					filepath.Join(dir, e.Install, "resources", "app", "bin", e.Binary+".cmd"))
+			}
+		}
+		return out
+	default:
+		return []string{
+			filepath.Join("/usr/share", e.Binary, "bin", e.Binary),
+			filepath.Join("/snap/bin", e.Binary),
+		}
+	}
+}
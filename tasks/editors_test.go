@@ -0,0 +1,68 @@
+package tasks
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func editorNames(es []Editor) []string {
+	out := make([]string, len(es))
+	for i, e := range es {
+		out[i] = e.Name
+	}
+	return out
+}
+
+func TestEditors_Priority(t *testing.T) {
+	t.Setenv("VSTASK_EDITORS", "")
+	if got, want := editorNames(Editors()), []string{"code", "insiders", "codium", "cursor"}; !slices.Equal(got, want) {
+		t.Fatalf("default order = %v", got)
+	}
+	t.Setenv("VSTASK_EDITORS", "Cursor, nope, codium,cursor")
+	if got, want := editorNames(Editors()), []string{"cursor", "codium", "code", "insiders"}; !slices.Equal(got, want) {
+		t.Fatalf("VSTASK_EDITORS order = %v", got)
+	}
+}
+
+func TestUserSettingsCandidates_FollowEditors(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("user settings layout is per OS")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("VSTASK_EDITORS", "cursor")
+	got := userSettingsCandidates()
+	if len(got) != 4 || got[0] != filepath.Join(home, ".config", "Cursor", "User", "settings.json") {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestEditorExecPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX executables")
+	}
+	bin := t.TempDir()
+	for _, name := range []string{"code", "cursor"} {
+		if err := os.WriteFile(filepath.Join(bin, name), []byte("#!/bin/sh\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", bin)
+	t.Setenv("VSCODE_EXEC_PATH", "")
+	t.Setenv("VSTASK_EDITORS", "")
+	if got := EditorExecPath(); got != filepath.Join(bin, "code") {
+		t.Fatalf("default = %q", got)
+	}
+	t.Setenv("VSTASK_EDITORS", "cursor")
+	if got := EditorExecPath(); got != filepath.Join(bin, "cursor") {
+		t.Fatalf("cursor first = %q", got)
+	}
+	t.Setenv("VSCODE_EXEC_PATH", "/opt/editor")
+	if got := EditorExecPath(); got != "/opt/editor" {
+		t.Fatalf("env = %q", got)
+	}
+}
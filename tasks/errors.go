@@ -0,0 +1,76 @@
+package tasks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/chenasraf/vstask/utils"
+)
+
+// ErrTaskNotFound is returned (wrapped) when no task matches a label, either given on the
+// command line or referenced in dependsOn.
+var ErrTaskNotFound = errors.New("task not found")
+
+// ErrTasksFileNotFound is returned when a workspace has no tasks file and no tasks from
+// anywhere else.
+var ErrTasksFileNotFound = errors.New("tasks.json not found")
+
+// ParseError is a JSONC file vstask couldn't parse: tasks.json, a .code-workspace file or a
+// .vstaskrc.
+type ParseError struct {
+	File string
+	Line int // 1-based; 0 when the error doesn't point at a position
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.File, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// reJSONCPosition is the position prefix of the JSONC parser's syntax errors.
+var reJSONCPosition = regexp.MustCompile(`^hujson: line (\d+), column \d+: `)
+
+// unmarshalJSONC decodes the JSONC data read from file into v, failing with a *ParseError.
+// Comments and trailing commas are blanked out in place, so offsets still match the file.
+func unmarshalJSONC(file string, data []byte, v any) error {
+	std := utils.StripJSONC(data)
+	err := json.Unmarshal(std, v)
+	if err == nil {
+		return nil
+	}
+	// Only now run the full JSONC parser: it's slower, but its syntax errors read better.
+	if _, err := utils.StandardizeJSONC(data); err != nil {
+		pe := &ParseError{File: file, Err: err}
+		if m := reJSONCPosition.FindStringSubmatchIndex(err.Error()); m != nil {
+			msg := err.Error()
+			pe.Line, _ = strconv.Atoi(msg[m[2]:m[3]])
+			pe.Err = errors.New(msg[m[1]:])
+		}
+		return pe
+	}
+	var offset int64
+	var se *json.SyntaxError
+	var te *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &se):
+		offset = se.Offset
+	case errors.As(err, &te):
+		offset = te.Offset
+	}
+	pe := &ParseError{File: file, Err: err}
+	if offset > 0 && offset <= int64(len(std)) {
+		pe.Line = bytes.Count(std[:offset], []byte("\n")) + 1
+	}
+	return pe
+}
@@ -0,0 +1,34 @@
+package tasks
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadFile_ParseErrorLine(t *testing.T) {
+	dir := t.TempDir()
+	for name, tc := range map[string]struct {
+		content string
+		line    int
+	}{
+		"syntax": {"{\n  // a comment\n  \"tasks\": [\n    { \"label\": \"a\" }\n    { \"label\": \"b\" }\n  ]\n}", 5},
+		"type":   {"{\n  \"version\": \"2.0.0\",\n  \"tasks\": [{ \"label\": 42 }]\n}", 3},
+		"custom": {"{\"tasks\": [{ \"label\": \"a\", \"dependsOn\": 1 }]}", 0},
+	} {
+		p := filepath.Join(dir, name+".json")
+		writeTestFile(t, p, tc.content)
+		_, err := LoadFile(p)
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Fatalf("%s: err = %v, want a *ParseError", name, err)
+		}
+		if pe.File != p || pe.Line != tc.line {
+			t.Errorf("%s: got %s:%d, want line %d", name, pe.File, pe.Line, tc.line)
+		}
+		if tc.line > 0 && !strings.HasPrefix(err.Error(), p+":") {
+			t.Errorf("%s: message %q lacks the position", name, err)
+		}
+	}
+}
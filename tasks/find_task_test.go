@@ -1,6 +1,7 @@
 package tasks
 
 import (
+	"errors"
 	"strings"
 	"testing"
 )
@@ -71,8 +72,8 @@ func TestFindTask_NoMatch(t *testing.T) {
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
-	if !strings.Contains(err.Error(), "task not found") {
-		t.Fatalf("unexpected error message: %v", err)
+	if !errors.Is(err, ErrTaskNotFound) || !strings.Contains(err.Error(), "task not found: deploy") {
+		t.Fatalf("unexpected error: %v", err)
 	}
 }
 
@@ -103,3 +104,71 @@ func TestFindTask_EmptyList(t *testing.T) {
 		t.Fatal("expected error, got nil")
 	}
 }
+
+func TestFindTask_PrefixWinsOverSubstring(t *testing.T) {
+	taskList := []Task{
+		{Label: "rebuild"},
+		{Label: "Build:prod"},
+	}
+	got, err := FindTask(taskList, "build")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Label != "Build:prod" {
+		t.Fatalf("got %q, want the only label starting with the query", got.Label)
+	}
+}
+
+func TestFindTask_CaseInsensitiveExactWinsOverPrefix(t *testing.T) {
+	taskList := []Task{
+		{Label: "Test"},
+		{Label: "test:e2e"},
+	}
+	got, err := FindTask(taskList, "TEST")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Label != "Test" {
+		t.Fatalf("got %q, want %q", got.Label, "Test")
+	}
+}
+
+func TestFindTask_Suggestions(t *testing.T) {
+	taskList := []Task{
+		{Label: "build"},
+		{Label: "test"},
+		{Label: "text"},
+		{Label: "deploy"},
+	}
+	_, err := FindTask(taskList, "tset")
+	if !errors.Is(err, ErrTaskNotFound) || !strings.Contains(err.Error(), `task not found: tset (did you mean "test"?)`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = FindTask(taskList, "tezt")
+	if err == nil || !strings.Contains(err.Error(), `(did you mean "test" or "text"?)`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = FindTask(taskList, "lint")
+	if err == nil || strings.Contains(err.Error(), "did you mean") {
+		t.Fatalf("nothing close should suggest nothing: %v", err)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	for _, c := range []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"build", "build", 0},
+		{"build", "biuld", 1},
+		{"abc", "ca", 3},
+		{"test", "tests", 1},
+		{"kitten", "sitting", 3},
+		{"🚀 deploy", "deploy", 2},
+	} {
+		if got := editDistance(c.a, c.b); got != c.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
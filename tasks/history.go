@@ -0,0 +1,62 @@
+package tasks
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/chenasraf/vstask/utils"
+)
+
+// HistoryFile is the workspace state file (see utils.WorkspaceStateDir) holding the run history.
+const HistoryFile = "history.json"
+
+// TaskHistory is the run history of one task in a workspace.
+type TaskHistory struct {
+	Runs     int           `json:"runs"`     // successful runs timed
+	Expected time.Duration `json:"expected"` // moving average of successful run durations
+	Last     time.Duration `json:"last"`
+	Updated  time.Time     `json:"updated"`
+	Usage    Usage         `json:"usage"` // runs the user asked for (see RecordUsage)
+}
+
+func historyPath(root string) (string, error) {
+	dir, err := utils.WorkspaceStateDir(root)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, HistoryFile), nil
+}
+
+// LoadHistory returns root's run history by task label; tasks never run aren't in it.
+func LoadHistory(root string) map[string]TaskHistory {
+	h := map[string]TaskHistory{}
+	if root == "" {
+		return h
+	}
+	p, err := historyPath(root)
+	if err != nil {
+		return h
+	}
+	if err := utils.ReadJSONFile(p, &h); err != nil {
+		return map[string]TaskHistory{}
+	}
+	return h
+}
+
+// UpdateHistory applies update to the run history of the task labelled label in root.
+func UpdateHistory(root, label string, update func(*TaskHistory)) error {
+	p, err := historyPath(root)
+	if err != nil {
+		return err
+	}
+	// Locked, so parallel tasks (and other vstask processes) don't lose each other's updates.
+	return utils.UpdateJSONFile(p, func(h *map[string]TaskHistory) error {
+		if *h == nil {
+			*h = map[string]TaskHistory{}
+		}
+		s := (*h)[label]
+		update(&s)
+		(*h)[label] = s
+		return nil
+	})
+}
@@ -0,0 +1,359 @@
+package tasks
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chenasraf/vstask/utils"
+)
+
+const (
+	// defaultIncludeMaxAge is how long a fetched include is used before it's fetched again
+	// ($VSTASK_INCLUDE_MAX_AGE).
+	defaultIncludeMaxAge = time.Hour
+	includeFetchTimeout  = 10 * time.Second
+	maxIncludeSize       = 10 << 20
+	maxIncludeDepth      = 10
+)
+
+// OriginInclude marks tasks from a file another tasks file includes.
+const OriginInclude = "include"
+
+// offline keeps loading tasks off the network (see Offline).
+var offline bool
+
+// errNotCached is a URL included while offline that was never fetched.
+var errNotCached = errors.New("not fetched yet")
+
+// Offline makes loading tasks never fetch included URLs: they come from the cache whatever its
+// age, and those never fetched are left out. For shell completion, which must be quick and do
+// nothing but read.
+func Offline() {
+	offline = true
+}
+
+// IsRemoteInclude reports whether an include source is a URL rather than a file.
+func IsRemoteInclude(src string) bool {
+	return strings.HasPrefix(src, "https://") || strings.HasPrefix(src, "http://")
+}
+
+// resolveInclude returns the source an "include" entry of the file (or URL) from points to: a
+// URL, or a file path, relative to from's directory unless absolute or under ~.
+func resolveInclude(entry, from string) string {
+	entry = strings.TrimSpace(entry)
+	if IsRemoteInclude(entry) {
+		return entry
+	}
+	if IsRemoteInclude(from) {
+		if base, err := url.Parse(from); err == nil {
+			if u, err := base.Parse(entry); err == nil {
+				return u.String()
+			}
+		}
+		return entry
+	}
+	if entry == "~" || strings.HasPrefix(entry, "~/") || strings.HasPrefix(entry, `~\`) {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, entry[1:])
+		}
+	}
+	if !filepath.IsAbs(entry) {
+		entry = filepath.Join(filepath.Dir(from), entry)
+	}
+	return entry
+}
+
+// loadTasksFile loads the tasks file at p (see LoadFile) with the tasks and inputs of the files
+// it includes after its own ones. A label (or input id) already defined shadows the included
+// one, like those of task providers.
+func loadTasksFile(p string) (File, error) {
+	f, err := LoadFile(p)
+	if err != nil {
+		return File{}, err
+	}
+	return withIncludes(f, p)
+}
+
+func withIncludes(f File, from string) (File, error) {
+	if len(f.Include) == 0 {
+		return f, nil
+	}
+	var included []Task
+	var inputs []Input
+	root := filepath.Dir(filepath.Dir(from))
+	err := walkIncludes(f, from, root, templateVariables(root), map[string]bool{from: true}, 0, func(src string, inc File) {
+		for _, t := range inc.Tasks {
+			t.Origin = OriginInclude
+			included = append(included, t)
+		}
+		inputs = append(inputs, inc.Inputs...)
+	}, warnUntrustedInclude)
+	if err != nil {
+		return File{}, err
+	}
+	f.Tasks = mergeProvided(f.Tasks, included)
+	for _, in := range inputs {
+		if !slices.ContainsFunc(f.Inputs, func(own Input) bool { return own.ID == in.ID }) {
+			f.Inputs = append(f.Inputs, in)
+		}
+	}
+	return f, nil
+}
+
+// UntrustedInclude is a URL a workspace's tasks file includes that isn't trusted in it, as
+// fetched now.
+type UntrustedInclude struct {
+	URL     string
+	Hash    string // sha256 of its content; see TrustInclude
+	Changed bool   // trusted before, with other content
+}
+
+func warnUntrustedInclude(u UntrustedInclude) {
+	why := "it isn't trusted yet"
+	if u.Changed {
+		why = "it changed since it was trusted"
+	}
+	fmt.Fprintf(os.Stderr, "Warning: not including tasks from %s: %s (run a task to be asked)\n", u.URL, why)
+}
+
+// walkIncludes calls fn with each file f includes, depth first (each before the files it
+// includes in turn), its {{placeholders}} filled from vars. A URL, wherever it's included from,
+// is passed to untrusted and skipped until its current content is trusted in the workspace root
+// (see TrustInclude) or $VSTASK_TRUST_ALL=1 turns the check off; only https URLs can be
+// included.
+func walkIncludes(f File, from, root string, vars map[string]string, seen map[string]bool, depth int, fn func(string, File), untrusted func(UntrustedInclude)) error {
+	if depth >= maxIncludeDepth {
+		return fmt.Errorf("%s: includes nested more than %d deep", from, maxIncludeDepth)
+	}
+	for _, entry := range f.Include {
+		src := resolveInclude(entry, from)
+		if seen[src] {
+			continue // included already, or an include cycle
+		}
+		seen[src] = true
+		var inc File
+		var err error
+		if IsRemoteInclude(src) {
+			if !strings.HasPrefix(src, "https://") {
+				return fmt.Errorf("include %s: only https:// URLs can be included", entry)
+			}
+			var data []byte
+			if inc, data, err = loadRemoteInclude(src, vars); errors.Is(err, errNotCached) {
+				continue
+			} else if err != nil {
+				return fmt.Errorf("include %s: %w", entry, err)
+			}
+			hash := hashBytes(data)
+			if trusted, ok := includeTrust(root, src); os.Getenv("VSTASK_TRUST_ALL") != "1" && (!ok || trusted != hash) {
+				untrusted(UntrustedInclude{URL: src, Hash: hash, Changed: ok})
+				continue
+			}
+		} else if inc, err = loadLocalInclude(src, vars); err != nil {
+			return fmt.Errorf("include %s: %w", entry, err)
+		}
+		fn(src, inc)
+		if err := walkIncludes(inc, src, root, vars, seen, depth+1, fn, untrusted); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UntrustedIncludes returns the URLs root's tasks file includes, directly or through the files
+// it includes, whose current content isn't trusted in root (see TrustInclude), fetching them if
+// need be. What an untrusted URL includes isn't listed until it's trusted.
+func UntrustedIncludes(root string) ([]UntrustedInclude, error) {
+	p := TasksFilePath(root)
+	f, err := LoadFile(p)
+	if err != nil {
+		return nil, nil // loading the tasks reports it
+	}
+	var out []UntrustedInclude
+	err = walkIncludes(f, p, root, templateVariables(root), map[string]bool{p: true}, 0, func(string, File) {}, func(u UntrustedInclude) {
+		out = append(out, u)
+	})
+	return out, err
+}
+
+// HasIncludes reports whether root's tasks file includes other files.
+func HasIncludes(root string) bool {
+	f, err := LoadFile(TasksFilePath(root))
+	return err == nil && len(f.Include) > 0
+}
+
+// includeMaxAge returns how long a fetched include is used before it's fetched again.
+func includeMaxAge() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("VSTASK_INCLUDE_MAX_AGE")); err == nil && d >= 0 {
+		return d
+	}
+	return defaultIncludeMaxAge
+}
+
+// includeCachePath is where the last fetched copy of src is kept.
+func includeCachePath(src string) (string, error) {
+	dir, err := utils.UserStateDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(src))
+	return filepath.Join(dir, "includes", hex.EncodeToString(sum[:])[:16]), nil
+}
+
+// loadLocalInclude loads the included tasks file at p, its {{placeholders}} filled from vars.
+func loadLocalInclude(p string, vars map[string]string) (File, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return File{}, err
+	}
+	return parseInclude(p, data, vars)
+}
+
+// loadRemoteInclude loads the tasks file at the URL src (YAML if its path ends in .yaml or
+// .yml), from the cache while it's fresh (see includeMaxAge), its {{placeholders}} filled from
+// vars; data is its content, as fetched. When fetching fails, the copy fetched before is used,
+// with a warning; a fetched file that doesn't parse isn't cached. Offline, the cache is used
+// however old, and a URL not in it is errNotCached.
+func loadRemoteInclude(src string, vars map[string]string) (f File, data []byte, err error) {
+	cached, err := includeCachePath(src)
+	if err != nil {
+		return File{}, nil, err
+	}
+	name, _, _ := strings.Cut(src, "?")
+	if offline {
+		if data, err := os.ReadFile(cached); err == nil {
+			f, err := parseInclude(name, data, vars)
+			return f, data, err
+		}
+		return File{}, nil, errNotCached
+	}
+	if info, err := os.Stat(cached); err == nil && time.Since(info.ModTime()) < includeMaxAge() {
+		if data, err := os.ReadFile(cached); err == nil {
+			f, err := parseInclude(name, data, vars)
+			return f, data, err
+		}
+	}
+	data, err = fetchInclude(src)
+	if err != nil {
+		if old, readErr := os.ReadFile(cached); readErr == nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v (using the copy fetched before)\n", err)
+			f, err := parseInclude(name, old, vars)
+			return f, old, err
+		}
+		return File{}, nil, err
+	}
+	if f, err = parseInclude(name, data, vars); err != nil {
+		return File{}, nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(cached), 0o755); err != nil {
+		return File{}, nil, err
+	}
+	return f, data, utils.WriteFileAtomic(cached, data, 0o644)
+}
+
+// includeClient fetches included URLs.
+var includeClient = &http.Client{Timeout: includeFetchTimeout}
+
+func fetchInclude(src string) ([]byte, error) {
+	resp, err := includeClient.Get(src)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", src, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxIncludeSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxIncludeSize {
+		return nil, errors.New("fetching " + src + ": too large")
+	}
+	return data, nil
+}
+
+// reTemplateVariable matches a {{placeholder}} of an included tasks file.
+var reTemplateVariable = regexp.MustCompile(`\{\{\s*([A-Za-z_][\w-]*)\s*\}\}`)
+
+// templateVariables returns the values of the {{placeholders}} of the files root's tasks file
+// includes ("vstask.templateVariables"): the user settings', overridden by the workspace ones.
+// Values that aren't strings are used as JSON (8080, true).
+func templateVariables(root string) map[string]string {
+	vars := map[string]string{}
+	add := func(s VSCodeSettings) {
+		for name, v := range s.TemplateVariables {
+			if str, ok := v.(string); ok {
+				vars[name] = str
+			} else if b, err := json.Marshal(v); err == nil {
+				vars[name] = string(b)
+			}
+		}
+	}
+	for _, p := range userSettingsCandidates() {
+		if s, ok := readSettingsFile(p); ok && s.TemplateVariables != nil {
+			add(s)
+			break
+		}
+	}
+	if s, ok := readSettingsFile(workspaceSettingsPath(root)); ok {
+		add(s)
+	}
+	return vars
+}
+
+// parseInclude parses the included tasks file name (see parseTasksFile) once its {{placeholders}}
+// are filled from vars. They're filled in the file's JSON (converted from YAML if need be), as
+// JSON string contents, so they belong in strings: "label": "{{service}}: build".
+func parseInclude(name string, data []byte, vars map[string]string) (File, error) {
+	if !reTemplateVariable.Match(data) {
+		return parseTasksFile(name, data)
+	}
+	if isYAMLFile(name) {
+		js, err := yamlToJSON(name, data)
+		if err != nil {
+			return File{}, err
+		}
+		data = js
+	}
+	var file File
+	if err := unmarshalJSONC(name, fillTemplate(name, data, vars), &file); err != nil {
+		return File{}, err
+	}
+	if err := checkVersion(name, data, file.Version); err != nil {
+		return File{}, err
+	}
+	return file, nil
+}
+
+// warnedTemplateVariables are the unset placeholders already warned about.
+var warnedTemplateVariables sync.Map
+
+// fillTemplate replaces the {{placeholders}} of the tasks file name's JSON data with the values
+// in vars, JSON-escaped. Placeholders without a value are left as they are, with a warning.
+func fillTemplate(name string, data []byte, vars map[string]string) []byte {
+	return reTemplateVariable.ReplaceAllFunc(data, func(m []byte) []byte {
+		v := string(reTemplateVariable.FindSubmatch(m)[1])
+		value, ok := vars[v]
+		if !ok {
+			if _, warned := warnedTemplateVariables.LoadOrStore(v, true); !warned {
+				fmt.Fprintf(os.Stderr, "Warning: %s uses {{%s}}, which isn't set (see \"vstask.templateVariables\")\n", name, v)
+			}
+			return m
+		}
+		b, _ := json.Marshal(value)
+		return b[1 : len(b)-1]
+	})
+}
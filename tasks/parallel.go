@@ -0,0 +1,26 @@
+package tasks
+
+import (
+	"os"
+	"strconv"
+)
+
+// MaxParallel returns the configured limit on how many dependencies run at once in root:
+// $VSTASK_JOBS, then "vstask.maxParallel" from the workspace and user settings. 0 means no
+// limit; invalid and negative values are ignored.
+func MaxParallel(root string) int {
+	if n, err := strconv.Atoi(os.Getenv("VSTASK_JOBS")); err == nil && n > 0 {
+		return n
+	}
+	if root != "" {
+		if s, ok := readSettingsFile(workspaceSettingsPath(root)); ok && s.MaxParallel > 0 {
+			return s.MaxParallel
+		}
+	}
+	for _, p := range userSettingsCandidates() {
+		if s, ok := readSettingsFile(p); ok && s.MaxParallel > 0 {
+			return s.MaxParallel
+		}
+	}
+	return 0
+}
@@ -0,0 +1,27 @@
+package tasks
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestMaxParallel_Precedence(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("VSTASK_JOBS", "")
+	root := t.TempDir()
+
+	if got := MaxParallel(root); got != 0 {
+		t.Fatalf("default: got %d, want 0 (no limit)", got)
+	}
+	writeTestFile(t, filepath.Join(root, ".vscode", "settings.json"), `{"vstask.maxParallel": 3}`)
+	if got := MaxParallel(root); got != 3 {
+		t.Fatalf("settings: got %d, want 3", got)
+	}
+	for in, want := range map[string]int{"4": 4, "-1": 3, "x": 3, "0": 3} {
+		t.Setenv("VSTASK_JOBS", in)
+		if got := MaxParallel(root); got != want {
+			t.Errorf("VSTASK_JOBS=%q: got %d, want %d", in, got, want)
+		}
+	}
+}
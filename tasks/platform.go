@@ -0,0 +1,84 @@
+package tasks
+
+import (
+	"maps"
+	"runtime"
+)
+
+// ApplyPlatformOverrides returns t as it runs on this platform: with the command, args,
+// options and presentation of its windows, osx or linux block applied (see mergeOptions).
+func ApplyPlatformOverrides(t Task) Task {
+	eff := t
+	switch runtime.GOOS {
+	case "windows":
+		if t.Windows != nil {
+			if t.Windows.Command != "" {
+				eff.Command = t.Windows.Command
+			}
+			if t.Windows.Args != nil {
+				eff.Args = append([]string(nil), t.Windows.Args...)
+			}
+			if t.Windows.Options != nil {
+				eff.Options = mergeOptions(t.Options, t.Windows.Options)
+			}
+			if t.Windows.Presentation != nil {
+				eff.Presentation = t.Windows.Presentation
+			}
+		}
+	case "darwin":
+		if t.Osx != nil {
+			if t.Osx.Command != "" {
+				eff.Command = t.Osx.Command
+			}
+			if t.Osx.Args != nil {
+				eff.Args = append([]string(nil), t.Osx.Args...)
+			}
+			if t.Osx.Options != nil {
+				eff.Options = mergeOptions(t.Options, t.Osx.Options)
+			}
+			if t.Osx.Presentation != nil {
+				eff.Presentation = t.Osx.Presentation
+			}
+		}
+	case "linux":
+		if t.Linux != nil {
+			if t.Linux.Command != "" {
+				eff.Command = t.Linux.Command
+			}
+			if t.Linux.Args != nil {
+				eff.Args = append([]string(nil), t.Linux.Args...)
+			}
+			if t.Linux.Options != nil {
+				eff.Options = mergeOptions(t.Options, t.Linux.Options)
+			}
+			if t.Linux.Presentation != nil {
+				eff.Presentation = t.Linux.Presentation
+			}
+		}
+	}
+	return eff
+}
+
+// mergeOptions overlays a platform block's options on the task's: a platform cwd or shell
+// replaces the task's, and platform env entries are added to (or override) the task's env.
+func mergeOptions(base, over *Options) *Options {
+	if base == nil {
+		return over
+	}
+	out := *base
+	if over.Cwd != "" {
+		out.Cwd = over.Cwd
+	}
+	if len(over.Env) > 0 {
+		out.Env = make(map[string]string, len(base.Env)+len(over.Env))
+		maps.Copy(out.Env, base.Env)
+		maps.Copy(out.Env, over.Env)
+	}
+	if over.Shell != nil {
+		out.Shell = over.Shell
+	}
+	if over.Path != nil {
+		out.Path = over.Path
+	}
+	return &out
+}
@@ -0,0 +1,38 @@
+package tasks
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestApplyPlatformOverrides_MergesOptions(t *testing.T) {
+	plat := &PlatformTask{Options: &Options{
+		Env:   map[string]string{"B": "plat", "C": "plat"},
+		Shell: &ShellOptions{Executable: "zsh"},
+	}}
+	tk := Task{
+		Command: "x",
+		Options: &Options{Cwd: "sub", Env: map[string]string{"A": "base", "B": "base"}},
+		Windows: plat, Osx: plat, Linux: plat,
+	}
+	eff := ApplyPlatformOverrides(tk)
+	switch runtime.GOOS {
+	case "windows", "darwin", "linux":
+	default:
+		t.Skip("no platform block for " + runtime.GOOS)
+	}
+	if eff.Options.Cwd != "sub" {
+		t.Errorf("cwd should be kept from the task, got %q", eff.Options.Cwd)
+	}
+	if eff.Options.Shell == nil || eff.Options.Shell.Executable != "zsh" {
+		t.Errorf("platform shell should apply, got %+v", eff.Options.Shell)
+	}
+	want := map[string]string{"A": "base", "B": "plat", "C": "plat"}
+	if !reflect.DeepEqual(eff.Options.Env, want) {
+		t.Errorf("env: got %v, want %v", eff.Options.Env, want)
+	}
+	if tk.Options.Env["B"] != "base" {
+		t.Error("the original task must not be modified")
+	}
+}
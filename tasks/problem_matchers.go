@@ -0,0 +1,83 @@
+package tasks
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+//go:embed problem_matchers.json
+var builtinProblemMatchersJSON []byte
+
+// builtinPatterns are the named patterns of problem_matchers.json ("pattern": "$go").
+var builtinPatterns = sync.OnceValue(func() map[string]ProblemMatcherPatterns {
+	var defs struct {
+		Patterns map[string]ProblemMatcherPatterns `json:"patterns"`
+	}
+	mustUnmarshalBuiltin(&defs)
+	return defs.Patterns
+})
+
+// builtinMatchers are the named matchers of problem_matchers.json ("problemMatcher": "$go").
+// Their patterns refer to builtinPatterns, so they're decoded separately.
+var builtinMatchers = sync.OnceValue(func() map[string]ProblemMatcherObject {
+	var defs struct {
+		Matchers map[string]ProblemMatcherObject `json:"matchers"`
+	}
+	mustUnmarshalBuiltin(&defs)
+	return defs.Matchers
+})
+
+func mustUnmarshalBuiltin(v any) {
+	if err := unmarshalJSONC("problem_matchers.json", builtinProblemMatchersJSON, v); err != nil {
+		panic(err) // embedded, so caught by the tests
+	}
+}
+
+// BuiltinProblemMatcher returns the built-in matcher called name (e.g., "$tsc").
+func BuiltinProblemMatcher(name string) (ProblemMatcherObject, bool) {
+	m, ok := builtinMatchers()[strings.TrimSpace(name)]
+	return m.clone(), ok
+}
+
+// clone copies o's pattern list and background, so decoding over the copy leaves o alone.
+func (o ProblemMatcherObject) clone() ProblemMatcherObject {
+	o.Pattern = append(ProblemMatcherPatterns(nil), o.Pattern...)
+	if o.Background != nil {
+		bg := *o.Background
+		o.Background = &bg
+	}
+	return o
+}
+
+// Matchers returns pm's matchers as objects: names ("$tsc") resolved to the built-in matchers,
+// and objects with a "base" decoded over the built-in matcher they extend. Names vstask
+// doesn't know (those of other extensions) are skipped, like an unknown base; objects that
+// don't decode are reported, and the rest still returned.
+func (pm ProblemMatcher) Matchers() ([]ProblemMatcherObject, error) {
+	var out []ProblemMatcherObject
+	var errs []error
+	for _, raw := range pm.Elems {
+		var name string
+		if json.Unmarshal(raw, &name) == nil {
+			if m, ok := BuiltinProblemMatcher(name); ok {
+				out = append(out, m)
+			}
+			continue
+		}
+		var head struct {
+			Base string `json:"base"`
+		}
+		_ = json.Unmarshal(raw, &head)
+		obj, _ := BuiltinProblemMatcher(head.Base)
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			errs = append(errs, fmt.Errorf("problemMatcher: %w", err))
+			continue
+		}
+		out = append(out, obj)
+	}
+	return out, errors.Join(errs...)
+}
@@ -0,0 +1,40 @@
+package tasks
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/chenasraf/vstask/utils"
+)
+
+func init() {
+	RegisterProvider(Provider{Name: "cargo", Detect: detectCargoTasks})
+}
+
+// detectCargoTasks provides the standard Cargo commands when root has a Cargo.toml.
+func detectCargoTasks(_ context.Context, root string) ([]Task, error) {
+	if !utils.FileExists(filepath.Join(root, "Cargo.toml")) {
+		return nil, nil
+	}
+	return []Task{
+		toolTask("cargo", "build", "build", "cargo", "build"),
+		toolTask("cargo", "test", "test", "cargo", "test"),
+		toolTask("cargo", "clippy", "", "cargo", "clippy"),
+		toolTask("cargo", "run", "", "cargo", "run"),
+	}, nil
+}
+
+// toolTask builds a provided task labeled "<provider>: <name>" that runs exe with args
+// directly (no shell). group, if set, is the task's group kind ("build" or "test").
+func toolTask(provider, name, group, exe string, args ...string) Task {
+	t := Task{
+		Label:   provider + ": " + name,
+		Type:    "process",
+		Command: exe,
+		Args:    args,
+	}
+	if group != "" {
+		t.Group = &Group{Kind: group}
+	}
+	return t
+}
@@ -0,0 +1,51 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func init() {
+	RegisterProvider(Provider{Name: "composer", Detect: detectComposerTasks})
+}
+
+// detectComposerTasks provides one "composer: <script>" task per composer.json script,
+// in file order. Scripts may be a command or a list of commands.
+func detectComposerTasks(_ context.Context, root string) ([]Task, error) {
+	b, err := os.ReadFile(filepath.Join(root, "composer.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var pkg struct {
+		Scripts json.RawMessage `json:"scripts"`
+	}
+	if err := json.Unmarshal(b, &pkg); err != nil {
+		return nil, err
+	}
+	names, scripts, err := orderedObject(pkg.Scripts)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Task, 0, len(names))
+	for _, name := range names {
+		var detail string
+		var many []string
+		switch {
+		case json.Unmarshal(scripts[name], &detail) == nil:
+		case json.Unmarshal(scripts[name], &many) == nil:
+			detail = strings.Join(many, " && ")
+		default:
+			continue
+		}
+		t := toolTask("composer", name, "", "composer", "run-script", name)
+		t.Detail = detail
+		out = append(out, t)
+	}
+	return out, nil
+}
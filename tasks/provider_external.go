@@ -0,0 +1,109 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// External providers are executables named vstask-provider-<name> on PATH. Enabling "<name>"
+// in vstask.providers (when it isn't a built-in provider) runs
+//
+//	vstask-provider-<name> list
+//
+// in the workspace folder, with $VSTASK_WORKSPACE_FOLDER set and $VSTASK_PROVIDER_PROTOCOL=1.
+// It must print a JSON array of tasks (or {"tasks": [...]}) in tasks.json format. Listed tasks
+// are labeled "<name>: <label>". A task with a command or script runs like any other task;
+// one without is run by the provider:
+//
+//	vstask-provider-<name> run '<payload>'
+//
+// where payload is a JSON runPayload. The provider's stdin, stdout and stderr are the task's.
+const (
+	externalProviderPrefix   = "vstask-provider-"
+	externalProviderProtocol = 1
+)
+
+// runPayload is passed to `vstask-provider-<name> run`.
+type runPayload struct {
+	Protocol        int             `json:"protocol"`
+	Label           string          `json:"label"` // the label as listed by the provider (unprefixed)
+	Task            json.RawMessage `json:"task"`  // the task object exactly as listed
+	WorkspaceFolder string          `json:"workspaceFolder"`
+}
+
+// externalProvider returns the provider backed by vstask-provider-<name>, if it's on PATH.
+func externalProvider(name string) (Provider, bool) {
+	exe, err := exec.LookPath(externalProviderPrefix + name)
+	if err != nil {
+		return Provider{}, false
+	}
+	return Provider{
+		Name: name,
+		Detect: func(ctx context.Context, root string) ([]Task, error) {
+			return listExternalTasks(ctx, name, exe, root)
+		},
+		RunsCommands: true,
+	}, true
+}
+
+func listExternalTasks(ctx context.Context, name, exe, root string) ([]Task, error) {
+	cmd := exec.CommandContext(ctx, exe, "list")
+	cmd.Dir = root
+	cmd.Env = append(os.Environ(),
+		"VSTASK_WORKSPACE_FOLDER="+root,
+		fmt.Sprintf("VSTASK_PROVIDER_PROTOCOL=%d", externalProviderProtocol),
+	)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var raws []json.RawMessage
+	out = bytes.TrimSpace(out)
+	if len(out) > 0 && out[0] == '{' {
+		var wrapped struct {
+			Tasks []json.RawMessage `json:"tasks"`
+		}
+		if err := json.Unmarshal(out, &wrapped); err != nil {
+			return nil, fmt.Errorf("%s list: %w", externalProviderPrefix+name, err)
+		}
+		raws = wrapped.Tasks
+	} else if err := json.Unmarshal(out, &raws); err != nil {
+		return nil, fmt.Errorf("%s list: %w", externalProviderPrefix+name, err)
+	}
+
+	ts := make([]Task, 0, len(raws))
+	for _, raw := range raws {
+		var t Task
+		if err := json.Unmarshal(raw, &t); err != nil || t.Label == "" {
+			continue
+		}
+		label := t.Label
+		t.Label = name + ": " + label
+		if t.DependsOn != nil {
+			for i := range t.DependsOn.Tasks {
+				t.DependsOn.Tasks[i].Label = name + ": " + t.DependsOn.Tasks[i].Label
+			}
+		}
+		if t.Command == "" && t.Script == "" {
+			payload, err := json.Marshal(runPayload{
+				Protocol:        externalProviderProtocol,
+				Label:           label,
+				Task:            raw,
+				WorkspaceFolder: root,
+			})
+			if err != nil {
+				continue
+			}
+			t.Type = "process"
+			t.Command = exe
+			t.Args = []string{"run", string(payload)}
+		}
+		ts = append(ts, t)
+	}
+	return ts, nil
+}
@@ -0,0 +1,24 @@
+package tasks
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/chenasraf/vstask/utils"
+)
+
+func init() {
+	RegisterProvider(Provider{Name: "go", Detect: detectGoTasks})
+}
+
+// detectGoTasks provides build, test and vet over all packages when root has a go.mod.
+func detectGoTasks(_ context.Context, root string) ([]Task, error) {
+	if !utils.FileExists(filepath.Join(root, "go.mod")) {
+		return nil, nil
+	}
+	return []Task{
+		toolTask("go", "build", "build", "go", "build", "./..."),
+		toolTask("go", "test", "test", "go", "test", "./..."),
+		toolTask("go", "vet", "", "go", "vet", "./..."),
+	}, nil
+}
@@ -0,0 +1,78 @@
+package tasks
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/chenasraf/vstask/utils"
+)
+
+func init() {
+	RegisterProvider(Provider{Name: "make", Detect: detectMakeTasks})
+}
+
+var makefileNames = []string{"GNUmakefile", "makefile", "Makefile"}
+
+// reMakeTarget matches rule lines ("build test: deps ## description"); pattern rules and
+// special targets (".PHONY") don't match.
+var reMakeTarget = regexp.MustCompile(`^([A-Za-z0-9][^:#=%$\s]*(?:\s+[A-Za-z0-9][^:#=%$\s]*)*)\s*::?(.*)$`)
+
+// detectMakeTasks provides one "make: <target>" task per explicit target in the Makefile.
+// A trailing "## text" on the rule line becomes the task's detail.
+func detectMakeTasks(ctx context.Context, root string) ([]Task, error) {
+	var path string
+	for _, name := range makefileNames {
+		if p := filepath.Join(root, name); utils.FileExists(p) {
+			path = p
+			break
+		}
+	}
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []Task
+	seen := map[string]bool{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if ctx.Err() != nil {
+			return out, ctx.Err()
+		}
+		line := sc.Text()
+		if line == "" || line[0] == '\t' || line[0] == '#' {
+			continue
+		}
+		m := reMakeTarget.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		prereqs, detail, _ := strings.Cut(m[2], "##")
+		if strings.Contains(prereqs, "=") {
+			continue // assignment (":=", "::=") or target-specific variable
+		}
+		detail = strings.TrimSpace(detail)
+		for _, target := range strings.Fields(m[1]) {
+			if seen[target] || strings.HasPrefix(target, ".") {
+				continue
+			}
+			seen[target] = true
+			out = append(out, Task{
+				Label:   "make: " + target,
+				Type:    "process",
+				Command: "make",
+				Args:    []string{target},
+				Detail:  detail,
+			})
+		}
+	}
+	return out, sc.Err()
+}
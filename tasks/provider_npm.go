@@ -0,0 +1,78 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+func init() {
+	RegisterProvider(Provider{Name: "npm", Detect: detectNpmTasks})
+}
+
+// detectNpmTasks provides one "npm: <script>" task per package.json script, in file order.
+func detectNpmTasks(_ context.Context, root string) ([]Task, error) {
+	b, err := os.ReadFile(filepath.Join(root, "package.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var pkg struct {
+		Scripts json.RawMessage `json:"scripts"`
+	}
+	if err := json.Unmarshal(b, &pkg); err != nil {
+		return nil, err
+	}
+	names, scripts, err := orderedObject(pkg.Scripts)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Task, 0, len(names))
+	for _, name := range names {
+		var script string
+		if json.Unmarshal(scripts[name], &script) != nil {
+			continue
+		}
+		out = append(out, Task{
+			Label:  "npm: " + name,
+			Type:   "npm",
+			Script: name,
+			Detail: script,
+		})
+	}
+	return out, nil
+}
+
+// orderedObject decodes a JSON object, also returning its keys in document order (Go maps
+// lose it, and users expect scripts listed the way they wrote them).
+func orderedObject(raw json.RawMessage) ([]string, map[string]json.RawMessage, error) {
+	vals := map[string]json.RawMessage{}
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return nil, vals, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := dec.Token(); err != nil { // {
+		return nil, nil, err
+	}
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key, _ := tok.(string)
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			return nil, nil, err
+		}
+		if _, dup := vals[key]; !dup {
+			keys = append(keys, key)
+		}
+		vals[key] = v
+	}
+	return keys, vals, nil
+}
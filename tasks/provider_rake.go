@@ -0,0 +1,56 @@
+package tasks
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/chenasraf/vstask/utils"
+)
+
+func init() {
+	RegisterProvider(Provider{Name: "rake", Detect: detectRakeTasks, RunsCommands: true})
+}
+
+var rakefileNames = []string{"Rakefile", "rakefile", "Rakefile.rb", "rakefile.rb"}
+
+// reRakeLine matches `rake -AT` output: "rake db:migrate[version]  # Migrate the database".
+var reRakeLine = regexp.MustCompile(`^rake\s+([^\s\[#]+)(?:\[[^\]]*\])?\s*(?:#\s*(.*))?$`)
+
+// detectRakeTasks provides one "rake: <task>" task per task listed by `rake -AT`. Asking rake
+// (rather than parsing the Rakefile) gets namespaces and imported task files right.
+func detectRakeTasks(ctx context.Context, root string) ([]Task, error) {
+	found := false
+	for _, name := range rakefileNames {
+		if utils.FileExists(filepath.Join(root, name)) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, nil
+	}
+	cmd := exec.CommandContext(ctx, "rake", "-AT")
+	cmd.Dir = root
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var ts []Task
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		m := reRakeLine.FindStringSubmatch(strings.TrimSpace(sc.Text()))
+		if m == nil {
+			continue
+		}
+		t := toolTask("rake", m[1], "", "rake", m[1])
+		t.Detail = strings.TrimSpace(m[2])
+		ts = append(ts, t)
+	}
+	return ts, nil
+}
@@ -0,0 +1,233 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chenasraf/vstask/utils"
+)
+
+// Provider auto-detects tasks in a workspace folder, like VS Code's task providers
+// (npm scripts, make targets, ...). Provided tasks are labeled "<name>: <task>".
+type Provider struct {
+	Name string
+	// Detect returns the tasks found in root. It should give up when ctx is done;
+	// either way its result is discarded once the provider's time is up.
+	Detect func(ctx context.Context, root string) ([]Task, error)
+	// RunsCommands is set when Detect runs commands on root's files (rake loads the Rakefile),
+	// rather than only reading them: the provider doesn't run until root is trusted.
+	RunsCommands bool
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   []Provider
+)
+
+// RegisterProvider adds a provider to the registry. Providers are opt-in per workspace
+// (see EnabledProviders).
+func RegisterProvider(p Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers = append(providers, p)
+}
+
+// Providers returns the registered providers, in registration order.
+func Providers() []Provider {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	return slices.Clone(providers)
+}
+
+const (
+	defaultProviderTimeout = time.Second
+	defaultProviderBudget  = 2 * time.Second
+)
+
+// providerTimeouts returns the per-provider timeout ($VSTASK_PROVIDER_TIMEOUT) and the total
+// discovery budget ($VSTASK_PROVIDER_BUDGET), both duration strings such as "500ms".
+func providerTimeouts() (each, total time.Duration) {
+	each, total = defaultProviderTimeout, defaultProviderBudget
+	if d, err := time.ParseDuration(os.Getenv("VSTASK_PROVIDER_TIMEOUT")); err == nil && d > 0 {
+		each = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("VSTASK_PROVIDER_BUDGET")); err == nil && d > 0 {
+		total = d
+	}
+	return each, total
+}
+
+// EnabledProviders returns the names of the providers enabled for root: $VSTASK_PROVIDERS
+// (comma-separated) if set, else "vstask.providers" from the workspace settings, then the
+// user settings. Nothing is enabled by default. Enabled providers that run commands wait for
+// the workspace to be trusted (see activeProviders).
+func EnabledProviders(root string) []string {
+	if v, ok := os.LookupEnv("VSTASK_PROVIDERS"); ok {
+		var out []string
+		for name := range strings.SplitSeq(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				out = append(out, name)
+			}
+		}
+		return out
+	}
+	// Providers run commands (e.g. rake loads the Rakefile), so an untrusted workspace's
+	// own settings can't turn them on.
+	if s, ok := readSettingsFile(workspaceSettingsPath(root)); ok && s.Providers != nil && IsTrusted(root) {
+		return s.Providers
+	}
+	for _, p := range userSettingsCandidates() {
+		if s, ok := readSettingsFile(p); ok && s.Providers != nil {
+			return s.Providers
+		}
+	}
+	return nil
+}
+
+// ProvidedTasks runs the enabled providers for root concurrently, once per invocation (see
+// detectTasksOnce). Each provider gets its own timeout and all of them share a total budget;
+// providers that run out of time contribute no tasks (and are reported in verbose output) so a
+// slow scan never holds up the picker. Built-in providers come first, in registry order, then
+// external ones (see externalProvider) in the order they were enabled.
+func ProvidedTasks(root string) []Task {
+	return runProviders(root, activeProviders(root))
+}
+
+// activeProviders returns the providers enabled for root, in the order ProvidedTasks runs them.
+// Those running commands are left out until root is trusted, however they were enabled: the
+// user settings and $VSTASK_PROVIDERS apply to every workspace, trusted or not.
+func activeProviders(root string) []Provider {
+	enabled := EnabledProviders(root)
+	if len(enabled) == 0 {
+		return nil
+	}
+	var active []Provider
+	builtin := Providers()
+	for _, p := range builtin {
+		if slices.ContainsFunc(enabled, func(n string) bool { return strings.EqualFold(n, p.Name) }) {
+			active = append(active, p)
+		}
+	}
+	for _, name := range enabled {
+		if slices.ContainsFunc(builtin, func(p Provider) bool { return strings.EqualFold(name, p.Name) }) {
+			continue
+		}
+		if p, ok := externalProvider(name); ok {
+			active = append(active, p)
+		} else {
+			utils.Debugf("provider %s: not a built-in provider and %s%s is not on PATH", name, externalProviderPrefix, name)
+		}
+	}
+	if !slices.ContainsFunc(active, func(p Provider) bool { return p.RunsCommands }) || IsTrusted(root) {
+		return active
+	}
+	return slices.DeleteFunc(active, func(p Provider) bool {
+		if p.RunsCommands {
+			utils.Debugf("provider %s: runs commands; skipped until the workspace is trusted", p.Name)
+		}
+		return p.RunsCommands
+	})
+}
+
+func runProviders(root string, ps []Provider) []Task {
+	return slices.Concat(detectTasksOnce(root, ps)...)
+}
+
+// providerRuns caches what providers found, by root and the providers run: one invocation
+// loads a workspace's tasks several times (its tasks, its inputs, ${defaultBuildTask} for
+// every task resolved, ...), and providers run commands, some of them slow or external.
+var providerRuns sync.Map // root and provider names -> *providerRun
+
+type providerRun struct {
+	once    sync.Once
+	results [][]Task
+}
+
+// detectTasksOnce is detectTasks, run once per root and providers (until ForgetProvidedTasks).
+func detectTasksOnce(root string, ps []Provider) [][]Task {
+	key := root
+	for _, p := range ps {
+		key += "\x00" + p.Name
+	}
+	v, _ := providerRuns.LoadOrStore(key, &providerRun{})
+	run := v.(*providerRun)
+	run.once.Do(func() { run.results = detectTasks(root, ps) })
+	return run.results
+}
+
+// ForgetProvidedTasks drops what providers found so far, for a long-running process (see
+// daemon) to notice changes to the files they read: they run again when next asked.
+func ForgetProvidedTasks() {
+	providerRuns.Clear()
+}
+
+// detectTasks runs ps concurrently (see ProvidedTasks) and returns each one's tasks.
+func detectTasks(root string, ps []Provider) [][]Task {
+	if len(ps) == 0 {
+		return nil
+	}
+	each, total := providerTimeouts()
+	budget, cancel := context.WithTimeout(context.Background(), total)
+	defer cancel()
+
+	results := make([][]Task, len(ps))
+	var wg sync.WaitGroup
+	for i, p := range ps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(budget, each)
+			defer cancel()
+
+			start := time.Now()
+			done := make(chan []Task, 1)
+			go func() {
+				defer func() {
+					if v := recover(); v != nil {
+						fmt.Fprintf(os.Stderr, "vstask: provider %s panicked (its tasks are skipped): %v\n%s", p.Name, v, debug.Stack())
+						done <- nil
+					}
+				}()
+				ts, err := p.Detect(ctx, root)
+				if err != nil {
+					utils.Debugf("provider %s: %v", p.Name, err)
+				}
+				done <- ts
+			}()
+			select {
+			case ts := <-done:
+				utils.Debugf("provider %s: %d tasks in %s", p.Name, len(ts), time.Since(start).Round(time.Millisecond))
+				results[i] = ts
+			case <-ctx.Done():
+				utils.Debugf("provider %s: skipped, no result after %s", p.Name, time.Since(start).Round(time.Millisecond))
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// mergeProvided appends provided tasks whose labels aren't already defined in tasks.json.
+func mergeProvided(defined, provided []Task) []Task {
+	if len(provided) == 0 {
+		return defined
+	}
+	seen := make(map[string]bool, len(defined))
+	for _, t := range defined {
+		seen[t.Label] = true
+	}
+	out := slices.Clone(defined)
+	for _, t := range provided {
+		if !seen[t.Label] {
+			seen[t.Label] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
@@ -0,0 +1,370 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func writeTestFile(t testing.TB, p, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func labels(ts []Task) []string {
+	out := make([]string, len(ts))
+	for i, t := range ts {
+		out[i] = t.Label
+	}
+	return out
+}
+
+// ---- registry & discovery ----
+
+func TestRunProviders_TimeoutsAndOrder(t *testing.T) {
+	t.Setenv("VSTASK_PROVIDER_TIMEOUT", "50ms")
+	t.Setenv("VSTASK_PROVIDER_BUDGET", "1s")
+
+	fast := func(label string) Provider {
+		return Provider{Name: label, Detect: func(context.Context, string) ([]Task, error) {
+			return []Task{{Label: label}}, nil
+		}}
+	}
+	slow := Provider{Name: "slow", Detect: func(ctx context.Context, _ string) ([]Task, error) {
+		time.Sleep(2 * time.Second) // ignores ctx on purpose
+		return []Task{{Label: "slow"}}, nil
+	}}
+
+	start := time.Now()
+	got := runProviders(t.TempDir(), []Provider{fast("a"), slow, fast("b")})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("discovery should not wait for the slow provider, took %s", elapsed)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(labels(got), want) {
+		t.Fatalf("got %v, want %v", labels(got), want)
+	}
+}
+
+func TestRunProviders_TotalBudget(t *testing.T) {
+	t.Setenv("VSTASK_PROVIDER_TIMEOUT", "5s")
+	t.Setenv("VSTASK_PROVIDER_BUDGET", "50ms")
+	slow := Provider{Name: "slow", Detect: func(ctx context.Context, _ string) ([]Task, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}
+	start := time.Now()
+	if got := runProviders(t.TempDir(), []Provider{slow, slow}); len(got) != 0 {
+		t.Fatalf("expected no tasks, got %v", labels(got))
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("budget not enforced, took %s", elapsed)
+	}
+}
+
+func TestRunProviders_PanicSkipsProvider(t *testing.T) {
+	bad := Provider{Name: "bad", Detect: func(context.Context, string) ([]Task, error) {
+		var m map[string]int
+		m["boom"]++ // nil map write
+		return nil, nil
+	}}
+	good := Provider{Name: "good", Detect: func(context.Context, string) ([]Task, error) {
+		return []Task{{Label: "good"}}, nil
+	}}
+	if got := runProviders(t.TempDir(), []Provider{bad, good}); !reflect.DeepEqual(labels(got), []string{"good"}) {
+		t.Fatalf("got %v, want the other provider's tasks", labels(got))
+	}
+}
+
+func TestRunProviders_Once(t *testing.T) {
+	var runs atomic.Int32
+	counted := Provider{Name: "counted", Detect: func(context.Context, string) ([]Task, error) {
+		runs.Add(1)
+		return []Task{{Label: "counted"}}, nil
+	}}
+	root := t.TempDir()
+	for range 3 {
+		if got := runProviders(root, []Provider{counted}); !reflect.DeepEqual(labels(got), []string{"counted"}) {
+			t.Fatalf("got %v", labels(got))
+		}
+	}
+	if n := runs.Load(); n != 1 {
+		t.Fatalf("the provider ran %d times, want once", n)
+	}
+	ForgetProvidedTasks()
+	runProviders(root, []Provider{counted})
+	if n := runs.Load(); n != 2 {
+		t.Fatalf("after ForgetProvidedTasks the provider ran %d times, want twice", n)
+	}
+}
+
+func TestEnabledProviders(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	if got := EnabledProviders(root); got != nil {
+		t.Fatalf("nothing should be enabled by default, got %v", got)
+	}
+	writeTestFile(t, filepath.Join(root, ".vscode", "settings.json"), `{
+		// jsonc
+		"vstask.providers": ["npm", "make"],
+	}`)
+	if got := EnabledProviders(root); got != nil {
+		t.Fatalf("an untrusted workspace can't enable providers, got %v", got)
+	}
+	if err := TrustWorkspace(root); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := EnabledProviders(root), []string{"npm", "make"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	t.Setenv("VSTASK_PROVIDERS", " make , ")
+	if got, want := EnabledProviders(root), []string{"make"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("env override: got %v, want %v", got, want)
+	}
+}
+
+func TestGetTasks_MergesProvidedTasks(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, ".vscode", "tasks.json"), `{"tasks": [{"label": "npm: build", "command": "custom"}]}`)
+	writeTestFile(t, filepath.Join(root, "package.json"), `{"scripts": {"build": "tsc", "test": "vitest"}}`)
+	t.Setenv("VSTASK_PROVIDERS", "npm")
+	t.Chdir(root)
+
+	got, err := GetTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// tasks.json wins on label clashes
+	if want := []string{"npm: build", "npm: test"}; !reflect.DeepEqual(labels(got), want) {
+		t.Fatalf("got %v, want %v", labels(got), want)
+	}
+	if got[0].Command != "custom" {
+		t.Fatalf("tasks.json definition should win, got %+v", got[0])
+	}
+}
+
+// ---- npm ----
+
+func TestDetectNpmTasks_KeepsScriptOrder(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "package.json"), `{
+		"name": "x",
+		"scripts": {"start": "node .", "build": "tsc", "lint": "eslint .", "bad": 1}
+	}`)
+	got, err := detectNpmTasks(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"npm: start", "npm: build", "npm: lint"}; !reflect.DeepEqual(labels(got), want) {
+		t.Fatalf("got %v, want %v", labels(got), want)
+	}
+	if got[1].Type != "npm" || got[1].Script != "build" || got[1].Detail != "tsc" {
+		t.Fatalf("unexpected task: %+v", got[1])
+	}
+
+	if ts, err := detectNpmTasks(context.Background(), t.TempDir()); err != nil || ts != nil {
+		t.Fatalf("no package.json: got %v, %v", ts, err)
+	}
+}
+
+// ---- make ----
+
+func TestDetectMakeTasks(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "Makefile"), `
+CC := gcc
+PREFIX ?= /usr/local
+VERSION ::= 1.0
+.PHONY: build test
+
+build: deps ## Build the binary
+	$(CC) -o app main.c
+
+test lint: build
+	./run-tests
+
+%.o: %.c
+	$(CC) -c $<
+
+debug: CFLAGS += -g
+deps:
+`)
+	got, err := detectMakeTasks(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"make: build", "make: test", "make: lint", "make: deps"}; !reflect.DeepEqual(labels(got), want) {
+		t.Fatalf("got %v, want %v", labels(got), want)
+	}
+	b := got[0]
+	if b.Type != "process" || b.Command != "make" || !reflect.DeepEqual(b.Args, []string{"build"}) || b.Detail != "Build the binary" {
+		t.Fatalf("unexpected task: %+v", b)
+	}
+}
+
+// ---- cargo & go ----
+
+func TestDetectToolchainTasks(t *testing.T) {
+	root := t.TempDir()
+	for _, detect := range []func(context.Context, string) ([]Task, error){detectCargoTasks, detectGoTasks} {
+		if ts, err := detect(context.Background(), root); err != nil || ts != nil {
+			t.Fatalf("empty folder: got %v, %v", ts, err)
+		}
+	}
+
+	writeTestFile(t, filepath.Join(root, "Cargo.toml"), "[package]\nname = \"x\"\n")
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module x\n")
+
+	cargo, _ := detectCargoTasks(context.Background(), root)
+	if want := []string{"cargo: build", "cargo: test", "cargo: clippy", "cargo: run"}; !reflect.DeepEqual(labels(cargo), want) {
+		t.Fatalf("cargo: got %v, want %v", labels(cargo), want)
+	}
+	goTasks, _ := detectGoTasks(context.Background(), root)
+	if want := []string{"go: build", "go: test", "go: vet"}; !reflect.DeepEqual(labels(goTasks), want) {
+		t.Fatalf("go: got %v, want %v", labels(goTasks), want)
+	}
+	test := goTasks[1]
+	if test.Type != "process" || test.Command != "go" || !reflect.DeepEqual(test.Args, []string{"test", "./..."}) ||
+		test.Group == nil || test.Group.Kind != "test" {
+		t.Fatalf("unexpected go test task: %+v", test)
+	}
+}
+
+// ---- composer & rake ----
+
+func TestDetectComposerTasks(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "composer.json"), `{
+		"scripts": {
+			"test": "phpunit",
+			"check": ["@lint", "@test"],
+			"bad": 3
+		}
+	}`)
+	got, err := detectComposerTasks(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"composer: test", "composer: check"}; !reflect.DeepEqual(labels(got), want) {
+		t.Fatalf("got %v, want %v", labels(got), want)
+	}
+	c := got[1]
+	if c.Command != "composer" || !reflect.DeepEqual(c.Args, []string{"run-script", "check"}) || c.Detail != "@lint && @test" {
+		t.Fatalf("unexpected task: %+v", c)
+	}
+}
+
+func TestDetectRakeTasks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake rake is a shell script")
+	}
+	root := t.TempDir()
+	if ts, err := detectRakeTasks(context.Background(), root); err != nil || ts != nil {
+		t.Fatalf("no Rakefile: got %v, %v", ts, err)
+	}
+
+	writeTestFile(t, filepath.Join(root, "Rakefile"), "task :default\n")
+	bin := t.TempDir()
+	writeTestFile(t, filepath.Join(bin, "rake"), `#!/bin/sh
+echo 'rake default                 # Run tests'
+echo 'rake db:migrate[version]     # Migrate the database'
+echo 'rake build'
+`)
+	if err := os.Chmod(filepath.Join(bin, "rake"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	got, err := detectRakeTasks(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"rake: default", "rake: db:migrate", "rake: build"}; !reflect.DeepEqual(labels(got), want) {
+		t.Fatalf("got %v, want %v", labels(got), want)
+	}
+	if got[1].Detail != "Migrate the database" || !reflect.DeepEqual(got[1].Args, []string{"db:migrate"}) {
+		t.Fatalf("unexpected task: %+v", got[1])
+	}
+}
+
+// ---- external providers ----
+
+func TestExternalProvider(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake provider is a shell script")
+	}
+	bin := t.TempDir()
+	exe := filepath.Join(bin, "vstask-provider-fake")
+	writeTestFile(t, exe, `#!/bin/sh
+[ "$1" = list ] || exit 2
+[ "$VSTASK_PROVIDER_PROTOCOL" = 1 ] || exit 3
+cat <<JSON
+{"tasks": [
+  {"label": "build", "target": "//app:build", "group": "build"},
+  {"label": "fmt", "type": "shell", "command": "fmt-all"},
+  {"label": "ci", "dependsOn": ["build", "fmt"]},
+  {"no": "label"}
+]}
+JSON
+`)
+	if err := os.Chmod(exe, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("VSTASK_PROVIDERS", "fake,missing")
+
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	t.Setenv("VSTASK_TRUST_ALL", "")
+
+	root := t.TempDir()
+	// It runs a command in root: enabled outside the workspace's settings or not, that waits
+	// for trust.
+	if got := ProvidedTasks(root); len(got) != 0 {
+		t.Fatalf("an untrusted workspace ran a command-running provider: %v", labels(got))
+	}
+	if err := TrustWorkspace(root); err != nil {
+		t.Fatal(err)
+	}
+	got := ProvidedTasks(root)
+	if want := []string{"fake: build", "fake: fmt", "fake: ci"}; !reflect.DeepEqual(labels(got), want) {
+		t.Fatalf("got %v, want %v", labels(got), want)
+	}
+
+	// Tasks without a command run through the provider with a payload.
+	b := got[0]
+	if b.Type != "process" || b.Command != exe || len(b.Args) != 2 || b.Args[0] != "run" {
+		t.Fatalf("unexpected run command: %+v", b)
+	}
+	var p runPayload
+	if err := json.Unmarshal([]byte(b.Args[1]), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Protocol != 1 || p.Label != "build" || p.WorkspaceFolder != root || !strings.Contains(string(p.Task), "//app:build") {
+		t.Fatalf("unexpected payload: %+v", p)
+	}
+	if b.Group == nil || b.Group.Kind != "build" {
+		t.Fatalf("listed fields should be kept: %+v", b)
+	}
+
+	// Tasks with a command run directly; dependsOn labels get the provider prefix.
+	if got[1].Command != "fmt-all" || got[1].Type != "shell" {
+		t.Fatalf("unexpected task: %+v", got[1])
+	}
+	if deps := got[2].DependsOn.Labels(); !reflect.DeepEqual(deps, []string{"fake: build", "fake: fmt"}) {
+		t.Fatalf("dependsOn: got %v", deps)
+	}
+}
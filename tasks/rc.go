@@ -0,0 +1,95 @@
+package tasks
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/chenasraf/vstask/utils"
+)
+
+// RCFileName is the per-directory defaults file, discovered from the cwd up to the workspace
+// folder (like direnv).
+const RCFileName = ".vstaskrc"
+
+// RC holds per-directory defaults from .vstaskrc files (JSONC):
+//
+//	{
+//	  "defaultTask": "dev",                // run by a bare `vstask`
+//	  "defaultAction": "build",            // or this, see DefaultAction
+//	  "env": { "NODE_ENV": "development" } // added to the environment of every task
+//	}
+//
+// Files nearer the cwd win; env maps are merged key by key. Only files in the workspace folder
+// count: one in a parent of it (a home directory, say) isn't read.
+type RC struct {
+	DefaultTask   string            `json:"defaultTask,omitempty"`
+	DefaultAction string            `json:"defaultAction,omitempty"`
+	Env           map[string]string `json:"env,omitempty"`
+
+	Files []string `json:"-"` // the files applied, nearest first
+}
+
+// LoadRC merges the .vstaskrc files in dir and its parents up to root, the workspace folder.
+// If dir isn't in root (or root is ""), only dir's own file is read.
+func LoadRC(dir, root string) (RC, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return RC{}, err
+	}
+	dir = evalOrSelf(dir)
+	if root != "" {
+		root = trustKey(root)
+	}
+	var files []string
+	for cur := dir; ; {
+		p := filepath.Join(cur, RCFileName)
+		if utils.FileExists(p) {
+			files = append(files, p)
+		}
+		parent := filepath.Dir(cur)
+		if cur == root || parent == cur || !isWithin(parent, root) {
+			break
+		}
+		cur = parent
+	}
+
+	rc := RC{Env: map[string]string{}}
+	// Farthest first, so nearer files override.
+	for _, p := range slices.Backward(files) {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return RC{}, err
+		}
+		var f RC
+		if err := unmarshalJSONC(p, b, &f); err != nil {
+			return RC{}, err
+		}
+		if f.DefaultTask != "" {
+			rc.DefaultTask = f.DefaultTask
+		}
+		if f.DefaultAction != "" {
+			rc.DefaultAction = f.DefaultAction
+		}
+		maps.Copy(rc.Env, f.Env)
+	}
+	rc.Files = files
+	return rc, nil
+}
+
+// ApplyEnv adds the rc's env entries to the process environment, so tasks, inputs and
+// variable substitution all see them. Variables already set in the environment are kept.
+func (rc RC) ApplyEnv() error {
+	var errs []error
+	for _, k := range slices.Sorted(maps.Keys(rc.Env)) {
+		if _, ok := os.LookupEnv(k); ok {
+			continue
+		}
+		if err := os.Setenv(k, rc.Env[k]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,77 @@
+package tasks
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadRC_NearestWins(t *testing.T) {
+	root := evalOrSelf(t.TempDir())
+	pkg := filepath.Join(root, "packages", "web")
+	writeTestFile(t, filepath.Join(root, RCFileName), `{
+		// repo-wide defaults
+		"defaultTask": "build",
+		"env": {"A": "root", "B": "root"},
+	}`)
+	writeTestFile(t, filepath.Join(pkg, RCFileName), `{"defaultTask": "web: dev", "env": {"B": "web"}}`)
+
+	if err := os.MkdirAll(filepath.Join(pkg, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	rc, err := LoadRC(filepath.Join(pkg, "src"), root) // no .vstaskrc in src itself
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rc.DefaultTask != "web: dev" {
+		t.Errorf("defaultTask: got %q", rc.DefaultTask)
+	}
+	if want := map[string]string{"A": "root", "B": "web"}; !reflect.DeepEqual(rc.Env, want) {
+		t.Errorf("env: got %v, want %v", rc.Env, want)
+	}
+	if len(rc.Files) != 2 || rc.Files[0] != filepath.Join(pkg, RCFileName) {
+		t.Errorf("files: got %v", rc.Files)
+	}
+
+	rc, err = LoadRC(root, root)
+	if err != nil || rc.DefaultTask != "build" {
+		t.Fatalf("root: got %+v, %v", rc, err)
+	}
+
+	// the walk stops at the workspace folder
+	rc, err = LoadRC(filepath.Join(pkg, "src"), pkg)
+	if err != nil || len(rc.Files) != 1 || rc.Env["A"] != "" {
+		t.Fatalf("workspace folder %s: got %+v, %v", pkg, rc, err)
+	}
+	rc, err = LoadRC(filepath.Join(pkg, "src"), "")
+	if err != nil || len(rc.Files) != 0 {
+		t.Fatalf("no workspace folder: got %+v, %v", rc, err)
+	}
+}
+
+func TestLoadRC_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, RCFileName), `{"env": [1]}`)
+	if _, err := LoadRC(dir, dir); err == nil {
+		t.Fatal("expected a parse error")
+	}
+}
+
+func TestRC_ApplyEnvKeepsExisting(t *testing.T) {
+	t.Setenv("VSTASK_RC_SET", "outer")
+	t.Setenv("VSTASK_RC_NEW", "")
+	os.Unsetenv("VSTASK_RC_NEW")
+	t.Cleanup(func() { os.Unsetenv("VSTASK_RC_NEW") })
+
+	rc := RC{Env: map[string]string{"VSTASK_RC_SET": "rc", "VSTASK_RC_NEW": "rc"}}
+	if err := rc.ApplyEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if got := os.Getenv("VSTASK_RC_SET"); got != "outer" {
+		t.Errorf("existing variable overridden: %q", got)
+	}
+	if got := os.Getenv("VSTASK_RC_NEW"); got != "rc" {
+		t.Errorf("new variable not added: %q", got)
+	}
+}
@@ -0,0 +1,37 @@
+package tasks
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSandbox_JSON(t *testing.T) {
+	cases := []struct {
+		in   string
+		want Sandbox
+		out  string
+	}{
+		{`true`, Sandbox{Enabled: true}, `true`},
+		{`false`, Sandbox{}, `false`},
+		{`{}`, Sandbox{Enabled: true}, `true`},
+		{`{"network": true, "writable": ["dist"]}`, Sandbox{Enabled: true, Network: true, Writable: []string{"dist"}}, `{"network":true,"writable":["dist"]}`},
+	}
+	for _, c := range cases {
+		var sb Sandbox
+		if err := json.Unmarshal([]byte(c.in), &sb); err != nil {
+			t.Fatalf("%s: %v", c.in, err)
+		}
+		if !reflect.DeepEqual(sb, c.want) {
+			t.Errorf("%s: got %+v, want %+v", c.in, sb, c.want)
+		}
+		out, err := json.Marshal(sb)
+		if err != nil || string(out) != c.out {
+			t.Errorf("%s: marshal = %s, %v; want %s", c.in, out, err, c.out)
+		}
+	}
+	var sb Sandbox
+	if err := json.Unmarshal([]byte(`"yes"`), &sb); err == nil {
+		t.Fatal("expected an error for a string")
+	}
+}
@@ -0,0 +1,60 @@
+package tasks
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/chenasraf/vstask/utils"
+)
+
+const seenTasksFile = "tasks-at-last-run.json"
+
+// seenTasks is a copy of a workspace's tasks.json tasks taken when a task last ran.
+type seenTasks struct {
+	Time  time.Time `json:"time"`
+	Tasks []Task    `json:"tasks"`
+}
+
+func seenTasksPath(root string) (string, error) {
+	dir, err := utils.WorkspaceStateDir(root)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, seenTasksFile), nil
+}
+
+// RecordSeenTasks keeps a copy of root's tasks.json tasks, for TaskChangesSinceLastRun.
+func RecordSeenTasks(root string) error {
+	file, err := LoadFile(TasksFilePath(root))
+	if err != nil {
+		return err
+	}
+	p, err := seenTasksPath(root)
+	if err != nil {
+		return err
+	}
+	return utils.WriteJSONFile(p, seenTasks{Time: time.Now(), Tasks: file.Tasks})
+}
+
+// TaskChangesSinceLastRun compares root's tasks.json with the copy RecordSeenTasks kept when
+// a task last ran. since is the zero time if there's no copy yet (nothing is reported then).
+func TaskChangesSinceLastRun(root string) (changes []TaskChange, since time.Time, err error) {
+	p, err := seenTasksPath(root)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var seen seenTasks
+	if err := utils.ReadJSONFile(p, &seen); err != nil || seen.Time.IsZero() {
+		return nil, time.Time{}, err
+	}
+	var cur []Task
+	if _, err := os.Stat(TasksFilePath(root)); err == nil {
+		file, err := LoadFile(TasksFilePath(root))
+		if err != nil {
+			return nil, seen.Time, err
+		}
+		cur = file.Tasks
+	}
+	return DiffTasks(seen.Tasks, cur), seen.Time, nil
+}
@@ -0,0 +1,32 @@
+package tasks
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestTaskChangesSinceLastRun(t *testing.T) {
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	root := t.TempDir()
+	tasksPath := filepath.Join(root, ".vscode", "tasks.json")
+	writeTestFile(t, tasksPath, `{"tasks": [{"label": "build", "command": "make"}]}`)
+
+	if changes, since, err := TaskChangesSinceLastRun(root); err != nil || !since.IsZero() || changes != nil {
+		t.Fatalf("nothing recorded yet: %v, %v, %v", changes, since, err)
+	}
+	if err := RecordSeenTasks(root); err != nil {
+		t.Fatal(err)
+	}
+	if changes, since, err := TaskChangesSinceLastRun(root); err != nil || since.IsZero() || len(changes) != 0 {
+		t.Fatalf("unchanged: %v, %v, %v", changes, since, err)
+	}
+
+	writeTestFile(t, tasksPath, `{"tasks": [{"label": "build", "command": "make all"}, {"label": "test", "command": "make test"}]}`)
+	changes, _, err := TaskChangesSinceLastRun(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[0].Kind() != "modified" || changes[1].Label != "test" || changes[1].Kind() != "added" {
+		t.Fatalf("changes = %+v", changes)
+	}
+}
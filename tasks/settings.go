@@ -17,6 +17,33 @@ type VSCodeSettings struct {
 	// Matches the VS Code setting: "npm.packageManager"
 	// Valid values: "npm", "yarn", "pnpm", "bun"
 	NPMPackageManager string `json:"npm.packageManager"`
+
+	// vstask: task providers to enable, e.g. ["npm", "make"] (see EnabledProviders)
+	Providers []string `json:"vstask.providers"`
+
+	// vstask: shell command templates for task types vstask doesn't run natively (see TypeCommands)
+	TaskTypes map[string]string `json:"vstask.taskTypes"`
+
+	// vstask: what a bare `vstask` does: "picker" | "build" | "last" (see DefaultAction)
+	DefaultAction string `json:"vstask.defaultAction"`
+
+	// vstask: require approval of tasks.json changes before running (see PinningEnabled)
+	PinTasks bool `json:"vstask.pinTasks"`
+
+	// vstask: how many dependencies run at once; 0 = no limit (see MaxParallel)
+	MaxParallel int `json:"vstask.maxParallel"`
+
+	// vstask: the shell to retry with when bash can't start, or "off" (see ShellFallback)
+	ShellFallback string `json:"vstask.shellFallback"`
+
+	// vstask: the default shell on Windows: "cmd" | "pwsh" | "powershell" | a path (see WindowsShell)
+	WindowsShell string `json:"vstask.windowsShell"`
+
+	// vstask: a command generating tasks.json before it's loaded (see TasksFileGenerator)
+	TasksFileGenerate string `json:"vstask.tasksFile.generate"`
+
+	// vstask: the values of the {{placeholders}} of included tasks files (see templateVariables)
+	TemplateVariables map[string]any `json:"vstask.templateVariables"`
 }
 
 // -----------------------------
@@ -42,13 +69,16 @@ func detectPackageManagerFromSettings(cwd string) (string, bool) {
 // Workspace settings
 // -----------------------------
 
+func workspaceSettingsPath(root string) string {
+	return filepath.Join(root, utils.VSCODE_DIR, "settings.json")
+}
+
 func readWorkspacePackageManager(cwd string) (string, bool) {
-	path := filepath.Join(cwd, ".vscode", "settings.json")
-	return readPackageManagerFromFile(path)
+	return readPackageManagerFromFile(workspaceSettingsPath(cwd))
 }
 
 // -----------------------------
-// User settings (Code / Insiders / VSCodium)
+// User settings (Code / Insiders / VSCodium / Cursor)
 // -----------------------------
 
 func readUserPackageManager() (string, bool) {
@@ -61,54 +91,40 @@ func readUserPackageManager() (string, bool) {
 	return "", false
 }
 
+// UserSettingsPaths returns the user settings.json locations vstask reads (VS Code, Insiders,
+// VSCodium, Cursor; see Editors), whether or not they exist.
+func UserSettingsPaths() []string {
+	return userSettingsCandidates()
+}
+
 func userSettingsCandidates() []string {
-	var dirs []string
+	var bases []string
 
 	switch runtime.GOOS {
 	case "darwin":
-		home, _ := os.UserHomeDir()
-		appSupport := filepath.Join(home, "Library", "Application Support")
-		if home != "" {
-			dirs = append(dirs,
-				filepath.Join(appSupport, "Code", "User", "settings.json"),
-				filepath.Join(appSupport, "Code - Insiders", "User", "settings.json"),
-				filepath.Join(appSupport, "VSCodium", "User", "settings.json"),
-			)
-		}
-	case "linux":
-		home, _ := os.UserHomeDir()
-		xdg := os.Getenv("XDG_CONFIG_HOME")
-		if xdg != "" {
-			dirs = append(dirs,
-				filepath.Join(xdg, "Code", "User", "settings.json"),
-				filepath.Join(xdg, "Code - Insiders", "User", "settings.json"),
-				filepath.Join(xdg, "VSCodium", "User", "settings.json"),
-			)
-		}
-		if home != "" {
-			dirs = append(dirs,
-				filepath.Join(home, ".config", "Code", "User", "settings.json"),
-				filepath.Join(home, ".config", "Code - Insiders", "User", "settings.json"),
-				filepath.Join(home, ".config", "VSCodium", "User", "settings.json"),
-			)
+		if home, _ := os.UserHomeDir(); home != "" {
+			bases = append(bases, filepath.Join(home, "Library", "Application Support"))
 		}
 	case "windows":
-		appData := os.Getenv("APPDATA")
-		if appData != "" {
-			dirs = append(dirs,
-				filepath.Join(appData, "Code", "User", "settings.json"),
-				filepath.Join(appData, "Code - Insiders", "User", "settings.json"),
-				filepath.Join(appData, "VSCodium", "User", "settings.json"),
-			)
+		if appData := os.Getenv("APPDATA"); appData != "" {
+			bases = append(bases, appData)
 		}
 	default:
-		// Fallback: try common Linux layout
-		home, _ := os.UserHomeDir()
-		if home != "" {
-			dirs = append(dirs, filepath.Join(home, ".config", "Code", "User", "settings.json"))
+		if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+			bases = append(bases, xdg)
+		}
+		if home, _ := os.UserHomeDir(); home != "" {
+			bases = append(bases, filepath.Join(home, ".config"))
 		}
 	}
 
+	var dirs []string
+	editors := Editors()
+	for _, base := range bases {
+		for _, e := range editors {
+			dirs = append(dirs, filepath.Join(base, e.DataDir, "User", "settings.json"))
+		}
+	}
 	return dirs
 }
 
@@ -116,15 +132,48 @@ func userSettingsCandidates() []string {
 // File loader
 // -----------------------------
 
-func readPackageManagerFromFile(path string) (string, bool) {
+// readSettingsFile parses a (JSONC) settings.json file.
+func readSettingsFile(path string) (VSCodeSettings, bool) {
 	b, err := os.ReadFile(path)
 	if err != nil {
-		return "", false
+		return VSCodeSettings{}, false
 	}
 	clean := utils.ConvertJsoncToJson(b)
 
 	var s VSCodeSettings
 	if err := json.Unmarshal([]byte(clean), &s); err != nil {
+		return VSCodeSettings{}, false
+	}
+	return s, true
+}
+
+// stringSetting returns a vstask setting of root: the environment variable env, then the
+// workspace settings, then the first user settings setting it (get reads it from one). It's ""
+// when none does.
+func stringSetting(root, env string, get func(VSCodeSettings) string) string {
+	if v := strings.TrimSpace(os.Getenv(env)); v != "" {
+		return v
+	}
+	if root != "" {
+		if s, ok := readSettingsFile(workspaceSettingsPath(root)); ok {
+			if v := strings.TrimSpace(get(s)); v != "" {
+				return v
+			}
+		}
+	}
+	for _, p := range userSettingsCandidates() {
+		if s, ok := readSettingsFile(p); ok {
+			if v := strings.TrimSpace(get(s)); v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+func readPackageManagerFromFile(path string) (string, bool) {
+	s, ok := readSettingsFile(path)
+	if !ok {
 		return "", false
 	}
 
@@ -174,6 +223,16 @@ func detectPackageManagerFromPackageJSON(cwd string) (string, bool) {
 }
 
 func ResolvePackageManagerExecutable(cwd string, defaultExe string) string {
+	if defaultExe == "" {
+		defaultExe = "npm"
+	}
+	// 0) A snapshot already resolved 1) and 2) for its root.
+	if s := currentSnapshot(); s != nil && filepath.Clean(cwd) == s.Root {
+		if s.PackageManager != "" {
+			return s.PackageManager
+		}
+		return defaultExe
+	}
 	// 1) VS Code settings take highest priority (explicit user preference).
 	if exe, ok := detectPackageManagerFromSettings(cwd); ok {
 		return exe
@@ -182,8 +241,5 @@ func ResolvePackageManagerExecutable(cwd string, defaultExe string) string {
 	if exe, ok := detectPackageManagerFromPackageJSON(cwd); ok {
 		return exe
 	}
-	if defaultExe == "" {
-		defaultExe = "npm"
-	}
 	return defaultExe
 }
@@ -0,0 +1,95 @@
+package tasks
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/chenasraf/vstask/utils"
+)
+
+// settingEnvVars are the environment variables that override a setting.
+var settingEnvVars = map[string]string{
+	"vstask.providers":     "VSTASK_PROVIDERS",
+	"vstask.defaultAction": "VSTASK_DEFAULT_ACTION",
+	"vstask.pinTasks":      "VSTASK_PIN_TASKS",
+	"vstask.maxParallel":   "VSTASK_JOBS",
+	"vstask.shellFallback": "VSTASK_SHELL_FALLBACK",
+	"vstask.windowsShell":  "VSTASK_WINDOWS_SHELL",
+}
+
+// SettingSource is one place a setting can come from.
+type SettingSource struct {
+	Source string          // settings.json path, or "$VAR" for an environment variable
+	Exists bool            // whether the file (or variable) exists
+	Value  json.RawMessage // the setting's value there; nil if it isn't set
+}
+
+// SettingChain returns every place vstask looks for the setting key, highest precedence first:
+// its environment variable (for the vstask settings that have one), root's .vscode/settings.json
+// (skipped when root is ""), then the user settings of each editor (see Editors). The first
+// source with a value wins, except for settings that merge all of them (see
+// MergedSetting).
+func SettingChain(root, key string) []SettingSource {
+	var chain []SettingSource
+	if name, ok := settingEnvVars[key]; ok {
+		src := SettingSource{Source: "$" + name}
+		if v, ok := os.LookupEnv(name); ok {
+			src.Exists = true
+			src.Value, _ = json.Marshal(v)
+		}
+		chain = append(chain, src)
+	}
+	var files []string
+	if root != "" {
+		files = append(files, workspaceSettingsPath(root))
+	}
+	for _, p := range append(files, userSettingsCandidates()...) {
+		src := SettingSource{Source: p}
+		if b, err := os.ReadFile(p); err == nil {
+			src.Exists = true
+			src.Value = lookupSetting(utils.ConvertJsoncToJson(b), key)
+		}
+		chain = append(chain, src)
+	}
+	return chain
+}
+
+// MergedSetting reports whether vstask merges key from every source instead of using the
+// first one that sets it.
+func MergedSetting(key string) bool {
+	return key == "vstask.taskTypes"
+}
+
+// KnownSettings returns the settings keys vstask reads.
+func KnownSettings() []string {
+	var keys []string
+	t := reflect.TypeFor[VSCodeSettings]()
+	for i := range t.NumField() {
+		if k, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ","); k != "" {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// lookupSetting finds key in a settings.json document, either as a flat key
+// ("npm.packageManager", as VS Code writes them) or as nested objects.
+func lookupSetting(data []byte, key string) json.RawMessage {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil
+	}
+	if v, ok := m[key]; ok {
+		return v
+	}
+	head, rest, ok := strings.Cut(key, ".")
+	if !ok {
+		return nil
+	}
+	if v, ok := m[head]; ok {
+		return lookupSetting(v, rest)
+	}
+	return nil
+}
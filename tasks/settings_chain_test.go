@@ -0,0 +1,55 @@
+package tasks
+
+import (
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestSettingChain(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("user settings layout is per OS")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	root := t.TempDir()
+	writeTestFile(t, workspaceSettingsPath(root), `{
+  // workspace
+  "npm.packageManager": "pnpm",
+}`)
+	writeTestFile(t, filepath.Join(home, ".config", "Code", "User", "settings.json"), `{"npm.packageManager": "yarn", "vstask": {"defaultAction": "last"}}`)
+	writeTestFile(t, filepath.Join(home, ".config", "VSCodium", "User", "settings.json"), `{}`)
+
+	chain := SettingChain(root, "npm.packageManager")
+	if len(chain) != 5 {
+		t.Fatalf("got %d sources: %+v", len(chain), chain)
+	}
+	for i, want := range []struct {
+		exists bool
+		value  string
+	}{{true, `"pnpm"`}, {true, `"yarn"`}, {false, ""}, {true, ""}, {false, ""}} {
+		if chain[i].Exists != want.exists || string(chain[i].Value) != want.value {
+			t.Errorf("source %d (%s) = %v %s, want %v %s", i, chain[i].Source, chain[i].Exists, chain[i].Value, want.exists, want.value)
+		}
+	}
+
+	t.Setenv("VSTASK_DEFAULT_ACTION", "build")
+	chain = SettingChain(root, "vstask.defaultAction")
+	if chain[0].Source != "$VSTASK_DEFAULT_ACTION" || string(chain[0].Value) != `"build"` {
+		t.Fatalf("env source = %+v", chain[0])
+	}
+	if string(chain[2].Value) != `"last"` {
+		t.Fatalf("nested user setting = %+v", chain[2])
+	}
+}
+
+func TestKnownSettings(t *testing.T) {
+	keys := KnownSettings()
+	for _, k := range []string{"npm.packageManager", "vstask.providers", "vstask.pinTasks"} {
+		if !slices.Contains(keys, k) {
+			t.Errorf("missing %s in %v", k, keys)
+		}
+	}
+}
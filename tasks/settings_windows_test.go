@@ -0,0 +1,27 @@
+//go:build windows
+
+package tasks
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestUserSettings_APPDATA(t *testing.T) {
+	appData := t.TempDir()
+	t.Setenv("APPDATA", appData)
+	t.Setenv("VSTASK_JOBS", "")
+
+	want := filepath.Join(appData, "Code", "User", "settings.json")
+	if got := userSettingsCandidates(); len(got) == 0 || got[0] != want {
+		t.Fatalf("userSettingsCandidates() = %q, want %q first", got, want)
+	}
+
+	writeTestFile(t, want, `{
+		// JSONC, as VS Code writes it
+		"vstask.maxParallel": 5,
+	}`)
+	if got := MaxParallel(t.TempDir()); got != 5 {
+		t.Fatalf("MaxParallel = %d, want 5 (from %%APPDATA%%)", got)
+	}
+}
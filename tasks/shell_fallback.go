@@ -0,0 +1,29 @@
+package tasks
+
+import (
+	"strings"
+)
+
+// ShellFallbackOff is the "vstask.shellFallback" value turning the fallback off.
+const ShellFallbackOff = "off"
+
+// ShellFallback returns the shell a bash that can't start (as on some locked-down systems) is
+// retried with in root: $VSTASK_SHELL_FALLBACK, then "vstask.shellFallback" from the workspace
+// and user settings, then /bin/sh. ok is false when it's "off": the task fails instead.
+func ShellFallback(root string) (shell string, ok bool) {
+	s := stringSetting(root, "VSTASK_SHELL_FALLBACK", func(s VSCodeSettings) string { return s.ShellFallback })
+	switch {
+	case s == "":
+		return "/bin/sh", true
+	case strings.EqualFold(s, ShellFallbackOff):
+		return "", false
+	}
+	return s, true
+}
+
+// WindowsShell returns the shell configured for shell tasks on Windows in root:
+// $VSTASK_WINDOWS_SHELL, then "vstask.windowsShell" from the workspace and user settings. It's
+// "cmd", "pwsh", "powershell" or a shell's path, or "" for the default.
+func WindowsShell(root string) string {
+	return stringSetting(root, "VSTASK_WINDOWS_SHELL", func(s VSCodeSettings) string { return s.WindowsShell })
+}
@@ -0,0 +1,25 @@
+package tasks
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestShellFallback_Precedence(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("VSTASK_SHELL_FALLBACK", "")
+	root := t.TempDir()
+
+	if sh, ok := ShellFallback(root); !ok || sh != "/bin/sh" {
+		t.Fatalf("default: got %q, %v; want /bin/sh", sh, ok)
+	}
+	writeTestFile(t, filepath.Join(root, ".vscode", "settings.json"), `{"vstask.shellFallback": "Off"}`)
+	if sh, ok := ShellFallback(root); ok {
+		t.Fatalf("settings off: got %q", sh)
+	}
+	t.Setenv("VSTASK_SHELL_FALLBACK", "/usr/local/bin/bash")
+	if sh, ok := ShellFallback(root); !ok || sh != "/usr/local/bin/bash" {
+		t.Fatalf("env: got %q, %v", sh, ok)
+	}
+}
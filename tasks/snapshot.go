@@ -0,0 +1,77 @@
+package tasks
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/chenasraf/vstask/utils"
+)
+
+// Snapshot is the parsed state of one workspace folder: everything vstask reads from disk to
+// list and run its tasks. The daemon keeps snapshots in memory and hands them to the CLI.
+type Snapshot struct {
+	Root           string            `json:"root"`
+	Tasks          []Task            `json:"tasks"`
+	Inputs         []Input           `json:"inputs,omitempty"`
+	PackageManager string            `json:"packageManager,omitempty"` // from settings/package.json at Root; empty if unset
+	TaskTypes      map[string]string `json:"taskTypes,omitempty"`      // see TypeCommands
+	Origins        []string          `json:"origins,omitempty"`        // Tasks[i].Origin, which JSON leaves out
+}
+
+// LoadSnapshot parses the workspace folder at root, including the tasks of enabled providers.
+func LoadSnapshot(root string) (*Snapshot, error) {
+	f, err := loadWorkspace(root)
+	if err != nil {
+		return nil, err
+	}
+	s := &Snapshot{Root: root, Tasks: f.Tasks, Inputs: f.Inputs}
+	if s.Inputs == nil {
+		s.Inputs = []Input{}
+	}
+	if slices.ContainsFunc(f.Tasks, func(t Task) bool { return t.Origin != "" }) {
+		s.Origins = make([]string, len(f.Tasks))
+		for i, t := range f.Tasks {
+			s.Origins[i] = t.Origin
+		}
+	}
+	if exe, ok := detectPackageManagerFromSettings(root); ok {
+		s.PackageManager = exe
+	} else if exe, ok := detectPackageManagerFromPackageJSON(root); ok {
+		s.PackageManager = exe
+	}
+	s.TaskTypes = TypeCommands(root)
+	return s, nil
+}
+
+var (
+	snapshotMu sync.RWMutex
+	snapshot   *Snapshot
+)
+
+// UseSnapshot makes ProjectRoot, GetTasks, GetInputs and ResolvePackageManagerExecutable answer
+// from s instead of reading the disk (nil restores disk reads).
+func UseSnapshot(s *Snapshot) {
+	if s != nil && len(s.Origins) == len(s.Tasks) {
+		for i := range s.Tasks {
+			s.Tasks[i].Origin = s.Origins[i]
+		}
+	}
+	snapshotMu.Lock()
+	defer snapshotMu.Unlock()
+	snapshot = s
+}
+
+func currentSnapshot() *Snapshot {
+	snapshotMu.RLock()
+	defer snapshotMu.RUnlock()
+	return snapshot
+}
+
+// ProjectRoot returns the current workspace folder: the snapshot's root if one is in use,
+// else the nearest parent of the cwd containing .vscode.
+func ProjectRoot() (string, error) {
+	if s := currentSnapshot(); s != nil {
+		return s.Root, nil
+	}
+	return utils.FindProjectRoot()
+}
@@ -0,0 +1,67 @@
+package tasks
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshot_ServesTasksInputsAndPackageManager(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".vscode"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".vscode", "tasks.json"), []byte(`{"tasks": [{"label": "a"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "package.json"), []byte(`{"packageManager": "pnpm@9.0.0"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	s, err := LoadSnapshot(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.PackageManager != "pnpm" || len(s.Tasks) != 1 || s.Inputs == nil {
+		t.Fatalf("unexpected snapshot: %+v", s)
+	}
+
+	// Once in use, the snapshot answers even after the files change.
+	UseSnapshot(s)
+	t.Cleanup(func() { UseSnapshot(nil) })
+	if err := os.Remove(filepath.Join(root, "package.json")); err != nil {
+		t.Fatal(err)
+	}
+	if got := ResolvePackageManagerExecutable(root, "npm"); got != "pnpm" {
+		t.Fatalf("package manager: got %q", got)
+	}
+	if r, err := ProjectRoot(); err != nil || r != root {
+		t.Fatalf("root: got %q, %v", r, err)
+	}
+	if ts, err := GetTasks(); err != nil || len(ts) != 1 {
+		t.Fatalf("tasks: got %+v, %v", ts, err)
+	}
+}
+
+func TestSnapshot_KeepsOriginsOverJSON(t *testing.T) {
+	s := &Snapshot{Root: "/ws", Tasks: []Task{{Label: "build"}, {Label: "up", Origin: OriginWorkspace}}, Origins: []string{"", OriginWorkspace}}
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Snapshot
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Tasks[1].Origin != "" {
+		t.Fatal("Task.Origin isn't JSON")
+	}
+	UseSnapshot(&got)
+	t.Cleanup(func() { UseSnapshot(nil) })
+	if ts, err := GetTasks(); err != nil || len(ts) != 2 || ts[0].Origin != "" || ts[1].Origin != OriginWorkspace {
+		t.Fatalf("tasks: got %+v, %v", ts, err)
+	}
+}
@@ -1,6 +1,7 @@
 package tasks
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"regexp"
@@ -9,9 +10,10 @@ import (
 
 // File is the root of .vscode/tasks.json
 type File struct {
-	Version string  `json:"version,omitempty"`
-	Tasks   []Task  `json:"tasks,omitempty"`
-	Inputs  []Input `json:"inputs,omitempty"` // VS Code "inputs" array
+	Version string   `json:"version,omitempty"`
+	Tasks   []Task   `json:"tasks,omitempty"`
+	Inputs  []Input  `json:"inputs,omitempty"`  // VS Code "inputs" array
+	Include []string `json:"include,omitempty"` // vstask: tasks files (paths or URLs) whose tasks and inputs are added
 }
 
 // -------------------------
@@ -23,18 +25,83 @@ type File struct {
 // - pickString:   { "id", "type":"pickString",  "description"?, "options":[...], "default"? }
 // - command:      { "id", "type":"command",     "command":"...", "args"?: any, "description"?, "default"? }
 //
+// vstask extensions (ignored by VS Code):
+// - pickMany:  an input type like pickString, but the user picks any number of the options
+// - separator: pickMany only; joins the picked values ("," by default), also in its default
+// - secret:    an input type like a password promptString, whose value is kept in the OS keychain
+// - timeout:   prompt timeout, e.g. "30s" or 30 (seconds)
+// - onTimeout: "default" (use the default value, the default behavior) | "fail"
+// - commandTimeout: command only; bounds the command (30s by default)
+// - onError:   command only; "fail" (abort the run, the default) | "fallback" (use the default, or prompt)
+// - pattern:   promptString only; a regexp the value must match (the prompt re-asks until it does)
+// - cwd, env:  command only; where and with which extra environment the command runs
+// - jsonPath:  command only; parse the output as JSON and select a value, e.g. "items[0].name"
+// - cache:     command only; "run" (default) | "session" | "ttl:5m" — persisted in the workspace state dir
+//
 // Note: We keep a superset struct; unused fields simply stay zero.
 type Input struct {
-	ID          string   `json:"id,omitempty"`
-	Type        string   `json:"type,omitempty"`        // "promptString" | "pickString" | "command"
-	Description string   `json:"description,omitempty"` // shown to the user
-	Default     string   `json:"default,omitempty"`     // default value if user just presses Enter
-	Password    bool     `json:"password,omitempty"`    // promptString only
-	Options     []string `json:"options,omitempty"`     // pickString only
+	ID          string       `json:"id,omitempty"`
+	Type        string       `json:"type,omitempty"`        // "promptString" | "pickString" | "command" | "pickMany" | "secret"
+	Description string       `json:"description,omitempty"` // shown to the user
+	Default     string       `json:"default,omitempty"`     // default value if user just presses Enter
+	Password    bool         `json:"password,omitempty"`    // promptString only
+	Options     []PickOption `json:"options,omitempty"`     // pickString and pickMany
 
 	// Command input
 	Command string          `json:"command,omitempty"` // command to run; we use its stdout as value
-	Args    json.RawMessage `json:"args,omitempty"`    // optional args payload (not used by runner yet)
+	Args    json.RawMessage `json:"args,omitempty"`    // array → arguments appended to the command; object → JSON on its stdin
+
+	// vstask extensions
+	Pattern        string            `json:"pattern,omitempty"`        // promptString only; a regexp the value must match
+	Separator      string            `json:"separator,omitempty"`      // pickMany only; joins the picked values (default ",")
+	Timeout        Duration          `json:"timeout,omitempty"`        // give up waiting for the user after this long
+	OnTimeout      string            `json:"onTimeout,omitempty"`      // "default" | "fail"
+	CommandTimeout Duration          `json:"commandTimeout,omitempty"` // command only; give up on the command after this long
+	OnError        string            `json:"onError,omitempty"`        // command only; "fail" | "fallback"
+	Cwd            string            `json:"cwd,omitempty"`            // command only; defaults to the workspace folder
+	Env            map[string]string `json:"env,omitempty"`            // command only; merged over the process env
+	JSONPath       string            `json:"jsonPath,omitempty"`       // command only; selector into JSON output
+	Cache          string            `json:"cache,omitempty"`          // command only; "run" | "session" | "ttl:<duration>"
+}
+
+// PickOption is one of a pickString (or pickMany) input's options: a string, or { "label", "value" } to show
+// the label in the picker and substitute the value.
+type PickOption struct {
+	Label string `json:"label,omitempty"`
+	Value string `json:"value"`
+}
+
+func (o *PickOption) UnmarshalJSON(b []byte) error {
+	var s string
+	if jsonKind(b) == '"' && json.Unmarshal(b, &s) == nil {
+		*o = PickOption{Value: s}
+		return nil
+	}
+	var obj struct {
+		Label string  `json:"label"`
+		Value *string `json:"value"`
+	}
+	if err := json.Unmarshal(b, &obj); err != nil || obj.Value == nil {
+		return fmt.Errorf("options: invalid entry %s", string(b))
+	}
+	*o = PickOption{Label: obj.Label, Value: *obj.Value}
+	return nil
+}
+
+func (o PickOption) MarshalJSON() ([]byte, error) {
+	if o.Label == "" {
+		return json.Marshal(o.Value)
+	}
+	type plain PickOption
+	return json.Marshal(plain(o))
+}
+
+// Display is what the picker shows for the option: its label, or else its value.
+func (o PickOption) Display() string {
+	if o.Label != "" {
+		return o.Label
+	}
+	return o.Value
 }
 
 // DescriptionOrFallback returns a non-empty label for prompting.
@@ -67,7 +134,7 @@ type Task struct {
 	IsBackground bool          `json:"isBackground,omitempty"`
 
 	// Dependencies & grouping
-	DependsOn    *DependsOn `json:"dependsOn,omitempty"`    // string | string[] | { tasks: string[] }
+	DependsOn    *DependsOn `json:"dependsOn,omitempty"`    // string | string[] | { tasks: string[] }; entries may be TaskRef objects
 	DependsOrder string     `json:"dependsOrder,omitempty"` // "sequence" | "parallel"
 	Group        *Group     `json:"group,omitempty"`        // "build" | "test" | { "kind": "...", "isDefault": bool }
 
@@ -76,6 +143,71 @@ type Task struct {
 
 	// Misc
 	Detail string `json:"detail,omitempty"` // shown in the UI
+
+	// vstask extensions (ignored by VS Code)
+	Exports    *Exports `json:"exports,omitempty"`    // env vars handed to the tasks that depend on this one
+	Sandbox    *Sandbox `json:"sandbox,omitempty"`    // true | { "network", "writable" }
+	Deprecated string   `json:"deprecated,omitempty"` // notice shown when the task runs, e.g. "use build:fast instead"
+	ReplacedBy string   `json:"replacedBy,omitempty"` // label of the task to offer running instead
+
+	HealthCheck *HealthCheck `json:"healthCheck,omitempty"` // background tasks: restart when unhealthy
+
+	ForwardSlashes bool `json:"forwardSlashes,omitempty"` // path variables use "/" on Windows too
+
+	Extensions *Extensions `json:"x-vstask,omitempty"` // vstask settings kept apart from VS Code's fields
+
+	// Origin is where vstask found the task, when that isn't the folder's tasks.json or a
+	// provider: OriginWorkspace or OriginInclude. Set by vstask, not read from tasks.json.
+	Origin string `json:"-"`
+}
+
+// OriginWorkspace marks tasks defined in the "tasks" section of a .code-workspace file.
+const OriginWorkspace = "workspace"
+
+// Exports declares environment variables a task produces for its dependents (vstask extension):
+//
+//	"exports": { "vars": { "API_URL": "^api_url = \"(.*)\"$" }, "file": "${workspaceFolder}/.deploy.env" }
+//
+// Each vars entry is a regex matched against the task's output lines; the last matching line
+// wins and its first capture group (or the whole match) is the value. File names a dotenv file
+// the task writes (relative to its cwd), read once it finishes. Vars take precedence over File.
+//
+// A background task's dependents start before it finishes, so it exports nothing, unless Ready
+// is set: then the capture groups of the line its problem matcher's beginsPattern matched (the
+// line that made it ready) are exported as VSTASK_READY_<LABEL>_<GROUP>, where GROUP is the
+// group's name or number, e.g. VSTASK_READY_DEV_SERVER_PORT.
+type Exports struct {
+	Vars  map[string]string `json:"vars,omitempty"`  // NAME → regex over the output
+	File  string            `json:"file,omitempty"`  // dotenv file written by the task
+	Ready bool              `json:"ready,omitempty"` // background tasks: export the readiness line's groups
+}
+
+// Extensions are vstask settings of a task grouped under "x-vstask" (vstask extension):
+//
+//	"x-vstask": { "watch": ["src/**/*.go", "go.mod"] }
+//
+// Watch are the files `vstask watch` reruns the task on, as globs relative to the workspace
+// folder: "*" matches within a path segment and "**" any number of segments.
+type Extensions struct {
+	Watch []string `json:"watch,omitempty"`
+}
+
+// HealthCheck keeps a background dependency healthy once it's ready (vstask extension):
+//
+//	"healthCheck": { "port": 5173, "interval": "10s", "retries": 3 }
+//
+// Every Interval (default 10s) the configured checks run: Port must accept TCP connections on
+// localhost, Command (run through the default shell, in the task's cwd) must exit 0, and no
+// output line since the last check may match UnhealthyPattern. The task exiting is a failure
+// too. After Retries (default 3) failures in a row, the task is restarted, at most MaxRestarts
+// (default 5) times.
+type HealthCheck struct {
+	Port             int      `json:"port,omitempty"`
+	Command          string   `json:"command,omitempty"`
+	UnhealthyPattern string   `json:"unhealthyPattern,omitempty"`
+	Interval         Duration `json:"interval,omitempty"`
+	Retries          int      `json:"retries,omitempty"`
+	MaxRestarts      int      `json:"maxRestarts,omitempty"`
 }
 
 // PlatformTask allows overriding per-OS parts of the task.
@@ -91,6 +223,9 @@ type Options struct {
 	Cwd   string            `json:"cwd,omitempty"`
 	Env   map[string]string `json:"env,omitempty"`
 	Shell *ShellOptions     `json:"shell,omitempty"`
+	// Path lists directories to put in front of PATH for this task (vstask extension), e.g.
+	// ["./bin", "${workspaceFolder}/tools"]. Relative entries are relative to the task's cwd.
+	Path []string `json:"path,omitempty"`
 	// Windows/Osx/Linux sub-options also exist - TODO add if needed
 }
 
@@ -112,6 +247,58 @@ type Presentation struct {
 	// "RevealProblems": "onProblem"|"onProblemDependingOnSeverity" may exist in newer versions
 }
 
+// -------------------------
+// Sandbox (bool | object)
+// -------------------------
+
+// Sandbox runs a task with restricted filesystem and network access (vstask extension), using
+// bwrap on Linux and sandbox-exec on macOS. The filesystem is read-only except for the task's
+// workspace folder, the run's scratch directory, a private /tmp and the Writable paths;
+// network access is denied unless Network is set.
+//
+//	"sandbox": true
+//	"sandbox": { "network": true, "writable": ["${userHome}/.cache/go-build"] }
+type Sandbox struct {
+	Enabled  bool     `json:"-"`
+	Network  bool     `json:"network,omitempty"`
+	Writable []string `json:"writable,omitempty"` // relative paths are resolved against the task's cwd
+}
+
+func (s *Sandbox) UnmarshalJSON(b []byte) error {
+	var on bool
+	if err := json.Unmarshal(b, &on); err == nil {
+		*s = Sandbox{Enabled: on}
+		return nil
+	}
+	type alias Sandbox
+	var obj alias
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return fmt.Errorf("sandbox: invalid value %s", string(b))
+	}
+	*s = Sandbox(obj)
+	s.Enabled = true
+	return nil
+}
+
+func (s Sandbox) MarshalJSON() ([]byte, error) {
+	if !s.Enabled || (!s.Network && len(s.Writable) == 0) {
+		return json.Marshal(s.Enabled)
+	}
+	type alias Sandbox
+	return json.Marshal(alias(s))
+}
+
+// jsonKind returns the first byte of the JSON value b, which tells its kind: '"' for a string,
+// '[' for an array, and so on. Checking it first spares the unmarshalers of values that can take
+// several forms a failed decode per form, which adds up in a tasks.json with thousands of tasks.
+func jsonKind(b []byte) byte {
+	b = bytes.TrimLeft(b, " \t\r\n")
+	if len(b) == 0 {
+		return 0
+	}
+	return b[0]
+}
+
 // -------------------------
 // Group (string | object)
 // -------------------------
@@ -124,7 +311,7 @@ type Group struct {
 func (g *Group) UnmarshalJSON(b []byte) error {
 	// Try simple string: "build"
 	var s string
-	if err := json.Unmarshal(b, &s); err == nil {
+	if jsonKind(b) == '"' && json.Unmarshal(b, &s) == nil {
 		*g = Group{Kind: s}
 		return nil
 	}
@@ -150,8 +337,10 @@ func (g Group) MarshalJSON() ([]byte, error) {
 // DependsOn (string | string[] | {tasks})
 // -----------------------------------------
 
+// DependsOn lists the tasks a task depends on. Entries may be plain labels or
+// TaskRef objects (see TaskRef.UnmarshalJSON).
 type DependsOn struct {
-	Tasks []string
+	Tasks []TaskRef
 }
 
 func (d *DependsOn) UnmarshalJSON(b []byte) error {
@@ -159,23 +348,25 @@ func (d *DependsOn) UnmarshalJSON(b []byte) error {
 		*d = DependsOn{}
 		return nil
 	}
-	// string
-	var s string
-	if err := json.Unmarshal(b, &s); err == nil {
-		if s != "" {
-			d.Tasks = []string{s}
+	// string | object entry
+	var one TaskRef
+	if jsonKind(b) != '[' {
+		if err := json.Unmarshal(b, &one); err == nil {
+			if one.Label != "" {
+				d.Tasks = []TaskRef{one}
+			}
+			return nil
 		}
-		return nil
 	}
-	// []string
-	var ss []string
-	if err := json.Unmarshal(b, &ss); err == nil {
-		d.Tasks = ss
+	// [](string | object)
+	var refs []TaskRef
+	if err := json.Unmarshal(b, &refs); err == nil {
+		d.Tasks = refs
 		return nil
 	}
-	// { "tasks": []string }
+	// { "tasks": [](string | object) }
 	var obj struct {
-		Tasks []string `json:"tasks"`
+		Tasks []TaskRef `json:"tasks"`
 	}
 	if err := json.Unmarshal(b, &obj); err == nil && obj.Tasks != nil {
 		d.Tasks = obj.Tasks
@@ -195,6 +386,74 @@ func (d DependsOn) MarshalJSON() ([]byte, error) {
 	}
 }
 
+// Labels returns the display form of every entry (see TaskRef.String), optional ones marked so.
+func (d DependsOn) Labels() []string {
+	out := make([]string, len(d.Tasks))
+	for i, r := range d.Tasks {
+		out[i] = r.String()
+		if r.Optional {
+			out[i] += " (optional)"
+		}
+	}
+	return out
+}
+
+// TaskRef is a single dependsOn entry: a task label, optionally qualified by the
+// multi-root workspace folder that defines it.
+//
+// Accepted forms:
+//   - "build"                              → a task in the same folder
+//   - "api: build"                         → if no task is labeled "api: build", task "build" in folder "api"
+//   - { "label": "build", "folder": "api" } → task "build" in folder "api" (vstask extension)
+//   - { "label": "lint", "optional": true } → a dependency whose failure is reported but doesn't
+//     stop the task depending on it (vstask extension)
+type TaskRef struct {
+	Label    string `json:"label,omitempty"`
+	Folder   string `json:"folder,omitempty"`
+	Optional bool   `json:"optional,omitempty"`
+}
+
+func (r *TaskRef) UnmarshalJSON(b []byte) error {
+	var s string
+	if jsonKind(b) == '"' && json.Unmarshal(b, &s) == nil {
+		*r = TaskRef{Label: s}
+		return nil
+	}
+	var obj struct {
+		Label    string `json:"label"`
+		Task     string `json:"task"`
+		Folder   string `json:"folder"`
+		Optional bool   `json:"optional"`
+	}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return fmt.Errorf("dependsOn: invalid entry %s", string(b))
+	}
+	if obj.Label == "" {
+		obj.Label = obj.Task
+	}
+	if obj.Label == "" {
+		return fmt.Errorf("dependsOn: entry without label %s", string(b))
+	}
+	*r = TaskRef{Label: obj.Label, Folder: obj.Folder, Optional: obj.Optional}
+	return nil
+}
+
+func (r TaskRef) MarshalJSON() ([]byte, error) {
+	if r.Folder == "" && !r.Optional {
+		return json.Marshal(r.Label)
+	}
+	type alias TaskRef
+	return json.Marshal(alias(r))
+}
+
+// String returns "folder: label" for folder-qualified refs, or just the label.
+func (r TaskRef) String() string {
+	if r.Folder != "" {
+		return r.Folder + ": " + r.Label
+	}
+	return r.Label
+}
+
 // -------------------------------------------------------
 // ProblemMatcher (string | string[] | object | object[])
 // -------------------------------------------------------
@@ -213,7 +472,7 @@ func (pm *ProblemMatcher) UnmarshalJSON(b []byte) error {
 
 	// Try as array (strings or objects)
 	var arr []json.RawMessage
-	if err := json.Unmarshal(b, &arr); err == nil {
+	if jsonKind(b) == '[' && json.Unmarshal(b, &arr) == nil {
 		pm.Elems = arr
 		return nil
 	}
@@ -272,66 +531,85 @@ type ProblemMatcherBackground struct {
 	EndsPattern   string `json:"endsPattern,omitempty"`
 }
 
+// ProblemMatcherPattern is one line of a problem matcher's pattern: a regexp, and which of its
+// groups hold what (groups count from 1; 0 means none, or for message the whole match).
 type ProblemMatcherPattern struct {
-	// We don't need to model full pattern structure for readiness,
-	// but keep a placeholder so future extensions can unmarshal cleanly.
-	Regexp string `json:"regexp,omitempty"`
+	Regexp    string `json:"regexp,omitempty"`
+	Kind      string `json:"kind,omitempty"`     // "location" (default) | "file": the problem is about the whole file
+	File      int    `json:"file,omitempty"`     // group of the file name
+	Location  int    `json:"location,omitempty"` // group of "line", "line,column" or "line,column,endLine,endColumn"
+	Line      int    `json:"line,omitempty"`     // groups of the location's parts, when not in one group
+	Column    int    `json:"column,omitempty"`
+	EndLine   int    `json:"endLine,omitempty"`
+	EndColumn int    `json:"endColumn,omitempty"`
+	Severity  int    `json:"severity,omitempty"` // group of "error", "warning", "info", ...
+	Code      int    `json:"code,omitempty"`     // group of the problem's code, e.g. TS2322
+	Message   int    `json:"message,omitempty"`  // group of the message
+	Loop      bool   `json:"loop,omitempty"`     // last of several patterns: matches each following line, one problem each
+}
+
+// ProblemMatcherPatterns is a matcher's pattern: one (an object) or several, matching
+// consecutive lines (an array), or the name of a built-in one (e.g., "$gcc"; an unknown name
+// leaves it empty).
+type ProblemMatcherPatterns []ProblemMatcherPattern
+
+func (p *ProblemMatcherPatterns) UnmarshalJSON(b []byte) error {
+	switch jsonKind(b) {
+	case 'n':
+		*p = nil
+		return nil
+	case '"':
+		var name string
+		if err := json.Unmarshal(b, &name); err != nil {
+			return err
+		}
+		*p = append(ProblemMatcherPatterns(nil), builtinPatterns()[strings.TrimSpace(name)]...)
+		return nil
+	case '[':
+		var arr []ProblemMatcherPattern
+		if err := json.Unmarshal(b, &arr); err != nil {
+			return err
+		}
+		*p = arr
+		return nil
+	}
+	var one ProblemMatcherPattern
+	if err := json.Unmarshal(b, &one); err != nil {
+		return err
+	}
+	*p = ProblemMatcherPatterns{one}
+	return nil
 }
 
 type ProblemMatcherObject struct {
+	Base         string                    `json:"base,omitempty"` // a built-in matcher this one extends (e.g., "$tsc")
 	Owner        string                    `json:"owner,omitempty"`
 	Source       string                    `json:"source,omitempty"`
-	FileLocation any                       `json:"fileLocation,omitempty"`
-	Pattern      any                       `json:"pattern,omitempty"`    // can be object or array; not used for readiness
-	Background   *ProblemMatcherBackground `json:"background,omitempty"` // what we need for readiness gating
-	Severity     string                    `json:"severity,omitempty"`
+	FileLocation any                       `json:"fileLocation,omitempty"` // "absolute" | "relative" | "autoDetect", or ["relative", "<dir>"]
+	Pattern      ProblemMatcherPatterns    `json:"pattern,omitempty"`      // what output lines are problems
+	Background   *ProblemMatcherBackground `json:"background,omitempty"`   // what we need for readiness gating
+	Severity     string                    `json:"severity,omitempty"`     // of problems whose pattern doesn't capture one
 }
 
-// FirstBackground returns the first background config found among object matchers,
-// or resolves a known string alias (e.g., "$tsc-watch") into a background config.
-// Returns nil if no usable background is present.
+// FirstBackground returns the first usable background config among pm's matchers (named ones
+// like "$tsc-watch" included; see Matchers), or nil if there's none.
 func (pm ProblemMatcher) FirstBackground() *ProblemMatcherBackground {
-	// 1) Object matchers with background
-	for _, raw := range pm.Objects() {
-		var obj ProblemMatcherObject
-		if err := json.Unmarshal(raw, &obj); err == nil && obj.Background != nil {
-			bg := *obj.Background // copy to avoid aliasing
-			// Normalize empty strings to zero values
-			bg.BeginsPattern = strings.TrimSpace(bg.BeginsPattern)
-			bg.EndsPattern = strings.TrimSpace(bg.EndsPattern)
-			if bg.ActiveOnStart || bg.BeginsPattern != "" {
-				return &bg
-			}
+	matchers, _ := pm.Matchers() // a broken matcher has no background to use
+	for _, m := range matchers {
+		if m.Background == nil {
+			continue
 		}
-	}
-
-	// 2) String aliases → known backgrounds (minimal set; extend as needed)
-	for _, s := range pm.Strings() {
-		s = strings.TrimSpace(s)
-		if bg, ok := builtinBackgroundByAlias[s]; ok {
-			// return a copy to avoid external mutation
-			cp := bg
-			return &cp
+		bg := *m.Background // copy to avoid aliasing
+		// Normalize empty strings to zero values
+		bg.BeginsPattern = strings.TrimSpace(bg.BeginsPattern)
+		bg.EndsPattern = strings.TrimSpace(bg.EndsPattern)
+		if bg.ActiveOnStart || bg.BeginsPattern != "" {
+			return &bg
 		}
 	}
-
 	return nil
 }
 
-// Minimal built-in alias → background mapping for readiness-only purposes.
-var builtinBackgroundByAlias = map[string]ProblemMatcherBackground{
-	// TypeScript watch: tsc -w
-	// Common lines seen:
-	// - "Starting compilation in watch mode..."
-	// - "Found 0 errors. Watching for file changes."
-	// - "Found X errors. Watching for file changes."
-	"$tsc-watch": {
-		ActiveOnStart: false,
-		BeginsPattern: `(?i)\bwatch(ing)? for file changes\b|^Starting compilation in watch mode`,
-		EndsPattern:   ``, // not required for readiness
-	},
-}
-
 // BgMatcher is used by the runner to hold compiled regexes.
 type BgMatcher struct {
 	ActiveOnStart bool
@@ -349,6 +627,17 @@ type RunOptions struct {
 	InstanceLimit   int    `json:"instanceLimit,omitempty"`   // max parallel instances
 }
 
+// RunsOnFolderOpen reports whether VS Code runs t when the folder opens ("runOn": "folderOpen").
+func (t Task) RunsOnFolderOpen() bool {
+	return t.RunOptions != nil && strings.EqualFold(t.RunOptions.RunOn, "folderOpen")
+}
+
+// IsCompound reports whether t only runs its dependencies, like VS Code's compound tasks: it
+// has a dependsOn but no type and, on this platform, no command.
+func (t Task) IsCompound() bool {
+	return t.DependsOn != nil && len(t.DependsOn.Tasks) > 0 && strings.TrimSpace(t.Type) == "" && commandPreview(t) == ""
+}
+
 func (t Task) IsEmpty() bool {
 	return t.Label == "" && t.Command == ""
 }
@@ -0,0 +1,252 @@
+package tasks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// TaskChange is a task that differs between two versions of a task list.
+type TaskChange struct {
+	Label string
+	Old   *Task // nil if the task was added
+	New   *Task // nil if the task was removed
+}
+
+// Kind is "added", "removed" or "modified".
+func (c TaskChange) Kind() string {
+	switch {
+	case c.Old == nil:
+		return "added"
+	case c.New == nil:
+		return "removed"
+	default:
+		return "modified"
+	}
+}
+
+// DiffTasks compares two task lists by label: added and modified tasks in new's order,
+// then removed ones in old's order.
+func DiffTasks(old, new []Task) []TaskChange {
+	byLabel := make(map[string]*Task, len(old))
+	for i := range old {
+		byLabel[old[i].Label] = &old[i]
+	}
+	seen := map[string]bool{}
+	var changes []TaskChange
+	for i := range new {
+		n := &new[i]
+		seen[n.Label] = true
+		o, ok := byLabel[n.Label]
+		switch {
+		case !ok:
+			changes = append(changes, TaskChange{Label: n.Label, New: n})
+		case !sameTask(*o, *n):
+			changes = append(changes, TaskChange{Label: n.Label, Old: o, New: n})
+		}
+	}
+	for i := range old {
+		if !seen[old[i].Label] {
+			changes = append(changes, TaskChange{Label: old[i].Label, Old: &old[i]})
+		}
+	}
+	return changes
+}
+
+// InputChange is an input that differs between two versions of an input list.
+type InputChange struct {
+	ID  string
+	Old *Input // nil if the input was added
+	New *Input // nil if the input was removed
+}
+
+// Kind is "added", "removed" or "modified".
+func (c InputChange) Kind() string {
+	switch {
+	case c.Old == nil:
+		return "added"
+	case c.New == nil:
+		return "removed"
+	default:
+		return "modified"
+	}
+}
+
+// DiffInputs compares two input lists by id, like DiffTasks.
+func DiffInputs(old, new []Input) []InputChange {
+	byID := make(map[string]*Input, len(old))
+	for i := range old {
+		byID[old[i].ID] = &old[i]
+	}
+	seen := map[string]bool{}
+	var changes []InputChange
+	for i := range new {
+		n := &new[i]
+		seen[n.ID] = true
+		o, ok := byID[n.ID]
+		switch {
+		case !ok:
+			changes = append(changes, InputChange{ID: n.ID, New: n})
+		case !sameJSON(*o, *n):
+			changes = append(changes, InputChange{ID: n.ID, Old: o, New: n})
+		}
+	}
+	for i := range old {
+		if !seen[old[i].ID] {
+			changes = append(changes, InputChange{ID: old[i].ID, Old: &old[i]})
+		}
+	}
+	return changes
+}
+
+func sameTask(a, b Task) bool {
+	return sameJSON(a, b)
+}
+
+func sameJSON(a, b any) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return string(ja) == string(jb)
+}
+
+// ANSI colors for WriteTaskDiff.
+const (
+	diffBold  = "\x1b[1m"
+	diffRed   = "\x1b[31m"
+	diffGreen = "\x1b[32m"
+	diffReset = "\x1b[0m"
+)
+
+// WriteTaskDiff prints changes as a unified-style diff of each task's JSON: added tasks are
+// all "+" lines, removed ones all "-" lines, and modified ones show only the lines that changed
+// (with a line of context around them). With color, headers are bold, "+" lines green and
+// "-" lines red.
+func WriteTaskDiff(w io.Writer, changes []TaskChange, color bool) {
+	for _, c := range changes {
+		writeChange(w, fmt.Sprintf("%s (%s)", c.Label, c.Kind()), jsonLines(c.Old), jsonLines(c.New), color)
+	}
+}
+
+// WriteInputDiff prints input changes like WriteTaskDiff, each headed "input <id>".
+func WriteInputDiff(w io.Writer, changes []InputChange, color bool) {
+	for _, c := range changes {
+		writeChange(w, fmt.Sprintf("input %s (%s)", c.ID, c.Kind()), jsonLines(c.Old), jsonLines(c.New), color)
+	}
+}
+
+// WriteSettingDiff writes changes like WriteTaskDiff.
+func WriteSettingDiff(w io.Writer, changes []SettingChange, color bool) {
+	for _, c := range changes {
+		writeChange(w, fmt.Sprintf("setting %s (%s)", c.Name, c.Kind()), rawLines(c.Old), rawLines(c.New), color)
+	}
+}
+
+func writeChange(w io.Writer, header string, old, new []string, color bool) {
+	paint := func(code, s string) string {
+		if !color {
+			return s
+		}
+		return code + s + diffReset
+	}
+	fmt.Fprintln(w, paint(diffBold, header))
+	for _, l := range diffLines(old, new) {
+		line := fmt.Sprintf("  %c %s", l.op, l.text)
+		switch l.op {
+		case '+':
+			line = paint(diffGreen, line)
+		case '-':
+			line = paint(diffRed, line)
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// jsonLines is v (a *Task or *Input) as indented JSON lines, or nil for a nil pointer.
+func jsonLines[T any](v *T) []string {
+	if v == nil {
+		return nil
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil
+	}
+	return strings.Split(string(b), "\n")
+}
+
+// diffLine is one line of a diff: op is '+', '-' or ' ' (context).
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// diffLines is a line diff of a and b (longest common subsequence), keeping one line of
+// context around changes.
+func diffLines(a, b []string) []diffLine {
+	// lcs[i][j] = LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var all []diffLine
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			all = append(all, diffLine{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			all = append(all, diffLine{'-', a[i]})
+			i++
+		default:
+			all = append(all, diffLine{'+', b[j]})
+			j++
+		}
+	}
+
+	// Trim unchanged lines that aren't next to a change; "..." marks the gaps.
+	var out []diffLine
+	last := -1
+	for k, l := range all {
+		if l.op != ' ' ||
+			(k > 0 && all[k-1].op != ' ') ||
+			(k+1 < len(all) && all[k+1].op != ' ') {
+			if k > last+1 {
+				out = append(out, diffLine{' ', "..."})
+			}
+			out = append(out, l)
+			last = k
+		}
+	}
+	if last >= 0 && last < len(all)-1 {
+		out = append(out, diffLine{' ', "..."})
+	}
+	return out
+}
+
+// rawLines is b as indented JSON lines, or nil for nil.
+func rawLines(b json.RawMessage) []string {
+	if b == nil {
+		return nil
+	}
+	var out bytes.Buffer
+	if json.Indent(&out, b, "", "  ") != nil {
+		return []string{string(b)}
+	}
+	return strings.Split(out.String(), "\n")
+}
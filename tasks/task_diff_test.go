@@ -0,0 +1,49 @@
+package tasks
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	a := []string{"{", `"label": "build",`, `"command": "make",`, `"args": [],`, `"detail": "x"`, "}"}
+	b := []string{"{", `"label": "build",`, `"command": "make all",`, `"args": [],`, `"detail": "x"`, "}"}
+	var got []string
+	for _, l := range diffLines(a, b) {
+		got = append(got, string(l.op)+l.text)
+	}
+	want := []string{" ...", ` "label": "build",`, `-"command": "make",`, `+"command": "make all",`, ` "args": [],`, " ..."}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestWriteTaskDiff(t *testing.T) {
+	old := []Task{{Label: "build", Command: "make"}, {Label: "gone", Command: "true"}}
+	cur := []Task{{Label: "build", Command: "make all"}, {Label: "new", Command: "echo"}}
+	var buf bytes.Buffer
+	WriteTaskDiff(&buf, DiffTasks(old, cur), false)
+	out := buf.String()
+	for _, want := range []string{
+		"build (modified)\n",
+		"  -   \"command\": \"make\"\n  +   \"command\": \"make all\"\n",
+		"new (added)\n  + {\n  +   \"label\": \"new\",",
+		"gone (removed)\n",
+		`  -   "command": "true"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("diff lacks %q:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	WriteTaskDiff(&buf, DiffTasks(old, cur)[:1], true)
+	if !strings.Contains(buf.String(), diffBold+"build (modified)"+diffReset) ||
+		!strings.Contains(buf.String(), diffGreen+`  +   "command": "make all"`+diffReset) {
+		t.Errorf("colored diff:\n%q", buf.String())
+	}
+	if DiffTasks(old, old) != nil {
+		t.Error("identical lists have no changes")
+	}
+}
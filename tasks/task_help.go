@@ -0,0 +1,209 @@
+package tasks
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+var reInputRef = regexp.MustCompile(`\$\{input:([^}]+)\}`)
+
+// InputRefs returns the sorted, de-duplicated ids of all ${input:*} references
+// in the task's command, script, args, cwd, env, path and shell options (after platform
+// overrides, if the caller applied them).
+func (t Task) InputRefs() []string {
+	seen := make(map[string]struct{})
+
+	grab := func(s string) {
+		for _, m := range reInputRef.FindAllStringSubmatch(s, -1) {
+			if len(m) == 2 {
+				seen[m[1]] = struct{}{}
+			}
+		}
+	}
+
+	grab(t.Command)
+	grab(t.Script)
+	for _, a := range t.Args {
+		grab(a)
+	}
+	if t.Options != nil {
+		grab(t.Options.Cwd)
+		for _, v := range t.Options.Env {
+			grab(v)
+		}
+		for _, p := range t.Options.Path {
+			grab(p)
+		}
+		if t.Options.Shell != nil {
+			grab(t.Options.Shell.Executable)
+			for _, a := range t.Options.Shell.Args {
+				grab(a)
+			}
+		}
+	}
+	out := make([]string, 0, len(seen))
+	for id := range seen {
+		out = append(out, id)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// DescribeTask renders per-task documentation (as shown by `vstask help <label>`)
+// from the task definition, as it runs on this platform, and the inputs declared in tasks.json.
+func DescribeTask(t Task, inputs []Input) string {
+	t = ApplyPlatformOverrides(t)
+	var b strings.Builder
+
+	b.WriteString(t.Label)
+	b.WriteByte('\n')
+	if d := strings.TrimSpace(t.Detail); d != "" {
+		for line := range strings.SplitSeq(d, "\n") {
+			b.WriteString("  " + line + "\n")
+		}
+	}
+	if t.Deprecated != "" {
+		b.WriteString("  Deprecated: " + t.Deprecated + "\n")
+	}
+	b.WriteByte('\n')
+
+	row := func(key, val string) {
+		if val == "" {
+			return
+		}
+		fmt.Fprintf(&b, "  %-13s %s\n", key+":", val)
+	}
+
+	typ := t.Type
+	if typ == "" {
+		typ = "shell"
+	}
+	row("Type", typ)
+	if t.Script != "" {
+		row("Script", t.Script)
+	}
+	row("Command", strings.TrimSpace(strings.Join(append([]string{t.Command}, t.Args...), " ")))
+	if t.Options != nil {
+		row("Cwd", t.Options.Cwd)
+		if len(t.Options.Env) > 0 {
+			keys := make([]string, 0, len(t.Options.Env))
+			for k := range t.Options.Env {
+				keys = append(keys, k)
+			}
+			slices.Sort(keys)
+			row("Env", strings.Join(keys, ", "))
+		}
+		if len(t.Options.Path) > 0 {
+			row("Path", strings.Join(t.Options.Path, ", "))
+		}
+	}
+	if t.Group != nil && t.Group.Kind != "" {
+		g := t.Group.Kind
+		if t.Group.IsDefault {
+			g += " (default)"
+		}
+		row("Group", g)
+	}
+	if t.IsBackground {
+		row("Background", "yes")
+	}
+	if t.DependsOn != nil && len(t.DependsOn.Tasks) > 0 {
+		order := t.DependsOrder
+		if order == "" {
+			order = "parallel"
+		}
+		row("Depends on", fmt.Sprintf("%s (%s)", strings.Join(t.DependsOn.Labels(), ", "), order))
+	}
+	if x := t.Exports; x != nil {
+		names := make([]string, 0, len(x.Vars)+1)
+		for k := range x.Vars {
+			names = append(names, k)
+		}
+		slices.Sort(names)
+		if x.File != "" {
+			names = append(names, "vars in "+x.File)
+		}
+		row("Exports", strings.Join(names, ", "))
+	}
+	if sb := t.Sandbox; sb != nil && sb.Enabled {
+		v := "yes"
+		if sb.Network {
+			v += ", network"
+		}
+		if len(sb.Writable) > 0 {
+			v += ", writable: " + strings.Join(sb.Writable, ", ")
+		}
+		row("Sandbox", v)
+	}
+	if t.RunOptions != nil {
+		var ro []string
+		if t.RunOptions.RunOn != "" {
+			ro = append(ro, "runOn="+t.RunOptions.RunOn)
+		}
+		if t.RunOptions.InstanceLimit > 0 {
+			ro = append(ro, fmt.Sprintf("instanceLimit=%d", t.RunOptions.InstanceLimit))
+		}
+		if t.RunOptions.ReevaluateOnRun {
+			ro = append(ro, "reevaluateOnRun")
+		}
+		row("Run options", strings.Join(ro, ", "))
+	}
+	var platforms []string
+	if t.Windows != nil {
+		platforms = append(platforms, "windows")
+	}
+	if t.Osx != nil {
+		platforms = append(platforms, "osx")
+	}
+	if t.Linux != nil {
+		platforms = append(platforms, "linux")
+	}
+	row("Overrides", strings.Join(platforms, ", "))
+	row("Replaced by", t.ReplacedBy)
+
+	refs := t.InputRefs()
+	if len(refs) > 0 {
+		byID := make(map[string]Input, len(inputs))
+		for _, in := range inputs {
+			byID[in.ID] = in
+		}
+		b.WriteString("\n  Inputs:\n")
+		for _, id := range refs {
+			in, ok := byID[id]
+			if !ok {
+				fmt.Fprintf(&b, "    %s (undeclared; prompts for a line of text)\n", id)
+				continue
+			}
+			fmt.Fprintf(&b, "    %s (%s): %s\n", id, in.Type, in.DescriptionOrFallback())
+			if len(in.Options) > 0 {
+				opts := make([]string, len(in.Options))
+				for i, o := range in.Options {
+					opts[i] = o.Value
+					if o.Label != "" && o.Label != o.Value {
+						opts[i] = fmt.Sprintf("%s (%s)", o.Value, o.Label)
+					}
+				}
+				fmt.Fprintf(&b, "      options: %s\n", strings.Join(opts, ", "))
+			}
+			if in.Command != "" {
+				fmt.Fprintf(&b, "      command: %s\n", in.Command)
+			}
+			if in.Default != "" && !in.Password {
+				fmt.Fprintf(&b, "      default: %s\n", in.Default)
+			}
+			if in.Timeout > 0 {
+				fmt.Fprintf(&b, "      timeout: %s\n", time.Duration(in.Timeout))
+				onTimeout := in.OnTimeout
+				if onTimeout == "" {
+					onTimeout = "default"
+				}
+				fmt.Fprintf(&b, "      onTimeout: %s\n", onTimeout)
+			}
+		}
+	}
+
+	return b.String()
+}
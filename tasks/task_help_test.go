@@ -0,0 +1,97 @@
+package tasks
+
+import (
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInputRefs_SortedAndDeduped(t *testing.T) {
+	tk := Task{
+		Command: "deploy ${input:env}",
+		Args:    []string{"--tag", "${input:tag}", "${input:env}"},
+		Options: &Options{
+			Cwd: "${input:dir}",
+			Env: map[string]string{"TOKEN": "${input:token}"},
+		},
+	}
+	got := tk.InputRefs()
+	want := []string{"dir", "env", "tag", "token"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("InputRefs = %v, want %v", got, want)
+	}
+}
+
+func TestDescribeTask(t *testing.T) {
+	tk := Task{
+		Label:        "deploy",
+		Type:         "shell",
+		Command:      "./deploy.sh",
+		Args:         []string{"${input:env}", "${input:other}"},
+		Detail:       "Deploy the app",
+		Group:        &Group{Kind: "build", IsDefault: true},
+		DependsOn:    &DependsOn{Tasks: []TaskRef{{Label: "build"}, {Label: "lint"}}},
+		DependsOrder: "sequence",
+	}
+	inputs := []Input{
+		{ID: "env", Type: "pickString", Description: "Target", Options: []PickOption{{Value: "dev"}, {Value: "prod"}}, Default: "dev"},
+	}
+	out := DescribeTask(tk, inputs)
+	for _, want := range []string{
+		"deploy\n  Deploy the app\n",
+		"Type:",
+		"./deploy.sh ${input:env} ${input:other}",
+		"build (default)",
+		"build, lint (sequence)",
+		"env (pickString): Target",
+		"options: dev, prod",
+		"default: dev",
+		"other (undeclared",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("DescribeTask output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestDescribeTask_OptionalDependency(t *testing.T) {
+	tk := Task{Label: "deploy", Command: "./deploy.sh", DependsOn: &DependsOn{Tasks: []TaskRef{{Label: "build"}, {Label: "lint", Optional: true}}}, DependsOrder: "sequence"}
+	if out := DescribeTask(tk, nil); !strings.Contains(out, "build, lint (optional) (sequence)") {
+		t.Fatalf("DescribeTask output missing the optional dependency:\n%s", out)
+	}
+}
+
+func TestDescribeTask_Deprecated(t *testing.T) {
+	tk := Task{Label: "build:old", Type: "shell", Command: "make", Deprecated: "use build:fast instead", ReplacedBy: "build:fast"}
+	out := DescribeTask(tk, nil)
+	for _, want := range []string{"build:old\n  Deprecated: use build:fast instead\n", "build:fast"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("DescribeTask output missing %q:\n%s", want, out)
+		}
+	}
+	if got := pickerLabel(tk, Usage{}); got != "build:old (deprecated)" {
+		t.Fatalf("pickerLabel = %q", got)
+	}
+	if got := pickerLabel(Task{Label: "build:fast"}, Usage{Runs: 3}); got != "build:fast  · 3 runs" {
+		t.Fatalf("pickerLabel = %q", got)
+	}
+}
+
+func TestDescribeTask_PlatformInputs(t *testing.T) {
+	plat := &PlatformTask{Command: "./deploy.sh ${input:target}"}
+	tk := Task{Label: "deploy", Command: "./deploy.sh", Windows: plat, Osx: plat, Linux: plat}
+	inputs := []Input{{ID: "target", Type: "promptString", Description: "Target", Timeout: Duration(30 * time.Second), OnTimeout: "fail"}}
+	out := DescribeTask(tk, inputs)
+	switch runtime.GOOS {
+	case "windows", "darwin", "linux":
+	default:
+		t.Skip("no platform block for " + runtime.GOOS)
+	}
+	for _, want := range []string{"Command:      ./deploy.sh ${input:target}", "target (promptString): Target", "timeout: 30s", "onTimeout: fail"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("DescribeTask output missing %q:\n%s", want, out)
+		}
+	}
+}
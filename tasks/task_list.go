@@ -1,34 +1,77 @@
 package tasks
 
 import (
-	"encoding/json"
-	"errors"
+	"bytes"
 	"fmt"
 	"os"
-	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"unicode/utf8"
 
 	"github.com/chenasraf/vstask/utils"
 )
 
 func GetTasks() ([]Task, error) {
+	if s := currentSnapshot(); s != nil {
+		return s.Tasks, nil
+	}
 	projectRoot, err := utils.FindProjectRoot()
 	if err != nil {
 		return []Task{}, err
 	}
 
-	tasksPath := path.Join(projectRoot, utils.VSCODE_DIR, utils.TASKS_JSON)
-
-	if !utils.FileExists(tasksPath) {
-		return []Task{}, errors.New("tasks.json not found")
+	file, err := loadWorkspace(projectRoot)
+	if err != nil {
+		return []Task{}, err
 	}
+	return file.Tasks, nil
+}
 
-	return LoadTasksFile(tasksPath)
+// loadWorkspace parses root's tasks file (tasks.json or tasks.yaml; see TasksFilePath), then
+// appends the tasks defined in its .code-workspace file and those of the enabled providers. A
+// missing tasks file is fine as long as there are tasks from somewhere else. It doesn't run a
+// tasks.json generator (see GenerateTasksFile), but if one ran and failed, the tasks.json it
+// left (if any) is used, with a warning.
+func loadWorkspace(root string) (File, error) {
+	genErr := generationError(root)
+	tasksPath := TasksFilePath(root)
+	provided := ProvidedTasks(root)
+
+	var file File
+	hasFile := utils.FileExists(tasksPath)
+	if hasFile {
+		if genErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v (using the existing tasks.json)\n", genErr)
+		}
+		var err error
+		if file, err = loadTasksFile(tasksPath); err != nil {
+			return File{}, err
+		}
+	}
+	file = withWorkspaceFileTasks(file, root)
+	if len(file.Tasks) == 0 && len(provided) == 0 && !hasFile {
+		if genErr != nil {
+			return File{}, genErr
+		}
+		if TasksFileGenerator(root) != "" {
+			return File{}, fmt.Errorf(`%w: it's generated ("vstask.tasksFile.generate") when running a task`, ErrTasksFileNotFound)
+		}
+		if target, ok := TasksFileTarget(root); ok {
+			return File{}, fmt.Errorf("%s is a symlink to %s, which doesn't exist", filepath.Base(tasksPath), target)
+		}
+		return File{}, ErrTasksFileNotFound
+	}
+	file.Tasks = mergeProvided(file.Tasks, provided)
+	return file, nil
 }
 
-// FindTask looks up a task by name. It first tries an exact match on the label,
-// then falls back to case-insensitive substring matching. Returns an error if
-// no match is found or if multiple tasks match the query.
+// FindTask looks up a task by name: an exact match on the label, then a case-insensitive one,
+// then a label starting with query, then one containing it (both case-insensitively). Returns an
+// error if no match is found, suggesting labels close to query (see suggestLabels), or if
+// multiple tasks match the query.
 func FindTask(taskList []Task, query string) (Task, error) {
 	// Exact match
 	for _, t := range taskList {
@@ -37,44 +80,153 @@ func FindTask(taskList []Task, query string) (Task, error) {
 		}
 	}
 
-	// Case-insensitive partial match
 	lower := strings.ToLower(query)
-	var matches []Task
+	matchers := []func(label string) bool{
+		func(l string) bool { return l == lower },
+		func(l string) bool { return strings.HasPrefix(l, lower) },
+		func(l string) bool { return strings.Contains(l, lower) },
+	}
+	for _, match := range matchers {
+		var matches []Task
+		for _, t := range taskList {
+			if match(strings.ToLower(t.Label)) {
+				matches = append(matches, t)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			continue
+		case 1:
+			return matches[0], nil
+		default:
+			labels := make([]string, len(matches))
+			for i, m := range matches {
+				labels[i] = m.Label
+			}
+			return Task{}, fmt.Errorf("multiple tasks match '%s':\n  - %s", query, strings.Join(labels, "\n  - "))
+		}
+	}
+
+	if s := suggestLabels(taskList, query); len(s) > 0 {
+		return Task{}, fmt.Errorf("%w: %s (did you mean %s?)", ErrTaskNotFound, query, strings.Join(s, " or "))
+	}
+	return Task{}, fmt.Errorf("%w: %s", ErrTaskNotFound, query)
+}
+
+// maxSuggestions is how many labels a "task not found" error suggests at most.
+const maxSuggestions = 3
+
+// suggestLabels returns the (quoted) labels of taskList closest to query, a likely typo of them:
+// within an edit distance of a third of query's length (at least 1), case-insensitively,
+// closest first.
+func suggestLabels(taskList []Task, query string) []string {
+	lower := strings.ToLower(query)
+	limit := max(1, utf8.RuneCountInString(lower)/3)
+	type candidate struct {
+		label string
+		dist  int
+	}
+	var cs []candidate
 	for _, t := range taskList {
-		if strings.Contains(strings.ToLower(t.Label), lower) {
-			matches = append(matches, t)
+		if d := editDistance(strings.ToLower(t.Label), lower); d <= limit {
+			cs = append(cs, candidate{t.Label, d})
 		}
 	}
+	slices.SortStableFunc(cs, func(a, b candidate) int { return a.dist - b.dist })
+	var out []string
+	for _, c := range cs[:min(len(cs), maxSuggestions)] {
+		out = append(out, strconv.Quote(c.label))
+	}
+	return out
+}
 
-	switch len(matches) {
-	case 0:
-		return Task{}, fmt.Errorf("task not found: %s", query)
-	case 1:
-		return matches[0], nil
-	default:
-		labels := make([]string, len(matches))
-		for i, m := range matches {
-			labels[i] = m.Label
+// editDistance is the edit distance between a and b, in runes: how many insertions, deletions,
+// substitutions and swaps of adjacent runes (a common typo) turn one into the other.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	// d[i][j] is the distance between ra[:i] and rb[:j].
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
 		}
-		return Task{}, fmt.Errorf("multiple tasks match '%s':\n  - %s", query, strings.Join(labels, "\n  - "))
 	}
+	return d[len(ra)][len(rb)]
 }
 
 func LoadTasksFile(tasksPath string) ([]Task, error) {
-	data, err := os.ReadFile(tasksPath)
+	file, err := LoadFile(tasksPath)
 	if err != nil {
 		return nil, err
 	}
+	return file.Tasks, nil
+}
+
+// parsedFiles caches LoadFile's results by path, with the bytes they were parsed from: one
+// invocation reads the same tasks.json several times (tasks, inputs, dependencies, change
+// tracking), and one with thousands of tasks takes a while to parse. Changed files are parsed
+// again.
+var parsedFiles sync.Map // path -> parsedFile
 
-	data = utils.ConvertJsoncToJson(data)
+type parsedFile struct {
+	data []byte
+	file File
+}
 
-	var file struct {
-		Version string `json:"version"`
-		Tasks   []Task `json:"tasks"`
+// LoadFile reads and parses a tasks file, including its inputs: a (JSONC) tasks.json, or a
+// tasks.yaml with the same schema.
+func LoadFile(tasksPath string) (File, error) {
+	data, err := os.ReadFile(tasksPath)
+	if err != nil {
+		return File{}, err
+	}
+	if c, ok := parsedFiles.Load(tasksPath); ok && bytes.Equal(c.(parsedFile).data, data) {
+		return c.(parsedFile).file.clone(), nil
 	}
 
-	if err := json.Unmarshal(data, &file); err != nil {
-		return nil, err
+	file, err := parseTasksFile(tasksPath, data)
+	if err != nil {
+		return File{}, err
 	}
-	return file.Tasks, nil
+	parsedFiles.Store(tasksPath, parsedFile{data: data, file: file})
+	return file.clone(), nil
+}
+
+// parseTasksFile parses the data of the tasks file name: YAML if it's named so, else JSONC. A
+// schema version vstask doesn't support is an error (see checkVersion).
+func parseTasksFile(name string, data []byte) (File, error) {
+	var file File
+	unmarshal := unmarshalJSONC
+	if isYAMLFile(name) {
+		unmarshal = unmarshalYAML
+	}
+	if err := unmarshal(name, data, &file); err != nil {
+		return File{}, err
+	}
+	if err := checkVersion(name, data, file.Version); err != nil {
+		return File{}, err
+	}
+	return file, nil
+}
+
+// clone copies f's task and input lists, so callers appending to them don't share the cached
+// ones (the tasks themselves are never modified in place).
+func (f File) clone() File {
+	f.Tasks = slices.Clone(f.Tasks)
+	f.Inputs = slices.Clone(f.Inputs)
+	return f
 }
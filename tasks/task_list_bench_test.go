@@ -0,0 +1,77 @@
+package tasks
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeHugeWorkspace writes a workspace folder whose tasks.json has n generated tasks, the
+// way some monorepos have one per package and script.
+func writeHugeWorkspace(tb testing.TB, n int) string {
+	tb.Helper()
+	root := tb.TempDir()
+	var b strings.Builder
+	b.WriteString("{\n  // generated\n  \"version\": \"2.0.0\",\n  \"tasks\": [\n")
+	for i := range n {
+		pkg := fmt.Sprintf("packages/pkg-%04d", i)
+		fmt.Fprintf(&b, `    {
+      "label": "%[1]s: build",
+      "type": "shell",
+      "command": "pnpm --filter ./%[1]s run build -- ${input:mode}",
+      "options": { "cwd": "${workspaceFolder}/%[1]s", "env": { "NODE_ENV": "production" } },
+      "dependsOn": ["%[1]s: codegen"],
+      "group": "build",
+      "problemMatcher": ["$tsc"],
+      "detail": "Build %[1]s", // generated
+    },
+`, pkg)
+	}
+	b.WriteString("  ],\n  \"inputs\": [{ \"id\": \"mode\", \"type\": \"pickString\", \"options\": [\"dev\", \"prod\"] }]\n}\n")
+	writeTestFile(tb, filepath.Join(root, ".vscode", "tasks.json"), b.String())
+	return root
+}
+
+func BenchmarkLoadTasksFile(b *testing.B) {
+	root := writeHugeWorkspace(b, 5000)
+	p := filepath.Join(root, ".vscode", "tasks.json")
+	b.ResetTimer()
+	for b.Loop() {
+		parsedFiles.Clear() // as in a new vstask process
+		if _, err := LoadTasksFile(p); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetTasks(b *testing.B) {
+	root := writeHugeWorkspace(b, 5000)
+	b.Chdir(filepath.Join(root, ".vscode"))
+	b.Setenv("XDG_CONFIG_HOME", b.TempDir())
+	b.Setenv("HOME", b.TempDir())
+	b.ResetTimer()
+	for b.Loop() {
+		parsedFiles.Clear() // as in a new vstask process
+		if ts, err := GetTasks(); err != nil || len(ts) != 5000 {
+			b.Fatalf("got %d tasks, %v", len(ts), err)
+		}
+	}
+}
+
+// BenchmarkTaskPicker measures the picker's startup: everything PromptForTask does before the
+// picker shows.
+func BenchmarkTaskPicker(b *testing.B) {
+	root := writeHugeWorkspace(b, 5000)
+	b.Chdir(root)
+	b.Setenv("XDG_CONFIG_HOME", b.TempDir())
+	b.Setenv("HOME", b.TempDir())
+	b.Setenv("VSTASK_STATE_DIR", b.TempDir())
+	b.ResetTimer()
+	for b.Loop() {
+		parsedFiles.Clear() // as in a new vstask process
+		if _, err := newTaskPicker(nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
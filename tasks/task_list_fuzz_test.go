@@ -0,0 +1,43 @@
+package tasks
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func FuzzLoadTasksFile(f *testing.F) {
+	for _, seed := range []string{
+		`{"version": "2.0.0", "tasks": [{"label": "build", "type": "shell", "command": "make"}]}`,
+		"{\n  // comment\n  \"tasks\": [\n    {\"label\": \"a\", \"dependsOn\": [\"b\"],},\n    {\"label\": \"b\", \"command\": \"echo ${input:x}\"},\n  ],\n}",
+		`{"tasks": [{"label": "t", "dependsOn": "single", "args": ["a", {"value": "b", "quoting": "strong"}]}]}`,
+		`{"inputs": [{"id": "x", "type": "pickString", "options": ["a", {"label": "B", "value": "b"}]}]}`,
+		`{"tasks": [{"label": 1}]}`,
+		`{"tasks": [`,
+		`{"tasks": {}}`,
+		"{\n\n  \"tasks\": [}\n",
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		p := filepath.Join(t.TempDir(), "tasks.json")
+		writeTestFile(t, p, string(data))
+
+		ts, err := LoadTasksFile(p)
+		if err != nil {
+			var pe *ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("error is %T, want *ParseError: %v", err, err)
+			}
+			if lines := bytes.Count(data, []byte("\n")) + 1; pe.Line < 0 || pe.Line > lines {
+				t.Fatalf("error on line %d of a %d-line file: %v", pe.Line, lines, err)
+			}
+			return
+		}
+		for _, task := range ts {
+			_ = task.InputRefs()
+			_ = task.IsEmpty()
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// TaskSummary is one task as listed by `vstask list`.
+type TaskSummary struct {
+	Label        string `json:"label"`
+	Type         string `json:"type"`
+	Group        string `json:"group,omitempty"`
+	IsDefault    bool   `json:"isDefault,omitempty"`
+	Detail       string `json:"detail,omitempty"`
+	IsBackground bool   `json:"isBackground,omitempty"`
+	Origin       string `json:"origin,omitempty"` // OriginWorkspace for .code-workspace tasks
+
+	// With usage (`vstask list --verbose`):
+	Runs    int        `json:"runs,omitempty"`
+	LastRun *time.Time `json:"lastRun,omitempty"`
+}
+
+// Summarize returns the listing of ts, in order. Tasks without a type are "shell" tasks, as in
+// VS Code, or "compound" ones if they only run their dependencies (see Task.IsCompound).
+func Summarize(ts []Task) []TaskSummary {
+	out := make([]TaskSummary, 0, len(ts))
+	for _, t := range ts {
+		s := TaskSummary{Label: t.Label, Type: t.Type, Detail: t.Detail, IsBackground: t.IsBackground, Origin: t.Origin}
+		switch {
+		case t.IsCompound():
+			s.Type = "compound"
+		case s.Type == "":
+			s.Type = "shell"
+		}
+		if t.Group != nil {
+			s.Group, s.IsDefault = t.Group.Kind, t.Group.IsDefault
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// WriteTaskList prints ts as an aligned table (or a JSON array with asJSON). With usage (see
+// TaskUsage), it adds how often and when last each task ran.
+func WriteTaskList(w io.Writer, ts []Task, asJSON bool, usage map[string]Usage) error {
+	sums := Summarize(ts)
+	for i, s := range sums {
+		if u, ok := usage[s.Label]; ok && u.Runs > 0 {
+			sums[i].Runs = u.Runs
+			if !u.Last.IsZero() {
+				sums[i].LastRun = &u.Last
+			}
+		}
+	}
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(sums)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if usage != nil {
+		fmt.Fprintln(tw, "LABEL\tTYPE\tGROUP\tBACKGROUND\tRUNS\tLAST RUN\tDETAIL")
+	} else {
+		fmt.Fprintln(tw, "LABEL\tTYPE\tGROUP\tBACKGROUND\tDETAIL")
+	}
+	for _, s := range sums {
+		group := s.Group
+		if s.IsDefault {
+			group += " (default)"
+		}
+		bg := ""
+		if s.IsBackground {
+			bg = "yes"
+		}
+		detail, _, _ := strings.Cut(strings.TrimSpace(s.Detail), "\n")
+		label := s.Label
+		if s.Origin != "" {
+			label += " (" + s.Origin + ")"
+		}
+		if usage == nil {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", label, s.Type, group, bg, detail)
+			continue
+		}
+		runs, last := "", ""
+		if s.Runs > 0 {
+			runs = fmt.Sprint(s.Runs)
+		}
+		if s.LastRun != nil {
+			last = ago(time.Since(*s.LastRun))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", label, s.Type, group, bg, runs, last, detail)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,102 @@
+package tasks
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var listFixture = []Task{
+	{Label: "build", Type: "npm", Group: &Group{Kind: "build", IsDefault: true}, Detail: "Compile\nsecond line"},
+	{Label: "watch", IsBackground: true},
+}
+
+func TestWriteTaskList_Table(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteTaskList(&buf, listFixture, false, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"LABEL  TYPE   GROUP            BACKGROUND  DETAIL",
+		"build  npm    build (default)              Compile",
+		"watch  shell                   yes         ",
+		"",
+	}, "\n")
+	if buf.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteTaskList_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteTaskList(&buf, listFixture, true, nil); err != nil {
+		t.Fatal(err)
+	}
+	var got []TaskSummary
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := []TaskSummary{
+		{Label: "build", Type: "npm", Group: "build", IsDefault: true, Detail: "Compile\nsecond line"},
+		{Label: "watch", Type: "shell", IsBackground: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v", got)
+	}
+
+	buf.Reset()
+	if err := WriteTaskList(&buf, nil, true, nil); err != nil || strings.TrimSpace(buf.String()) != "[]" {
+		t.Fatalf("empty list = %q, %v", buf.String(), err)
+	}
+}
+
+func TestWriteTaskList_Usage(t *testing.T) {
+	last := time.Now().Add(-2 * time.Hour)
+	usage := map[string]Usage{"build": {Runs: 7, Last: last}}
+
+	var buf bytes.Buffer
+	if err := WriteTaskList(&buf, listFixture, false, usage); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"LABEL  TYPE   GROUP            BACKGROUND  RUNS  LAST RUN  DETAIL",
+		"build  npm    build (default)              7     2h ago    Compile",
+		"watch  shell                   yes                         ",
+		"",
+	}, "\n")
+	if buf.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := WriteTaskList(&buf, listFixture, true, usage); err != nil {
+		t.Fatal(err)
+	}
+	var got []TaskSummary
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got[0].Runs != 7 || got[0].LastRun == nil || !got[0].LastRun.Equal(last) || got[1].Runs != 0 || got[1].LastRun != nil {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+func TestSummarize_Compound(t *testing.T) {
+	deps := &DependsOn{Tasks: []TaskRef{{Label: "build"}}}
+	got := Summarize([]Task{
+		{Label: "all", DependsOn: deps},
+		{Label: "deploy", Command: "./deploy", DependsOn: deps},
+		{Label: "win", DependsOn: deps, Windows: &PlatformTask{Command: "deploy.cmd"}, Osx: &PlatformTask{Command: "./deploy"}, Linux: &PlatformTask{Command: "./deploy"}},
+		{Label: "empty"},
+	})
+	var types []string
+	for _, s := range got {
+		types = append(types, s.Type)
+	}
+	if want := []string{"compound", "shell", "shell", "shell"}; !reflect.DeepEqual(types, want) {
+		t.Fatalf("types %v, want %v", types, want)
+	}
+}
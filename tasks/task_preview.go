@@ -0,0 +1,83 @@
+package tasks
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"sync"
+
+	json "github.com/neilotoole/jsoncolor"
+)
+
+// previewCacheSize bounds how many rendered previews the picker keeps around.
+const previewCacheSize = 128
+
+// previewer renders picker previews lazily: a task's preview is built the first time the
+// cursor lands on it and memoized (up to previewCacheSize entries), and terminal color
+// support is detected once instead of on every cursor move.
+type previewer struct {
+	tasks []Task
+	color bool
+
+	mu    sync.Mutex
+	cache map[int]string
+	order []int // insertion order, for eviction
+}
+
+func newPreviewer(ts []Task) *previewer {
+	return &previewer{
+		tasks: ts,
+		color: json.IsColorTerminal(os.Stdout),
+		cache: make(map[int]string),
+	}
+}
+
+func (p *previewer) Preview(i int) string {
+	if i < 0 || i >= len(p.tasks) {
+		return "No task selected"
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if s, ok := p.cache[i]; ok {
+		return s
+	}
+	s := p.render(p.tasks[i])
+	if len(p.order) >= previewCacheSize {
+		delete(p.cache, p.order[0])
+		p.order = p.order[1:]
+	}
+	p.cache[i] = s
+	p.order = append(p.order, i)
+	return s
+}
+
+func (p *previewer) render(t Task) string {
+	var buf bytes.Buffer
+	if line := commandPreview(t); line != "" {
+		buf.WriteString("$ " + line + "\n\n")
+	}
+	enc := json.NewEncoder(&buf)
+	if p.color {
+		enc.SetColors(json.DefaultColors())
+	}
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(t); err != nil {
+		return "Error displaying task details"
+	}
+	return buf.String()
+}
+
+// commandPreview is the command line the task runs on this platform, before variable
+// substitution (e.g. "npm run build" or "go test ./..."). It's informational only; the
+// runner does the actual quoting.
+func commandPreview(t Task) string {
+	eff := ApplyPlatformOverrides(t)
+	cmd, args := eff.Command, eff.Args
+	if strings.EqualFold(t.Type, "npm") && t.Script != "" {
+		cmd = "npm run " + t.Script
+		if len(args) > 0 {
+			cmd += " --"
+		}
+	}
+	return strings.TrimSpace(strings.Join(append([]string{cmd}, args...), " "))
+}
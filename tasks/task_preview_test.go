@@ -0,0 +1,63 @@
+package tasks
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestPreviewer_MemoizesAndBoundsCache(t *testing.T) {
+	ts := make([]Task, previewCacheSize+10)
+	for i := range ts {
+		ts[i] = Task{Label: fmt.Sprintf("t%d", i), Command: "echo", Args: []string{fmt.Sprint(i)}}
+	}
+	p := newPreviewer(ts)
+
+	first := p.Preview(3)
+	if !strings.HasPrefix(first, "$ echo 3\n") || !strings.Contains(first, `"label": "t3"`) {
+		t.Fatalf("unexpected preview:\n%s", first)
+	}
+	// Mutating the task shows the cache is used rather than re-rendering.
+	ts[3].Command = "changed"
+	if got := p.Preview(3); got != first {
+		t.Fatalf("expected memoized preview, got:\n%s", got)
+	}
+
+	for i := range ts {
+		p.Preview(i)
+	}
+	if len(p.cache) != previewCacheSize || len(p.order) != previewCacheSize {
+		t.Fatalf("cache should be bounded to %d, got %d/%d", previewCacheSize, len(p.cache), len(p.order))
+	}
+	if got := p.Preview(-1); got != "No task selected" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestCommandPreview(t *testing.T) {
+	cases := []struct {
+		t    Task
+		want string
+	}{
+		{Task{Command: "go", Args: []string{"test", "./..."}}, "go test ./..."},
+		{Task{Type: "npm", Script: "build"}, "npm run build"},
+		{Task{Type: "npm", Script: "test", Args: []string{"--watch"}}, "npm run test -- --watch"},
+		{Task{}, ""},
+	}
+	for _, c := range cases {
+		if got := commandPreview(c.t); got != c.want {
+			t.Errorf("%+v: got %q, want %q", c.t, got, c.want)
+		}
+	}
+
+	pt := &PlatformTask{Command: "native"}
+	tk := Task{Command: "generic", Windows: pt, Osx: pt, Linux: pt}
+	want := "native"
+	if runtime.GOOS != "windows" && runtime.GOOS != "darwin" && runtime.GOOS != "linux" {
+		want = "generic"
+	}
+	if got := commandPreview(tk); got != want {
+		t.Fatalf("platform override: got %q, want %q", got, want)
+	}
+}
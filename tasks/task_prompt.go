@@ -1,45 +1,36 @@
 package tasks
 
 import (
-	"bytes"
 	"fmt"
-	"os"
-	"path/filepath"
 
 	"github.com/chenasraf/vstask/utils"
 	"github.com/ktr0731/go-fuzzyfinder"
-	json "github.com/neilotoole/jsoncolor"
 )
 
 func PromptForTask() (Task, error) {
-	taskList, err := GetTasks()
+	return promptAmong(nil)
+}
+
+// PromptForGroupTask is PromptForTask, listing only the tasks in group (see GroupTasks).
+func PromptForGroupTask(group string) (Task, error) {
+	return promptAmong(func(t Task) bool {
+		return len(GroupTasks([]Task{t}, group)) > 0
+	})
+}
+
+// promptAmong opens the picker on the tasks keep keeps (all of them for a nil keep).
+func promptAmong(keep func(Task) bool) (Task, error) {
+	p, err := newTaskPicker(keep)
 	if err != nil {
 		return Task{}, err
 	}
-
 	idx, err := fuzzyfinder.Find(
-		taskList,
+		p.tasks,
 		func(i int) string {
-			return taskList[i].Label
+			return p.labels[i]
 		},
 		fuzzyfinder.WithPreviewWindow(func(i, w, h int) string {
-			if i == -1 {
-				return "No task selected"
-			}
-			var buf bytes.Buffer
-			enc := json.NewEncoder(&buf)
-
-			if json.IsColorTerminal(os.Stdout) {
-				clrs := json.DefaultColors()
-				enc.SetColors(clrs)
-			}
-
-			enc.SetIndent("", "  ")
-			err := enc.Encode(taskList[i])
-			if err != nil {
-				return "Error displaying task details"
-			}
-			return buf.String()
+			return p.preview.Preview(i)
 		}))
 
 	if err != nil {
@@ -49,27 +40,72 @@ func PromptForTask() (Task, error) {
 		return Task{}, err
 	}
 
-	return taskList[idx], nil
+	return p.tasks[idx], nil
 }
 
-// GetInputs loads .vscode/tasks.json from the nearest project root and returns the "inputs" array.
-// If the file exists but has no inputs, it returns an empty slice (not nil).
-func GetInputs() ([]Input, error) {
-	root, err := utils.FindProjectRoot()
+// taskPicker is what PromptForTask shows: the tasks, their labels, and their previews (which
+// are rendered as they're shown).
+type taskPicker struct {
+	tasks   []Task
+	labels  []string
+	preview *previewer
+}
+
+func newTaskPicker(keep func(Task) bool) (*taskPicker, error) {
+	all, err := GetTasks()
 	if err != nil {
-		return nil, fmt.Errorf("find project root: %w", err)
+		return nil, err
+	}
+	var taskList []Task
+	for _, t := range all {
+		if keep == nil || keep(t) {
+			taskList = append(taskList, t)
+		}
+	}
+	root, _ := ProjectRoot()
+	usage := TaskUsage(root)
+	labels := make([]string, len(taskList))
+	for i, t := range taskList {
+		labels[i] = pickerLabel(t, usage[t.Label])
 	}
+	return &taskPicker{tasks: taskList, labels: labels, preview: newPreviewer(taskList)}, nil
+}
 
-	p := filepath.Join(root, ".vscode", "tasks.json")
-	data, err := os.ReadFile(p)
+// pickerLabel is how t is listed in the picker, with a hint of how much it's used.
+func pickerLabel(t Task, u Usage) string {
+	l := t.Label
+	if t.Origin == OriginWorkspace {
+		l += " (workspace)"
+	}
+	if t.Deprecated != "" {
+		l += " (deprecated)"
+	}
+	if s := u.String(); s != "" {
+		l += "  · " + s
+	}
+	return l
+}
+
+// GetInputs loads .vscode/tasks.json from the nearest project root and returns the "inputs" array,
+// with those of the .code-workspace file's tasks after it. If there are none, it returns an empty
+// slice (not nil).
+func GetInputs() ([]Input, error) {
+	if s := currentSnapshot(); s != nil {
+		return s.Inputs, nil
+	}
+	root, err := utils.FindProjectRoot()
 	if err != nil {
-		return nil, fmt.Errorf("read tasks.json: %w", err)
+		return nil, fmt.Errorf("find project root: %w", err)
 	}
 
+	p := TasksFilePath(root)
 	var f File
-	if err := json.Unmarshal(data, &f); err != nil {
-		return nil, fmt.Errorf("parse tasks.json: %w", err)
+	if utils.FileExists(p) {
+		if f, err = loadTasksFile(p); err != nil {
+			return nil, fmt.Errorf("load tasks.json: %w", err)
+		}
 	}
+	f = withWorkspaceFileTasks(f, root)
 
 	if f.Inputs == nil {
 		return []Input{}, nil
@@ -0,0 +1,72 @@
+package tasks
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// nativeTypes are the task types vstask runs itself ("" means VS Code's default, shell).
+var nativeTypes = []string{"", "shell", "process", "npm"}
+
+// defaultTypeCommands map common extension task types to shell command templates.
+// Azure Functions tasks look like {"type": "func", "command": "host start"}.
+var defaultTypeCommands = map[string]string{
+	"func": "func ${command} ${args}",
+}
+
+// IsNativeType reports whether vstask runs tasks of this type without a passthrough mapping.
+func IsNativeType(typ string) bool {
+	return slices.Contains(nativeTypes, strings.ToLower(strings.TrimSpace(typ)))
+}
+
+// TypeCommands returns the passthrough mapping for extension task types: task type → shell
+// command template. The built-in defaults are overridden by "vstask.taskTypes" from the user
+// settings, then from the workspace settings. Templates may use ${command}, ${args} (quoted),
+// ${script}, ${label} and ${type}; an empty template removes a mapping.
+func TypeCommands(root string) map[string]string {
+	if s := currentSnapshot(); s != nil && s.Root == root && s.TaskTypes != nil {
+		return s.TaskTypes
+	}
+	m := maps.Clone(defaultTypeCommands)
+	cands := userSettingsCandidates()
+	for _, p := range slices.Backward(cands) { // first candidate wins, so apply it last
+		if s, ok := readSettingsFile(p); ok {
+			mergeTypeCommands(m, s.TaskTypes)
+		}
+	}
+	if s, ok := readSettingsFile(workspaceSettingsPath(root)); ok {
+		mergeTypeCommands(m, s.TaskTypes)
+	}
+	return m
+}
+
+func mergeTypeCommands(dst, src map[string]string) {
+	for k, v := range src {
+		k = strings.ToLower(strings.TrimSpace(k))
+		if strings.TrimSpace(v) == "" {
+			delete(dst, k)
+		} else {
+			dst[k] = v
+		}
+	}
+}
+
+// UnsupportedTypes returns each task type in ts that is neither native nor mapped in
+// typeCommands, with the labels of the tasks using it.
+func UnsupportedTypes(ts []Task, typeCommands map[string]string) map[string][]string {
+	out := map[string][]string{}
+	for _, t := range ts {
+		typ := strings.ToLower(strings.TrimSpace(t.Type))
+		if IsNativeType(typ) {
+			continue
+		}
+		if _, ok := typeCommands[typ]; ok {
+			continue
+		}
+		if !slices.Contains(out[typ], t.Label) {
+			out[typ] = append(out[typ], t.Label)
+		}
+	}
+	return out
+}
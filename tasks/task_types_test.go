@@ -0,0 +1,46 @@
+package tasks
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTypeCommands_MergesDefaultsUserAndWorkspace(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+
+	if got := TypeCommands(root); got["func"] == "" {
+		t.Fatalf("func should be mapped by default, got %v", got)
+	}
+
+	writeTestFile(t, filepath.Join(xdg, "Code", "User", "settings.json"), `{
+		"vstask.taskTypes": {"gulp": "npx gulp ${script}", "bazel": "bazel run ${command}"}
+	}`)
+	writeTestFile(t, filepath.Join(root, ".vscode", "settings.json"), `{
+		"vstask.taskTypes": {"Bazel": "bazelisk run ${command}", "func": ""}
+	}`)
+	want := map[string]string{
+		"gulp":  "npx gulp ${script}",
+		"bazel": "bazelisk run ${command}",
+	}
+	if got := TypeCommands(root); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestUnsupportedTypes(t *testing.T) {
+	ts := []Task{
+		{Label: "a"},
+		{Label: "b", Type: "Shell"},
+		{Label: "c", Type: "func"},
+		{Label: "d", Type: "bazel"},
+		{Label: "e", Type: "bazel"},
+	}
+	got := UnsupportedTypes(ts, map[string]string{"func": "func ${command}"})
+	if want := map[string][]string{"bazel": {"d", "e"}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,157 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chenasraf/vstask/utils"
+)
+
+// tasksFileGenerateTimeout bounds a tasks.json generator (see TasksFileGenerator).
+const tasksFileGenerateTimeout = 30 * time.Second
+
+// tasksFileNames are the names of a tasks file, by precedence: tasks.json, VS Code's, wins over
+// a YAML one (kept, say, as the source tasks.json is generated from).
+var tasksFileNames = []string{utils.TASKS_JSON, utils.TASKS_YAML, utils.TASKS_YML}
+
+// TasksFilePath returns the path of root's tasks file: tasks.json, or else tasks.yaml or
+// tasks.yml (see LoadFile); tasks.json if there's none.
+func TasksFilePath(root string) string {
+	dir := filepath.Join(root, utils.VSCODE_DIR)
+	for _, name := range tasksFileNames {
+		if _, err := os.Lstat(filepath.Join(dir, name)); err == nil {
+			return filepath.Join(dir, name)
+		}
+	}
+	return filepath.Join(dir, utils.TASKS_JSON)
+}
+
+// TasksFileTarget returns what root's tasks file links to, if it's a symlink (whether or not
+// the target exists).
+func TasksFileTarget(root string) (string, bool) {
+	p := TasksFilePath(root)
+	info, err := os.Lstat(p)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return "", false
+	}
+	if target, err := filepath.EvalSymlinks(p); err == nil {
+		return target, true
+	}
+	target, err := os.Readlink(p)
+	if err != nil {
+		return "", false
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(p), target)
+	}
+	return target, true
+}
+
+// TasksFileGenerator returns the command generating root's tasks.json ("vstask.tasksFile.generate"),
+// from the workspace settings, then the user settings; "" if there's none. It runs a command,
+// so an untrusted workspace's own settings can't set it.
+func TasksFileGenerator(root string) string {
+	if s, ok := readSettingsFile(workspaceSettingsPath(root)); ok && s.TasksFileGenerate != "" && IsTrusted(root) {
+		return s.TasksFileGenerate
+	}
+	for _, p := range userSettingsCandidates() {
+		if s, ok := readSettingsFile(p); ok && s.TasksFileGenerate != "" {
+			return s.TasksFileGenerate
+		}
+	}
+	return ""
+}
+
+// generated remembers the roots whose tasks.json was generated, and how that went: the
+// generator runs once per invocation, however many tasks run.
+var generated sync.Map // root -> *generation
+
+type generation struct {
+	once sync.Once
+	err  error
+}
+
+// ForgetGeneratedTasksFile makes the next GenerateTasksFile for root run its generator again.
+func ForgetGeneratedTasksFile(root string) {
+	generated.Delete(root)
+}
+
+// GenerateTasksFile runs root's tasks.json generator, if it has one, once per invocation. It
+// runs a command and writes the workspace's tasks.json, so it's only for running tasks, once
+// the workspace (and the generator, see CheckPinnedGenerator) is trusted: listing them reads
+// the tasks.json last generated. How it went is reported when the tasks are loaded (see
+// loadWorkspace). A snapshot in use (see UseSnapshot) predates the new tasks.json, so it's
+// dropped.
+func GenerateTasksFile(root string) {
+	command := TasksFileGenerator(root)
+	if command == "" {
+		return
+	}
+	g, _ := generated.LoadOrStore(root, &generation{})
+	gen := g.(*generation)
+	gen.once.Do(func() {
+		gen.err = runTasksFileGenerator(root, command)
+		UseSnapshot(nil)
+	})
+}
+
+// generationError returns how root's tasks.json generator failed, if it ran and did.
+func generationError(root string) error {
+	if g, ok := generated.Load(root); ok {
+		return g.(*generation).err
+	}
+	return nil
+}
+
+// runTasksFileGenerator runs command through the shell in root. What it prints, if anything,
+// becomes tasks.json (when it parses, and differs from what's there); a generator printing
+// nothing is expected to have written the file itself.
+func runTasksFileGenerator(root, command string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), tasksFileGenerateTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd.exe", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "/bin/sh", "-c", command)
+	}
+	cmd.Dir = root
+	cmd.Env = append(os.Environ(), "VSTASK_WORKSPACE_FOLDER="+root)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", tasksFileGenerateTimeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		return fmt.Errorf("generating tasks.json (%s): %w", command, err)
+	}
+
+	out := stdout.Bytes()
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil
+	}
+	var f File
+	if err := unmarshalJSONC("generated tasks.json", out, &f); err != nil {
+		return fmt.Errorf("generating tasks.json (%s): %w", command, err)
+	}
+	p := filepath.Join(root, utils.VSCODE_DIR, utils.TASKS_JSON)
+	if cur, err := os.ReadFile(p); err == nil && bytes.Equal(cur, out) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(p, out, 0o644) // through a symlink, to its target
+}
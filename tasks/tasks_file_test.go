@@ -0,0 +1,124 @@
+package tasks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func isolateTasksFile(t *testing.T) {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("VSTASK_WORKSPACE", "")
+	t.Setenv("VSTASK_PROVIDERS", "")
+}
+
+func TestLoadWorkspace_SymlinkedTasksFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges")
+	}
+	isolateTasksFile(t)
+	root := t.TempDir()
+	target := filepath.Join(t.TempDir(), "shared.json")
+	writeTestFile(t, target, `{"tasks": [{"label": "shared"}]}`)
+	link := TasksFilePath(root)
+	if err := os.MkdirAll(filepath.Dir(link), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := loadWorkspace(root)
+	if err != nil || len(f.Tasks) != 1 || f.Tasks[0].Label != "shared" {
+		t.Fatalf("through the link: %+v, %v", f.Tasks, err)
+	}
+	if got, ok := TasksFileTarget(root); !ok || filepath.Base(got) != "shared.json" {
+		t.Fatalf("target = %q, %v", got, ok)
+	}
+
+	if err := os.Remove(target); err != nil {
+		t.Fatal(err)
+	}
+	_, err = loadWorkspace(root)
+	if err == nil || !strings.Contains(err.Error(), "is a symlink to "+target) {
+		t.Fatalf("dangling link: %v", err)
+	}
+}
+
+func TestLoadWorkspace_GeneratedTasksFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	isolateTasksFile(t)
+	t.Setenv("VSTASK_TRUST_ALL", "1")
+	root, _ := filepath.EvalSymlinks(t.TempDir())
+	t.Cleanup(func() { ForgetGeneratedTasksFile(root) })
+	writeTestFile(t, workspaceSettingsPath(root), `{
+		// prints tasks.json, counting its runs
+		"vstask.tasksFile.generate": "echo x >> runs; echo '{\"tasks\": [{\"label\": \"gen-'$(wc -l < runs | tr -d ' ')'\"}]}'"
+	}`)
+
+	// Loading the tasks doesn't run it; running them does.
+	if _, err := loadWorkspace(root); !errors.Is(err, ErrTasksFileNotFound) || !strings.Contains(err.Error(), "generated") {
+		t.Fatalf("not generated yet: %v", err)
+	}
+	for range 2 {
+		GenerateTasksFile(root)
+		f, err := loadWorkspace(root)
+		if err != nil || len(f.Tasks) != 1 || f.Tasks[0].Label != "gen-1" {
+			t.Fatalf("generated: %+v, %v", f.Tasks, err)
+		}
+	}
+	ForgetGeneratedTasksFile(root)
+	GenerateTasksFile(root)
+	if f, err := loadWorkspace(root); err != nil || f.Tasks[0].Label != "gen-2" {
+		t.Fatalf("regenerated: %+v, %v", f.Tasks, err)
+	}
+	if data, _ := os.ReadFile(TasksFilePath(root)); !strings.Contains(string(data), "gen-2") {
+		t.Fatalf("tasks.json not written: %s", data)
+	}
+
+	// Untrusted, the workspace can't run its generator.
+	t.Setenv("VSTASK_TRUST_ALL", "")
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	if got := TasksFileGenerator(root); got != "" {
+		t.Fatalf("untrusted generator = %q", got)
+	}
+}
+
+func TestLoadWorkspace_FailingGeneratorFallsBack(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX shell integration")
+	}
+	isolateTasksFile(t)
+	t.Setenv("VSTASK_TRUST_ALL", "1")
+	root, _ := filepath.EvalSymlinks(t.TempDir())
+	t.Cleanup(func() { ForgetGeneratedTasksFile(root) })
+	writeTestFile(t, workspaceSettingsPath(root), `{"vstask.tasksFile.generate": "echo broken >&2; exit 3"}`)
+
+	GenerateTasksFile(root)
+	_, err := loadWorkspace(root)
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Fatalf("without a tasks.json: %v", err)
+	}
+
+	ForgetGeneratedTasksFile(root)
+	writeTestFile(t, TasksFilePath(root), `{"tasks": [{"label": "stale"}]}`)
+	GenerateTasksFile(root)
+	if f, err := loadWorkspace(root); err != nil || f.Tasks[0].Label != "stale" {
+		t.Fatalf("falls back to the existing file: %+v, %v", f.Tasks, err)
+	}
+
+	// Output that isn't a tasks file doesn't replace it either.
+	ForgetGeneratedTasksFile(root)
+	writeTestFile(t, workspaceSettingsPath(root), `{"vstask.tasksFile.generate": "echo not json"}`)
+	GenerateTasksFile(root)
+	if f, err := loadWorkspace(root); err != nil || f.Tasks[0].Label != "stale" {
+		t.Fatalf("invalid output: %+v, %v", f.Tasks, err)
+	}
+}
@@ -0,0 +1,121 @@
+package tasks
+
+import (
+	"cmp"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/chenasraf/vstask/utils"
+)
+
+// TrustFileName is the file in the user state directory listing trusted workspace roots.
+const TrustFileName = "trusted.json"
+
+type trustStore struct {
+	Roots    []string         `json:"roots"`
+	Includes []trustedInclude `json:"remoteIncludes,omitempty"` // see TrustInclude
+}
+
+// trustedInclude is a URL a workspace's tasks file may include, with the content trusted.
+type trustedInclude struct {
+	Root string `json:"root"` // see trustKey
+	URL  string `json:"url"`
+	Hash string `json:"hash"` // sha256 of the content
+}
+
+func trustPath() (string, error) {
+	dir, err := utils.UserStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, TrustFileName), nil
+}
+
+// IsTrusted reports whether root (or one of its parents) was trusted with TrustWorkspace, or
+// $VSTASK_TRUST_ALL=1 turns the check off (e.g. in CI). Like VS Code's Workspace Trust, tasks
+// from untrusted workspaces aren't run without asking, and their own settings can't enable
+// task providers.
+func IsTrusted(root string) bool {
+	if os.Getenv("VSTASK_TRUST_ALL") == "1" {
+		return true
+	}
+	if root == "" {
+		return false
+	}
+	p, err := trustPath()
+	if err != nil {
+		return false
+	}
+	var ts trustStore
+	if err := utils.ReadJSONFile(p, &ts); err != nil {
+		return false
+	}
+	dir := trustKey(root)
+	return slices.ContainsFunc(ts.Roots, func(r string) bool { return isWithin(dir, r) })
+}
+
+// TrustWorkspace records root as trusted for the current user: a workspace folder, or a
+// .code-workspace file (see UntrustedWorkspaceFile).
+func TrustWorkspace(root string) error {
+	p, err := trustPath()
+	if err != nil {
+		return err
+	}
+	dir := trustKey(root)
+	return utils.UpdateJSONFile(p, func(ts *trustStore) error {
+		if !slices.Contains(ts.Roots, dir) {
+			ts.Roots = append(ts.Roots, dir)
+			slices.Sort(ts.Roots)
+		}
+		return nil
+	})
+}
+
+// trustKey is the absolute, symlink-free form of a root, so each directory has one entry.
+func trustKey(root string) string {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	return filepath.Clean(evalOrSelf(root))
+}
+
+// includeTrust returns the hash of the content of the URL src trusted in root (see
+// TrustInclude), if any.
+func includeTrust(root, src string) (hash string, ok bool) {
+	p, err := trustPath()
+	if err != nil {
+		return "", false
+	}
+	var ts trustStore
+	if err := utils.ReadJSONFile(p, &ts); err != nil {
+		return "", false
+	}
+	dir := trustKey(root)
+	for _, in := range ts.Includes {
+		if in.Root == dir && in.URL == src {
+			return in.Hash, true
+		}
+	}
+	return "", false
+}
+
+// TrustInclude records the URL src as one root's tasks files may include tasks from, as long
+// as its content has the sha256 hash (see UntrustedIncludes): they can run any command, so
+// like a workspace's, a shared task set is trusted before it's used, and again when it
+// changes.
+func TrustInclude(root, src, hash string) error {
+	p, err := trustPath()
+	if err != nil {
+		return err
+	}
+	dir := trustKey(root)
+	return utils.UpdateJSONFile(p, func(ts *trustStore) error {
+		ts.Includes = slices.DeleteFunc(ts.Includes, func(in trustedInclude) bool { return in.Root == dir && in.URL == src })
+		ts.Includes = append(ts.Includes, trustedInclude{Root: dir, URL: src, Hash: hash})
+		slices.SortFunc(ts.Includes, func(a, b trustedInclude) int {
+			return cmp.Or(cmp.Compare(a.Root, b.Root), cmp.Compare(a.URL, b.URL))
+		})
+		return nil
+	})
+}
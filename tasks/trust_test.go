@@ -0,0 +1,46 @@
+package tasks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chenasraf/vstask/utils"
+)
+
+func TestTrustWorkspace(t *testing.T) {
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	t.Setenv("VSTASK_TRUST_ALL", "")
+	base := t.TempDir()
+	repo := filepath.Join(base, "repo")
+	if err := os.MkdirAll(filepath.Join(repo, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if IsTrusted(repo) || IsTrusted("") {
+		t.Fatal("nothing is trusted yet")
+	}
+	if err := TrustWorkspace(repo); err != nil {
+		t.Fatal(err)
+	}
+	if err := TrustWorkspace(repo + string(filepath.Separator)); err != nil {
+		t.Fatal(err)
+	}
+	if !IsTrusted(repo) || !IsTrusted(filepath.Join(repo, "sub")) {
+		t.Fatal("repo and its subfolders should be trusted")
+	}
+	if IsTrusted(base) || IsTrusted(repo+"-fork") {
+		t.Fatal("parents and siblings are not trusted")
+	}
+
+	var ts trustStore
+	p, _ := trustPath()
+	if err := utils.ReadJSONFile(p, &ts); err != nil || len(ts.Roots) != 1 {
+		t.Fatalf("roots = %v, %v; want one entry", ts.Roots, err)
+	}
+
+	t.Setenv("VSTASK_TRUST_ALL", "1")
+	if !IsTrusted(base) {
+		t.Fatal("VSTASK_TRUST_ALL=1 trusts everything")
+	}
+}
@@ -0,0 +1,328 @@
+package tasks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chenasraf/vstask/utils"
+)
+
+// UpgradeNote is a line of UpgradeTasksFile's report: a change to a task (or, with Task "", to
+// the file), or with Manual, something left to do by hand.
+type UpgradeNote struct {
+	Task   string
+	Text   string
+	Manual bool
+}
+
+// legacyGlobals are the file-wide settings of the 0.1.0 schema, applying to every task.
+type legacyGlobals struct {
+	Command          string          `json:"command"`
+	Args             []string        `json:"args"`
+	IsShellCommand   json.RawMessage `json:"isShellCommand"` // bool | { "executable", "args" }
+	Options          json.RawMessage `json:"options"`
+	ProblemMatcher   json.RawMessage `json:"problemMatcher"`
+	ShowOutput       string          `json:"showOutput"`
+	EchoCommand      *bool           `json:"echoCommand"`
+	SuppressTaskName bool            `json:"suppressTaskName"`
+	TaskSelector     string          `json:"taskSelector"`
+}
+
+// legacyGlobalNames are the members of legacyGlobals, in the report's order.
+var legacyGlobalNames = []string{"command", "args", "isShellCommand", "options", "problemMatcher", "showOutput", "echoCommand", "suppressTaskName", "taskSelector"}
+
+// showOutputReveal maps the deprecated "showOutput" to "presentation": { "reveal" }.
+var showOutputReveal = map[string]string{"always": "always", "silent": "silent", "never": "never"}
+
+// UpgradeTasksFile rewrites the tasks.json data as a 2.0.0 file: the 0.1.0 schema's file-wide
+// command becomes each task's, and deprecated task fields (taskName, isShellCommand,
+// isBuildCommand, isTestCommand, showOutput, echoCommand, suppressTaskName, isWatching) their
+// replacements. Comments and layout are kept. No notes means there was nothing to upgrade.
+func UpgradeTasksFile(name string, data []byte) ([]byte, []UpgradeNote, error) {
+	doc, err := parseJSONCDoc(data)
+	if err != nil {
+		return nil, nil, &ParseError{File: name, Err: err}
+	}
+	root, err := doc.root()
+	if err != nil {
+		return nil, nil, &ParseError{File: name, Err: err}
+	}
+	u := upgrader{root: root}
+	if err := u.run(); err != nil {
+		return nil, nil, err
+	}
+	if len(u.notes) == 0 {
+		return data, nil, nil
+	}
+	out := doc.pack()
+	var f File
+	if err := unmarshalJSONC(name, out, &f); err != nil {
+		return nil, nil, fmt.Errorf("upgraded file doesn't parse: %w", err)
+	}
+	return out, u.notes, nil
+}
+
+type upgrader struct {
+	root  jsoncObject
+	g     legacyGlobals
+	notes []UpgradeNote
+}
+
+func (u *upgrader) note(task, format string, args ...any) {
+	u.notes = append(u.notes, UpgradeNote{Task: task, Text: fmt.Sprintf(format, args...)})
+}
+
+func (u *upgrader) manual(task, format string, args ...any) {
+	u.notes = append(u.notes, UpgradeNote{Task: task, Text: fmt.Sprintf(format, args...), Manual: true})
+}
+
+func (u *upgrader) run() error {
+	var version string
+	u.root.decode("version", &version)
+	if latest := SupportedVersions[len(SupportedVersions)-1]; compareVersions(version, latest) > 0 {
+		return fmt.Errorf("schema version %q is newer than %s: there's nothing to upgrade", version, latest)
+	}
+	var present []string
+	for _, name := range legacyGlobalNames {
+		if u.root.has(name) {
+			present = append(present, name)
+		}
+	}
+	if len(present) > 0 {
+		if err := u.root.decodeAll(&u.g); err != nil {
+			return fmt.Errorf("file-wide settings: %w", err)
+		}
+	}
+
+	tasks := u.root.objects("tasks")
+	for _, t := range tasks {
+		if err := u.task(t); err != nil {
+			return err
+		}
+	}
+	if len(tasks) == 0 && u.g.Command != "" {
+		task := Task{Label: u.g.Command, Type: shellType(u.g.IsShellCommand), Command: u.g.Command, Args: u.g.Args}
+		if err := u.root.set("tasks", []Task{task}); err != nil {
+			return err
+		}
+		u.note("", "added a task running the file-wide command %q", u.g.Command)
+	}
+
+	if len(present) > 0 {
+		for _, name := range present {
+			u.root.remove(name)
+		}
+		u.note("", "removed the file-wide %s (now set on the tasks)", quotedList(present))
+	}
+	for _, platform := range []string{"windows", "osx", "linux"} {
+		if u.root.has(platform) && (len(present) > 0 || version == "0.1.0") {
+			u.manual("", "move the file-wide %q settings into the tasks' own %q sections", platform, platform)
+		}
+	}
+	if version != "2.0.0" && (version != "" || len(u.notes) > 0) {
+		if err := u.root.set("version", "2.0.0"); err != nil {
+			return err
+		}
+		if version == "" {
+			u.note("", `set "version" to "2.0.0"`)
+		} else {
+			u.note("", `"version" %q → "2.0.0"`, version)
+		}
+	}
+	return nil
+}
+
+// task upgrades one task.
+func (u *upgrader) task(t jsoncObject) error {
+	var taskName string
+	t.decode("taskName", &taskName)
+	label := taskName
+	if !t.decode("label", &label) && t.has("taskName") {
+		t.rename("taskName", "label")
+		u.note(label, `"taskName" → "label"`)
+	} else if t.remove("taskName") {
+		u.note(label, `removed "taskName" (the task has a "label")`)
+	}
+
+	suppress := u.g.SuppressTaskName
+	t.decode("suppressTaskName", &suppress)
+	legacyCommand := !t.has("command") && u.g.Command != ""
+	if legacyCommand {
+		args := append([]string(nil), u.g.Args...)
+		if !suppress && taskName != "" {
+			args = append(args, u.g.TaskSelector+taskName)
+		}
+		var own []string
+		t.decode("args", &own)
+		args = append(args, own...)
+		if err := t.set("command", u.g.Command); err != nil {
+			return err
+		}
+		if len(args) > 0 {
+			if err := t.set("args", args); err != nil {
+				return err
+			}
+		}
+		u.note(label, "runs %s (the file-wide command)", shellQuoteArgs(append([]string{u.g.Command}, args...)))
+	}
+	if t.remove("suppressTaskName") {
+		u.note(label, `removed "suppressTaskName"`)
+	}
+
+	shell := u.g.IsShellCommand
+	if raw := t.raw("isShellCommand"); raw != nil {
+		shell = raw
+		t.remove("isShellCommand")
+		u.note(label, `removed "isShellCommand"`)
+	}
+	if !t.has("type") && (legacyCommand || shell != nil) {
+		typ := shellType(shell)
+		if err := t.set("type", typ); err != nil {
+			return err
+		}
+		u.note(label, `"type": %q`, typ)
+	}
+	if len(u.g.Options) > 0 && !t.has("options") {
+		if err := t.set("options", u.g.Options); err != nil {
+			return err
+		}
+		u.note(label, `"options" from the file-wide ones`)
+	}
+	var shellObj map[string]any
+	if json.Unmarshal(shell, &shellObj) == nil && shellObj != nil {
+		if err := u.setIn(t, "options", "shell", shellObj); err != nil {
+			return err
+		}
+		u.note(label, `"options": { "shell" } from "isShellCommand"`)
+	}
+	if len(u.g.ProblemMatcher) > 0 && !t.has("problemMatcher") {
+		if err := t.set("problemMatcher", u.g.ProblemMatcher); err != nil {
+			return err
+		}
+		u.note(label, `"problemMatcher" from the file-wide one`)
+	}
+
+	showOutput := u.g.ShowOutput
+	t.decode("showOutput", &showOutput)
+	t.remove("showOutput")
+	if showOutput != "" {
+		if reveal, ok := showOutputReveal[showOutput]; ok {
+			if err := u.setIn(t, "presentation", "reveal", reveal); err != nil {
+				return err
+			}
+			u.note(label, `"showOutput" → "presentation": { "reveal": %q }`, reveal)
+		} else {
+			u.manual(label, `"showOutput" %q has no equivalent; set "presentation" by hand`, showOutput)
+		}
+	}
+	echo := u.g.EchoCommand
+	if own := false; t.decode("echoCommand", &own) {
+		echo = &own
+	}
+	t.remove("echoCommand")
+	if echo != nil {
+		if err := u.setIn(t, "presentation", "echo", *echo); err != nil {
+			return err
+		}
+		u.note(label, `"echoCommand" → "presentation": { "echo": %t }`, *echo)
+	}
+
+	if t.has("isWatching") {
+		if t.has("isBackground") {
+			t.remove("isWatching")
+		} else {
+			t.rename("isWatching", "isBackground")
+		}
+		u.note(label, `"isWatching" → "isBackground"`)
+	}
+	for _, kind := range []string{"build", "test"} {
+		field := "is" + strings.ToUpper(kind[:1]) + kind[1:] + "Command"
+		var on bool
+		t.decode(field, &on)
+		if !t.remove(field) {
+			continue
+		}
+		switch {
+		case !on:
+			u.note(label, "removed %q", field)
+		case t.has("group"):
+			u.note(label, `removed %q (the task has a "group")`, field)
+		default:
+			if err := t.set("group", json.RawMessage(`{"kind":"`+kind+`","isDefault":true}`)); err != nil {
+				return err
+			}
+			u.note(label, `%q → "group": { "kind": %q, "isDefault": true }`, field, kind)
+		}
+	}
+	return nil
+}
+
+// setIn sets t's member obj.name, adding obj if t hasn't got it.
+func (u *upgrader) setIn(t jsoncObject, obj, name string, v any) error {
+	if o, ok := t.object(obj); ok {
+		return o.set(name, v)
+	}
+	return t.set(obj, map[string]any{name: v})
+}
+
+// shellType is the 2.0.0 "type" of a task with the deprecated "isShellCommand" (true, or the
+// shell to use): "process", 0.1.0's default, without it.
+func shellType(isShellCommand json.RawMessage) string {
+	var on bool
+	if isShellCommand == nil || (json.Unmarshal(isShellCommand, &on) == nil && !on) {
+		return "process"
+	}
+	return "shell"
+}
+
+func quotedList(names []string) string {
+	q := make([]string, len(names))
+	for i, n := range names {
+		q[i] = fmt.Sprintf("%q", n)
+	}
+	return strings.Join(q, ", ")
+}
+
+// shellQuoteArgs renders a command line for the report.
+func shellQuoteArgs(args []string) string {
+	out := make([]string, len(args))
+	for i, a := range args {
+		if a == "" || strings.ContainsAny(a, " \t\"'\\$") {
+			a = fmt.Sprintf("%q", a)
+		}
+		out[i] = a
+	}
+	return strings.Join(out, " ")
+}
+
+// UpgradeSchema upgrades root's tasks.json (see UpgradeTasksFile), writing it (through a
+// symlink, to its target) unless dryRun. It returns the file's path and the report.
+func UpgradeSchema(root string, dryRun bool) (string, []UpgradeNote, error) {
+	p := TasksFilePath(root)
+	if isYAMLFile(p) {
+		return p, nil, fmt.Errorf("%s: only tasks.json files can be upgraded", filepath.Base(p))
+	}
+	if TasksFileGenerator(root) != "" {
+		return p, nil, errors.New(`tasks.json is generated ("vstask.tasksFile.generate"): upgrade what generates it`)
+	}
+	data, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil, errors.New("tasks.json not found")
+	}
+	if err != nil {
+		return p, nil, err
+	}
+	out, notes, err := UpgradeTasksFile(p, data)
+	if err != nil || len(notes) == 0 || dryRun {
+		return p, notes, err
+	}
+	target := p
+	if t, err := filepath.EvalSymlinks(p); err == nil {
+		target = t
+	}
+	return p, notes, utils.WriteFileAtomic(target, out, 0o644)
+}
@@ -0,0 +1,165 @@
+package tasks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpgradeTasksFile_Legacy(t *testing.T) {
+	in := `{
+	// See https://go.microsoft.com/fwlink/?LinkId=733558
+	"version": "0.1.0",
+	"command": "gulp",
+	"isShellCommand": true,
+	"args": ["--no-color"],
+	"showOutput": "silent",
+	"tasks": [
+		{
+			// the default build
+			"taskName": "build",
+			"isBuildCommand": true,
+			"problemMatcher": "$tsc"
+		},
+		{
+			"taskName": "test",
+			"args": ["--watch"],
+			"isTestCommand": true,
+			"showOutput": "always" // keep an eye on it
+		},
+		{
+			"taskName": "clean",
+			"suppressTaskName": true,
+			"args": ["clean:all"],
+			"isWatching": false
+		}
+	]
+}
+`
+	want := `{
+	// See https://go.microsoft.com/fwlink/?LinkId=733558
+	"version": "2.0.0",
+	"tasks": [
+		{
+			// the default build
+			"label": "build",
+			"problemMatcher": "$tsc",
+			"command": "gulp",
+			"args": ["--no-color", "build"],
+			"type": "shell",
+			"presentation": { "reveal": "silent" },
+			"group": { "kind": "build", "isDefault": true }
+		},
+		{
+			"label": "test",
+			"args": ["--no-color", "test", "--watch"],
+			"command": "gulp",
+			"type": "shell",
+			"presentation": { "reveal": "always" },
+			"group": { "kind": "test", "isDefault": true }
+		},
+		{
+			"label": "clean",
+			"args": ["--no-color", "clean:all"],
+			"isBackground": false,
+			"command": "gulp",
+			"type": "shell",
+			"presentation": { "reveal": "silent" }
+		}
+	]
+}
+`
+	out, notes, err := UpgradeTasksFile("tasks.json", []byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", out, want)
+	}
+	var report []string
+	for _, n := range notes {
+		if n.Manual {
+			t.Errorf("unexpected manual step: %+v", n)
+		}
+		report = append(report, n.Task+": "+n.Text)
+	}
+	for _, line := range []string{
+		`build: "taskName" → "label"`,
+		`build: runs gulp --no-color build (the file-wide command)`,
+		`test: "isTestCommand" → "group": { "kind": "test", "isDefault": true }`,
+		`clean: removed "suppressTaskName"`,
+		`: "version" "0.1.0" → "2.0.0"`,
+	} {
+		if !strings.Contains(strings.Join(report, "\n"), line) {
+			t.Errorf("report lacks %q:\n%s", line, strings.Join(report, "\n"))
+		}
+	}
+
+	again, notes, err := UpgradeTasksFile("tasks.json", out)
+	if err != nil || len(notes) != 0 || string(again) != string(out) {
+		t.Fatalf("upgrading twice: %v, %+v", err, notes)
+	}
+}
+
+func TestUpgradeTasksFile_DeprecatedFields(t *testing.T) {
+	in := `{
+  "version": "2.0.0",
+  "tasks": [
+    { "label": "lint", "type": "shell", "command": "make lint", "taskName": "lint", "echoCommand": true, "presentation": { "panel": "dedicated" } },
+    { "label": "serve", "command": "npm start", "isShellCommand": false, "isWatching": true, "isBuildCommand": false },
+  ],
+}
+`
+	want := `{
+  "version": "2.0.0",
+  "tasks": [
+    { "label": "lint", "type": "shell", "command": "make lint", "presentation": { "panel": "dedicated", "echo": true } },
+    { "label": "serve", "command": "npm start", "isBackground": true, "type": "process" },
+  ],
+}
+`
+	out, _, err := UpgradeTasksFile("tasks.json", []byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestUpgradeSchema(t *testing.T) {
+	isolateTasksFile(t)
+	root := t.TempDir()
+	p := filepath.Join(root, ".vscode", "tasks.json")
+	legacy := `{"version": "0.1.0", "command": "make", "tasks": [{"taskName": "all"}]}`
+	writeTestFile(t, p, legacy)
+
+	if _, notes, err := UpgradeSchema(root, true); err != nil || len(notes) == 0 {
+		t.Fatalf("dry run: %+v, %v", notes, err)
+	}
+	if b, _ := os.ReadFile(p); string(b) != legacy {
+		t.Fatalf("dry run wrote the file:\n%s", b)
+	}
+	if _, _, err := UpgradeSchema(root, false); err != nil {
+		t.Fatal(err)
+	}
+	f, err := LoadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Version != "2.0.0" || len(f.Tasks) != 1 || f.Tasks[0].Label != "all" || f.Tasks[0].Command != "make" || f.Tasks[0].Args[0] != "all" {
+		t.Fatalf("upgraded: %+v", f)
+	}
+	if _, notes, err := UpgradeSchema(root, false); err != nil || len(notes) != 0 {
+		t.Fatalf("already upgraded: %+v, %v", notes, err)
+	}
+
+	writeTestFile(t, filepath.Join(root, ".vscode", "tasks.json"), `{"version": "0.1.0", "command": "make"}`)
+	if _, _, err := UpgradeSchema(root, false); err != nil {
+		t.Fatal(err)
+	}
+	if f, err := LoadFile(p); err != nil || len(f.Tasks) != 1 || f.Tasks[0].Label != "make" || f.Tasks[0].Type != "process" {
+		t.Fatalf("a command without tasks: %+v, %v", f, err)
+	}
+}
@@ -0,0 +1,61 @@
+package tasks
+
+import (
+	"fmt"
+	"time"
+)
+
+// Usage is how often, and when last, the user ran a task in a workspace. It's kept in the run
+// history (see TaskHistory).
+type Usage struct {
+	Runs int       `json:"runs"`
+	Last time.Time `json:"last"`
+}
+
+// RecordUsage counts a run of the task labelled label (one the user ran, not a dependency).
+func RecordUsage(root, label string) error {
+	return UpdateHistory(root, label, func(h *TaskHistory) {
+		h.Usage.Runs++
+		h.Usage.Last = time.Now()
+	})
+}
+
+// TaskUsage returns the usage of root's tasks by label; tasks never run aren't in it.
+func TaskUsage(root string) map[string]Usage {
+	u := map[string]Usage{}
+	for label, h := range LoadHistory(root) {
+		if h.Usage.Runs > 0 {
+			u[label] = h.Usage
+		}
+	}
+	return u
+}
+
+// String is e.g. "12 runs, 3h ago", or "" for a task never run.
+func (u Usage) String() string {
+	if u.Runs == 0 {
+		return ""
+	}
+	runs := "1 run"
+	if u.Runs != 1 {
+		runs = fmt.Sprintf("%d runs", u.Runs)
+	}
+	if u.Last.IsZero() {
+		return runs
+	}
+	return runs + ", " + ago(time.Since(u.Last))
+}
+
+// ago is a rough, short rendering of how long ago something happened.
+func ago(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d/time.Hour))
+	default:
+		return fmt.Sprintf("%dd ago", int(d/(24*time.Hour)))
+	}
+}
@@ -0,0 +1,52 @@
+package tasks
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordUsage(t *testing.T) {
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	root := t.TempDir()
+	if got := TaskUsage(root); len(got) != 0 {
+		t.Fatalf("no usage yet: %v", got)
+	}
+	for _, label := range []string{"build", "test", "build"} {
+		if err := RecordUsage(root, label); err != nil {
+			t.Fatal(err)
+		}
+	}
+	u := TaskUsage(root)
+	if u["build"].Runs != 2 || u["test"].Runs != 1 {
+		t.Fatalf("got %+v", u)
+	}
+	if time.Since(u["build"].Last) > time.Minute {
+		t.Fatalf("last run time not recorded: %v", u["build"].Last)
+	}
+
+	// Usage shares the run history with the durations the runner records.
+	if err := UpdateHistory(root, "build", func(h *TaskHistory) { h.Runs, h.Expected = 1, time.Second }); err != nil {
+		t.Fatal(err)
+	}
+	if h := LoadHistory(root)["build"]; h.Usage.Runs != 2 || h.Expected != time.Second {
+		t.Fatalf("history %+v", h)
+	}
+}
+
+func TestUsageString(t *testing.T) {
+	now := time.Now()
+	for _, tc := range []struct {
+		u    Usage
+		want string
+	}{
+		{Usage{}, ""},
+		{Usage{Runs: 1, Last: now}, "1 run, just now"},
+		{Usage{Runs: 12, Last: now.Add(-3*time.Hour - time.Minute)}, "12 runs, 3h ago"},
+		{Usage{Runs: 2, Last: now.Add(-50 * time.Hour)}, "2 runs, 2d ago"},
+		{Usage{Runs: 4}, "4 runs"},
+	} {
+		if got := tc.u.String(); got != tc.want {
+			t.Errorf("%+v: got %q, want %q", tc.u, got, tc.want)
+		}
+	}
+}
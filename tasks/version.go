@@ -0,0 +1,80 @@
+package tasks
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// SupportedVersions are the tasks.json schema versions vstask reads. A file without a
+// "version" is read as the latest.
+var SupportedVersions = []string{"2.0.0"}
+
+// forceVersion makes tasks files declaring other versions load anyway (see ForceVersion).
+var forceVersion atomic.Bool
+
+// ForceVersion makes tasks files declaring a schema version vstask doesn't support load
+// anyway, as if they were 2.0.0 ones (--force).
+func ForceVersion() {
+	forceVersion.Store(true)
+}
+
+// reVersionKey finds the line declaring the version, in JSON or YAML.
+var reVersionKey = regexp.MustCompile(`(?m)^\s*\{?\s*"?version"?\s*:`)
+
+// checkVersion fails with a *ParseError when the tasks file name (read from data) declares a
+// version vstask doesn't support, rather than decoding fields that may mean something else.
+func checkVersion(name string, data []byte, version string) error {
+	if version == "" || forceVersion.Load() {
+		return nil
+	}
+	for _, v := range SupportedVersions {
+		if version == v {
+			return nil
+		}
+	}
+	supported := strings.Join(SupportedVersions, ", ")
+	var err error
+	switch latest := SupportedVersions[len(SupportedVersions)-1]; compareVersions(version, latest) {
+	case -1:
+		err = fmt.Errorf(`schema version %q is older than vstask supports (%s): run "vstask upgrade-schema" to upgrade the file, or pass --force to read it anyway`, version, supported)
+	case 1:
+		err = fmt.Errorf(`schema version %q is newer than vstask supports (%s): update vstask, or pass --force to read it anyway`, version, supported)
+	default:
+		err = fmt.Errorf(`unknown schema version %q (vstask supports %s): fix the "version", run "vstask upgrade-schema" if it's an old file, or pass --force to read it anyway`, version, supported)
+	}
+	pe := &ParseError{File: name, Err: err}
+	if loc := reVersionKey.FindIndex(data); loc != nil {
+		pe.Line = strings.Count(string(data[:loc[1]]), "\n") + 1
+	}
+	return pe
+}
+
+// compareVersions compares the dotted versions a and b: -1, 0 or 1, or 0 when either isn't
+// one (e.g., "2.0.0-beta").
+func compareVersions(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < max(len(pa), len(pb)); i++ {
+		var x, y int
+		var err error
+		if i < len(pa) {
+			if x, err = strconv.Atoi(pa[i]); err != nil {
+				return 0
+			}
+		}
+		if i < len(pb) {
+			if y, err = strconv.Atoi(pb[i]); err != nil {
+				return 0
+			}
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
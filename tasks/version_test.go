@@ -0,0 +1,56 @@
+package tasks
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckVersion(t *testing.T) {
+	data := []byte("{\n  // old\n  \"version\": \"0.1.0\"\n}")
+	for _, tc := range []struct {
+		version, want string
+	}{
+		{"", ""},
+		{"2.0.0", ""},
+		{"0.1.0", "older than vstask supports (2.0.0): run \"vstask upgrade-schema\""},
+		{"2.1.0", "newer than vstask supports (2.0.0): update vstask"},
+		{"two", "unknown schema version"},
+	} {
+		err := checkVersion("tasks.json", data, tc.version)
+		if tc.want == "" {
+			if err != nil {
+				t.Errorf("%q: %v", tc.version, err)
+			}
+			continue
+		}
+		var pe *ParseError
+		if !errors.As(err, &pe) || pe.Line != 3 || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%q: got %v, want a ParseError on line 3 with %q", tc.version, err, tc.want)
+		}
+	}
+
+	ForceVersion()
+	defer forceVersion.Store(false)
+	if err := checkVersion("tasks.json", data, "3.0.0"); err != nil {
+		t.Errorf("forced: %v", err)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"0.1.0", "2.0.0", -1},
+		{"2.0.0", "2.0.0", 0},
+		{"2.0.1", "2.0.0", 1},
+		{"10.0.0", "2.0.0", 1},
+		{"2.0", "2.0.0", 0},
+		{"2.0.0-beta", "2.0.0", 0},
+	} {
+		if got := compareVersions(tc.a, tc.b); got != tc.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
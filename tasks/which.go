@@ -0,0 +1,116 @@
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Definition is one place a task label is defined.
+type Definition struct {
+	Source string // tasks file, included file (or URL) or .code-workspace path, or "provider <name>"
+	Line   int    // line of the label in Source (0 if unknown)
+	Task   Task
+}
+
+func (d Definition) String() string {
+	if d.Line > 0 {
+		return fmt.Sprintf("%s:%d", d.Source, d.Line)
+	}
+	return d.Source
+}
+
+// WhichTask returns every definition of the task labelled label in root, the one vstask runs
+// first: tasks.json entries in file order (the first wins), then those of the files it
+// includes, then the .code-workspace file's tasks, then the tasks of the enabled providers in
+// the order they're merged. Each source shadows the ones after it.
+func WhichTask(root, label string) ([]Definition, error) {
+	var defs []Definition
+	p := TasksFilePath(root)
+	if _, err := os.Stat(p); err == nil {
+		file, err := LoadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		lines := labelLines(p, label)
+		for _, t := range file.Tasks {
+			if t.Label != label {
+				continue
+			}
+			d := Definition{Source: p, Task: t}
+			if len(lines) > 0 {
+				d.Line, lines = lines[0], lines[1:]
+			}
+			defs = append(defs, d)
+		}
+		vars := templateVariables(root)
+		err = walkIncludes(file, p, root, vars, map[string]bool{p: true}, 0, func(src string, inc File) {
+			var lines []int
+			if !IsRemoteInclude(src) {
+				if data, err := readTasksFileJSON(src); err == nil {
+					lines = labelLinesIn(fillTemplate(src, data, vars), label)
+				}
+			}
+			for _, t := range inc.Tasks {
+				if t.Label != label {
+					continue
+				}
+				t.Origin = OriginInclude
+				d := Definition{Source: src, Task: t}
+				if len(lines) > 0 {
+					d.Line, lines = lines[0], lines[1:]
+				}
+				defs = append(defs, d)
+			}
+		}, warnUntrustedInclude)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if wf, p := workspaceFileTasks(root); p != "" {
+		lines := labelLines(p, label)
+		for _, t := range wf.Tasks {
+			if t.Label != label {
+				continue
+			}
+			d := Definition{Source: p, Task: t}
+			if len(lines) > 0 {
+				d.Line, lines = lines[0], lines[1:]
+			}
+			defs = append(defs, d)
+		}
+	}
+	ps := activeProviders(root)
+	for i, ts := range detectTasksOnce(root, ps) {
+		for _, t := range ts {
+			if t.Label == label {
+				defs = append(defs, Definition{Source: "provider " + ps[i].Name, Task: t})
+			}
+		}
+	}
+	return defs, nil
+}
+
+// labelLines returns the lines of the file at p where a task is labelled label, in order.
+func labelLines(p, label string) []int {
+	data, err := readTasksFileJSON(p)
+	if err != nil {
+		return nil
+	}
+	return labelLinesIn(data, label)
+}
+
+// labelLinesIn returns the lines of a tasks file's JSON data where a task is labelled label.
+func labelLinesIn(data []byte, label string) []int {
+	quoted, _ := json.Marshal(label)
+	re := regexp.MustCompile(`"label"\s*:\s*` + regexp.QuoteMeta(string(quoted)))
+	var lines []int
+	for i, l := range strings.Split(string(data), "\n") {
+		if re.MatchString(l) {
+			lines = append(lines, i+1)
+		}
+	}
+	return lines
+}
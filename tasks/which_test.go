@@ -0,0 +1,52 @@
+package tasks
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestWhichTask(t *testing.T) {
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	root := t.TempDir()
+	p := filepath.Join(root, ".vscode", "tasks.json")
+	writeTestFile(t, p, `{
+  "version": "2.0.0",
+  "tasks": [
+    { "label": "npm: build", "command": "make" },
+    { "label": "test", "command": "go test" },
+    {
+      "label": "npm: build",
+      "command": "make again"
+    }
+  ]
+}`)
+	RegisterProvider(Provider{Name: "whichtest", Detect: func(context.Context, string) ([]Task, error) {
+		return []Task{{Label: "npm: build", Command: "npm run build"}, {Label: "npm: lint"}}, nil
+	}})
+	t.Setenv("VSTASK_PROVIDERS", "whichtest")
+
+	defs, err := WhichTask(root, "npm: build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{p + ":4", p + ":7", "provider whichtest"}
+	if len(defs) != len(want) {
+		t.Fatalf("got %v", defs)
+	}
+	for i, d := range defs {
+		if d.String() != want[i] {
+			t.Errorf("definition %d = %s, want %s", i, d, want[i])
+		}
+	}
+	if defs[0].Task.Command != "make" || defs[2].Task.Command != "npm run build" {
+		t.Fatalf("wrong tasks: %+v", defs)
+	}
+
+	if defs, err := WhichTask(root, "npm: lint"); err != nil || len(defs) != 1 || defs[0].Source != "provider whichtest" {
+		t.Fatalf("npm: lint = %v, %v", defs, err)
+	}
+	if defs, err := WhichTask(root, "nope"); err != nil || len(defs) != 0 {
+		t.Fatalf("nope = %v, %v", defs, err)
+	}
+}
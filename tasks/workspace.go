@@ -0,0 +1,210 @@
+package tasks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// WorkspaceFolder is one root of a multi-root (.code-workspace) workspace.
+type WorkspaceFolder struct {
+	Name string // "name" from the workspace file, or the folder's basename
+	Path string // absolute path
+}
+
+// Workspace is a parsed .code-workspace file.
+type Workspace struct {
+	File    string
+	Folders []WorkspaceFolder
+	Tasks   File // the file's own "tasks" section, if any (tasks marked OriginWorkspace)
+}
+
+// LoadWorkspaceFile parses a (JSONC) .code-workspace file. Folder paths are
+// resolved relative to the file's directory.
+func LoadWorkspaceFile(p string) (*Workspace, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	var raw struct {
+		Folders []struct {
+			Name string `json:"name"`
+			Path string `json:"path"`
+		} `json:"folders"`
+		Tasks File `json:"tasks"`
+	}
+	if err := unmarshalJSONC(p, data, &raw); err != nil {
+		return nil, err
+	}
+
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return nil, err
+	}
+	ws := &Workspace{File: abs, Tasks: raw.Tasks}
+	for i := range ws.Tasks.Tasks {
+		ws.Tasks.Tasks[i].Origin = OriginWorkspace
+	}
+	base := filepath.Dir(abs)
+	for _, f := range raw.Folders {
+		if f.Path == "" {
+			continue
+		}
+		fp := filepath.FromSlash(f.Path)
+		if !filepath.IsAbs(fp) {
+			fp = filepath.Join(base, fp)
+		}
+		fp = filepath.Clean(fp)
+		name := f.Name
+		if name == "" {
+			name = filepath.Base(fp)
+		}
+		ws.Folders = append(ws.Folders, WorkspaceFolder{Name: name, Path: fp})
+	}
+	return ws, nil
+}
+
+// FindWorkspace looks for a *.code-workspace file in dir and its parents and
+// returns the first one that lists dir (or one of its parents) as a folder.
+// $VSTASK_WORKSPACE may point at a workspace file explicitly.
+// Returns (nil, nil) when dir isn't part of a multi-root workspace.
+func FindWorkspace(dir string) (*Workspace, error) {
+	if p := os.Getenv("VSTASK_WORKSPACE"); p != "" {
+		return LoadWorkspaceFile(p)
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	real := evalOrSelf(dir)
+	for cur := dir; ; {
+		matches, _ := filepath.Glob(filepath.Join(cur, "*.code-workspace"))
+		slices.Sort(matches)
+		for _, m := range matches {
+			ws, err := LoadWorkspaceFile(m)
+			if err != nil {
+				continue
+			}
+			if ws.FolderFor(real) != nil {
+				return ws, nil
+			}
+		}
+		parent := filepath.Dir(cur)
+		if parent == cur {
+			return nil, nil
+		}
+		cur = parent
+	}
+}
+
+// Folder returns the folder with the given name (case-insensitive), or nil.
+func (w *Workspace) Folder(name string) *WorkspaceFolder {
+	if w == nil {
+		return nil
+	}
+	for i := range w.Folders {
+		if strings.EqualFold(w.Folders[i].Name, name) {
+			return &w.Folders[i]
+		}
+	}
+	return nil
+}
+
+// FolderFor returns the folder containing dir (the deepest match), or nil.
+func (w *Workspace) FolderFor(dir string) *WorkspaceFolder {
+	if w == nil {
+		return nil
+	}
+	dir = evalOrSelf(dir)
+	var best *WorkspaceFolder
+	bestPath := ""
+	for i := range w.Folders {
+		fp := evalOrSelf(w.Folders[i].Path)
+		rel, err := filepath.Rel(fp, dir)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if best == nil || len(fp) > len(bestPath) {
+			best, bestPath = &w.Folders[i], fp
+		}
+	}
+	return best
+}
+
+// workspaceFileTasks returns the tasks and inputs defined in the .code-workspace file root
+// belongs to, if any. A file outside root is only used once it's trusted (see
+// UntrustedWorkspaceFile); until then it's left out, with a warning.
+func workspaceFileTasks(root string) (File, string) {
+	ws, err := FindWorkspace(root)
+	if err != nil || ws == nil {
+		return File{}, ""
+	}
+	if !workspaceFileTrusted(ws, root) {
+		if len(ws.Tasks.Tasks) > 0 || len(ws.Tasks.Inputs) > 0 {
+			if _, warned := warnedWorkspaceFiles.LoadOrStore(ws.File, true); !warned {
+				fmt.Fprintf(os.Stderr, "Warning: not using the tasks of %s: it isn't trusted yet (run a task to be asked)\n", ws.File)
+			}
+		}
+		return File{}, ""
+	}
+	return ws.Tasks, ws.File
+}
+
+// warnedWorkspaceFiles are the untrusted workspace files already warned about.
+var warnedWorkspaceFiles sync.Map
+
+// UntrustedWorkspaceFile returns the .code-workspace file root belongs to if it defines tasks
+// (or inputs) that can't be used yet: it's outside root, and neither it nor a folder it's in
+// is trusted (see IsTrusted). Trusting root doesn't cover it; TrustWorkspace(file) does.
+func UntrustedWorkspaceFile(root string) string {
+	ws, err := FindWorkspace(root)
+	if err != nil || ws == nil || (len(ws.Tasks.Tasks) == 0 && len(ws.Tasks.Inputs) == 0) || workspaceFileTrusted(ws, root) {
+		return ""
+	}
+	return ws.File
+}
+
+func workspaceFileTrusted(ws *Workspace, root string) bool {
+	return isWithin(evalOrSelf(ws.File), trustKey(root)) || IsTrusted(ws.File)
+}
+
+// withWorkspaceFileTasks adds the tasks and inputs of root's .code-workspace file to f, after
+// its own. The folder's own definitions win when labels (or input ids) collide.
+func withWorkspaceFileTasks(f File, root string) File {
+	wf, _ := workspaceFileTasks(root)
+	if len(wf.Tasks) == 0 && len(wf.Inputs) == 0 {
+		return f
+	}
+	f.Tasks = mergeProvided(f.Tasks, wf.Tasks)
+	for _, in := range wf.Inputs {
+		if !slices.ContainsFunc(f.Inputs, func(own Input) bool { return own.ID == in.ID }) {
+			f.Inputs = append(f.Inputs, in)
+		}
+	}
+	return f
+}
+
+// LoadFolderTasks loads the tasks file (tasks and inputs, included ones too) of a workspace
+// folder.
+func LoadFolderTasks(f WorkspaceFolder) (File, error) {
+	return loadTasksFile(TasksFilePath(f.Path))
+}
+
+func evalOrSelf(p string) string {
+	if r, err := filepath.EvalSymlinks(p); err == nil {
+		return r
+	}
+	return p
+}
+
+// isWithin reports whether dir is root or inside it; nothing is within "".
+func isWithin(dir, root string) bool {
+	if root == "" {
+		return false
+	}
+	rel, err := filepath.Rel(root, dir)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,199 @@
+package tasks
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// ---- dependsOn forms ----
+
+func TestDependsOn_Unmarshal(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []TaskRef
+	}{
+		{`"build"`, []TaskRef{{Label: "build"}}},
+		{`["build", "api: lint"]`, []TaskRef{{Label: "build"}, {Label: "api: lint"}}},
+		{`{"label": "build", "folder": "api"}`, []TaskRef{{Label: "build", Folder: "api"}}},
+		{`[{"task": "build", "folder": "api"}, "test"]`, []TaskRef{{Label: "build", Folder: "api"}, {Label: "test"}}},
+		{`{"tasks": ["a", "b"]}`, []TaskRef{{Label: "a"}, {Label: "b"}}},
+		{`["build", {"label": "lint", "optional": true}]`, []TaskRef{{Label: "build"}, {Label: "lint", Optional: true}}},
+	}
+	for _, c := range cases {
+		var d DependsOn
+		if err := json.Unmarshal([]byte(c.in), &d); err != nil {
+			t.Fatalf("%s: %v", c.in, err)
+		}
+		if !reflect.DeepEqual(d.Tasks, c.want) {
+			t.Errorf("%s: got %+v, want %+v", c.in, d.Tasks, c.want)
+		}
+	}
+}
+
+func TestDependsOn_MarshalRoundTrip(t *testing.T) {
+	d := DependsOn{Tasks: []TaskRef{{Label: "build"}, {Label: "lint", Folder: "web"}, {Label: "e2e", Optional: true}}}
+	b, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), `["build",{"label":"lint","folder":"web"},{"label":"e2e","optional":true}]`; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+	var back DependsOn
+	if err := json.Unmarshal(b, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, d) {
+		t.Fatalf("round trip: got %+v", back)
+	}
+}
+
+// ---- workspace files ----
+
+func writeWorkspace(t *testing.T, dir, content string) string {
+	t.Helper()
+	p := filepath.Join(dir, "proj.code-workspace")
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestLoadWorkspaceFile(t *testing.T) {
+	dir := t.TempDir()
+	p := writeWorkspace(t, dir, `{
+		// comments are fine
+		"folders": [
+			{ "path": "api" },
+			{ "path": "./web", "name": "Frontend" },
+		],
+	}`)
+	ws, err := LoadWorkspaceFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []WorkspaceFolder{
+		{Name: "api", Path: filepath.Join(dir, "api")},
+		{Name: "Frontend", Path: filepath.Join(dir, "web")},
+	}
+	if !reflect.DeepEqual(ws.Folders, want) {
+		t.Fatalf("got %+v, want %+v", ws.Folders, want)
+	}
+	if ws.Folder("frontend") == nil {
+		t.Fatal("folder lookup should be case-insensitive")
+	}
+}
+
+func TestFindWorkspace(t *testing.T) {
+	t.Setenv("VSTASK_WORKSPACE", "")
+	dir := t.TempDir()
+	api := filepath.Join(dir, "api")
+	if err := os.MkdirAll(filepath.Join(api, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeWorkspace(t, dir, `{"folders": [{"path": "api"}, {"path": "web"}]}`)
+
+	ws, err := FindWorkspace(filepath.Join(api, "src"))
+	if err != nil || ws == nil {
+		t.Fatalf("expected workspace, got %v, %v", ws, err)
+	}
+	if f := ws.FolderFor(api); f == nil || f.Name != "api" {
+		t.Fatalf("FolderFor: got %+v", f)
+	}
+
+	// A directory not listed in the workspace isn't part of it.
+	other := filepath.Join(dir, "other")
+	if err := os.MkdirAll(other, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if ws, err := FindWorkspace(other); err != nil || ws != nil {
+		t.Fatalf("expected no workspace, got %+v, %v", ws, err)
+	}
+}
+
+func TestLoadWorkspace_WorkspaceFileTasks(t *testing.T) {
+	t.Setenv("VSTASK_WORKSPACE", "")
+	t.Setenv("VSTASK_PROVIDERS", "")
+	dir := t.TempDir()
+	api := filepath.Join(dir, "api")
+	writeTestFile(t, filepath.Join(api, ".vscode", "tasks.json"), `{
+		"version": "2.0.0",
+		"tasks": [{ "label": "build", "command": "go build", "origin": "spoofed" }],
+		"inputs": [{ "id": "env", "type": "promptString" }]
+	}`)
+	p := writeWorkspace(t, dir, `{
+		"folders": [{ "path": "api" }],
+		"tasks": {
+			"version": "2.0.0",
+			"tasks": [
+				{ "label": "build", "command": "make all" },
+				{ "label": "up", "command": "docker compose up -d ${input:profile}" }
+			],
+			"inputs": [{ "id": "env", "type": "pickString" }, { "id": "profile", "type": "promptString" }]
+		}
+	}`)
+
+	// Outside the folder, the workspace file is used once it's trusted itself.
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	t.Setenv("VSTASK_TRUST_ALL", "")
+	if err := TrustWorkspace(api); err != nil {
+		t.Fatal(err)
+	}
+	f, err := loadWorkspace(api)
+	if err != nil || !reflect.DeepEqual(labels(f.Tasks), []string{"build"}) || len(f.Inputs) != 1 {
+		t.Fatalf("untrusted workspace file: %+v, %v", f, err)
+	}
+	if got := UntrustedWorkspaceFile(api); got != p {
+		t.Fatalf("untrusted workspace file = %q, want %q", got, p)
+	}
+	if err := TrustWorkspace(p); err != nil {
+		t.Fatal(err)
+	}
+	if got := UntrustedWorkspaceFile(api); got != "" {
+		t.Fatalf("trusted workspace file = %q", got)
+	}
+
+	f, err = loadWorkspace(api)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := labels(f.Tasks); !reflect.DeepEqual(got, []string{"build", "up"}) {
+		t.Fatalf("tasks %v", got)
+	}
+	if f.Tasks[0].Command != "go build" || f.Tasks[0].Origin != "" {
+		t.Fatalf("the folder's build should win and not be marked: %+v", f.Tasks[0])
+	}
+	if f.Tasks[1].Origin != OriginWorkspace {
+		t.Fatalf("workspace task origin = %q", f.Tasks[1].Origin)
+	}
+	if len(f.Inputs) != 2 || f.Inputs[0].Type != "promptString" || f.Inputs[1].ID != "profile" {
+		t.Fatalf("inputs %+v", f.Inputs)
+	}
+
+	defs, err := WhichTask(api, "build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(defs) != 2 || defs[1].Source != p || defs[1].Line == 0 {
+		t.Fatalf("which: %+v", defs)
+	}
+}
+
+func TestLoadWorkspace_OnlyWorkspaceFileTasks(t *testing.T) {
+	t.Setenv("VSTASK_WORKSPACE", "")
+	t.Setenv("VSTASK_PROVIDERS", "")
+	dir := t.TempDir()
+	api := filepath.Join(dir, "api")
+	if err := os.MkdirAll(filepath.Join(api, ".vscode"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VSTASK_TRUST_ALL", "1")
+	writeWorkspace(t, dir, `{"folders": [{"path": "api"}], "tasks": {"tasks": [{"label": "up", "command": "x"}]}}`)
+	f, err := loadWorkspace(api)
+	if err != nil || len(f.Tasks) != 1 {
+		t.Fatalf("without tasks.json: %+v, %v", f.Tasks, err)
+	}
+}
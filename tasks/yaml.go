@@ -0,0 +1,166 @@
+package tasks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// isYAMLFile reports whether p is a YAML tasks file (tasks.yaml or tasks.yml).
+func isYAMLFile(p string) bool {
+	ext := strings.ToLower(filepath.Ext(p))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// readTasksFileJSON returns the tasks file at p as JSON(C): as is, or converted from YAML with
+// each value on the line it's on in the YAML, so lines found in the JSON are the file's.
+func readTasksFileJSON(p string) ([]byte, error) {
+	data, err := os.ReadFile(p)
+	if err != nil || !isYAMLFile(p) {
+		return data, err
+	}
+	return yamlToJSON(p, data)
+}
+
+// reYAMLPosition is the position prefix of the YAML parser's errors.
+var reYAMLPosition = regexp.MustCompile(`^yaml: line (\d+): `)
+
+// yamlToJSON converts the YAML data read from file to JSON (see readTasksFileJSON), failing with
+// a *ParseError.
+func yamlToJSON(file string, data []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		pe := &ParseError{File: file, Err: err}
+		if m := reYAMLPosition.FindStringSubmatchIndex(err.Error()); m != nil {
+			msg := err.Error()
+			pe.Line, _ = strconv.Atoi(msg[m[2]:m[3]])
+			pe.Err = errors.New(msg[m[1]:])
+		}
+		return nil, pe
+	}
+	c := yamlConverter{line: 1}
+	if doc.Kind == 0 { // an empty file
+		return []byte("{}"), nil
+	}
+	if err := c.node(&doc, 0); err != nil {
+		return nil, &ParseError{File: file, Line: c.line, Err: err}
+	}
+	return c.b.Bytes(), nil
+}
+
+// unmarshalYAML decodes the YAML data read from file into v, like unmarshalJSONC: the same
+// schema, and errors pointing at the YAML's lines.
+func unmarshalYAML(file string, data []byte, v any) error {
+	js, err := yamlToJSON(file, data)
+	if err != nil {
+		return err
+	}
+	return unmarshalJSONC(file, js, v)
+}
+
+// maxYAMLDepth bounds the nesting of a YAML document, aliases included (an alias can refer to
+// a node containing itself).
+const maxYAMLDepth = 100
+
+// maxYAMLNodes bounds the size of a YAML document once its aliases are expanded: a few aliases
+// of aliases ("billion laughs") would otherwise expand to more nodes than fit in memory. It's
+// far more than any tasks file has.
+const maxYAMLNodes = 250_000
+
+// yamlConverter writes a YAML node tree as JSON, starting each node on its line in the YAML
+// (nodes are visited in file order, so lines only ever grow; an alias's node is written where
+// the alias is).
+type yamlConverter struct {
+	b     bytes.Buffer
+	line  int // of the end of b
+	nodes int // written so far (see maxYAMLNodes)
+}
+
+func (c *yamlConverter) at(line int) {
+	for c.line < line {
+		c.b.WriteByte('\n')
+		c.line++
+	}
+}
+
+func (c *yamlConverter) node(n *yaml.Node, depth int) error {
+	if depth > maxYAMLDepth {
+		return errors.New("nested too deeply")
+	}
+	if c.nodes++; c.nodes > maxYAMLNodes {
+		return fmt.Errorf("more than %d values, aliases expanded", maxYAMLNodes)
+	}
+	c.at(n.Line)
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			c.b.WriteString("null")
+			return nil
+		}
+		return c.node(n.Content[0], depth+1)
+	case yaml.AliasNode:
+		return c.node(n.Alias, depth+1)
+	case yaml.SequenceNode:
+		c.b.WriteByte('[')
+		for i, item := range n.Content {
+			if i > 0 {
+				c.b.WriteByte(',')
+			}
+			if err := c.node(item, depth+1); err != nil {
+				return err
+			}
+		}
+		c.b.WriteByte(']')
+		return nil
+	case yaml.MappingNode:
+		c.b.WriteByte('{')
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if key.Kind != yaml.ScalarNode {
+				c.at(key.Line)
+				return errors.New("mapping keys must be strings")
+			}
+			if i > 0 {
+				c.b.WriteByte(',')
+			}
+			c.at(key.Line)
+			k, _ := json.Marshal(key.Value)
+			c.b.Write(k)
+			c.b.WriteByte(':')
+			if err := c.node(value, depth+1); err != nil {
+				return err
+			}
+		}
+		c.b.WriteByte('}')
+		return nil
+	}
+	return c.scalar(n)
+}
+
+// scalar writes a scalar with the JSON type of its YAML one: null, a boolean, a number, or
+// (for the rest, timestamps included) a string.
+func (c *yamlConverter) scalar(n *yaml.Node) error {
+	var v any = n.Value
+	switch n.ShortTag() {
+	case "!!null":
+		v = nil
+	case "!!bool", "!!int", "!!float":
+		if err := n.Decode(&v); err != nil {
+			return err
+		}
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", n.Value, err)
+	}
+	c.b.Write(b)
+	return nil
+}
@@ -0,0 +1,106 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadFile_YAML(t *testing.T) {
+	isolateTasksFile(t)
+	root := t.TempDir()
+	p := filepath.Join(root, ".vscode", "tasks.yaml")
+	writeTestFile(t, p, `# comments are fine
+version: "2.0.0"
+x-env: &env
+  GOFLAGS: -mod=readonly
+  CGO_ENABLED: "0"
+tasks:
+  - label: build
+    command: go build ./...
+    options:
+      env: *env
+    dependsOn: [lint, "gen"]
+    isBackground: false
+  - label: lint
+    command: golangci-lint
+    args:
+      - run
+      - --timeout=5m
+  - label: gen
+    command: go generate
+inputs:
+  - id: env
+    type: pickString
+    options: [dev, prod]
+`)
+	if got := TasksFilePath(root); got != p {
+		t.Fatalf("tasks file = %q, want %q", got, p)
+	}
+	f, err := loadWorkspace(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Tasks) != 3 || len(f.Inputs) != 1 || f.Inputs[0].ID != "env" {
+		t.Fatalf("tasks %v, inputs %+v", labels(f.Tasks), f.Inputs)
+	}
+	b := f.Tasks[0]
+	if b.Command != "go build ./..." || b.Options == nil || b.Options.Env["CGO_ENABLED"] != "0" || b.Options.Env["GOFLAGS"] != "-mod=readonly" {
+		t.Fatalf("build = %+v", b)
+	}
+	if deps := b.DependsOn.Labels(); len(deps) != 2 || deps[1] != "gen" {
+		t.Fatalf("dependsOn = %v", deps)
+	}
+
+	defs, err := WhichTask(root, "lint")
+	if err != nil || len(defs) != 1 || defs[0].Line != 13 {
+		t.Fatalf("which: %+v, %v", defs, err)
+	}
+
+	// tasks.json, what VS Code reads, wins.
+	writeTestFile(t, filepath.Join(root, ".vscode", "tasks.json"), `{"tasks": [{"label": "json"}]}`)
+	if f, err := loadWorkspace(root); err != nil || len(f.Tasks) != 1 || f.Tasks[0].Label != "json" {
+		t.Fatalf("with tasks.json: %v, %v", labels(f.Tasks), err)
+	}
+}
+
+func TestLoadFile_YAMLErrors(t *testing.T) {
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		content string
+		line    int
+		msg     string
+	}{
+		{"tasks:\n  - label: a\n  - label: [1, 2]\n", 3, "cannot unmarshal array"},
+		{"tasks:\n  - label: a\n    command: b: c\n", 3, "mapping values are not allowed"},
+		{"tasks:\n  - label: a\n    options: {[x]: y}\n", 3, "mapping keys must be strings"},
+	} {
+		p := filepath.Join(dir, "tasks.yml")
+		writeTestFile(t, p, tc.content)
+		_, err := LoadFile(p)
+		var pe *ParseError
+		if !errors.As(err, &pe) || pe.Line != tc.line || !strings.Contains(err.Error(), tc.msg) {
+			t.Errorf("%q: got %v, want an error on line %d", tc.content, err, tc.line)
+		}
+	}
+
+	// billion laughs: each level ten aliases of the one before
+	laughs := "a: &a [lol, lol, lol, lol, lol, lol, lol, lol, lol, lol]\n"
+	for i := 'b'; i <= 'i'; i++ {
+		prev := string(i - 1)
+		laughs += fmt.Sprintf("%c: &%c [%s]\n", i, i, strings.TrimSuffix(strings.Repeat("*"+prev+", ", 10), ", "))
+	}
+	p := filepath.Join(dir, "laughs.yaml")
+	writeTestFile(t, p, laughs)
+	if _, err := LoadFile(p); err == nil || !strings.Contains(err.Error(), "aliases expanded") {
+		t.Fatalf("billion laughs: %v", err)
+	}
+
+	p = filepath.Join(dir, "empty.yaml")
+	writeTestFile(t, p, "# nothing yet\n")
+	if f, err := LoadFile(p); err != nil || len(f.Tasks) != 0 {
+		t.Fatalf("empty file: %+v, %v", f, err)
+	}
+}
@@ -0,0 +1,322 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// What a flag's value completes to, if it takes one (see completionFlag): these, or the words
+// it can be, separated by spaces.
+const (
+	argLabels = "<labels>" // task labels
+	argInputs = "<inputs>" // input ids, followed by "="
+	argFiles  = "<files>"
+	argAny    = "<any>" // nothing in particular
+)
+
+// completionFlag is a flag the completion scripts know, with what its value completes to ("" if
+// it takes none).
+type completionFlag struct {
+	Name, Short string
+	Arg         string
+}
+
+// completionFlags are the global options (see PrintHelp).
+var completionFlags = []completionFlag{
+	{Name: "--last"},
+	{Name: "--group", Arg: "build test"},
+	{Name: "--retry-failed"},
+	{Name: "--file", Arg: argFiles},
+	{Name: "--line", Arg: argAny},
+	{Name: "--selected-text", Arg: argAny},
+	{Name: "--max-parallel", Arg: argAny},
+	{Name: "--depends-order", Arg: "sequence parallel"},
+	{Name: "--start-at", Arg: argLabels},
+	{Name: "--skip", Arg: argLabels},
+	{Name: "--fail", Arg: argLabels},
+	{Name: "--hermetic"},
+	{Name: "--input", Arg: argInputs},
+	{Name: "--no-input"},
+	{Name: "--quiet", Short: "-q"},
+	{Name: "--prefix-output"},
+	{Name: "--timestamps"},
+	{Name: "--shell-fallback", Arg: argAny},
+	{Name: "--verbose"},
+	{Name: "--fail-on-problems"},
+	{Name: "--sarif", Arg: argFiles},
+	{Name: "--trust"},
+	{Name: "--accept-changes"},
+	{Name: "--force"},
+	{Name: "--help", Short: "-h"},
+	{Name: "--version", Short: "-v"},
+}
+
+// completionCommands are vstask's commands; completionLabelCommands those taking task labels.
+var (
+	completionCommands      = []string{"list", "help", "which", "diff", "add", "aliases", "settings", "daemon", "folder-open", "clean", "build", "test", "run", "watch", "status", "stop", "logs", "upgrade-schema", "secret", "bug-report", "completion"}
+	completionLabelCommands = []string{"help", "which", "run", "watch", "stop", "logs"}
+	completionShells        = []string{"bash", "zsh", "fish", "pwsh"}
+)
+
+// Completion returns the script completing vstask's commands, options and, through
+// `vstask __complete`, the current workspace's task labels and input ids in shell: "bash",
+// "zsh", "fish" or "pwsh".
+func Completion(shell string) (string, error) {
+	var tmpl *template.Template
+	switch strings.ToLower(shell) {
+	case "bash":
+		tmpl = bashCompletion
+	case "zsh":
+		tmpl = zshCompletion
+	case "fish":
+		tmpl = fishCompletion
+	case "pwsh", "powershell":
+		tmpl = pwshCompletion
+	default:
+		return "", fmt.Errorf("unsupported shell: %q (want %s)", shell, strings.Join(completionShells, ", "))
+	}
+	// The run command's --order is completed too.
+	flags := append(completionFlags[:len(completionFlags):len(completionFlags)], completionFlag{Name: "--order", Arg: "sequence parallel"})
+	var b strings.Builder
+	err := tmpl.Execute(&b, map[string]any{
+		"Flags":         completionFlags,
+		"ValueFlags":    flags,
+		"Commands":      completionCommands,
+		"LabelCommands": completionLabelCommands,
+		"Shells":        completionShells,
+	})
+	return b.String(), err
+}
+
+var completionFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"split": strings.Fields,
+	// names are the flags' names (and short names) taking a value, or all of them.
+	"names": func(flags []completionFlag, valued bool) []string {
+		var out []string
+		for _, f := range flags {
+			if valued && f.Arg == "" {
+				continue
+			}
+			out = append(out, f.Name)
+			if f.Short != "" {
+				out = append(out, f.Short)
+			}
+		}
+		return out
+	},
+	// psList is a PowerShell array of words.
+	"psList": func(words []string) string {
+		return "@('" + strings.Join(words, "', '") + "')"
+	},
+	"words": func(arg string) bool {
+		return arg != argLabels && arg != argInputs && arg != argFiles && arg != argAny
+	},
+}
+
+func completionTemplate(name, text string) *template.Template {
+	return template.Must(template.New(name).Funcs(completionFuncs).Parse(text))
+}
+
+var bashCompletion = completionTemplate("bash", `# bash completion for vstask. Load it with: source <(vstask completion bash)
+_vstask() {
+	local cur prev words cword
+	if declare -F _get_comp_words_by_ref >/dev/null; then
+		_get_comp_words_by_ref -n =: cur prev words cword
+	else
+		cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}
+		words=("${COMP_WORDS[@]}") cword=$COMP_CWORD
+	fi
+	COMPREPLY=()
+	case $prev in
+{{- range .ValueFlags}}{{if .Arg}}
+	{{.Name}})
+		{{- if eq .Arg "<labels>"}} _vstask_words labels; return ;;
+		{{- else if eq .Arg "<inputs>"}} _vstask_words inputs; compopt -o nospace 2>/dev/null; return ;;
+		{{- else if eq .Arg "<files>"}} COMPREPLY=($(compgen -f -- "$cur")); return ;;
+		{{- else if eq .Arg "<any>"}} return ;;
+		{{- else}} COMPREPLY=($(compgen -W "{{.Arg}}" -- "$cur")); return ;;
+		{{- end}}
+{{- end}}{{end}}
+	esac
+
+	# The command: the first word that isn't an option or an option's value.
+	local i cmd=
+	for ((i = 1; i < cword; i++)); do
+		case ${words[i]} in
+		{{join (names .ValueFlags true) "|"}}) ((i++)) ;;
+		-*) ;;
+		*) cmd=${words[i]}; break ;;
+		esac
+	done
+	if [[ $cur == -* ]]; then
+		[[ -z $cmd ]] && COMPREPLY=($(compgen -W "{{join (names .Flags false) " "}}" -- "$cur"))
+		return
+	fi
+	case $cmd in
+	"")
+		COMPREPLY=($(compgen -W "{{join .Commands " "}}" -- "$cur"))
+		_vstask_words labels ;;
+	{{join .LabelCommands "|"}}) _vstask_words labels ;;
+	completion) COMPREPLY=($(compgen -W "{{join .Shells " "}}" -- "$cur")) ;;
+	*) COMPREPLY=($(compgen -f -- "$cur")) ;;
+	esac
+}
+
+# _vstask_words adds what `+"`vstask __complete $1`"+` lists that starts with the current word.
+_vstask_words() {
+	local w
+	while IFS= read -r w; do
+		[[ $w == "$cur"* ]] && COMPREPLY+=("$(printf '%q' "$w")")
+	done < <(vstask __complete "$1" 2>/dev/null)
+	# With bash-completion, the word includes what's before a ":" (as in "build:prod").
+	declare -F __ltrim_colon_completions >/dev/null && __ltrim_colon_completions "$cur"
+}
+
+complete -F _vstask vstask
+`)
+
+var zshCompletion = completionTemplate("zsh", `#compdef vstask
+# zsh completion for vstask. Load it with: source <(vstask completion zsh)
+_vstask() {
+	case ${words[CURRENT-1]} in
+{{- range .ValueFlags}}{{if .Arg}}
+	{{.Name}})
+		{{- if eq .Arg "<labels>"}} _vstask_words labels; return ;;
+		{{- else if eq .Arg "<inputs>"}} _vstask_words inputs -S ''; return ;;
+		{{- else if eq .Arg "<files>"}} _files; return ;;
+		{{- else if eq .Arg "<any>"}} return ;;
+		{{- else}} compadd -- {{.Arg}}; return ;;
+		{{- end}}
+{{- end}}{{end}}
+	esac
+
+	# The command: the first word that isn't an option or an option's value.
+	local i cmd=
+	for ((i = 2; i < CURRENT; i++)); do
+		case ${words[i]} in
+		{{join (names .ValueFlags true) "|"}}) ((i++)) ;;
+		-*) ;;
+		*) cmd=${words[i]}; break ;;
+		esac
+	done
+	if [[ $PREFIX == -* ]]; then
+		[[ -z $cmd ]] && compadd -- {{join (names .Flags false) " "}}
+		return
+	fi
+	case $cmd in
+	'')
+		compadd -- {{join .Commands " "}}
+		_vstask_words labels ;;
+	{{join .LabelCommands "|"}}) _vstask_words labels ;;
+	completion) compadd -- {{join .Shells " "}} ;;
+	*) _files ;;
+	esac
+}
+
+# _vstask_words offers what `+"`vstask __complete $1`"+` lists, with compadd's options that follow.
+_vstask_words() {
+	local -a ws
+	ws=(${(f)"$(vstask __complete $1 2>/dev/null)"})
+	compadd "${@:2}" -a ws
+}
+
+compdef _vstask vstask
+`)
+
+var fishCompletion = completionTemplate("fish", `# fish completion for vstask. Load it with: vstask completion fish | source
+
+# The command being completed: the first word that isn't an option or an option's value.
+function __vstask_cmd
+	set -l words (commandline -opc)
+	set -e words[1]
+	set -l skip 0
+	for w in $words
+		if test $skip = 1
+			set skip 0
+			continue
+		end
+		switch $w
+			case {{join (names .ValueFlags true) " "}}
+				set skip 1
+			case '-*'
+			case '*'
+				echo $w
+				return 0
+		end
+	end
+	return 1
+end
+
+function __vstask_wants_label
+	set -l cmd (__vstask_cmd)
+	or return 0
+	contains -- $cmd {{join .LabelCommands " "}}
+end
+
+complete -c vstask -f
+complete -c vstask -n 'not __vstask_cmd' -a '{{join .Commands " "}}'
+complete -c vstask -n __vstask_wants_label -a '(vstask __complete labels 2>/dev/null)'
+complete -c vstask -n '__fish_seen_subcommand_from completion' -a '{{join .Shells " "}}'
+complete -c vstask -n '__fish_seen_subcommand_from run' -l order -x -a 'sequence parallel'
+{{- range .Flags}}
+complete -c vstask -n 'not __vstask_cmd' -l {{slice .Name 2}}
+	{{- if .Short}} -s {{slice .Short 1}}{{end}}
+	{{- if eq .Arg "<labels>"}} -x -a '(vstask __complete labels 2>/dev/null)'
+	{{- else if eq .Arg "<inputs>"}} -x -a '(vstask __complete inputs 2>/dev/null)'
+	{{- else if eq .Arg "<files>"}} -r -F
+	{{- else if eq .Arg "<any>"}} -x
+	{{- else if .Arg}} -x -a '{{.Arg}}'
+	{{- end}}
+{{- end}}
+`)
+
+var pwshCompletion = completionTemplate("pwsh", `# PowerShell completion for vstask. Load it with: vstask completion pwsh | Out-String | Invoke-Expression
+Register-ArgumentCompleter -Native -CommandName vstask -ScriptBlock {
+	param($wordToComplete, $commandAst, $cursorPosition)
+	# The words before the one being completed, vstask first.
+	$words = @($commandAst.CommandElements | Where-Object { $_.Extent.EndOffset -lt $cursorPosition } | ForEach-Object {
+		if ($_ -is [System.Management.Automation.Language.StringConstantExpressionAst]) { $_.Value } else { $_.Extent.Text }
+	})
+	$prev = if ($words.Count -gt 1) { $words[-1] } else { '' }
+	$valueFlags = {{psList (names .ValueFlags true)}}
+
+	# The command: the first word that isn't an option or an option's value.
+	$cmd = $null
+	for ($i = 1; $i -lt $words.Count; $i++) {
+		if ($valueFlags -contains $words[$i]) { $i++ }
+		elseif (-not $words[$i].StartsWith('-')) { $cmd = $words[$i]; break }
+	}
+
+	$candidates = switch ($prev) {
+{{- range .ValueFlags}}
+	{{- if eq .Arg "<labels>"}}
+		'{{.Name}}' { vstask __complete labels 2>$null; break }
+	{{- else if eq .Arg "<inputs>"}}
+		'{{.Name}}' { vstask __complete inputs 2>$null; break }
+	{{- else if words .Arg}}
+		'{{.Name}}' { {{psList (split .Arg)}}; break }
+	{{- end}}
+{{- end}}
+	}
+	if ($valueFlags -contains $prev) {
+		if ($null -eq $candidates) { return } # a file, or anything
+	} elseif ($wordToComplete.StartsWith('-')) {
+		if ($cmd) { return }
+		$candidates = {{psList (names .Flags false)}}
+	} elseif (-not $cmd) {
+		$candidates = {{psList .Commands}} + @(vstask __complete labels 2>$null)
+	} elseif ({{psList .LabelCommands}} -contains $cmd) {
+		$candidates = @(vstask __complete labels 2>$null)
+	} elseif ($cmd -eq 'completion') {
+		$candidates = {{psList .Shells}}
+	} else {
+		return
+	}
+	$candidates | Where-Object { $_.StartsWith($wordToComplete, [System.StringComparison]::OrdinalIgnoreCase) } | ForEach-Object {
+		$text = if ($_ -match '^[\w./:=+-]+$') { $_ } else { "'" + ($_ -replace "'", "''") + "'" }
+		[System.Management.Automation.CompletionResult]::new($text, $_, 'ParameterValue', $_)
+	}
+}
+`)
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompletion(t *testing.T) {
+	// The shells' syntax checkers, for those installed.
+	check := map[string][]string{"bash": {"bash", "-n"}, "zsh": {"zsh", "-n"}, "fish": {"fish", "--no-execute"}}
+	for _, shell := range completionShells {
+		t.Run(shell, func(t *testing.T) {
+			out, err := Completion(shell)
+			if err != nil {
+				t.Fatalf("Completion(%q): %v", shell, err)
+			}
+			for _, want := range []string{"vstask __complete", "labels", "inputs", "--start-at", "upgrade-schema"} {
+				if !strings.Contains(out, want) {
+					t.Errorf("Completion(%q) lacks %q:\n%s", shell, want, out)
+				}
+			}
+			args, ok := check[shell]
+			if !ok {
+				return
+			}
+			if _, err := exec.LookPath(args[0]); err != nil {
+				t.Skipf("%s not installed", args[0])
+			}
+			script := filepath.Join(t.TempDir(), "vstask."+shell)
+			if err := os.WriteFile(script, []byte(out), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if b, err := exec.Command(args[0], append(args[1:], script)...).CombinedOutput(); err != nil {
+				t.Errorf("%s %s: %v\n%s", strings.Join(args, " "), script, err, b)
+			}
+		})
+	}
+}
+
+func TestCompletion_UnknownShell(t *testing.T) {
+	if _, err := Completion("tcsh"); err == nil || !strings.Contains(err.Error(), "bash, zsh, fish, pwsh") {
+		t.Fatalf("Completion(tcsh) err = %v, want unsupported shell", err)
+	}
+}
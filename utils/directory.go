@@ -10,6 +10,8 @@ import (
 const (
 	VSCODE_DIR = ".vscode"
 	TASKS_JSON = "tasks.json"
+	TASKS_YAML = "tasks.yaml"
+	TASKS_YML  = "tasks.yml"
 )
 
 func FindProjectRoot() (string, error) {
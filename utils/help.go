@@ -5,9 +5,56 @@ import (
 )
 
 func PrintHelp() {
-	fmt.Println("Usage: vstask [task-name]")
+	fmt.Println("Usage: vstask [options] [task-name]")
+	fmt.Println("       vstask <command> [args]")
+	fmt.Println("Commands:")
+	fmt.Println("  list               List all tasks (--json, --verbose for usage counts)")
+	fmt.Println("  help <task-name>   Show documentation for a task")
+	fmt.Println("  which <task-name>  Show where a task is defined and what it shadows")
+	fmt.Println("  diff               Show tasks.json changes since the last run")
+	fmt.Println("  add                Add a task to tasks.json, asking for its command, group, ...")
+	fmt.Println("  aliases            Print shell functions for all tasks (--prefix, --shell)")
+	fmt.Println("  settings <key>     Show a setting's value and which settings.json it came from")
+	fmt.Println("  daemon             Keep parsed workspaces in memory (stop, status)")
+	fmt.Println("  folder-open        Run the tasks VS Code runs when the folder opens (runOn: folderOpen)")
+	fmt.Println("  clean              Remove caches and leftovers (--dry-run, --all)")
+	fmt.Println("  build, test        Run the default build or test task (see --group)")
+	fmt.Println("  run <label>...     Run several tasks at once (--order sequence|parallel)")
+	fmt.Println("  watch <label>      Rerun a task when files change (--glob <pattern>, --debounce)")
+	fmt.Println("  status             List the background tasks running in this workspace")
+	fmt.Println("  stop <label>       Stop a running background task")
+	fmt.Println("  logs <label>       Print a background task's output (-n <lines>, -f to follow)")
+	fmt.Println("  upgrade-schema     Upgrade a 0.1.0 tasks.json, or deprecated fields, to 2.0.0 (--dry-run)")
+	fmt.Println("  secret forget <id> Forget a secret input's value kept in the OS keychain")
+	fmt.Println("  bug-report         Write a diagnostic zip to attach to an issue (-o <file>)")
+	fmt.Println("  completion <shell> Print a completion script for bash, zsh, fish or pwsh")
 	fmt.Println("Options:")
+	fmt.Println("  --last             Re-run the last task run in this workspace")
+	fmt.Println("  --group <kind>     Run the group's default task, e.g. build or test (or pick one)")
+	fmt.Println("  --retry-failed     Re-run the last task, skipping dependencies that succeeded")
+	fmt.Println("  --file <path>      Set ${file} and related variables as if the file were open")
+	fmt.Println("  --line <n>         Set ${lineNumber}")
+	fmt.Println("  --selected-text    Set ${selectedText} to the next argument")
+	fmt.Println("  --max-parallel <n> Run at most n dependencies at once")
+	fmt.Println("  --depends-order    Run every task's dependencies in sequence or in parallel")
+	fmt.Println("  --start-at <label> Skip the steps of sequences before this one, to resume a pipeline")
+	fmt.Println("  --skip <label>     Don't run this dependency (repeatable)")
+	fmt.Println("  --fail <label>     Report this task as failed without running it (repeatable)")
+	fmt.Println("  --hermetic         Resolve process commands to absolute paths before running")
+	fmt.Println("  --input <id=value> Set an input's value (repeatable; overrides the environment)")
+	fmt.Println("  --no-input         Never prompt; take inputs from the environment or their defaults")
+	fmt.Println("  -q, --quiet        Leave out progress lines (sequence steps, watchers' cycles)")
+	fmt.Println("  --prefix-output    Start each line of a task's output with its name")
+	fmt.Println("  --timestamps       Start each line of a task's output with the time")
+	fmt.Println("  --shell-fallback   Shell to retry with when bash can't start (default /bin/sh; off to fail)")
+	fmt.Println("  --verbose          Trace fallbacks (PTY to stdio, bash to sh) and other decisions")
+	fmt.Println("  --fail-on-problems Fail when problem matchers find errors, even if the tasks succeed")
+	fmt.Println("  --sarif <file>     Write the problems found to a SARIF file")
+	fmt.Println("  --trust            Trust the workspace's tasks (and included ones) without asking")
+	fmt.Println("  --accept-changes   Approve tasks.json changes when pinning is on")
+	fmt.Println("  --force            Read a tasks.json whose schema version vstask doesn't support")
 	fmt.Println("  -h, --help         Show this help message")
+	fmt.Println("  -v, --version      Show the version")
 }
 
 func PrintVersion() {
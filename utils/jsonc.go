@@ -1,12 +1,76 @@
 package utils
 
-import "github.com/tailscale/hujson"
+import (
+	"bytes"
+	"unicode/utf8"
+
+	"github.com/tailscale/hujson"
+)
 
 func ConvertJsoncToJson(jsonc []byte) []byte {
-	std, err := hujson.Standardize(jsonc) // strips comments & trailing commas
+	std, err := StandardizeJSONC(jsonc) // strips comments & trailing commas
 	if err != nil {
 		// fall back to original on parse error
 		return jsonc
 	}
 	return std
 }
+
+// StandardizeJSONC is ConvertJsoncToJson for callers that want the syntax error rather than
+// the original bytes back. jsonc is left as is (hujson works in place).
+func StandardizeJSONC(jsonc []byte) ([]byte, error) {
+	return hujson.Standardize(bytes.Clone(jsonc))
+}
+
+// StripJSONC blanks out the comments and trailing commas of jsonc, in a copy, so offsets and
+// line numbers still match. Unlike StandardizeJSONC it doesn't validate anything: it's the fast
+// path for input that's expected to be valid, for json.Unmarshal to check. Input hujson rejects
+// stays invalid: an unterminated comment (// included), one that isn't UTF-8, or a comma with no value before it, is left alone.
+func StripJSONC(jsonc []byte) []byte {
+	out := bytes.Clone(jsonc)
+	// The last two bytes that aren't whitespace or comments, or -1.
+	last, prev := -1, -1
+	sig := func(i int) { last, prev = i, last }
+	for i := 0; i < len(out); i++ {
+		switch c := out[i]; c {
+		case ' ', '\t', '\r', '\n':
+		case '"':
+			start := i
+			for i++; i < len(out) && out[i] != '"'; i++ {
+				if out[i] == '\\' {
+					i++
+				}
+			}
+			sig(start)
+		case '/':
+			if i+1 < len(out) && out[i+1] == '/' {
+				if end := bytes.IndexByte(out[i:], '\n'); end >= 0 && utf8.Valid(out[i:i+end]) {
+					for end += i; i < end; i++ {
+						out[i] = ' '
+					}
+					continue
+				}
+			}
+			if i+1 < len(out) && out[i+1] == '*' {
+				if end := bytes.Index(out[i+2:], []byte("*/")); end >= 0 && utf8.Valid(out[i:i+2+end]) {
+					for j := i; j < i+2+end+2; j++ {
+						if out[j] != '\n' {
+							out[j] = ' '
+						}
+					}
+					i += 2 + end + 1
+					continue
+				}
+			}
+			sig(i)
+		case ']', '}':
+			if last >= 0 && out[last] == ',' && prev >= 0 && !bytes.ContainsRune([]byte("[{,:"), rune(out[prev])) {
+				out[last] = ' '
+			}
+			sig(i)
+		default:
+			sig(i)
+		}
+	}
+	return out
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func FuzzConvertJsoncToJson(f *testing.F) {
+	for _, seed := range []string{
+		``,
+		`{}`,
+		`{"version": "2.0.0", "tasks": []}`,
+		"{\n  // comment\n  \"a\": [1, 2,],\n  /* block */ \"b\": {\"c\": null,},\n}",
+		`{"a": "// not a comment", "b": "/* nor this */"}`,
+		`/* unterminated`,
+		`{"a": 1 // trailing`,
+		"\ufeff{\"bom\": true}",
+		`[,]`,
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		in := append([]byte(nil), data...)
+		got := ConvertJsoncToJson(data)
+		if string(data) != string(in) {
+			t.Fatalf("ConvertJsoncToJson modified its input")
+		}
+		std, err := StandardizeJSONC(in)
+		if err != nil {
+			if strip := StripJSONC(in); json.Valid(strip) {
+				t.Fatalf("invalid JSONC %q: StripJSONC made it valid JSON %q", in, strip)
+			}
+			if string(got) != string(in) {
+				t.Fatalf("invalid JSONC %q: got %q, want the input back", in, got)
+			}
+			return
+		}
+		if string(got) != string(std) {
+			t.Fatalf("ConvertJsoncToJson = %q, StandardizeJSONC = %q", got, std)
+		}
+		if !json.Valid(std) {
+			t.Fatalf("JSONC %q standardized to invalid JSON %q", in, std)
+		}
+		if strip := StripJSONC(in); string(strip) != string(std) {
+			t.Fatalf("StripJSONC(%q) = %q, StandardizeJSONC = %q", in, strip, std)
+		}
+		if len(std) != len(in) {
+			t.Fatalf("standardizing %q changed its length (%d -> %d): offsets no longer match", in, len(in), len(std))
+		}
+	})
+}
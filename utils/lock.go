@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// LockSuffix is appended to a state file's path to name its lock file.
+const LockSuffix = ".lock"
+
+// LockFile takes an exclusive advisory lock guarding the state file p, waiting for other
+// vstask processes to release it. The lock lives in a separate p+LockSuffix file, so p itself
+// can be replaced atomically while locked. Call the returned func to release it.
+func LockFile(p string) (func(), error) {
+	f, err := openLockFile(p)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFD(f, true); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return func() {
+		_ = unlockFD(f)
+		_ = f.Close()
+	}, nil
+}
+
+// TryLockFile is LockFile without waiting: ok is false if another process holds the lock.
+func TryLockFile(p string) (unlock func(), ok bool, err error) {
+	f, err := openLockFile(p)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := lockFD(f, false); err != nil {
+		_ = f.Close()
+		if errLockHeld(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return func() {
+		_ = unlockFD(f)
+		_ = f.Close()
+	}, true, nil
+}
+
+func openLockFile(p string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(p+LockSuffix, os.O_RDWR|os.O_CREATE, 0o644)
+}
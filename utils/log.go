@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+)
+
+// Verbose reports whether diagnostic output is enabled ($VSTASK_VERBOSE=1, or Trace).
+func Verbose() bool {
+	return os.Getenv("VSTASK_VERBOSE") == "1" || Trace()
+}
+
+// Trace reports whether trace output is enabled ($VSTASK_TRACE=1, set by --verbose): the
+// decisions vstask makes along the way, like falling back from a PTY to plain stdio.
+func Trace() bool {
+	return os.Getenv("VSTASK_TRACE") == "1"
+}
+
+// Debugf prints a diagnostic line to stderr when Verbose is on.
+func Debugf(format string, args ...any) {
+	if !Verbose() {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "vstask: "+format+"\n", args...)
+}
+
+// Tracef prints a trace line to stderr when Trace is on.
+func Tracef(format string, args ...any) {
+	if !Trace() {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "vstask: trace: "+format+"\n", args...)
+}
@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// UserStateDir returns vstask's state directory for the current user, creating it if needed.
+// Resolution order: $VSTASK_STATE_DIR, $XDG_STATE_HOME/vstask, %LOCALAPPDATA%\vstask (Windows),
+// ~/.local/state/vstask.
+func UserStateDir() (string, error) {
+	dir := os.Getenv("VSTASK_STATE_DIR")
+	if dir == "" {
+		if xdg := os.Getenv("XDG_STATE_HOME"); xdg != "" {
+			dir = filepath.Join(xdg, "vstask")
+		} else if runtime.GOOS == "windows" && os.Getenv("LOCALAPPDATA") != "" {
+			dir = filepath.Join(os.Getenv("LOCALAPPDATA"), "vstask")
+		} else {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			dir = filepath.Join(home, ".local", "state", "vstask")
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// WorkspaceStateDir returns the state directory for a workspace root, creating it if needed.
+// Each workspace gets its own folder named after the root's basename plus a short hash of
+// its absolute path, so same-named projects don't collide.
+func WorkspaceStateDir(root string) (string, error) {
+	if root == "" {
+		return "", errors.New("no workspace root")
+	}
+	base, err := UserStateDir()
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(abs))
+	dir := filepath.Join(base, "workspaces", filepath.Base(abs)+"-"+hex.EncodeToString(sum[:])[:12])
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// AllWorkspaceStateDirs returns the state directories of every workspace vstask has seen.
+func AllWorkspaceStateDirs() ([]string, error) {
+	base, err := UserStateDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(filepath.Join(base, "workspaces"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, e := range entries {
+		if e.IsDir() {
+			dirs = append(dirs, filepath.Join(base, "workspaces", e.Name()))
+		}
+	}
+	return dirs, nil
+}
+
+// ReadJSONFile decodes the JSON file at p into v.
+// A missing file is not an error; v is left untouched.
+func ReadJSONFile(p string, v any) error {
+	b, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// WriteJSONFile encodes v as indented JSON into the file at p, creating parent directories.
+// The file is replaced atomically (write to a temporary file, then rename), so readers never
+// see a partial write.
+func WriteJSONFile(p string, v any) error {
+	return writeJSONFile(p, v, 0o644)
+}
+
+func writeJSONFile(p string, v any, perm os.FileMode) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(p, append(b, '\n'), perm)
+}
+
+// WriteFileAtomic writes data to p with permissions perm via a temporary file in the same
+// directory and a rename, creating parent directories.
+func WriteFileAtomic(p string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(p)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, "."+filepath.Base(p)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp, p)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+	}
+	return err
+}
+
+// UpdateJSONFile does a locked read-modify-write of the JSON file at p: it takes p's lock
+// (see LockFile), decodes the file (a missing or corrupt file gives the zero T), calls update
+// and, if it returns nil, writes the value back atomically.
+func UpdateJSONFile[T any](p string, update func(v *T) error) error {
+	return updateJSONFile(p, 0o644, update)
+}
+
+// UpdatePrivateJSONFile is UpdateJSONFile for a file only the user may read (e.g. cached
+// input values, which can be secrets).
+func UpdatePrivateJSONFile[T any](p string, update func(v *T) error) error {
+	return updateJSONFile(p, 0o600, update)
+}
+
+func updateJSONFile[T any](p string, perm os.FileMode, update func(v *T) error) error {
+	unlock, err := LockFile(p)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	var v T
+	if err := ReadJSONFile(p, &v); err != nil {
+		var zero T
+		v = zero
+	}
+	if err := update(&v); err != nil {
+		return err
+	}
+	return writeJSONFile(p, v, perm)
+}
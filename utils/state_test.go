@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWorkspaceStateDir_PerRoot(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("VSTASK_STATE_DIR", base)
+
+	a, err := WorkspaceStateDir(filepath.Join(t.TempDir(), "app"))
+	if err != nil {
+		t.Fatalf("WorkspaceStateDir: %v", err)
+	}
+	b, err := WorkspaceStateDir(filepath.Join(t.TempDir(), "app"))
+	if err != nil {
+		t.Fatalf("WorkspaceStateDir: %v", err)
+	}
+	if a == b {
+		t.Fatalf("same-named roots share a state dir: %q", a)
+	}
+	if !strings.HasPrefix(a, base) || !strings.HasPrefix(filepath.Base(a), "app-") {
+		t.Fatalf("unexpected state dir %q", a)
+	}
+	if !DirExists(a) {
+		t.Fatalf("state dir %q not created", a)
+	}
+	if _, err := WorkspaceStateDir(""); err == nil {
+		t.Fatal("expected error for empty root")
+	}
+}
+
+func TestAllWorkspaceStateDirs(t *testing.T) {
+	t.Setenv("VSTASK_STATE_DIR", t.TempDir())
+	if dirs, err := AllWorkspaceStateDirs(); err != nil || len(dirs) != 0 {
+		t.Fatalf("fresh state: %v, %v", dirs, err)
+	}
+	a, _ := WorkspaceStateDir(filepath.Join(t.TempDir(), "a"))
+	b, _ := WorkspaceStateDir(filepath.Join(t.TempDir(), "b"))
+	dirs, err := AllWorkspaceStateDirs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dirs) != 2 || !slices.Contains(dirs, a) || !slices.Contains(dirs, b) {
+		t.Fatalf("got %v, want %s and %s", dirs, a, b)
+	}
+}
+
+func TestReadWriteJSONFile(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "nested", "state.json")
+
+	var missing map[string]int
+	if err := ReadJSONFile(p, &missing); err != nil || missing != nil {
+		t.Fatalf("missing file: %v, %v", missing, err)
+	}
+
+	if err := WriteJSONFile(p, map[string]int{"a": 1}); err != nil {
+		t.Fatalf("WriteJSONFile: %v", err)
+	}
+	got := map[string]int{}
+	if err := ReadJSONFile(p, &got); err != nil || got["a"] != 1 {
+		t.Fatalf("ReadJSONFile = %v, %v", got, err)
+	}
+}
+
+func TestWriteJSONFile_LeavesNoTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "state.json")
+	for i := range 3 {
+		if err := WriteJSONFile(p, map[string]int{"n": i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 || entries[0].Name() != "state.json" {
+		t.Fatalf("dir holds %v", entries)
+	}
+}
+
+func TestUpdateJSONFile_Concurrent(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "counter.json")
+	if err := os.WriteFile(p, []byte("{corrupt"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := UpdateJSONFile(p, func(c *map[string]int) error {
+				if *c == nil {
+					*c = map[string]int{}
+				}
+				(*c)["n"]++
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	got := map[string]int{}
+	if err := ReadJSONFile(p, &got); err != nil || got["n"] != 20 {
+		t.Fatalf("counter = %v, %v; want 20 (a corrupt file starts over)", got, err)
+	}
+
+	if err := UpdateJSONFile(p, func(c *map[string]int) error { return errors.New("nope") }); err == nil {
+		t.Fatal("expected the update's error")
+	}
+	if err := ReadJSONFile(p, &got); err != nil || got["n"] != 20 {
+		t.Fatalf("a failed update must not write: %v", got)
+	}
+}
+
+func TestTryLockFile(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "state.json")
+	unlock, err := LockFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := TryLockFile(p); err != nil || ok {
+		t.Fatalf("lock is held: ok=%v err=%v", ok, err)
+	}
+	unlock()
+	unlock2, ok, err := TryLockFile(p)
+	if err != nil || !ok {
+		t.Fatalf("lock is free: ok=%v err=%v", ok, err)
+	}
+	unlock2()
+}